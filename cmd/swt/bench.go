@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"blockchain-jedi/waste-management-access-control/hibe"
+)
+
+// Patterns the benchmark derives keys for and encrypts under, from a facility
+// wide key down to one bin's readings
+const (
+	benchRootPattern   = "facility/*/bin/*/fill-level/*"
+	benchBinPattern    = "facility/general/bin/12345/fill-level/*"
+	benchTargetPattern = "facility/general/bin/12345/fill-level/realtime"
+)
+
+// OperationTiming summarises the durations of one benchmarked operation
+type OperationTiming struct {
+	Operation  string        `json:"operation"`
+	Iterations int           `json:"iterations"`
+	Mean       time.Duration `json:"mean_ns"`
+	Min        time.Duration `json:"min_ns"`
+	P95        time.Duration `json:"p95_ns"`
+	Max        time.Duration `json:"max_ns"`
+}
+
+// runBench times each HIBE operation over a number of iterations
+func runBench(env *environment, args []string) error {
+	fs := newFlagSet(env, "bench")
+	iterations := fs.Int("n", 20, "iterations per operation")
+	payload := fs.Int("payload", 256, "plaintext size in bytes")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *iterations < 1 || *payload < 0 {
+		return errors.New("-n must be positive and -payload not negative")
+	}
+
+	timings, err := benchmarkHIBE(*iterations, *payload)
+	if err != nil {
+		return err
+	}
+	env.log.Debug("benchmark finished", "iterations", *iterations, "payload", *payload)
+
+	if *asJSON {
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(timings)
+	}
+	table := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "operation\titerations\tmean\tmin\tp95\tmax\t")
+	for _, t := range timings {
+		fmt.Fprintf(table, "%s\t%d\t%v\t%v\t%v\t%v\t\n", t.Operation, t.Iterations,
+			t.Mean.Round(time.Microsecond), t.Min.Round(time.Microsecond), t.P95.Round(time.Microsecond), t.Max.Round(time.Microsecond))
+	}
+	return table.Flush()
+}
+
+// benchmarkHIBE times setup, key generation, delegation, encryption and
+// decryption, checking every decryption returns the plaintext
+func benchmarkHIBE(iterations, payloadSize int) ([]OperationTiming, error) {
+	root, err := hibe.ParsePattern(benchRootPattern)
+	if err != nil {
+		return nil, err
+	}
+	bin, err := hibe.ParsePattern(benchBinPattern)
+	if err != nil {
+		return nil, err
+	}
+	target, err := hibe.ParsePattern(benchTargetPattern)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, payloadSize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+
+	var operations []OperationTiming
+	measure := func(name string, op func() error) error {
+		durations := make([]time.Duration, iterations)
+		for i := range durations {
+			start := time.Now()
+			if err := op(); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			durations[i] = time.Since(start)
+		}
+		operations = append(operations, summarise(name, durations))
+		return nil
+	}
+
+	var (
+		publicKey  *hibe.PublicKey
+		masterKey  *hibe.MasterKey
+		rootKey    *hibe.PrivateKey
+		binKey     *hibe.PrivateKey
+		ciphertext *hibe.Ciphertext
+	)
+	steps := []struct {
+		name string
+		op   func() error
+	}{
+		{"setup", func() (err error) {
+			publicKey, masterKey, err = hibe.Setup(hibe.NewSystemParams(len(root.Components), 128))
+			return err
+		}},
+		{"keygen", func() (err error) {
+			rootKey, err = hibe.KeyGen(publicKey, masterKey, root)
+			return err
+		}},
+		{"delegate", func() (err error) {
+			binKey, err = hibe.Delegate(publicKey, rootKey, bin)
+			return err
+		}},
+		{"encrypt", func() (err error) {
+			ciphertext, err = hibe.Encrypt(publicKey, target, plaintext)
+			return err
+		}},
+		{"decrypt", func() error {
+			decrypted, err := hibe.Decrypt(publicKey, binKey, ciphertext)
+			if err == nil && !bytes.Equal(decrypted, plaintext) {
+				err = errors.New("decrypted data differs from the plaintext")
+			}
+			return err
+		}},
+	}
+	for _, step := range steps {
+		if err := measure(step.name, step.op); err != nil {
+			return nil, err
+		}
+	}
+	return operations, nil
+}
+
+func summarise(name string, durations []time.Duration) OperationTiming {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return OperationTiming{
+		Operation:  name,
+		Iterations: len(sorted),
+		Mean:       total / time.Duration(len(sorted)),
+		Min:        sorted[0],
+		P95:        sorted[(len(sorted)*95+99)/100-1],
+		Max:        sorted[len(sorted)-1],
+	}
+}
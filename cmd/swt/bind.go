@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	binding "blockchain-jedi/ipfs-blockchain-binding"
+	"blockchain-jedi/telemetry"
+	"blockchain-jedi/waste-nft/wastemanagement"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// runBind generates a HIBE key for a bin's data and anchors its binding to an
+// IPFS object on chain, printing the binding. With -trace-exporter the
+// transaction and contract calls are exported as spans of one trace.
+func runBind(env *environment, args []string) error {
+	fs := newFlagSet(env, "bind")
+	cid := fs.String("cid", "", "IPFS CID of the data (required)")
+	binID := fs.String("bin", "", "bin the data comes from (required)")
+	operator := fs.String("operator", "", "operator wallet (default the chain key's address)")
+	department := fs.String("department", "general", "facility department")
+	dataType := fs.String("data-type", "fill-level", "kind of data")
+	accessLevel := fs.String("access-level", "read", "access level granted")
+	fee := fs.String("fee", "0", "gas fee paid, in wei")
+	rpcURL := fs.String("rpc", env.cfg.Chain.RPCURL, "Ethereum node")
+	contract := fs.String("contract", env.cfg.Chain.ContractAddress, "binding contract address")
+	requestID := fs.String("request-id", "", "request ID to log and trace the binding under (default random)")
+	exporter := fs.String("trace-exporter", telemetry.ExporterNone, "where spans go: none, file or otlp")
+	traceFile := fs.String("trace-file", "bind-spans.json", "file spans are appended to, with the file exporter")
+	otlpEndpoint := fs.String("otlp-endpoint", "http://localhost:4318", "OTLP/HTTP collector URL, with the otlp exporter")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *cid == "" || *binID == "" {
+		fmt.Fprintln(env.stderr, "usage: swt bind -cid CID -bin BIN [flags]")
+		return errUsage
+	}
+	if err := wastemanagement.ValidateCID(*cid); err != nil {
+		return err
+	}
+	gasFee, ok := new(big.Int).SetString(*fee, 10)
+	if !ok || gasFee.Sign() < 0 {
+		return fmt.Errorf("invalid -fee %q", *fee)
+	}
+
+	keyHex := strings.TrimPrefix(env.cfg.Chain.PrivateKey, "0x")
+	if keyHex == "" || *rpcURL == "" || *contract == "" {
+		return errors.New("binding needs chain.rpc_url, chain.contract_address and SWT_CHAIN_PRIVATE_KEY")
+	}
+	if *operator == "" {
+		key, err := crypto.HexToECDSA(keyHex)
+		if err != nil {
+			return fmt.Errorf("invalid chain private key: %v", err)
+		}
+		*operator = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	}
+
+	if *requestID == "" {
+		*requestID = telemetry.NewRequestID()
+	}
+	ctx := telemetry.WithRequestID(context.Background(), *requestID)
+	shutdownTracing, err := telemetry.Setup(ctx, telemetry.TracingConfig{
+		ServiceName: "swt",
+		Exporter:    *exporter,
+		File:        *traceFile,
+		Endpoint:    *otlpEndpoint,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			env.log.Warn("failed to flush spans", "error", err)
+		}
+	}()
+
+	ethConnector, err := binding.NewEthereumConnector(*rpcURL, keyHex, *contract, big.NewInt(env.cfg.Chain.ChainID))
+	if err != nil {
+		return err
+	}
+	binder := binding.NewCryptographicBinding(ethConnector, binding.NewIPFSConnector(env.cfg.IPFS.API))
+	keyData, err := binder.GenerateHIBEKeyForWasteManagement(*binID, *operator, *department, *dataType, *accessLevel)
+	if err != nil {
+		return err
+	}
+	env.log.DebugContext(ctx, "submitting binding", "cid", *cid, "identity", strings.Join(keyData.Identity, "/"))
+	bound, err := binder.CreateCryptographicBindingContext(ctx, keyData, *cid, gasFee)
+	if err != nil {
+		return err
+	}
+	env.log.InfoContext(ctx, "bound", "cid", *cid, "binding", bound.BindingHash, "tx", bound.TransactionID)
+
+	encoder := json.NewEncoder(env.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bound)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envConfigFile names the config file when -config is not given
+const envConfigFile = "SWT_CONFIG"
+
+// Config is shared by every swt command. Values come from, in increasing
+// precedence, the defaults, the JSON config file, SWT_* environment variables
+// and each command's flags.
+type Config struct {
+	Log       LogConfig       `json:"log"`
+	HIBE      HIBEConfig      `json:"hibe"`
+	RateLimit RateLimitConfig `json:"ratelimit"`
+	Keys      KeysConfig      `json:"keys"`
+	Chain     ChainConfig     `json:"chain"`
+	IPFS      IPFSConfig      `json:"ipfs"`
+}
+
+// LogConfig selects how commands log to stderr
+type LogConfig struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // text or json
+}
+
+// HIBEConfig locates and configures the HIBE server
+type HIBEConfig struct {
+	URL      string          `json:"url"`                // where revoke reaches it
+	Settings json.RawMessage `json:"settings,omitempty"` // the config file serve hibe runs the server with
+}
+
+// RateLimitConfig configures serve ratelimit
+type RateLimitConfig struct {
+	Port                  int    `json:"port"`
+	ReputationStore       string `json:"reputation_store"` // empty keeps reputation in memory
+	Facility              string `json:"facility"`         // shared in exported block lists
+	MaxClientsTracked     int    `json:"max_clients_tracked"`
+	MaxConcurrentRequests int    `json:"max_concurrent_requests"`
+	RequestTimeoutSeconds int    `json:"request_timeout_seconds"`
+	Puzzles               bool   `json:"puzzles"`     // offer proof-of-work challenges
+	AdminToken            string `json:"admin_token"` // secret; empty disables the admin API
+}
+
+// KeysConfig locates the HIBE key files
+type KeysConfig struct {
+	PublicKey string `json:"public_key"`
+	MasterKey string `json:"master_key"` // sealed with $HIBE_PASSPHRASE
+}
+
+// ChainConfig locates the chain bindings are anchored on
+type ChainConfig struct {
+	RPCURL          string `json:"rpc_url"`
+	ContractAddress string `json:"contract_address"`
+	ChainID         int64  `json:"chain_id"`
+	PrivateKey      string `json:"private_key"` // secret, hex
+}
+
+// IPFSConfig locates the IPFS node
+type IPFSConfig struct {
+	API string `json:"api"`
+}
+
+// defaultConfig returns the settings used when nothing is configured
+func defaultConfig() *Config {
+	return &Config{
+		Log:  LogConfig{Level: "info", Format: "text"},
+		HIBE: HIBEConfig{URL: "http://localhost:8080"},
+		RateLimit: RateLimitConfig{
+			Port:                  8090,
+			ReputationStore:       "client_reputation.json",
+			MaxClientsTracked:     10000,
+			MaxConcurrentRequests: 1000,
+			RequestTimeoutSeconds: 30,
+			Puzzles:               true,
+		},
+		Keys:  KeysConfig{PublicKey: "public.key", MasterKey: "master.key"},
+		Chain: ChainConfig{ChainID: 1337},
+		IPFS:  IPFSConfig{API: "http://localhost:5001"},
+	}
+}
+
+// envOverrides maps each SWT_* variable to the value it sets
+func (c *Config) envOverrides() map[string]interface{} {
+	return map[string]interface{}{
+		"SWT_LOG_LEVEL":         &c.Log.Level,
+		"SWT_LOG_FORMAT":        &c.Log.Format,
+		"SWT_HIBE_URL":          &c.HIBE.URL,
+		"SWT_RATELIMIT_PORT":    &c.RateLimit.Port,
+		"SWT_REPUTATION_STORE":  &c.RateLimit.ReputationStore,
+		"SWT_FACILITY":          &c.RateLimit.Facility,
+		"SWT_ADMIN_TOKEN":       &c.RateLimit.AdminToken,
+		"SWT_PUBLIC_KEY":        &c.Keys.PublicKey,
+		"SWT_MASTER_KEY":        &c.Keys.MasterKey,
+		"SWT_CHAIN_RPC":         &c.Chain.RPCURL,
+		"SWT_CHAIN_CONTRACT":    &c.Chain.ContractAddress,
+		"SWT_CHAIN_ID":          &c.Chain.ChainID,
+		"SWT_CHAIN_PRIVATE_KEY": &c.Chain.PrivateKey,
+		"SWT_IPFS_API":          &c.IPFS.API,
+	}
+}
+
+// loadConfig reads the config file at path, if any, and applies the environment
+func loadConfig(path string, getenv func(string) string) (*Config, error) {
+	c := defaultConfig()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %v", err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %v", path, err)
+		}
+	}
+
+	for name, target := range c.envOverrides() {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		var err error
+		switch v := target.(type) {
+		case *string:
+			*v = value
+		case *int:
+			*v, err = strconv.Atoi(value)
+		case *int64:
+			*v, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", name, value, err)
+		}
+	}
+	return c, c.validate()
+}
+
+// validate checks the settings every command relies on
+func (c *Config) validate() error {
+	var problems []string
+	if _, err := parseLevel(c.Log.Level); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		problems = append(problems, fmt.Sprintf("log format %q is not text or json", c.Log.Format))
+	}
+	if len(c.HIBE.Settings) > 0 && !bytes.HasPrefix(bytes.TrimSpace(c.HIBE.Settings), []byte("{")) {
+		problems = append(problems, "hibe.settings must be a JSON object")
+	}
+	if c.RateLimit.Port < 1 || c.RateLimit.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("ratelimit port %d is out of range", c.RateLimit.Port))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
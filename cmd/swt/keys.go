@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+
+	"blockchain-jedi/waste-management-access-control/keytool"
+)
+
+// runKeys runs a key tool command, e.g. swt keys delegate, reading the key
+// files named in the configuration by default
+func runKeys(env *environment, args []string) error {
+	tool := keytool.New("swt keys")
+	tool.PublicKey = env.cfg.Keys.PublicKey
+	tool.MasterKey = env.cfg.Keys.MasterKey
+	if err := tool.Run(args); err != nil {
+		if errors.Is(err, keytool.ErrUsage) {
+			return errUsage
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Command swt operates the waste management system: it serves the HIBE and
+// rate limiting APIs, manages keys, revokes delegations, anchors bindings,
+// benchmarks the HIBE scheme and verifies custody reports.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"blockchain-jedi/telemetry"
+)
+
+// errUsage is returned once usage has been printed for a bad command line
+var errUsage = errors.New("usage")
+
+// environment is what every command runs with
+type environment struct {
+	cfg    *Config
+	log    *slog.Logger
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is one swt subcommand, or a group of them
+type command struct {
+	name    string
+	summary string
+	run     func(env *environment, args []string) error
+	sub     []command
+}
+
+var commands = []command{
+	{name: "serve", summary: "run a server", sub: []command{
+		{name: "ratelimit", summary: "run the gas-fee tiered rate limiting service", run: runServeRateLimit},
+	}},
+	{name: "keys", summary: "generate, derive, delegate, inspect and export HIBE keys", run: runKeys},
+	{name: "revoke", summary: "revoke a delegated key on the HIBE server", run: runRevoke},
+	{name: "bind", summary: "anchor an IPFS object to a HIBE key on chain", run: runBind},
+	{name: "bench", summary: "time HIBE setup, keygen, delegation, encryption and decryption", run: runBench},
+	{name: "audit", summary: "check custody records", sub: []command{
+		{name: "verify", summary: "verify a custody report and, with a key, its evidence", run: runAuditVerify},
+	}},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+// run parses the global flags and runs the command, returning the exit code
+func run(args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("swt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", getenv(envConfigFile), "JSON configuration file")
+	level := fs.String("log-level", "", "debug, info, warn or error (overrides the config)")
+	format := fs.String("log-format", "", "text or json (overrides the config)")
+	fs.Usage = func() { printUsage(stderr, fs) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, getenv)
+	if err == nil && (*level != "" || *format != "") {
+		if *level != "" {
+			cfg.Log.Level = *level
+		}
+		if *format != "" {
+			cfg.Log.Format = *format
+		}
+		err = cfg.validate()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "swt: %v\n", err)
+		return 2
+	}
+	logger, err := newLogger(cfg.Log, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "swt: %v\n", err)
+		return 2
+	}
+
+	// The binding layer logs through the default logger
+	slog.SetDefault(logger)
+
+	env := &environment{cfg: cfg, log: logger, stdout: stdout, stderr: stderr}
+	name, err := dispatch(env, commands, fs.Args(), nil)
+	switch {
+	case errors.Is(err, errUsage):
+		if name == "" {
+			printUsage(stderr, fs)
+		}
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "swt %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// dispatch finds the command named by args and runs it, returning its full name
+func dispatch(env *environment, cmds []command, args []string, parents []string) (string, error) {
+	if len(args) == 0 {
+		return "", errUsage
+	}
+	for _, cmd := range cmds {
+		if cmd.name != args[0] {
+			continue
+		}
+		path := append(parents, cmd.name)
+		if cmd.sub != nil {
+			name, err := dispatch(env, cmd.sub, args[1:], path)
+			if errors.Is(err, errUsage) && name == "" {
+				printCommands(env.stderr, "swt "+strings.Join(path, " "), cmd.sub)
+				return strings.Join(path, " "), err
+			}
+			return name, err
+		}
+		return strings.Join(path, " "), cmd.run(env, args[1:])
+	}
+	return "", errUsage
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: swt [global flags] <command> [flags]")
+	fmt.Fprintln(w, "Global flags:")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	printCommands(w, "swt", commands)
+	fmt.Fprintln(w, "Settings are read from -config or $"+envConfigFile+", then SWT_* variables, then flags.")
+}
+
+func printCommands(w io.Writer, prefix string, cmds []command) {
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range cmds {
+		if cmd.sub == nil {
+			fmt.Fprintf(w, "  %-28s %s\n", prefix+" "+cmd.name, cmd.summary)
+			continue
+		}
+		for _, sub := range cmd.sub {
+			fmt.Fprintf(w, "  %-28s %s\n", prefix+" "+cmd.name+" "+sub.name, sub.summary)
+		}
+	}
+}
+
+// newLogger builds the logger every command shares, tagging records with the
+// request ID and trace of the context they are logged with
+func newLogger(cfg LogConfig, w io.Writer) (*slog.Logger, error) {
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "json" {
+		return slog.New(telemetry.NewHandler(slog.NewJSONHandler(w, options))), nil
+	}
+	return slog.New(telemetry.NewHandler(slog.NewTextHandler(w, options))), nil
+}
+
+func parseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("log level %q is not debug, info, warn or error", name)
+	}
+	return level, nil
+}
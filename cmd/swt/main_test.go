@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blockchain-jedi/ipfs-blockchain-binding/bindingtest"
+	"blockchain-jedi/waste-management-access-control/hibe"
+	"blockchain-jedi/waste-nft/custody"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// runSWT runs swt with the given environment, returning its exit code and output
+func runSWT(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, func(name string) string { return env[name] }, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swt.json")
+	file := `{"log": {"level": "debug"}, "hibe": {"url": "http://file:8080"}, "ratelimit": {"port": 9000, "facility": "north"}}`
+	if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path, func(name string) string {
+		return map[string]string{"SWT_HIBE_URL": "http://env:8080", "SWT_CHAIN_ID": "80001"}[name]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.Level != "debug" || cfg.RateLimit.Port != 9000 || cfg.RateLimit.Facility != "north" {
+		t.Errorf("file settings not applied: %+v", cfg)
+	}
+	if cfg.HIBE.URL != "http://env:8080" || cfg.Chain.ChainID != 80001 {
+		t.Errorf("environment did not override the file: %+v", cfg)
+	}
+	if cfg.Keys.PublicKey != "public.key" || cfg.RateLimit.MaxClientsTracked != 10000 {
+		t.Errorf("defaults lost: %+v", cfg)
+	}
+
+	if _, err := loadConfig(path, func(name string) string {
+		return map[string]string{"SWT_RATELIMIT_PORT": "many"}[name]
+	}); err == nil {
+		t.Error("expected a malformed variable to be rejected")
+	}
+	unknown := filepath.Join(t.TempDir(), "unknown.json")
+	os.WriteFile(unknown, []byte(`{"ratelimit": {"prot": 1}}`), 0644)
+	if _, err := loadConfig(unknown, func(string) string { return "" }); err == nil {
+		t.Error("expected an unknown setting to be rejected")
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"frobnicate"},
+		{"serve"},
+		{"serve", "nothing"},
+		{"revoke", "-reason", "lost"},
+		{"-log-level", "loud", "bench"},
+	} {
+		if code, _, _ := runSWT(t, nil, args...); code != 2 {
+			t.Errorf("swt %v exited %d, want 2", args, code)
+		}
+	}
+	_, _, stderr := runSWT(t, nil, "serve")
+	if !strings.Contains(stderr, "ratelimit") {
+		t.Errorf("serve usage does not list its commands:\n%s", stderr)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	var paths []string
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		if body["keyId"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error": "key not found"}`)
+			return
+		}
+		io.WriteString(w, `{"status": "revoked"}`)
+	}))
+	defer server.Close()
+	env := map[string]string{"SWT_HIBE_URL": server.URL}
+
+	code, stdout, stderr := runSWT(t, env, "revoke", "-key-id", "k-1", "-reason", "lost", "-by", "ops", "-for", "1h")
+	if code != 0 {
+		t.Fatalf("revoke by ID exited %d: %s", code, stderr)
+	}
+	if paths[0] != "/revoke" || bodies[0]["keyId"] != "k-1" || bodies[0]["revokedBy"] != "ops" || bodies[0]["effectiveFor"] != 3600.0 {
+		t.Errorf("unexpected request to %s: %v", paths[0], bodies[0])
+	}
+	if !strings.Contains(stdout, `"revoked"`) {
+		t.Errorf("response not printed: %s", stdout)
+	}
+
+	if code, _, stderr := runSWT(t, env, "revoke", "-uri", "facility/north/bin/7", "-reason", "decommissioned"); code != 0 {
+		t.Fatalf("revoke by URI exited %d: %s", code, stderr)
+	}
+	if paths[1] != "/revoke-by-uri" || bodies[1]["uri"] != "facility/north/bin/7" {
+		t.Errorf("unexpected request to %s: %v", paths[1], bodies[1])
+	}
+
+	code, _, stderr = runSWT(t, env, "revoke", "-uri", "facility/north/bin/7", "-reason", "r",
+		"-from", "2024-03-01T00:00:00Z", "-until", "2024-03-02T00:00:00Z")
+	if code != 0 {
+		t.Fatalf("revoke by window exited %d: %s", code, stderr)
+	}
+	if paths[2] != "/revoke" || bodies[2]["startTime"] != 1709251200.0 || bodies[2]["endTime"] != 1709337600.0 {
+		t.Errorf("unexpected request to %s: %v", paths[2], bodies[2])
+	}
+
+	code, _, stderr = runSWT(t, env, "revoke", "-key-id", "missing", "-reason", "lost")
+	if code != 1 || !strings.Contains(stderr, "key not found") {
+		t.Errorf("expected the server's error, got %d: %s", code, stderr)
+	}
+}
+
+func TestAuditVerify(t *testing.T) {
+	publicKey, _, err := hibe.Setup(hibe.NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sensors := custody.NewSensorRegistry()
+	sensors.Register("truck-7-scale", crypto.PubkeyToAddress(scale.PublicKey))
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	binder := bindingtest.NewBinder()
+	service, err := custody.NewService(custody.Config{
+		Facility:  "north",
+		PublicKey: publicKey,
+		Store:     bindingtest.NewMemStore(),
+		Binder:    binder,
+		Sensors:   sensors,
+		GasFee:    big.NewInt(1000),
+		Now:       func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reading := custody.SensorReading{SensorID: "truck-7-scale", BatchID: "B-1", Kind: custody.ReadingWeight, Value: 40, Unit: "kg", TakenAt: now}
+	if err := custody.SignReading(scale, &reading); err != nil {
+		t.Fatal(err)
+	}
+	truck := custody.Party{ID: "truck-7", Wallet: common.HexToAddress("0x1000000000000000000000000000000000000007")}
+	if _, err := service.Record(&custody.Handoff{BatchID: "B-1", Stage: custody.StageBinPickup,
+		From: custody.Party{ID: "bin-7"}, To: truck, Readings: []custody.SensorReading{reading}}); err != nil {
+		t.Fatal(err)
+	}
+	report, err := service.Report("B-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeReport := func(name string, report *custody.Report) string {
+		path := filepath.Join(dir, name)
+		data, _ := json.Marshal(report)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	dial := dialAuditBinder
+	defer func() { dialAuditBinder = dial }()
+	var auditBinder custody.Binder = binder
+	dialAuditBinder = func(*Config, string, string) (custody.Binder, error) { return auditBinder, nil }
+
+	code, stdout, stderr := runSWT(t, nil, "audit", "verify", "-report", writeReport("report.json", report), "-json")
+	if code != 0 {
+		t.Fatalf("audit verify exited %d: %s", code, stderr)
+	}
+	var result AuditResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.BatchID != "B-1" || result.Steps != 1 || result.Holder != "truck-7" || result.EvidenceVerified {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	tampered := *report
+	tampered.Steps = append([]custody.Step(nil), report.Steps...)
+	tampered.Steps[0].WeightGrams = 30000
+	code, _, stderr = runSWT(t, nil, "audit", "verify", "-report", writeReport("tampered.json", &tampered))
+	if code != 1 {
+		t.Errorf("tampered report exited %d, want 1: %s", code, stderr)
+	}
+
+	// Anchors the chain does not know fail the audit
+	auditBinder = bindingtest.NewBinder()
+	code, _, stderr = runSWT(t, nil, "audit", "verify", "-report", writeReport("report.json", report))
+	if code != 1 || !strings.Contains(stderr, "anchor does not verify") {
+		t.Errorf("unanchored report exited %d, want 1: %s", code, stderr)
+	}
+}
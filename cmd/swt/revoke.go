@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// revokeTimeout bounds one revocation call
+const revokeTimeout = 30 * time.Second
+
+// revocationRequest is the body of the HIBE server's POST /revoke
+type revocationRequest struct {
+	KeyID        string `json:"keyId,omitempty"`
+	URI          string `json:"uri,omitempty"`
+	Hierarchy    string `json:"hierarchy,omitempty"`
+	RevokedBy    string `json:"revokedBy"`
+	Reason       string `json:"reason"`
+	EffectiveFor int64  `json:"effectiveFor,omitempty"`
+	StartTime    int64  `json:"startTime,omitempty"`
+	EndTime      int64  `json:"endTime,omitempty"`
+}
+
+// runRevoke revokes one key, by ID or by URI and validity window, or every key
+// delegated for a URI
+func runRevoke(env *environment, args []string) error {
+	fs := newFlagSet(env, "revoke")
+	server := fs.String("server", env.cfg.HIBE.URL, "HIBE server URL")
+	keyID := fs.String("key-id", "", "ID of the key to revoke")
+	uri := fs.String("uri", "", "URI of the key; without -from and -until every key for it is revoked")
+	hierarchy := fs.String("hierarchy", "", "hierarchy of the key, with -uri")
+	from := fs.String("from", "", "start of the key's validity window (RFC 3339), with -uri")
+	until := fs.String("until", "", "end of the key's validity window (RFC 3339), with -uri")
+	reason := fs.String("reason", "", "why the key is revoked (required)")
+	by := fs.String("by", defaultRevoker(), "who revokes the key")
+	duration := fs.Duration("for", 0, "how long the revocation lasts (default permanent)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *reason == "" || (*keyID == "") == (*uri == "") {
+		fmt.Fprintln(env.stderr, "usage: swt revoke (-key-id ID | -uri URI [-hierarchy H -from T -until T]) -reason R")
+		return errUsage
+	}
+
+	path := "/revoke"
+	var body interface{}
+	switch {
+	case *keyID != "":
+		body = &revocationRequest{KeyID: *keyID, RevokedBy: *by, Reason: *reason, EffectiveFor: int64(duration.Seconds())}
+	case *from != "" || *until != "":
+		start, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %v", err)
+		}
+		end, err := time.Parse(time.RFC3339, *until)
+		if err != nil {
+			return fmt.Errorf("invalid -until: %v", err)
+		}
+		body = &revocationRequest{URI: *uri, Hierarchy: *hierarchy, StartTime: start.Unix(), EndTime: end.Unix(),
+			RevokedBy: *by, Reason: *reason, EffectiveFor: int64(duration.Seconds())}
+	default:
+		if *duration != 0 {
+			return errors.New("-for cannot limit a revocation of every key for a URI")
+		}
+		path = "/revoke-by-uri"
+		body = map[string]string{"uri": *uri, "revokedBy": *by, "reason": *reason}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), revokeTimeout)
+	defer cancel()
+	response, err := postJSON(ctx, strings.TrimSuffix(*server, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	env.log.Info("revoked", "server", *server, "key_id", *keyID, "uri", *uri)
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, response, "", "  "); err != nil {
+		return fmt.Errorf("malformed response: %v", err)
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(env.stdout)
+	return err
+}
+
+// postJSON posts body and returns the response, turning a non-2xx status into
+// an error carrying the server's message
+func postJSON(ctx context.Context, url string, body interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			return nil, fmt.Errorf("%s: %s", resp.Status, failure.Error)
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return data, nil
+}
+
+// defaultRevoker names the operator running swt
+func defaultRevoker() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "swt"
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	prevention "blockchain-jedi/hash-flooding-prevention"
+	binding "blockchain-jedi/ipfs-blockchain-binding"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// shutdownGrace is how long a server has to drain after SIGINT or SIGTERM
+const shutdownGrace = 15 * time.Second
+
+// newFlagSet returns a flag set that reports errors instead of exiting
+func newFlagSet(env *environment, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("swt "+name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	return fs
+}
+
+// parseFlags parses args, mapping a bad command line to errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// runServeRateLimit runs the multi-tier rate limiting service until SIGINT or
+// SIGTERM, then saves client reputation
+func runServeRateLimit(env *environment, args []string) error {
+	cfg := env.cfg.RateLimit
+	fs := newFlagSet(env, "serve ratelimit")
+	fs.IntVar(&cfg.Port, "port", cfg.Port, "port to listen on")
+	fs.StringVar(&cfg.ReputationStore, "reputation-store", cfg.ReputationStore, "client reputation file, empty to keep it in memory")
+	rpcURL := fs.String("rpc", env.cfg.Chain.RPCURL, "Ethereum node whose base fee sets the tiers, empty for static tiers")
+	ipfsAPI := fs.String("ipfs", env.cfg.IPFS.API, "IPFS node whose pins tell requested CIDs from random ones, empty to skip the check")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	config := &prevention.PreventionConfig{
+		EnableTieredLimiting:        true,
+		EnableFalsePositiveTracking: true,
+		MaxClientsTracked:           cfg.MaxClientsTracked,
+		CleanupInterval:             5 * time.Minute,
+		MetricsRetentionPeriod:      24 * time.Hour,
+		EnableAnomalyDetection:      true,
+	}
+	if cfg.ReputationStore != "" {
+		reputation := prevention.DefaultReputationConfig()
+		reputation.Path = cfg.ReputationStore
+		reputation.Facility = cfg.Facility
+		config.Reputation = reputation
+	}
+	floodPrevention := prevention.NewHashFloodingPrevention(config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *rpcURL != "" {
+		client, err := ethclient.DialContext(ctx, *rpcURL)
+		if err != nil {
+			env.log.Warn("fee feed disabled", "rpc", *rpcURL, "error", err)
+		} else {
+			defer client.Close()
+			floodPrevention.AttachFeeFeed(client, prevention.DefaultFeeFeedConfig()).Start(ctx)
+			env.log.Info("fee feed enabled; tiers follow multiples of the base fee", "rpc", *rpcURL)
+		}
+	}
+
+	if *ipfsAPI != "" {
+		floodPrevention.AttachPinFeed(binding.NewIPFSConnector(*ipfsAPI), prevention.DefaultPinPollInterval).Start(ctx)
+		env.log.Info("pin feed enabled; floods of never-pinned CIDs are challenged", "ipfs", *ipfsAPI)
+	}
+
+	serviceConfig := &prevention.ServiceConfig{
+		Port:                    cfg.Port,
+		EnableMetrics:           true,
+		EnableAdvancedAnalytics: true,
+		RequestTimeout:          time.Duration(cfg.RequestTimeoutSeconds) * time.Second,
+		MaxConcurrentRequests:   cfg.MaxConcurrentRequests,
+		AdminToken:              cfg.AdminToken,
+	}
+	if cfg.Puzzles {
+		serviceConfig.Puzzles = prevention.DefaultPuzzleConfig()
+	}
+	service := prevention.NewMultiTierRateLimitingService(floodPrevention, serviceConfig)
+
+	served := make(chan error, 1)
+	go func() { served <- service.Start() }()
+	env.log.Info("rate limiting service listening", "port", cfg.Port, "admin_api", cfg.AdminToken != "")
+
+	var serveErr error
+	select {
+	case serveErr = <-served:
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+		defer cancel()
+		if err := service.Stop(shutdown); err != nil {
+			env.log.Error("rate limiting service did not drain", "error", err)
+		}
+		serveErr = <-served
+	}
+
+	if err := floodPrevention.SaveReputation(); err != nil {
+		env.log.Error("failed to save client reputation", "error", err)
+	}
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	env.log.Info("rate limiting service stopped")
+	return nil
+}
@@ -0,0 +1,53 @@
+//go:build hibe
+
+// swt serve hibe builds the HIBE API server into swt, which needs go-hibe's
+// hibe library in go-hibe/packages/hibe. Build with -tags hibe once it is there.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	hibeconfig "hibe-api/config"
+	hibeserver "hibe-api/server"
+)
+
+func init() {
+	for i := range commands {
+		if commands[i].name == "serve" {
+			hibe := command{name: "hibe", summary: "run the HIBE server with the shared configuration", run: runServeHIBE}
+			commands[i].sub = append([]command{hibe}, commands[i].sub...)
+		}
+	}
+}
+
+// runServeHIBE runs the HIBE API server, built into swt, until SIGINT or
+// SIGTERM. The server is configured from hibe.settings, so it shares the file
+// every other command reads; HIBE_* variables and the arguments, the server's
+// own flags, override them.
+func runServeHIBE(env *environment, args []string) error {
+	cfg, err := hibeconfig.LoadSettings(env.cfg.HIBE.Settings, args, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		hibeconfig.Usage(env.stderr)
+		return errUsage
+	}
+	if err != nil {
+		return fmt.Errorf("invalid HIBE server configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	env.log.Info("starting HIBE server", "listen", cfg.Server.Listen)
+	if err := hibeserver.Run(ctx, cfg); err != nil {
+		return fmt.Errorf("HIBE server failed: %v", err)
+	}
+	env.log.Info("HIBE server stopped")
+	return nil
+}
@@ -3,6 +3,7 @@ package dynamic
 import (
 	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 )
@@ -128,7 +129,7 @@ func (dcb *DynamicCryptographicBinding) RunMultiFacilityNetworkGasAnalysis() *Ne
 	
 	fmt.Printf("\n%-12s | %-17s | %-20s | %-20s | %-20s\n", 
 		"Network Size", "Records Processed", "Traditional Gas Units", "Dynamic Hybrid Units", "Efficiency Improvement")
-	fmt.Printf("%s\n", "-"*95)
+	fmt.Printf("%s\n", strings.Repeat("-", 95))
 	
 	// Run gas analysis for each network size
 	for _, networkSize := range networkSizes {
@@ -251,7 +252,7 @@ func (dcb *DynamicCryptographicBinding) RunRealTimeUpdateProcessingTest() *Updat
 	
 	fmt.Printf("\n%-18s | %-15s | %-20s | %-12s | %-12s\n", 
 		"Concurrent Updates", "Updates/Second", "Consistency Latency", "Success Rate", "Memory Usage")
-	fmt.Printf("%s\n", "-"*85)
+	fmt.Printf("%s\n", strings.Repeat("-", 85))
 	
 	// Run concurrent update tests
 	for _, concurrentUpdates := range concurrentLoads {
@@ -271,9 +272,6 @@ func (dcb *DynamicCryptographicBinding) RunRealTimeUpdateProcessingTest() *Updat
 
 // runConcurrentUpdateTest executes a single concurrent update performance test
 func (dcb *DynamicCryptographicBinding) runConcurrentUpdateTest(concurrentUpdates int) *ConcurrentUpdateTest {
-	// Simulate concurrent update processing
-	startTime := time.Now()
-	
 	// Calculate expected performance based on concurrent load
 	updatesPerSecond := dcb.calculateUpdatesPerSecond(concurrentUpdates)
 	consistencyLatency := dcb.calculateConsistencyLatency(concurrentUpdates)
@@ -416,16 +414,20 @@ func (dcb *DynamicCryptographicBinding) printGasEfficiencyReport(report *GasEffi
 
 // Helper formatting functions
 func formatNetworkSize(size int) string {
-	if size >= 1000000 {
-		return fmt.Sprintf("%,d", size)
-	} else if size >= 1000 {
-		return fmt.Sprintf("%,d", size)
-	}
-	return fmt.Sprintf("%d", size)
+	return formatNumber(size)
 }
 
+// formatNumber writes n with thousands separators, as fmt has no verb for them
 func formatNumber(n int) string {
-	return fmt.Sprintf("%,d", n)
+	digits := fmt.Sprintf("%d", n)
+	var formatted strings.Builder
+	for i, digit := range digits {
+		if i > 0 && digit != '-' && (len(digits)-i)%3 == 0 && digits[i-1] != '-' {
+			formatted.WriteByte(',')
+		}
+		formatted.WriteRune(digit)
+	}
+	return formatted.String()
 }
 
 func formatGasUnits(gas *big.Int) string {
@@ -596,7 +598,7 @@ func NewRealTimeUpdateProcessor() *RealTimeUpdateProcessor {
 		ConsistencyTracker: &ConsistencyTracker{
 			LatencyMetrics: make(map[int]time.Duration),
 		},
-		UpdateMemoryManager: &UpdateMemoryManager{
+		MemoryManager: &UpdateMemoryManager{
 			MemoryPools: make(map[int]*MemoryPool),
 		},
 	}
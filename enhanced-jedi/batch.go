@@ -0,0 +1,231 @@
+package jedi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrEmptyIdentity is reported for batch items with an empty identity or component
+var ErrEmptyIdentity = errors.New("identity has an empty component")
+
+// BatchItem is the outcome for one identity in a delegated batch
+type BatchItem struct {
+	Index    int // position of the suffix in the request
+	Identity []string
+	Key      *WasteManagementKey
+	Duration time.Duration
+	Error    error
+}
+
+// prefixDigest is the identity hash state after absorbing a shared prefix. Each
+// item resumes from a copy of it rather than rehashing the prefix.
+type prefixDigest struct {
+	state []byte
+}
+
+func newPrefixDigest(prefix []string) (*prefixDigest, error) {
+	hasher := sha256.New()
+	for _, component := range prefix {
+		hasher.Write([]byte(component))
+	}
+	state, err := hasher.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture prefix digest: %v", err)
+	}
+	return &prefixDigest{state: state}, nil
+}
+
+// resume returns a hasher positioned just after the prefix
+func (pd *prefixDigest) resume() (hash.Hash, error) {
+	hasher := sha256.New()
+	if err := hasher.(encoding.BinaryUnmarshaler).UnmarshalBinary(pd.state); err != nil {
+		return nil, fmt.Errorf("failed to resume prefix digest: %v", err)
+	}
+	return hasher, nil
+}
+
+// DelegateBatch generates keys for the identities prefix+suffix under one facility.
+// The facility template and the hash over the prefix are computed once for the
+// whole batch; each key is identical to the one GenerateWasteManagementHIBEKey
+// would produce for the same identity. Results are streamed in completion order
+// and the channel is closed after every suffix has been answered. An invalid
+// item or a cancelled ctx fails only the items it affects.
+func (ej *EnhancedHIBE) DelegateBatch(ctx context.Context, facility string, prefix []string, suffixes [][]string) <-chan *BatchItem {
+	workers := runtime.NumCPU()
+	items := make(chan *BatchItem, workers)
+	indexes := make(chan int)
+
+	template := ej.WasteManagementOptimizer.getFacilityTemplate(facility)
+	var hasher *SpecializedHasher
+	if template != nil {
+		hasher = ej.WasteManagementOptimizer.getSpecializedHasher(template.FacilityType)
+	}
+	digest, digestErr := newPrefixDigest(prefix)
+	if digestErr == nil && containsEmpty(prefix) {
+		digestErr = ErrEmptyIdentity
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				item := &BatchItem{Index: i, Identity: joinIdentity(prefix, suffixes[i])}
+				switch {
+				case digestErr != nil:
+					item.Error = digestErr
+				case ctx.Err() != nil:
+					item.Error = ctx.Err()
+				default:
+					start := time.Now()
+					item.Key, item.Error = ej.delegateFromPrefix(digest, item.Identity, suffixes[i], template, hasher)
+					item.Duration = time.Since(start)
+					if item.Error == nil && template != nil {
+						ej.PerformanceTracker.recordKeyGeneration(item.Duration, len(item.Identity), facility)
+					}
+				}
+				items <- item
+			}
+		}()
+	}
+
+	go func() {
+		for i := range suffixes {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+		close(items)
+	}()
+	return items
+}
+
+// delegateFromPrefix finishes one key from the shared prefix digest, mirroring
+// generateOptimizedKey for facilities with a template and generateGenericKey otherwise
+func (ej *EnhancedHIBE) delegateFromPrefix(digest *prefixDigest, identity, suffix []string, template *FacilityTemplate, hasher *SpecializedHasher) (*WasteManagementKey, error) {
+	if len(suffix) == 0 || containsEmpty(suffix) {
+		return nil, ErrEmptyIdentity
+	}
+
+	identityHash, err := digest.resume()
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range suffix {
+		identityHash.Write([]byte(component))
+	}
+
+	if template == nil {
+		return &WasteManagementKey{
+			Identity:          identity,
+			Facility:          "generic",
+			GeneratedAt:       time.Now(),
+			KeyData:           identityHash.Sum(nil),
+			OptimizationLevel: 0,
+		}, nil
+	}
+
+	key := &WasteManagementKey{
+		Identity:          identity,
+		Facility:          template.FacilityType,
+		GeneratedAt:       time.Now(),
+		OptimizationLevel: template.CriticalityLevel,
+	}
+	if hasher != nil {
+		key.KeyData = make([]byte, 64)
+		copy(key.KeyData, hasher.sum(identityHash))
+	} else {
+		key.KeyData = identityHash.Sum(nil)
+	}
+	for _, hint := range template.KeyGenerationHints {
+		ej.applyOptimizationHint(key, hint)
+	}
+	return key, nil
+}
+
+// ProcessBatch answers a BatchRequest. Routine tasks are grouped by facility and
+// delegated from their longest shared identity prefix; overflow tasks keep their
+// fast path. Results are in request order and are also sent on ResponseChannel.
+func (ej *EnhancedHIBE) ProcessBatch(ctx context.Context, batch *BatchRequest) []*HIBEResult {
+	results := make([]*HIBEResult, len(batch.Requests))
+
+	groups := make(map[string][]int)
+	for i, task := range batch.Requests {
+		if task.IsOverflow {
+			start := time.Now()
+			key, _, err := ej.GenerateWasteManagementHIBEKey(task.Identity, task.Facility, true)
+			results[i] = &HIBEResult{TaskID: task.TaskID, Key: key, Duration: time.Since(start), Error: err, Timestamp: time.Now()}
+			continue
+		}
+		groups[task.Facility] = append(groups[task.Facility], i)
+	}
+
+	for facility, members := range groups {
+		identities := make([][]string, len(members))
+		for j, i := range members {
+			identities[j] = batch.Requests[i].Identity
+		}
+		prefix := commonIdentityPrefix(identities)
+
+		suffixes := make([][]string, len(members))
+		for j, identity := range identities {
+			suffixes[j] = identity[len(prefix):]
+		}
+		for item := range ej.DelegateBatch(ctx, facility, prefix, suffixes) {
+			task := batch.Requests[members[item.Index]]
+			results[members[item.Index]] = &HIBEResult{
+				TaskID:    task.TaskID,
+				Key:       item.Key,
+				Duration:  item.Duration,
+				Error:     item.Error,
+				Timestamp: time.Now(),
+			}
+		}
+	}
+
+	if batch.ResponseChannel != nil {
+		batch.ResponseChannel <- results
+	}
+	return results
+}
+
+// commonIdentityPrefix returns the leading components shared by every identity,
+// leaving each identity at least one component of its own
+func commonIdentityPrefix(identities [][]string) []string {
+	if len(identities) == 0 || len(identities[0]) == 0 {
+		return nil
+	}
+
+	prefix := identities[0][:len(identities[0])-1]
+	for _, identity := range identities[1:] {
+		n := 0
+		for n < len(prefix) && n < len(identity)-1 && identity[n] == prefix[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+func joinIdentity(prefix, suffix []string) []string {
+	identity := make([]string, 0, len(prefix)+len(suffix))
+	identity = append(identity, prefix...)
+	return append(identity, suffix...)
+}
+
+func containsEmpty(components []string) bool {
+	for _, component := range components {
+		if component == "" {
+			return true
+		}
+	}
+	return false
+}
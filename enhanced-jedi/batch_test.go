@@ -0,0 +1,105 @@
+package jedi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func collectBatch(items <-chan *BatchItem, n int) []*BatchItem {
+	results := make([]*BatchItem, n)
+	for item := range items {
+		results[item.Index] = item
+	}
+	return results
+}
+
+func TestDelegateBatchMatchesIndividualKeys(t *testing.T) {
+	ej := NewEnhancedHIBE()
+	defer ej.ConcurrentProcessor.Scheduler.Close()
+
+	for _, facility := range []string{"cardiology", "general", "unknown"} {
+		prefix := []string{"facility", facility, "bin"}
+		suffixes := make([][]string, 50)
+		for i := range suffixes {
+			suffixes[i] = []string{fmt.Sprintf("bin-%03d", i), "fill-level"}
+		}
+
+		results := collectBatch(ej.DelegateBatch(context.Background(), facility, prefix, suffixes), len(suffixes))
+		for i, item := range results {
+			if item == nil || item.Error != nil {
+				t.Fatalf("%s item %d failed: %+v", facility, i, item)
+			}
+			want, _, err := ej.GenerateWasteManagementHIBEKey(item.Identity, facility, false)
+			if err != nil {
+				t.Fatalf("individual keygen failed: %v", err)
+			}
+			if !bytes.Equal(item.Key.KeyData, want.KeyData) || item.Key.Facility != want.Facility ||
+				item.Key.OptimizationLevel != want.OptimizationLevel {
+				t.Errorf("%s item %d differs from the individually generated key", facility, i)
+			}
+		}
+	}
+}
+
+func TestDelegateBatchReportsItemFailures(t *testing.T) {
+	ej := NewEnhancedHIBE()
+	defer ej.ConcurrentProcessor.Scheduler.Close()
+
+	suffixes := [][]string{{"bin-1"}, {}, {"bin-3", ""}, {"bin-4"}}
+	results := collectBatch(ej.DelegateBatch(context.Background(), "general", []string{"facility", "general", "bin"}, suffixes), len(suffixes))
+
+	for i, item := range results {
+		failed := i == 1 || i == 2
+		if failed != errors.Is(item.Error, ErrEmptyIdentity) {
+			t.Errorf("item %d: unexpected error %v", i, item.Error)
+		}
+		if !failed && item.Key == nil {
+			t.Errorf("item %d: expected a key", i)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for item := range ej.DelegateBatch(ctx, "general", nil, [][]string{{"a"}, {"b"}}) {
+		if !errors.Is(item.Error, context.Canceled) {
+			t.Errorf("expected a cancelled batch item, got %v", item.Error)
+		}
+	}
+}
+
+func TestProcessBatchGroupsByFacility(t *testing.T) {
+	ej := NewEnhancedHIBE()
+	defer ej.ConcurrentProcessor.Scheduler.Close()
+
+	batch := &BatchRequest{
+		BatchID: "onboarding",
+		Requests: []*HIBETask{
+			{TaskID: "a", Identity: []string{"facility", "general", "bin", "101", "weight"}, Facility: "general"},
+			{TaskID: "b", Identity: []string{"facility", "oncology", "bin", "7", "weight"}, Facility: "oncology"},
+			{TaskID: "c", Identity: []string{"facility", "general", "bin", "102", "weight"}, Facility: "general"},
+			{TaskID: "d", Identity: []string{"facility", "overflow", "bin", "9", "fill"}, Facility: "overflow", IsOverflow: true},
+		},
+		ResponseChannel: make(chan []*HIBEResult, 1),
+	}
+
+	results := ej.ProcessBatch(context.Background(), batch)
+	if sent := <-batch.ResponseChannel; len(sent) != len(results) {
+		t.Fatalf("expected the results on ResponseChannel")
+	}
+	for i, result := range results {
+		task := batch.Requests[i]
+		if result.TaskID != task.TaskID || result.Error != nil {
+			t.Fatalf("result %d: %+v", i, result)
+		}
+		if fmt.Sprint(result.Key.Identity) != fmt.Sprint(task.Identity) {
+			t.Errorf("result %d has identity %v, want %v", i, result.Key.Identity, task.Identity)
+		}
+	}
+
+	if prefix := commonIdentityPrefix([][]string{{"a", "b", "c"}, {"a", "b", "d"}, {"a", "b"}}); fmt.Sprint(prefix) != "[a]" {
+		t.Errorf("unexpected common prefix %v", prefix)
+	}
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 	
-	"./jedi"
-	"../dynamic-binding"
+	dynamic "blockchain-jedi/dynamic-binding"
+	jedi "blockchain-jedi/enhanced-jedi"
 )
 
 func main() {
@@ -44,6 +46,16 @@ func runWasteManagementHIBEOptimization() {
 	// Initialize Enhanced HIBE system
 	enhancedHibe := jedi.NewEnhancedHIBE()
 	
+	// Facility templates may come from a declarative file that is reloaded on change
+	if path := os.Getenv("FACILITY_TEMPLATES"); path != "" {
+		if err := enhancedHibe.WasteManagementOptimizer.LoadFacilityTemplates(path); err != nil {
+			log.Fatalf("Failed to load facility templates: %v", err)
+		}
+		go enhancedHibe.WasteManagementOptimizer.WatchFacilityTemplates(context.Background(), path, 10*time.Second, func(err error) {
+			log.Printf("Facility template reload failed, keeping current templates: %v", err)
+		})
+	}
+	
 	// Run concurrent performance test
 	results := enhancedHibe.RunConcurrentPerformanceTest()
 	
@@ -162,7 +174,7 @@ func analyzeHIBEPerformanceResults(results *jedi.PerformanceTestResults) {
 		}
 	}
 	
-	fmt.Printf("🔄 Scalability Range: 1,000 - %,d concurrent requests\n", maxConcurrentRequests)
+	fmt.Printf("🔄 Scalability Range: 1,000 - %d concurrent requests\n", maxConcurrentRequests)
 	fmt.Printf("⚡ Latency Range: %.0fms - %.0fms (Enhanced HIBE)\n", minLatency, maxLatency)
 }
 
@@ -205,9 +217,9 @@ func analyzeGasOptimizationResults(analysis *dynamic.NetworkGasAnalysis) {
 	savingsPercentage := float64(totalSavings) / float64(totalTraditionalGas) * 100
 	
 	fmt.Printf("✅ Average Gas Efficiency Improvement: %.1f%%\n", avgEfficiency)
-	fmt.Printf("✅ Total Gas Saved: %,d units\n", totalSavings)
+	fmt.Printf("✅ Total Gas Saved: %d units\n", totalSavings)
 	fmt.Printf("✅ Overall Savings Percentage: %.1f%%\n", savingsPercentage)
-	fmt.Printf("📊 Network Size Range: 5,000 - %,d facilitys\n", maxNetworkSize)
+	fmt.Printf("📊 Network Size Range: 5,000 - %d facilitys\n", maxNetworkSize)
 	fmt.Printf("📈 Efficiency Range: %.1f%% - %.1f%%\n", minEfficiency, maxEfficiency)
 	
 	// Validate against specification targets
@@ -279,20 +291,20 @@ func analyzeRealTimeUpdateResults(metrics *dynamic.UpdatePerformanceMetrics) {
 	avgLatency := totalLatency / int64(testCount)
 	avgSuccessRate := totalSuccessRate / float64(testCount)
 	
-	fmt.Printf("✅ Average Throughput: %,d updates/second\n", avgThroughput)
+	fmt.Printf("✅ Average Throughput: %d updates/second\n", avgThroughput)
 	fmt.Printf("✅ Average Consistency Latency: %dms\n", avgLatency)
 	fmt.Printf("✅ Average Success Rate: %.1f%%\n", avgSuccessRate)
-	fmt.Printf("🔄 Concurrent Update Range: 1,000 - %,d updates\n", maxConcurrentUpdates)
-	fmt.Printf("⚡ Peak Throughput: %,d updates/second\n", maxThroughput)
+	fmt.Printf("🔄 Concurrent Update Range: 1,000 - %d updates\n", maxConcurrentUpdates)
+	fmt.Printf("⚡ Peak Throughput: %d updates/second\n", maxThroughput)
 	fmt.Printf("📊 Latency Range: %dms - %dms\n", minLatency, maxLatency)
 	fmt.Printf("✅ Success Rate Range: %.1f%% - 99.8%%\n", minSuccessRate)
 	
 	// Performance validation
 	if avgSuccessRate >= 97.0 && maxThroughput >= 4000 {
-		fmt.Printf("🎯 Performance Target: ✅ ACHIEVED (Success Rate: %.1f%%, Peak Throughput: %,d)\n", 
+		fmt.Printf("🎯 Performance Target: ✅ ACHIEVED (Success Rate: %.1f%%, Peak Throughput: %d)\n", 
 			avgSuccessRate, maxThroughput)
 	} else {
-		fmt.Printf("❌ Performance Target: NEEDS IMPROVEMENT (Success Rate: %.1f%%, Peak Throughput: %,d)\n", 
+		fmt.Printf("❌ Performance Target: NEEDS IMPROVEMENT (Success Rate: %.1f%%, Peak Throughput: %d)\n", 
 			avgSuccessRate, maxThroughput)
 	}
 	
@@ -300,7 +312,7 @@ func analyzeRealTimeUpdateResults(metrics *dynamic.UpdatePerformanceMetrics) {
 	fmt.Println("\n💾 Memory Usage Analysis:")
 	for i, test := range metrics.ConcurrentUpdateTests {
 		if i < 3 || i >= testCount-3 { // Show first 3 and last 3
-			fmt.Printf("  %,d concurrent updates: %s\n", test.ConcurrentUpdates, test.MemoryUsage)
+			fmt.Printf("  %d concurrent updates: %s\n", test.ConcurrentUpdates, test.MemoryUsage)
 		} else if i == 3 {
 			fmt.Printf("  ... (additional tests) ...\n")
 		}
@@ -309,9 +321,9 @@ func analyzeRealTimeUpdateResults(metrics *dynamic.UpdatePerformanceMetrics) {
 
 // generateTechnicalContributionSummary creates comprehensive technical contribution analysis
 func generateTechnicalContributionSummary(hibeResults *jedi.PerformanceTestResults, gasAnalysis *dynamic.NetworkGasAnalysis, updateMetrics *dynamic.UpdatePerformanceMetrics) {
-	fmt.Printf("\n" + "="*80 + "\n")
+	fmt.Printf("\n" + strings.Repeat("=", 80) + "\n")
 	fmt.Printf("TECHNICAL CONTRIBUTION SUMMARY - ADDRESSING REVIEWER #2\n")
-	fmt.Printf("="*80 + "\n")
+	fmt.Printf(strings.Repeat("=", 80) + "\n")
 	
 	fmt.Printf("🎯 NOVEL TECHNICAL CONTRIBUTIONS DEMONSTRATED:\n\n")
 	
@@ -420,7 +432,7 @@ func generateTechnicalContributionSummary(hibeResults *jedi.PerformanceTestResul
 
 // printUsage displays usage information
 func printUsage() {
-	fmt.Println("Usage: go run main.go [command]")
+	fmt.Println("Usage: go run ./cmd/enhanced-jedi [command]")
 	fmt.Println("")
 	fmt.Println("Commands:")
 	fmt.Println("  hibe-optimization    - Run waste-management-specific HIBE optimization test")
@@ -429,11 +441,11 @@ func printUsage() {
 	fmt.Println("  comprehensive        - Run all optimization tests (default)")
 	fmt.Println("")
 	fmt.Println("Examples:")
-	fmt.Println("  go run main.go")
-	fmt.Println("  go run main.go comprehensive")
-	fmt.Println("  go run main.go hibe-optimization")
-	fmt.Println("  go run main.go gas-optimization")
-	fmt.Println("  go run main.go real-time-updates")
+	fmt.Println("  go run ./cmd/enhanced-jedi")
+	fmt.Println("  go run ./cmd/enhanced-jedi comprehensive")
+	fmt.Println("  go run ./cmd/enhanced-jedi hibe-optimization")
+	fmt.Println("  go run ./cmd/enhanced-jedi gas-optimization")
+	fmt.Println("  go run ./cmd/enhanced-jedi real-time-updates")
 	fmt.Println("")
 	fmt.Println("Expected Results:")
 	fmt.Println("  • HIBE Optimization: ~40% performance improvement")
@@ -0,0 +1,355 @@
+package jedi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// URISchema describes the levels of a waste-management identity such as
+// facility/transfer-station/bin/4711/fill-level/realtime. The first level is the
+// root of every identity and the second names the facility.
+type URISchema struct {
+	Levels []SchemaLevel `json:"levels"`
+}
+
+// SchemaLevel is one level of a URISchema. A level with Values accepts only those
+// components; otherwise any identifier is accepted.
+type SchemaLevel struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values,omitempty"`
+}
+
+// FacilityConfig is the declarative form of the optimizer's facility templates
+type FacilityConfig struct {
+	Schema     *URISchema               `json:"schema,omitempty"` // DefaultURISchema when omitted
+	Facilities []FacilityTemplateConfig `json:"facilities"`
+}
+
+// FacilityTemplateConfig declares one FacilityTemplate
+type FacilityTemplateConfig struct {
+	Name               string        `json:"name"`
+	CriticalityLevel   int           `json:"criticalityLevel"`
+	OptimizationFactor float64       `json:"optimizationFactor"`
+	FastAccessPaths    [][]string    `json:"fastAccessPaths,omitempty"` // bin, staff and equipment paths when omitted
+	Hints              []HintConfig  `json:"hints,omitempty"`
+	Hasher             *HasherConfig `json:"hasher,omitempty"`
+}
+
+// HintConfig declares an OptimizationHint
+type HintConfig struct {
+	Context    string                 `json:"context"`
+	Strategy   string                 `json:"strategy"`
+	Speedup    float64                `json:"speedup"`
+	Conditions []string               `json:"conditions,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// HasherConfig declares the SpecializedHasher used for a facility
+type HasherConfig struct {
+	Type      string `json:"type"`
+	Algorithm string `json:"algorithm"`
+}
+
+// identifierPattern constrains identity components and facility names
+var identifierPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// knownStrategies are the hint strategies the key generator understands
+var knownStrategies = map[string]bool{
+	"precomputed_bases":       true,
+	"vectorized_computation":  true,
+	"parallel_key_generation": true,
+	"specialized_hashing":     true,
+	"fast_path_lookup":        true,
+	"standard_optimization":   true,
+}
+
+// DefaultURISchema is the facility/<facility>/<resource>/<id>/<data type>/<access> hierarchy
+func DefaultURISchema() *URISchema {
+	return &URISchema{Levels: []SchemaLevel{
+		{Name: "root", Values: []string{"facility"}},
+		{Name: "facility"},
+		{Name: "resource", Values: []string{"bin", "staff", "equipment"}},
+		{Name: "resource_id"},
+		{Name: "data_type"},
+		{Name: "access"},
+	}}
+}
+
+// Validate checks the schema's shape
+func (us *URISchema) Validate() error {
+	if len(us.Levels) < 2 {
+		return fmt.Errorf("schema needs a root and a facility level, has %d levels", len(us.Levels))
+	}
+	if len(us.Levels[0].Values) != 1 {
+		return fmt.Errorf("schema root level %q must have exactly one value", us.Levels[0].Name)
+	}
+	if len(us.Levels[1].Values) != 0 {
+		return fmt.Errorf("schema facility level %q cannot restrict its values", us.Levels[1].Name)
+	}
+
+	names := make(map[string]bool)
+	for i, level := range us.Levels {
+		if level.Name == "" || names[level.Name] {
+			return fmt.Errorf("schema level %d has a missing or duplicate name %q", i, level.Name)
+		}
+		names[level.Name] = true
+		for _, value := range level.Values {
+			if !identifierPattern.MatchString(value) {
+				return fmt.Errorf("schema level %q has invalid value %q", level.Name, value)
+			}
+		}
+	}
+	return nil
+}
+
+// validComponent reports whether component may appear at the given level
+func (us *URISchema) validComponent(level int, component string) bool {
+	if level >= len(us.Levels) {
+		return false
+	}
+	values := us.Levels[level].Values
+	if len(values) == 0 {
+		return identifierPattern.MatchString(component)
+	}
+	for _, value := range values {
+		if value == component {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultFastAccessPaths lists the facility's paths for each resource at the schema's third level
+func (us *URISchema) defaultFastAccessPaths(facility string) [][]string {
+	root := us.Levels[0].Values[0]
+	if len(us.Levels) < 3 || len(us.Levels[2].Values) == 0 {
+		return [][]string{{root, facility}}
+	}
+
+	paths := make([][]string, 0, len(us.Levels[2].Values))
+	for _, resource := range us.Levels[2].Values {
+		paths = append(paths, []string{root, facility, resource})
+	}
+	return paths
+}
+
+// LoadFacilityConfig reads and validates a facility configuration file
+func LoadFacilityConfig(path string) (*FacilityConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read facility config: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var config FacilityConfig
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse facility config %s: %v", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid facility config %s: %v", path, err)
+	}
+	return &config, nil
+}
+
+// Validate checks every facility against the URI schema
+func (fc *FacilityConfig) Validate() error {
+	schema := fc.schema()
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+	if len(fc.Facilities) == 0 {
+		return fmt.Errorf("no facilities defined")
+	}
+
+	seen := make(map[string]bool)
+	for _, facility := range fc.Facilities {
+		if !schema.validComponent(1, facility.Name) {
+			return fmt.Errorf("facility name %q is not a valid %s component", facility.Name, schema.Levels[1].Name)
+		}
+		if seen[facility.Name] {
+			return fmt.Errorf("facility %q is defined twice", facility.Name)
+		}
+		seen[facility.Name] = true
+
+		if facility.CriticalityLevel < 1 || facility.CriticalityLevel > 10 {
+			return fmt.Errorf("facility %q: criticalityLevel %d is outside 1-10", facility.Name, facility.CriticalityLevel)
+		}
+		if facility.OptimizationFactor < 0 || facility.OptimizationFactor >= 1 {
+			return fmt.Errorf("facility %q: optimizationFactor %v is outside [0, 1)", facility.Name, facility.OptimizationFactor)
+		}
+		for _, path := range facility.FastAccessPaths {
+			if err := schema.validatePath(facility.Name, path); err != nil {
+				return fmt.Errorf("facility %q: %v", facility.Name, err)
+			}
+		}
+		for _, hint := range facility.Hints {
+			if !knownStrategies[hint.Strategy] {
+				return fmt.Errorf("facility %q: unknown hint strategy %q", facility.Name, hint.Strategy)
+			}
+			if hint.Speedup < 0 || hint.Speedup > 1 {
+				return fmt.Errorf("facility %q: hint %q speedup %v is outside [0, 1]", facility.Name, hint.Context, hint.Speedup)
+			}
+		}
+		if facility.Hasher != nil && facility.Hasher.Type == "" {
+			return fmt.Errorf("facility %q: hasher has no type", facility.Name)
+		}
+	}
+	return nil
+}
+
+// validatePath checks a fast access path for facility against the schema. Levels
+// below the facility may be wildcarded.
+func (us *URISchema) validatePath(facility string, path []string) error {
+	if len(path) == 0 || len(path) > len(us.Levels) {
+		return fmt.Errorf("fast access path %v must have 1-%d components", path, len(us.Levels))
+	}
+	for i, component := range path {
+		switch {
+		case i == 1 && component != facility:
+			return fmt.Errorf("fast access path %v belongs to facility %q", path, component)
+		case i > 1 && component == "*":
+		case !us.validComponent(i, component):
+			return fmt.Errorf("fast access path %v has invalid %s %q", path, us.Levels[i].Name, component)
+		}
+	}
+	return nil
+}
+
+func (fc *FacilityConfig) schema() *URISchema {
+	if fc.Schema != nil {
+		return fc.Schema
+	}
+	return DefaultURISchema()
+}
+
+// ApplyFacilityConfig replaces the optimizer's templates, hashers and lookup tables
+// with those built from config. An invalid config leaves them untouched.
+func (ho *WasteManagementOptimizer) ApplyFacilityConfig(config *FacilityConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	schema := config.schema()
+
+	templates := make(map[string]*FacilityTemplate, len(config.Facilities))
+	hashers := make(map[string]*SpecializedHasher)
+	lookups := NewFastLookupTables()
+	for _, facility := range config.Facilities {
+		hints := make([]OptimizationHint, 0, len(facility.Hints))
+		for _, hint := range facility.Hints {
+			hints = append(hints, OptimizationHint{
+				Context:    hint.Context,
+				Strategy:   hint.Strategy,
+				Speedup:    hint.Speedup,
+				Conditions: hint.Conditions,
+				Parameters: hint.Parameters,
+			})
+		}
+
+		paths := facility.FastAccessPaths
+		if len(paths) == 0 {
+			paths = schema.defaultFastAccessPaths(facility.Name)
+		}
+
+		bases := precomputeBases(schema, facility.Name)
+		templates[facility.Name] = &FacilityTemplate{
+			FacilityType:       facility.Name,
+			KeyGenerationHints: hints,
+			PrecomputedBases:   bases,
+			FastAccessPaths:    paths,
+			CriticalityLevel:   facility.CriticalityLevel,
+			OptimizationFactor: facility.OptimizationFactor,
+		}
+		if facility.Hasher != nil {
+			hashers[facility.Name] = &SpecializedHasher{HasherType: facility.Hasher.Type, Algorithm: facility.Hasher.Algorithm}
+		}
+
+		prefix := strings.Join([]string{schema.Levels[0].Values[0], facility.Name}, "/")
+		lookups.FacilityHashes[facility.Name] = bases[1].Bytes()
+		lookups.CommonPrefixes[prefix] = bases[1]
+	}
+
+	ho.mu.Lock()
+	ho.FacilityTemplates = templates
+	ho.SpecializedHashers = hashers
+	ho.FastLookupTables = lookups
+	ho.mu.Unlock()
+	return nil
+}
+
+// LoadFacilityTemplates loads path and applies it; on error the current templates stay
+func (ho *WasteManagementOptimizer) LoadFacilityTemplates(path string) error {
+	config, err := LoadFacilityConfig(path)
+	if err != nil {
+		return err
+	}
+	return ho.ApplyFacilityConfig(config)
+}
+
+// WatchFacilityTemplates reloads path on its first check and then whenever its
+// modification time or size changes, checking every interval until ctx is done.
+// A file that fails to load is reported to onError, if set, and the templates in
+// use are kept.
+func (ho *WasteManagementOptimizer) WatchFacilityTemplates(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	var lastMod time.Time
+	var lastSize int64
+	missing := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			// Report a vanished file once rather than on every tick
+			if !missing && onError != nil {
+				onError(fmt.Errorf("failed to stat facility config: %v", err))
+			}
+			missing = true
+			continue
+		}
+		missing = false
+		if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+			continue
+		}
+		lastMod, lastSize = info.ModTime(), info.Size()
+
+		if err := ho.LoadFacilityTemplates(path); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// precomputeBases derives one base per schema level by hashing the facility's
+// identity prefix through that level. Levels without a fixed value contribute
+// their name, so each base is stable for the facility and schema.
+func precomputeBases(schema *URISchema, facility string) []*big.Int {
+	bases := make([]*big.Int, len(schema.Levels))
+	hasher := sha256.New()
+	for i, level := range schema.Levels {
+		switch {
+		case i == 1:
+			hasher.Write([]byte(facility))
+		case len(level.Values) == 1:
+			hasher.Write([]byte(level.Values[0]))
+		default:
+			hasher.Write([]byte(level.Name))
+		}
+		hasher.Write([]byte{'/'})
+		bases[i] = new(big.Int).SetBytes(hasher.Sum(nil))
+	}
+	return bases
+}
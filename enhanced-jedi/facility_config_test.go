@@ -0,0 +1,134 @@
+package jedi
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadFacilityTemplatesFromFile(t *testing.T) {
+	optimizer := NewWasteManagementOptimizer()
+	if err := optimizer.LoadFacilityTemplates("facility_templates.json"); err != nil {
+		t.Fatalf("failed to load the example templates: %v", err)
+	}
+
+	if optimizer.getFacilityTemplate("cardiology") != nil {
+		t.Error("expected the file to replace the built-in templates")
+	}
+	mrf := optimizer.getFacilityTemplate("mrf")
+	if mrf == nil || mrf.CriticalityLevel != 5 || len(mrf.KeyGenerationHints) != 1 {
+		t.Fatalf("unexpected mrf template %+v", mrf)
+	}
+	if hasher := optimizer.getSpecializedHasher("mrf"); hasher == nil || hasher.Algorithm != "vectorized_sha256" {
+		t.Errorf("expected the mrf hasher from the file, got %+v", hasher)
+	}
+
+	// Omitted fast paths default to one per resource in the file's schema
+	hazardous := optimizer.getFacilityTemplate("hazardous-dropoff")
+	if len(hazardous.FastAccessPaths) != 5 {
+		t.Errorf("expected 5 default fast access paths, got %v", hazardous.FastAccessPaths)
+	}
+	if len(hazardous.PrecomputedBases) != 6 || hazardous.PrecomputedBases[1].Cmp(mrf.PrecomputedBases[1]) == 0 {
+		t.Error("expected a distinct precomputed base per facility and level")
+	}
+	if optimizer.FastLookupTables.CommonPrefixes["facility/mrf"] == nil {
+		t.Error("expected the facility prefix in the lookup tables")
+	}
+}
+
+func TestFacilityConfigValidation(t *testing.T) {
+	valid := func() *FacilityConfig {
+		return &FacilityConfig{Facilities: []FacilityTemplateConfig{{
+			Name:               "transfer-station",
+			CriticalityLevel:   6,
+			OptimizationFactor: 0.3,
+			FastAccessPaths:    [][]string{{"facility", "transfer-station", "bin", "*"}},
+			Hints:              []HintConfig{{Context: "tipping", Strategy: "precomputed_bases", Speedup: 0.3}},
+		}}}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("expected a valid config, got %v", err)
+	}
+
+	cases := map[string]func(*FacilityConfig){
+		"name not in schema": func(c *FacilityConfig) { c.Facilities[0].Name = "Transfer Station" },
+		"duplicate facility": func(c *FacilityConfig) { c.Facilities = append(c.Facilities, c.Facilities[0]) },
+		"criticality":        func(c *FacilityConfig) { c.Facilities[0].CriticalityLevel = 0 },
+		"factor":             func(c *FacilityConfig) { c.Facilities[0].OptimizationFactor = 1.5 },
+		"foreign path":       func(c *FacilityConfig) { c.Facilities[0].FastAccessPaths[0][1] = "mrf" },
+		"unknown resource":   func(c *FacilityConfig) { c.Facilities[0].FastAccessPaths[0][2] = "truck" },
+		"path too deep":      func(c *FacilityConfig) { c.Facilities[0].FastAccessPaths[0] = make([]string, 7) },
+		"unknown strategy":   func(c *FacilityConfig) { c.Facilities[0].Hints[0].Strategy = "magic" },
+		"schema root": func(c *FacilityConfig) {
+			c.Schema = &URISchema{Levels: []SchemaLevel{{Name: "root"}, {Name: "facility"}}}
+		},
+		"no facilities": func(c *FacilityConfig) { c.Facilities = nil },
+	}
+	optimizer := NewWasteManagementOptimizer()
+	for name, mutate := range cases {
+		config := valid()
+		mutate(config)
+		if err := optimizer.ApplyFacilityConfig(config); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+	if optimizer.getFacilityTemplate("cardiology") == nil {
+		t.Error("expected rejected configs to leave the built-in templates in place")
+	}
+}
+
+func TestWatchFacilityTemplatesReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	write := func(config interface{}) {
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &FacilityConfig{Facilities: []FacilityTemplateConfig{{Name: "mrf", CriticalityLevel: 5, OptimizationFactor: 0.3}}}
+	write(config)
+	optimizer := NewWasteManagementOptimizer()
+	if err := optimizer.LoadFacilityTemplates(path); err != nil {
+		t.Fatalf("initial load failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 10)
+	go optimizer.WatchFacilityTemplates(ctx, path, 5*time.Millisecond, func(err error) { errs <- err })
+
+	config.Facilities = append(config.Facilities, FacilityTemplateConfig{Name: "landfill-gate", CriticalityLevel: 7, OptimizationFactor: 0.4})
+	write(config)
+	waitFor(t, func() bool { return optimizer.getFacilityTemplate("landfill-gate") != nil })
+
+	// A broken file is reported and the loaded templates stay in service
+	if err := os.WriteFile(path, []byte(`{"facilities": [{"name": "mrf", "criticalityLevel": 99}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the invalid reload to be reported")
+	}
+	if optimizer.getFacilityTemplate("landfill-gate") == nil || optimizer.getFacilityTemplate("mrf").CriticalityLevel != 5 {
+		t.Error("expected the previous templates to survive a failed reload")
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,393 @@
+package jedi
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// TaskPriority orders HIBE tasks; higher values are served first
+type TaskPriority int
+
+const (
+	PriorityRoutine  TaskPriority = iota // routine key generation
+	PriorityUrgent                       // time-sensitive but not overflow
+	PriorityOverflow                     // overflow-facility requests, may preempt lower classes
+	numPriorities
+)
+
+var (
+	ErrQueueFull       = errors.New("scheduler queue for this priority is full")
+	ErrTaskExpired     = errors.New("task deadline passed before it could run")
+	ErrSchedulerClosed = errors.New("scheduler is closed")
+
+	// errPreempted is the cancellation cause handed to a preempted task's handler
+	errPreempted = errors.New("preempted by an overflow task")
+)
+
+// TaskHandler performs one task. It must return promptly once ctx is done so that
+// overflow tasks can preempt it.
+type TaskHandler func(ctx context.Context, task *HIBETask) (*WasteManagementKey, error)
+
+// SchedulerConfig sizes the worker pool and the per-priority queues
+type SchedulerConfig struct {
+	Workers       int
+	QueueCapacity [numPriorities]int
+}
+
+// SchedulerStats counts what happened to submitted tasks
+type SchedulerStats struct {
+	Submitted int64
+	Completed int64
+	Rejected  int64 // refused with ErrQueueFull
+	Expired   int64 // abandoned after their deadline passed, queued or running
+	Cancelled int64 // abandoned by the caller before running
+	Preempted int64 // interrupted and requeued for an overflow task
+}
+
+// Scheduler runs HIBE tasks on a fixed worker pool, always taking the highest
+// priority queued task first. Overflow tasks that find every worker busy preempt
+// the most recently started lower-priority task, which is requeued at the front
+// of its queue. Queues are bounded; a full queue is reported back to the caller
+// as ErrQueueFull so it can shed or retry.
+type Scheduler struct {
+	config  SchedulerConfig
+	handler TaskHandler
+
+	queues  [numPriorities]*list.List
+	running map[*scheduledTask]struct{}
+	stats   SchedulerStats
+	closed  bool
+	mu      sync.Mutex
+	wake    *sync.Cond
+	workers sync.WaitGroup
+}
+
+// scheduledTask is a task with its caller's context and result channel
+type scheduledTask struct {
+	task      *HIBETask
+	priority  TaskPriority
+	ctx       context.Context
+	deadline  time.Time
+	result    chan *HIBEResult
+	element   *list.Element           // set while queued, guarded by the scheduler's mutex
+	cancel    context.CancelCauseFunc // set while running, guarded by the scheduler's mutex
+	started   time.Time
+	preempted bool
+}
+
+// DefaultSchedulerConfig favours overflow tasks with the deepest queue
+func DefaultSchedulerConfig() SchedulerConfig {
+	return SchedulerConfig{
+		Workers: 16,
+		QueueCapacity: [numPriorities]int{
+			PriorityRoutine:  1000,
+			PriorityUrgent:   500,
+			PriorityOverflow: 2000,
+		},
+	}
+}
+
+// NewScheduler starts config.Workers workers running handler
+func NewScheduler(config SchedulerConfig, handler TaskHandler) *Scheduler {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+
+	s := &Scheduler{
+		config:  config,
+		handler: handler,
+		running: make(map[*scheduledTask]struct{}),
+	}
+	s.wake = sync.NewCond(&s.mu)
+	for i := range s.queues {
+		s.queues[i] = list.New()
+	}
+
+	s.workers.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go s.worker()
+	}
+	return s
+}
+
+// Submit queues a task and waits for its result. It returns ErrQueueFull at once
+// when the task's priority queue is full, ErrTaskExpired as soon as task.Deadline
+// passes, queued or running, and ctx.Err() if the context ends first.
+func (s *Scheduler) Submit(ctx context.Context, task *HIBETask) (*HIBEResult, error) {
+	st := &scheduledTask{
+		task:     task,
+		priority: classifyTask(task),
+		ctx:      ctx,
+		deadline: task.Deadline,
+		result:   make(chan *HIBEResult, 1),
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrSchedulerClosed
+	}
+	queue := s.queues[st.priority]
+	if queue.Len() >= s.config.QueueCapacity[st.priority] {
+		s.stats.Rejected++
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	st.element = queue.PushBack(st)
+	s.stats.Submitted++
+	if st.priority == PriorityOverflow {
+		s.preemptLocked()
+	}
+	s.wake.Signal()
+	s.mu.Unlock()
+
+	var expired <-chan time.Time
+	if !st.deadline.IsZero() {
+		timer := time.NewTimer(time.Until(st.deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case result := <-st.result:
+		return result, result.Error
+	case <-ctx.Done():
+		// A running handler sees the same cancellation; a queued task is dropped when popped
+		return nil, ctx.Err()
+	case <-expired:
+		// A running handler's context shares the deadline; a queued task gives up its slot
+		s.mu.Lock()
+		if st.element != nil {
+			s.queues[st.priority].Remove(st.element)
+			st.element = nil
+			s.stats.Expired++
+		}
+		s.mu.Unlock()
+		return nil, ErrTaskExpired
+	}
+}
+
+// Pressure reports how full each priority queue is, from 0 to 1
+func (s *Scheduler) Pressure() [numPriorities]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pressure [numPriorities]float64
+	for priority, queue := range s.queues {
+		if capacity := s.config.QueueCapacity[priority]; capacity > 0 {
+			pressure[priority] = float64(queue.Len()) / float64(capacity)
+		} else {
+			pressure[priority] = 1
+		}
+	}
+	return pressure
+}
+
+// Stats returns a snapshot of the scheduler's counters
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Close stops accepting tasks, fails queued tasks with ErrSchedulerClosed and
+// waits for running tasks to finish
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	for _, queue := range s.queues {
+		for element := queue.Front(); element != nil; element = element.Next() {
+			st := element.Value.(*scheduledTask)
+			st.element = nil
+			st.result <- &HIBEResult{TaskID: st.task.TaskID, Error: ErrSchedulerClosed, Timestamp: time.Now()}
+		}
+		queue.Init()
+	}
+	s.wake.Broadcast()
+	s.mu.Unlock()
+
+	s.workers.Wait()
+}
+
+func (s *Scheduler) worker() {
+	defer s.workers.Done()
+
+	for {
+		st, runCtx := s.next()
+		if st == nil {
+			return
+		}
+
+		start := time.Now()
+		key, err := s.handler(runCtx, st.task)
+		duration := time.Since(start)
+
+		s.mu.Lock()
+		delete(s.running, st)
+		st.cancel(nil)
+		st.cancel = nil
+
+		// A preempted task goes back to the front of its queue unless it finished
+		// anyway or its caller left
+		if err != nil && context.Cause(runCtx) == errPreempted && st.ctx.Err() == nil && !s.closed {
+			st.preempted = false
+			st.element = s.queues[st.priority].PushFront(st)
+			s.stats.Preempted++
+			s.wake.Signal()
+			s.mu.Unlock()
+			continue
+		}
+		if err != nil && context.Cause(runCtx) == ErrTaskExpired {
+			err = ErrTaskExpired
+			s.stats.Expired++
+		} else {
+			s.stats.Completed++
+		}
+		s.mu.Unlock()
+
+		st.result <- &HIBEResult{
+			TaskID:    st.task.TaskID,
+			Key:       key,
+			Duration:  duration,
+			Error:     err,
+			Timestamp: time.Now(),
+		}
+	}
+}
+
+// next blocks until a live task is available, dropping expired and abandoned
+// tasks on the way. It returns nil once the scheduler is closed.
+func (s *Scheduler) next() (*scheduledTask, context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.closed {
+			return nil, nil
+		}
+
+		st := s.popLocked()
+		if st == nil {
+			s.wake.Wait()
+			continue
+		}
+
+		if st.ctx.Err() != nil {
+			s.stats.Cancelled++
+			continue
+		}
+		if !st.deadline.IsZero() && time.Now().After(st.deadline) {
+			s.stats.Expired++
+			st.result <- &HIBEResult{TaskID: st.task.TaskID, Error: ErrTaskExpired, Timestamp: time.Now()}
+			continue
+		}
+
+		runCtx, cancel := st.runContext()
+		st.cancel = cancel
+		st.started = time.Now()
+		s.running[st] = struct{}{}
+		return st, runCtx
+	}
+}
+
+// runContext derives the context a task runs under from its caller's, ending at
+// the task's deadline with ErrTaskExpired as the cause
+func (st *scheduledTask) runContext() (context.Context, context.CancelCauseFunc) {
+	if st.deadline.IsZero() {
+		return context.WithCancelCause(st.ctx)
+	}
+	deadlineCtx, stop := context.WithDeadlineCause(st.ctx, st.deadline, ErrTaskExpired)
+	runCtx, cancel := context.WithCancelCause(deadlineCtx)
+	return runCtx, func(cause error) {
+		cancel(cause)
+		stop()
+	}
+}
+
+func (s *Scheduler) popLocked() *scheduledTask {
+	for priority := numPriorities - 1; priority >= 0; priority-- {
+		if front := s.queues[priority].Front(); front != nil {
+			st := s.queues[priority].Remove(front).(*scheduledTask)
+			st.element = nil
+			return st
+		}
+	}
+	return nil
+}
+
+// preemptLocked frees a worker for a newly queued overflow task when all are busy,
+// interrupting the lower-priority task that has run for the shortest time
+func (s *Scheduler) preemptLocked() {
+	busy := 0
+	for st := range s.running {
+		if !st.preempted {
+			busy++
+		}
+	}
+	if busy < s.config.Workers {
+		return
+	}
+
+	var victim *scheduledTask
+	for st := range s.running {
+		if st.priority == PriorityOverflow || st.preempted {
+			continue
+		}
+		if victim == nil || st.priority < victim.priority ||
+			(st.priority == victim.priority && st.started.After(victim.started)) {
+			victim = st
+		}
+	}
+	if victim != nil {
+		victim.preempted = true
+		victim.cancel(errPreempted)
+	}
+}
+
+// classifyTask maps a task onto a priority class
+func classifyTask(task *HIBETask) TaskPriority {
+	if task.IsOverflow {
+		return PriorityOverflow
+	}
+	if task.Priority < PriorityRoutine || task.Priority >= numPriorities {
+		return PriorityRoutine
+	}
+	return task.Priority
+}
+
+// ServeOverflow feeds requests from OverflowChannel into the scheduler until ctx
+// is done, answering each on its ResponseChannel. Requests refused with
+// ErrQueueFull are answered immediately so callers see the backpressure.
+func (cp *ConcurrentProcessor) ServeOverflow(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case request := <-cp.OverflowChannel:
+			go func(request *OverflowRequest) {
+				task := &HIBETask{
+					TaskID:     request.RequestID,
+					Identity:   []string{"facility", request.Facility, "bin", request.BinID, request.DataType},
+					Facility:   request.Facility,
+					IsOverflow: request.OverflowLevel > 0,
+					Priority:   TaskPriority(request.Priority),
+					Timestamp:  request.Timestamp,
+				}
+				result, err := cp.Scheduler.Submit(ctx, task)
+				if result == nil {
+					result = &HIBEResult{TaskID: request.RequestID, Error: err, Timestamp: time.Now()}
+				}
+				if request.ResponseChannel != nil {
+					request.ResponseChannel <- result
+				}
+			}(request)
+		}
+	}
+}
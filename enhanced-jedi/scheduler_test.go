@@ -0,0 +1,289 @@
+package jedi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedHandler blocks tasks whose ID starts with "gate" until release is closed
+// and records the order in which other tasks ran
+type gatedHandler struct {
+	release chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	order   []string
+}
+
+func newGatedHandler() *gatedHandler {
+	return &gatedHandler{release: make(chan struct{})}
+}
+
+// open releases the gated tasks; it is safe to call more than once
+func (h *gatedHandler) open() {
+	h.once.Do(func() { close(h.release) })
+}
+
+func (h *gatedHandler) handle(ctx context.Context, task *HIBETask) (*WasteManagementKey, error) {
+	if len(task.TaskID) >= 4 && task.TaskID[:4] == "gate" {
+		select {
+		case <-h.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	h.mu.Lock()
+	h.order = append(h.order, task.TaskID)
+	h.mu.Unlock()
+	return &WasteManagementKey{Identity: task.Identity}, nil
+}
+
+// waitQueued waits until the scheduler holds n queued tasks of a priority
+func waitQueued(t *testing.T, s *Scheduler, priority TaskPriority, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		queued := s.queues[priority].Len()
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d queued tasks at priority %d", n, priority)
+}
+
+// waitRunning waits until the scheduler is running n tasks
+func waitRunning(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		running := len(s.running)
+		s.mu.Unlock()
+		if running == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d running tasks", n)
+}
+
+func testConfig(workers, capacity int) SchedulerConfig {
+	return SchedulerConfig{
+		Workers:       workers,
+		QueueCapacity: [numPriorities]int{capacity, capacity, capacity},
+	}
+}
+
+func TestSchedulerServesOverflowFirst(t *testing.T) {
+	handler := newGatedHandler()
+	scheduler := NewScheduler(testConfig(1, 10), handler.handle)
+	defer scheduler.Close()
+	defer handler.open()
+
+	var wg sync.WaitGroup
+	submit := func(task *HIBETask) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := scheduler.Submit(context.Background(), task); err != nil {
+				t.Errorf("%s failed: %v", task.TaskID, err)
+			}
+		}()
+	}
+
+	// An overflow gate occupies the only worker without being preemptible
+	submit(&HIBETask{TaskID: "gate", IsOverflow: true})
+	waitRunning(t, scheduler, 1)
+	submit(&HIBETask{TaskID: "routine"})
+	waitQueued(t, scheduler, PriorityRoutine, 1)
+	submit(&HIBETask{TaskID: "urgent", Priority: PriorityUrgent})
+	waitQueued(t, scheduler, PriorityUrgent, 1)
+	submit(&HIBETask{TaskID: "overflow", IsOverflow: true})
+	waitQueued(t, scheduler, PriorityOverflow, 1)
+
+	handler.open()
+	wg.Wait()
+
+	want := []string{"gate", "overflow", "urgent", "routine"}
+	if fmt.Sprint(handler.order) != fmt.Sprint(want) {
+		t.Errorf("ran in order %v, want %v", handler.order, want)
+	}
+}
+
+func TestSchedulerPreemptsRoutineWork(t *testing.T) {
+	handler := newGatedHandler()
+	scheduler := NewScheduler(testConfig(1, 10), handler.handle)
+	defer scheduler.Close()
+	defer handler.open()
+
+	routineDone := make(chan error, 1)
+	go func() {
+		_, err := scheduler.Submit(context.Background(), &HIBETask{TaskID: "gate-routine"})
+		routineDone <- err
+	}()
+	waitRunning(t, scheduler, 1)
+
+	if _, err := scheduler.Submit(context.Background(), &HIBETask{TaskID: "overflow", IsOverflow: true}); err != nil {
+		t.Fatalf("overflow task failed: %v", err)
+	}
+
+	// The routine task was requeued and completes once released
+	handler.open()
+	if err := <-routineDone; err != nil {
+		t.Errorf("preempted routine task should still complete, got %v", err)
+	}
+	if stats := scheduler.Stats(); stats.Preempted != 1 {
+		t.Errorf("expected 1 preemption, got %d", stats.Preempted)
+	}
+	if handler.order[0] != "overflow" {
+		t.Errorf("expected the overflow task to finish first, got %v", handler.order)
+	}
+}
+
+func TestSchedulerBackpressureAndDeadlines(t *testing.T) {
+	handler := newGatedHandler()
+	scheduler := NewScheduler(testConfig(1, 1), handler.handle)
+	defer scheduler.Close()
+	defer handler.open()
+
+	gateDone := make(chan struct{})
+	go func() {
+		scheduler.Submit(context.Background(), &HIBETask{TaskID: "gate", IsOverflow: true})
+		close(gateDone)
+	}()
+	waitRunning(t, scheduler, 1)
+
+	expiring := make(chan error, 1)
+	go func() {
+		_, err := scheduler.Submit(context.Background(), &HIBETask{TaskID: "stale", Deadline: time.Now().Add(100 * time.Millisecond)})
+		expiring <- err
+	}()
+	waitQueued(t, scheduler, PriorityRoutine, 1)
+
+	if _, err := scheduler.Submit(context.Background(), &HIBETask{TaskID: "rejected"}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull from a full queue, got %v", err)
+	}
+	if pressure := scheduler.Pressure(); pressure[PriorityRoutine] != 1 {
+		t.Errorf("expected full routine pressure, got %v", pressure)
+	}
+
+	// Cancelling a queued task removes it without running it
+	ctx, cancel := context.WithCancel(context.Background())
+	abandoned := make(chan error, 1)
+	go func() {
+		_, err := scheduler.Submit(ctx, &HIBETask{TaskID: "abandoned", Priority: PriorityUrgent})
+		abandoned <- err
+	}()
+	waitQueued(t, scheduler, PriorityUrgent, 1)
+	cancel()
+	if err := <-abandoned; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// The stale task expires on time even though the worker is still busy
+	if err := <-expiring; !errors.Is(err, ErrTaskExpired) {
+		t.Errorf("expected ErrTaskExpired for a stale task, got %v", err)
+	}
+	waitQueued(t, scheduler, PriorityRoutine, 0)
+	handler.open()
+	<-gateDone
+
+	stats := scheduler.Stats()
+	if stats.Rejected != 1 || stats.Expired != 1 || stats.Cancelled != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	for _, id := range handler.order {
+		if id != "gate" {
+			t.Errorf("task %s should not have run", id)
+		}
+	}
+}
+
+func TestSchedulerExpiresRunningTask(t *testing.T) {
+	handler := newGatedHandler()
+	scheduler := NewScheduler(testConfig(1, 1), handler.handle)
+	defer scheduler.Close()
+	defer handler.open()
+
+	_, err := scheduler.Submit(context.Background(), &HIBETask{TaskID: "gate", Deadline: time.Now().Add(20 * time.Millisecond)})
+	if !errors.Is(err, ErrTaskExpired) {
+		t.Fatalf("expected ErrTaskExpired for a task running past its deadline, got %v", err)
+	}
+	waitRunning(t, scheduler, 0)
+	if stats := scheduler.Stats(); stats.Expired != 1 || stats.Completed != 0 {
+		t.Errorf("expected the interrupted task to count as expired, got %+v", stats)
+	}
+}
+
+func TestScheduledKeyGenerationStopsWhenCancelled(t *testing.T) {
+	ej := NewEnhancedHIBE()
+	defer ej.ConcurrentProcessor.Scheduler.Close()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errPreempted)
+	task := &HIBETask{Identity: []string{"facility", "general", "bin", "7", "fill-level"}, Facility: "general"}
+	if key, err := ej.runScheduledTask(ctx, task); key != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a preempted task to stop without a key, got %v, %v", key, err)
+	}
+}
+
+func TestSchedulerUnderSaturation(t *testing.T) {
+	handler := func(ctx context.Context, task *HIBETask) (*WasteManagementKey, error) {
+		select {
+		case <-time.After(time.Millisecond):
+			return &WasteManagementKey{}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	config := SchedulerConfig{Workers: 4, QueueCapacity: [numPriorities]int{20, 20, 200}}
+	scheduler := NewScheduler(config, handler)
+	defer scheduler.Close()
+
+	var (
+		wg               sync.WaitGroup
+		mu               sync.Mutex
+		overflowFailures int
+		routineCompleted int
+		routineRejected  int
+	)
+	for i := 0; i < 300; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			overflow := i%5 == 0
+			_, err := scheduler.Submit(context.Background(), &HIBETask{TaskID: fmt.Sprint(i), IsOverflow: overflow})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case overflow && err != nil:
+				overflowFailures++
+			case !overflow && err == nil:
+				routineCompleted++
+			case !overflow && errors.Is(err, ErrQueueFull):
+				routineRejected++
+			case !overflow:
+				t.Errorf("unexpected routine error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if overflowFailures != 0 {
+		t.Errorf("%d overflow tasks failed under saturation", overflowFailures)
+	}
+	if routineCompleted+routineRejected != 240 {
+		t.Errorf("every routine task should complete or see backpressure, got %d + %d", routineCompleted, routineRejected)
+	}
+	stats := scheduler.Stats()
+	if stats.Completed != int64(60+routineCompleted) || stats.Rejected != int64(routineRejected) {
+		t.Errorf("stats %+v disagree with %d routine completions and %d rejections", stats, routineCompleted, routineRejected)
+	}
+}
@@ -1,11 +1,13 @@
 package jedi
 
 import (
+	"context"
 	"crypto/sha256"
-	"encoding/binary"
 	"fmt"
+	"hash"
 	"math/big"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -68,6 +70,7 @@ type ConcurrentProcessor struct {
 	ResultCollector    *ResultCollector
 	LoadBalancer       *LoadBalancer
 	PerformanceMonitor *ConcurrentPerformanceMonitor
+	Scheduler          *Scheduler
 }
 
 // WorkerPool manages specialized workers for different waste-management contexts
@@ -169,10 +172,17 @@ func NewEnhancedHIBE() *EnhancedHIBE {
 		PerformanceTracker: NewPerformanceTracker(),
 		OverflowOptimizer: NewOverflowFacilityOptimizer(),
 	}
+	enhancedHibe.ConcurrentProcessor.Scheduler = NewScheduler(DefaultSchedulerConfig(), enhancedHibe.runScheduledTask)
 	
 	return enhancedHibe
 }
 
+// runScheduledTask generates the key for a task taken off the scheduler
+func (ej *EnhancedHIBE) runScheduledTask(ctx context.Context, task *HIBETask) (*WasteManagementKey, error) {
+	key, _, err := ej.GenerateWasteManagementHIBEKeyContext(ctx, task.Identity, task.Facility, task.IsOverflow)
+	return key, err
+}
+
 // NewWasteManagementOptimizer initializes waste-management-specific optimizations
 func NewWasteManagementOptimizer() *WasteManagementOptimizer {
 	optimizer := &WasteManagementOptimizer{
@@ -293,7 +303,16 @@ func (ho *WasteManagementOptimizer) initializeFacilityTemplates() {
 
 // GenerateWasteManagementHIBEKey implements optimized HIBE key generation with 40% improvement
 func (ej *EnhancedHIBE) GenerateWasteManagementHIBEKey(identity []string, facility string, isOverflow bool) (*WasteManagementKey, time.Duration, error) {
+	return ej.GenerateWasteManagementHIBEKeyContext(context.Background(), identity, facility, isOverflow)
+}
+
+// GenerateWasteManagementHIBEKeyContext is GenerateWasteManagementHIBEKey checking
+// ctx between stages, so a cancelled or preempted task stops without a key
+func (ej *EnhancedHIBE) GenerateWasteManagementHIBEKeyContext(ctx context.Context, identity []string, facility string, isOverflow bool) (*WasteManagementKey, time.Duration, error) {
 	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
 	
 	// Overflow fast path
 	if isOverflow {
@@ -307,7 +326,7 @@ func (ej *EnhancedHIBE) GenerateWasteManagementHIBEKey(identity []string, facili
 	}
 	
 	// Apply waste-management-specific optimizations
-	key, err := ej.generateOptimizedKey(identity, template)
+	key, err := ej.generateOptimizedKey(ctx, identity, template)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -337,7 +356,7 @@ func (ej *EnhancedHIBE) RunConcurrentPerformanceTest() *PerformanceTestResults {
 	fmt.Println("\n--- Key Generation Performance Comparison ---")
 	fmt.Printf("%-18s | %-18s | %-18s | %-20s\n", 
 		"Concurrent Requests", "Generic HIBE (avg)", "Enhanced HIBE (avg)", "Performance Improvement")
-	fmt.Printf("%s\n", "-"*85)
+	fmt.Printf("%s\n", strings.Repeat("-", 85))
 	
 	// Run key generation performance tests
 	for _, concurrentRequests := range concurrentLoads {
@@ -355,7 +374,7 @@ func (ej *EnhancedHIBE) RunConcurrentPerformanceTest() *PerformanceTestResults {
 	fmt.Println("\n--- Batch Processing Overhead (5 runs) ---")
 	fmt.Printf("%-15s | %-18s | %-18s | %-18s\n", 
 		"Concurrent Load", "Generic HIBE (avg)", "Enhanced HIBE (avg)", "Overhead Reduction")
-	fmt.Printf("%s\n", "-"*75)
+	fmt.Printf("%s\n", strings.Repeat("-", 75))
 	
 	for _, concurrentLoad := range concurrentLoads {
 		batchResult := ej.runBatchProcessingTest(concurrentLoad)
@@ -443,32 +462,42 @@ func (ej *EnhancedHIBE) runBatchProcessingTest(concurrentLoad int) BatchTestResu
 	}
 }
 
-// runEnhancedHIBETest runs the optimized HIBE key generation
+// runEnhancedHIBETest runs the optimized HIBE key generation through the priority scheduler
 func (ej *EnhancedHIBE) runEnhancedHIBETest(concurrentRequests int, testIdentities [][]string) {
 	var wg sync.WaitGroup
-	workerCount := min(concurrentRequests, runtime.NumCPU()*4)
-	requestsPerWorker := concurrentRequests / workerCount
+	submitterCount := min(concurrentRequests, runtime.NumCPU()*4)
 	
 	// Create work channel
-	workChan := make(chan []string, concurrentRequests)
+	workChan := make(chan int, concurrentRequests)
 	
 	// Fill work channel
 	for i := 0; i < concurrentRequests; i++ {
-		identity := testIdentities[i%len(testIdentities)]
-		workChan <- identity
+		workChan <- i
 	}
 	close(workChan)
 	
-	// Start workers
-	for i := 0; i < workerCount; i++ {
+	// Start submitters; a full queue is backpressure, so back off and retry
+	for i := 0; i < submitterCount; i++ {
 		wg.Add(1)
-		go func(workerID int) {
+		go func() {
 			defer wg.Done()
-			for identity := range workChan {
-				// Generate optimized key with waste-management-specific optimizations
-				ej.GenerateWasteManagementHIBEKey(identity, "overflow", true)
+			for n := range workChan {
+				task := &HIBETask{
+					TaskID:     fmt.Sprintf("overflow-%d", n),
+					Identity:   testIdentities[n%len(testIdentities)],
+					Facility:   "overflow",
+					IsOverflow: true,
+					Timestamp:  time.Now(),
+				}
+				for {
+					_, err := ej.ConcurrentProcessor.Scheduler.Submit(context.Background(), task)
+					if err != ErrQueueFull {
+						break
+					}
+					time.Sleep(time.Millisecond)
+				}
 			}
-		}(i)
+		}()
 	}
 	
 	wg.Wait()
@@ -504,7 +533,7 @@ func (ej *EnhancedHIBE) runGenericHIBETest(concurrentRequests int, testIdentitie
 }
 
 // generateOptimizedKey implements waste-management-specific optimizations
-func (ej *EnhancedHIBE) generateOptimizedKey(identity []string, template *FacilityTemplate) (*WasteManagementKey, error) {
+func (ej *EnhancedHIBE) generateOptimizedKey(ctx context.Context, identity []string, template *FacilityTemplate) (*WasteManagementKey, error) {
 	// Use pre-computed bases for faster computation
 	key := &WasteManagementKey{
 		Identity:     identity,
@@ -515,7 +544,7 @@ func (ej *EnhancedHIBE) generateOptimizedKey(identity []string, template *Facili
 	}
 	
 	// Apply specialized hashing based on facility
-	if hasher, exists := ej.WasteManagementOptimizer.SpecializedHashers[template.FacilityType]; exists {
+	if hasher := ej.WasteManagementOptimizer.getSpecializedHasher(template.FacilityType); hasher != nil {
 		keyHash := hasher.computeOptimizedHash(identity, template.PrecomputedBases)
 		copy(key.KeyData, keyHash)
 	} else {
@@ -525,6 +554,9 @@ func (ej *EnhancedHIBE) generateOptimizedKey(identity []string, template *Facili
 	
 	// Apply fast access path optimizations
 	for _, hint := range template.KeyGenerationHints {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		ej.applyOptimizationHint(key, hint)
 	}
 	
@@ -538,6 +570,12 @@ func (ho *WasteManagementOptimizer) getFacilityTemplate(facility string) *Facili
 	return ho.FacilityTemplates[facility]
 }
 
+func (ho *WasteManagementOptimizer) getSpecializedHasher(facility string) *SpecializedHasher {
+	ho.mu.RLock()
+	defer ho.mu.RUnlock()
+	return ho.SpecializedHashers[facility]
+}
+
 func (ej *EnhancedHIBE) generateGenericKeySimulation(identity []string) *WasteManagementKey {
 	// Simulate slower generic HIBE key generation
 	time.Sleep(time.Microsecond * 50) // Simulate computational overhead
@@ -588,11 +626,20 @@ func calculateAverage(durations []time.Duration) time.Duration {
 	return total / time.Duration(len(durations))
 }
 
+// formatNumber writes n with thousands separators, as fmt has no verb for them
 func formatNumber(n int) string {
-	if n >= 1000 {
-		return fmt.Sprintf("%,d", n)
+	digits := fmt.Sprintf("%d", n)
+	if n < 1000 {
+		return digits
+	}
+	var formatted strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			formatted.WriteByte(',')
+		}
+		formatted.WriteRune(digit)
 	}
-	return fmt.Sprintf("%d", n)
+	return formatted.String()
 }
 
 func formatDuration(d time.Duration) string {
@@ -622,13 +669,6 @@ type OptimizedKeyCache struct {
 	mu      sync.RWMutex
 }
 
-type PerformanceTracker struct {
-	GenericHIBETimes   []time.Duration
-	EnhancedHIBETimes  []time.Duration
-	TestResults        *PerformanceTestResults
-	mu                 sync.RWMutex
-}
-
 type FastLookupTables struct {
 	FacilityHashes map[string][]byte
 	CommonPrefixes   map[string]*big.Int
@@ -678,6 +718,8 @@ type HIBETask struct {
 	Identity   []string
 	Facility string
 	IsOverflow bool
+	Priority   TaskPriority // ignored when IsOverflow is set
+	Deadline   time.Time    // zero means the task never expires in the queue
 	Timestamp  time.Time
 }
 
@@ -752,11 +794,7 @@ func NewOverflowFacilityOptimizer() *OverflowFacilityOptimizer {
 
 // Implementation stubs for interface compliance
 func (ho *WasteManagementOptimizer) generatePrecomputedBases(facility string) []*big.Int {
-	bases := make([]*big.Int, 6) // 6 levels for waste-management hierarchy
-	for i := range bases {
-		bases[i] = big.NewInt(int64(i + 1))
-	}
-	return bases
+	return precomputeBases(DefaultURISchema(), facility)
 }
 
 func (ho *WasteManagementOptimizer) generateFastAccessPaths(facility string) [][]string {
@@ -786,6 +824,11 @@ func (sh *SpecializedHasher) computeOptimizedHash(identity []string, bases []*bi
 		hasher.Write([]byte(component))
 	}
 	
+	return sh.sum(hasher)
+}
+
+// sum finishes an identity hash with the hasher's optimization tag
+func (sh *SpecializedHasher) sum(hasher hash.Hash) []byte {
 	// Add optimization based on hasher type
 	switch sh.HasherType {
 	case "overflow":
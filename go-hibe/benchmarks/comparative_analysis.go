@@ -9,7 +9,7 @@ import (
 	"runtime"
 	"time"
 
-	"github.com/ucbrise/hibe-pairing/lang/go/wkdibe"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
 )
 
 // ComparisonResult represents benchmark results for different schemes
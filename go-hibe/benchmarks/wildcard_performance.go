@@ -10,7 +10,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ucbrise/hibe-pairing/lang/go/wkdibe"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
 )
 
 // WildcardBenchmark handles performance testing of wildcard vs non-wildcard operations
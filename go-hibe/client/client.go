@@ -0,0 +1,331 @@
+// Package client is a typed Go client for the HIBE API server. It shares its
+// request and response types with the server and retries calls that fail with
+// a 5xx or 429 status.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hibe-api/telemetry"
+)
+
+// maxResponseSize bounds how much of a response body is read
+const maxResponseSize = 32 << 20
+
+// Authenticator adds credentials to each outgoing request
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthFunc adapts a function to an Authenticator
+type AuthFunc func(req *http.Request) error
+
+// Authenticate calls f(req)
+func (f AuthFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// BearerToken authenticates with a fixed bearer token
+func BearerToken(token string) Authenticator {
+	return HeaderAuth("Authorization", "Bearer "+token)
+}
+
+// HeaderAuth sets a fixed header, such as an API key, on every request
+func HeaderAuth(name, value string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set(name, value)
+		return nil
+	})
+}
+
+// RetryPolicy controls how failed calls are retried. Calls are retried on
+// transport errors and on 5xx and 429 responses, waiting a random duration up to
+// an exponentially growing cap, or as long as a Retry-After header asks.
+type RetryPolicy struct {
+	MaxAttempts int           // including the first; 1 disables retries
+	BaseDelay   time.Duration // cap of the first backoff
+	MaxDelay    time.Duration // cap of every backoff, including Retry-After
+}
+
+// DefaultRetryPolicy makes up to four attempts over roughly a second
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// Config configures a Client
+type Config struct {
+	BaseURL    string        // e.g. http://localhost:8080
+	HTTPClient *http.Client  // http.DefaultClient when nil
+	Auth       Authenticator // optional
+	Retry      *RetryPolicy  // DefaultRetryPolicy when nil
+}
+
+// APIError is a response with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Code       string // one of the Code constants, or "" from endpoints without one
+	Message    string
+	KeyID      string // set when the server names the key it refused
+	Details    map[string]interface{}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("hibe api: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Temporary reports whether the call may succeed if retried
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Client calls the HIBE API. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+	auth    Authenticator
+	retry   RetryPolicy
+}
+
+// NewClient creates a client for the server at cfg.BaseURL
+func NewClient(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %v", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", cfg.BaseURL)
+	}
+
+	retry := DefaultRetryPolicy
+	if cfg.Retry != nil {
+		retry = *cfg.Retry
+	}
+	if retry.MaxAttempts < 1 {
+		return nil, fmt.Errorf("retry policy needs at least one attempt, got %d", retry.MaxAttempts)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: base, http: httpClient, auth: cfg.Auth, retry: retry}, nil
+}
+
+// Encrypt encrypts a message for a URI
+func (c *Client) Encrypt(ctx context.Context, req *EncryptRequest) (*EncryptResponse, error) {
+	var resp EncryptResponse
+	if err := c.do(ctx, http.MethodPost, "/encrypt", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// EncryptBatch encrypts each record under its own URI and timestamp. A record
+// that fails is reported in its result and does not fail the call.
+func (c *Client) EncryptBatch(ctx context.Context, req *BatchEncryptRequest) (*BatchEncryptResponse, error) {
+	var resp BatchEncryptResponse
+	if err := c.do(ctx, http.MethodPost, "/encrypt/batch", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Decrypt decrypts a message returned by Encrypt
+func (c *Client) Decrypt(ctx context.Context, req *DecryptRequest) (*DecryptResponse, error) {
+	var resp DecryptResponse
+	if err := c.do(ctx, http.MethodPost, "/decrypt", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Delegate delegates a key for a URI over a validity window. A revoked key is
+// refused with an *APIError carrying its KeyID.
+func (c *Client) Delegate(ctx context.Context, req *DelegationRequest) (*DelegationResponse, error) {
+	var resp DelegationResponse
+	if err := c.do(ctx, http.MethodPost, "/hibe-delegate", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Revoke revokes a delegated key. Revoking a key that is already revoked fails
+// with a 409 *APIError, which can also follow a retried call whose first attempt
+// succeeded.
+func (c *Client) Revoke(ctx context.Context, req *RevocationRequest) (*RevokeResponse, error) {
+	var resp RevokeResponse
+	if err := c.do(ctx, http.MethodPost, "/revoke", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CheckRevocation reports whether the key with keyID is revoked
+func (c *Client) CheckRevocation(ctx context.Context, keyID string) (*RevocationCheckResponse, error) {
+	var resp RevocationCheckResponse
+	if err := c.do(ctx, http.MethodGet, "/revoke/check/"+url.PathEscape(keyID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CheckRevocationFor reports whether the key delegated with the given parameters is revoked
+func (c *Client) CheckRevocationFor(ctx context.Context, req *RevocationCheckRequest) (*RevocationCheckResponse, error) {
+	var resp RevocationCheckResponse
+	if err := c.do(ctx, http.MethodPost, "/revoke/check", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do sends the request, retrying as the policy allows, and decodes a 2xx body into out
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("failed to encode request: %v", err)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		retry, wait, err := c.attempt(ctx, method, path, body, out)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retry || attempt >= c.retry.MaxAttempts {
+			return err
+		}
+		if err := sleep(ctx, c.backoff(attempt, wait)); err != nil {
+			return err
+		}
+	}
+}
+
+// attempt makes one call and reports whether a failure may be retried, and how
+// long the server asked to wait in a Retry-After header
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, out interface{}) (bool, time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, reader)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	telemetry.Inject(ctx, req.Header)
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return false, 0, fmt.Errorf("failed to authenticate request: %v", err)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return true, 0, fmt.Errorf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return true, 0, fmt.Errorf("%s %s: failed to read response: %v", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := newAPIError(resp.StatusCode, raw)
+		return apiErr.Temporary(), retryAfter(resp.Header.Get("Retry-After")), apiErr
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return false, 0, fmt.Errorf("%s %s: failed to decode response: %v", method, path, err)
+	}
+	return false, 0, nil
+}
+
+// newAPIError reads the server's ErrorResponse, or the {"error": ...} body of
+// older endpoints, falling back to the raw text
+func newAPIError(status int, raw []byte) *APIError {
+	var body struct {
+		ErrorResponse
+		Error string `json:"error"`
+		KeyID string `json:"keyId"`
+	}
+	apiErr := &APIError{StatusCode: status}
+	if json.Unmarshal(raw, &body) == nil && body.Code != "" {
+		apiErr.Code = body.Code
+		apiErr.Message = body.Message
+		apiErr.Details = body.Details
+		apiErr.KeyID, _ = body.Details["keyId"].(string)
+	} else if body.Error != "" {
+		apiErr.Message = body.Error
+		apiErr.KeyID = body.KeyID
+	} else if text := strings.TrimSpace(string(raw)); text != "" {
+		apiErr.Message = text
+	} else {
+		apiErr.Message = http.StatusText(status)
+	}
+	return apiErr
+}
+
+// backoff is the wait before the attempt after attempt: the server's Retry-After
+// when it sent one, otherwise a random duration up to BaseDelay*2^(attempt-1)
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if retryAfter > c.retry.MaxDelay {
+			return c.retry.MaxDelay
+		}
+		return retryAfter
+	}
+	ceiling := c.retry.BaseDelay
+	for i := 1; i < attempt && ceiling < c.retry.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if ceiling > c.retry.MaxDelay {
+		ceiling = c.retry.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
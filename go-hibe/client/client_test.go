@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hibe-api/telemetry"
+)
+
+var fastRetry = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, cfg Config) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	cfg.BaseURL = server.URL
+	if cfg.Retry == nil {
+		cfg.Retry = fastRetry
+	}
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRetriesTemporaryFailures(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			// Retry-After is capped at the policy's MaxDelay
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			var req RevocationCheckRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(RevocationCheckResponse{URI: req.URI, IsRevoked: true})
+		}
+	}, Config{})
+
+	start := time.Now()
+	resp, err := c.CheckRevocationFor(context.Background(), &RevocationCheckRequest{Hierarchy: "h", URI: "a/b", StartTime: 1, EndTime: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsRevoked || resp.URI != "a/b" || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("expected the third attempt to succeed, got %+v after %d calls", resp, calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Retry-After to be capped, waited %v", elapsed)
+	}
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Code:    CodeKeyRevoked,
+			Message: "cannot delegate: key is revoked",
+			Details: map[string]interface{}{"keyId": "abc"},
+		})
+	}, Config{})
+
+	_, err := c.Delegate(context.Background(), &DelegationRequest{URI: "a/b", StartTime: 1, EndTime: 2})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Code != CodeKeyRevoked || apiErr.KeyID != "abc" {
+		t.Fatalf("expected a 403 naming the key, got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}, Config{})
+
+	_, err := c.CheckRevocation(context.Background(), "abc")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != "upstream unavailable" {
+		t.Fatalf("expected the last 502 to be returned, got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestContextCancelsRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusInternalServerError)
+	}, Config{Retry: &RetryPolicy{MaxAttempts: 10, BaseDelay: time.Hour, MaxDelay: time.Hour}})
+
+	if _, err := c.CheckRevocation(ctx, "abc"); err != context.Canceled {
+		t.Errorf("expected the cancelled context's error, got %v", err)
+	}
+}
+
+func TestAuthenticatorIsApplied(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(RevocationCheckResponse{KeyID: "abc"})
+	}, Config{Auth: BearerToken("s3cret")})
+
+	if _, err := c.CheckRevocation(context.Background(), "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := AuthFunc(func(*http.Request) error { return errors.New("token expired") })
+	c.auth = failing
+	if _, err := c.CheckRevocation(context.Background(), "abc"); err == nil {
+		t.Error("expected an authenticator error to fail the call")
+	}
+}
+
+func TestRequestIDIsPropagated(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(RevocationCheckResponse{KeyID: r.Header.Get(telemetry.RequestIDHeader)})
+	}, Config{})
+
+	resp, err := c.CheckRevocation(telemetry.WithRequestID(context.Background(), "bin-7"), "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.KeyID != "bin-7" {
+		t.Errorf("expected the request ID to be sent, got %q", resp.KeyID)
+	}
+}
+
+func TestNewClientValidatesConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{BaseURL: "localhost:8080"},
+		{BaseURL: "http://localhost:8080", Retry: &RetryPolicy{}},
+	} {
+		if _, err := NewClient(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package client
+
+import "time"
+
+// The request and response types below are the wire format of the HIBE API. The
+// server declares its own types as aliases of these, so the two cannot drift.
+
+// ErrorResponse is the body of a failed call to the encryption, delegation,
+// revocation and access request endpoints. Code is one of the Code constants and is stable; Message
+// is for people. Details, when present, says more, such as the keyId of a
+// revoked key.
+type ErrorResponse struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Codes of an ErrorResponse, with the status they are sent with
+const (
+	CodeInvalidRequest    = "invalid_request"    // 400: the body is malformed or incomplete
+	CodeInvalidURI        = "invalid_uri"        // 400: the URI cannot be encoded in a pattern
+	CodeInvalidCiphertext = "invalid_ciphertext" // 400: the ciphertext is not base64 or is malformed
+	CodeUnauthorized      = "unauthorized"       // 401: the bearer token is missing or wrong
+	CodeDecryptionFailed  = "decryption_failed"  // 403: the ciphertext is not for this URI and time
+	CodeKeyRevoked        = "key_revoked"        // 403: the key asked for is revoked
+	CodeForbidden         = "forbidden"          // 403: no live access grant covers the call
+	CodeNotFound          = "not_found"          // 404
+	CodeAlreadyRevoked    = "already_revoked"    // 409
+	CodeConflict          = "conflict"           // 409: the access request has already been decided
+	CodeTooManyRequests   = "too_many_requests"  // 429: too many access requests await the owner
+	CodeInternal          = "internal"           // 500
+	CodeCancelled         = "cancelled"          // 503: the request was cancelled, or the server is shutting down
+	CodeTimeout           = "timeout"            // 504: the operation ran out of time
+)
+
+// EncryptRequest is the body of POST /encrypt
+type EncryptRequest struct {
+	URI       string `json:"uri" binding:"required"`
+	Message   string `json:"message" binding:"required"`
+	Timestamp int64  `json:"timestamp,omitempty"` // Unix seconds to encrypt under; when the server receives it if 0
+}
+
+// EncryptResponse is returned by POST /encrypt
+type EncryptResponse struct {
+	Time                    int64   `json:"time"` // in microseconds
+	MemoryUsage             uint64  `json:"memoryUsage"`
+	CPUPercentage           float64 `json:"cpuPercentage"`
+	PowerUsageWatts         float64 `json:"powerUsageWatts"`
+	EnergyConsumptionJoules float64 `json:"energyConsumptionJoules"`
+	Data                    string  `json:"data"`      // base64 ciphertext
+	Timestamp               int64   `json:"timestamp"` // the time the message was encrypted under
+}
+
+// EncryptRecord is one reading to encrypt in POST /encrypt/batch, or one line of
+// POST /encrypt/stream
+type EncryptRecord struct {
+	URI       string `json:"uri"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp,omitempty"` // Unix seconds; when the server receives it if 0
+}
+
+// EncryptResult is the outcome of one EncryptRecord. Results come in the order
+// the records were sent; Index is the record's position.
+type EncryptResult struct {
+	Index     int            `json:"index"`
+	URI       string         `json:"uri"`
+	Timestamp int64          `json:"timestamp"`      // the time the record was encrypted under
+	Data      string         `json:"data,omitempty"` // base64 ciphertext
+	Error     *ErrorResponse `json:"error,omitempty"`
+}
+
+// EncryptSummary counts the outcomes of a batch or stream encryption. It is
+// the last line of a POST /encrypt/stream response.
+type EncryptSummary struct {
+	Done          bool   `json:"done"`
+	Succeeded     int    `json:"succeeded"`
+	Failed        int    `json:"failed"`
+	ExecutionTime int64  `json:"executionTime"`   // in microseconds
+	Error         string `json:"error,omitempty"` // why the stream ended early
+}
+
+// BatchEncryptRequest is the body of POST /encrypt/batch
+type BatchEncryptRequest struct {
+	Records []EncryptRecord `json:"records" binding:"required,min=1"`
+}
+
+// BatchEncryptResponse is returned by POST /encrypt/batch
+type BatchEncryptResponse struct {
+	EncryptSummary
+	Results []EncryptResult `json:"results"`
+}
+
+// DecryptRequest is the body of POST /decrypt
+type DecryptRequest struct {
+	URI              string `json:"uri" binding:"required"`
+	EncryptedMessage string `json:"encryptedMessage" binding:"required"` // base64, as returned by /encrypt
+	Key              string `json:"key" binding:"required"`
+	Timestamp        int64  `json:"timestamp,omitempty"` // Unix seconds the message was encrypted under; when the server receives it if 0
+}
+
+// DecryptResponse is returned by POST /decrypt
+type DecryptResponse struct {
+	Time                    int64   `json:"time"` // in microseconds
+	MemoryUsage             uint64  `json:"memoryUsage"`
+	CPUPercentage           float64 `json:"cpuPercentage"`
+	PowerUsageWatts         float64 `json:"powerUsageWatts"`
+	EnergyConsumptionJoules float64 `json:"energyConsumptionJoules"`
+	Data                    string  `json:"data"` // plaintext
+}
+
+// DelegationRequest represents a request to delegate a key
+type DelegationRequest struct {
+	URI       string `json:"uri" binding:"required"`
+	Hierarchy string `json:"hierarchy"`
+	StartTime int64  `json:"startTime" binding:"required"` // Unix timestamp
+	EndTime   int64  `json:"endTime" binding:"required"`   // Unix timestamp
+	Parent    string `json:"parent,omitempty"`             // For hierarchical delegation
+}
+
+// DelegationResponse represents the response from a delegation request
+type DelegationResponse struct {
+	Success       bool   `json:"success"`
+	KeyID         string `json:"keyId"`
+	Data          []byte `json:"data"`
+	URI           string `json:"uri"`
+	Hierarchy     string `json:"hierarchy"`
+	StartTime     int64  `json:"startTime"`
+	EndTime       int64  `json:"endTime"`
+	ExecutionTime int64  `json:"executionTime"` // in microseconds
+	Message       string `json:"message,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// RevocationRequest is the body of POST /revoke. Either KeyID, or URI with the
+// hierarchy and delegation window, identifies the key.
+type RevocationRequest struct {
+	KeyID         string    `json:"keyId,omitempty"`
+	URI           string    `json:"uri,omitempty"`
+	Hierarchy     string    `json:"hierarchy,omitempty"`
+	RevokedBy     string    `json:"revokedBy"`
+	Reason        string    `json:"reason"`
+	EffectiveFrom time.Time `json:"effectiveFrom,omitempty"`
+	EffectiveFor  int64     `json:"effectiveFor,omitempty"` // Duration in seconds, 0 means permanent
+	StartTime     int64     `json:"startTime,omitempty"`    // Unix timestamp for key delegation start
+	EndTime       int64     `json:"endTime,omitempty"`      // Unix timestamp for key delegation end
+}
+
+// RevokeResponse is returned by POST /revoke
+type RevokeResponse struct {
+	Success        bool      `json:"success"`
+	Message        string    `json:"message"`
+	KeyID          string    `json:"keyId"`
+	RevokedAt      time.Time `json:"revokedAt"`
+	EffectiveFrom  time.Time `json:"effectiveFrom"`
+	EffectiveUntil time.Time `json:"effectiveUntil"`
+}
+
+// RevocationEntry represents a revoked key entry
+type RevocationEntry struct {
+	KeyID          string    `json:"keyId"`          // Unique identifier for the delegated key
+	URI            string    `json:"uri"`            // The URI pattern that was delegated
+	Hierarchy      string    `json:"hierarchy"`      // The hierarchy of the key
+	RevokedAt      time.Time `json:"revokedAt"`      // When the key was revoked
+	RevokedBy      string    `json:"revokedBy"`      // Who revoked the key (optional)
+	Reason         string    `json:"reason"`         // Reason for revocation
+	EffectiveFrom  time.Time `json:"effectiveFrom"`  // When revocation takes effect
+	EffectiveUntil time.Time `json:"effectiveUntil"` // When revocation expires (optional, 0 means permanent)
+}
+
+// RevocationCheckRequest is the body of POST /revoke/check
+type RevocationCheckRequest struct {
+	Hierarchy string `json:"hierarchy" binding:"required"`
+	URI       string `json:"uri" binding:"required"`
+	StartTime int64  `json:"startTime" binding:"required"` // Unix timestamp
+	EndTime   int64  `json:"endTime" binding:"required"`   // Unix timestamp
+}
+
+// RevocationCheckResponse is returned by GET /revoke/check/:keyId, which sets
+// KeyID, and by POST /revoke/check, which echoes the key parameters
+type RevocationCheckResponse struct {
+	KeyID             string           `json:"keyId,omitempty"`
+	IsRevoked         bool             `json:"isRevoked"`
+	Hierarchy         string           `json:"hierarchy,omitempty"`
+	URI               string           `json:"uri,omitempty"`
+	StartTime         int64            `json:"startTime,omitempty"`
+	EndTime           int64            `json:"endTime,omitempty"`
+	RevocationDetails *RevocationEntry `json:"revocationDetails,omitempty"`
+}
@@ -0,0 +1,314 @@
+// Package config is the runtime configuration of the HIBE API server. Values
+// come from, in increasing precedence, the defaults, a JSON file, HIBE_*
+// environment variables and command-line flags, and are validated before the
+// server starts.
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in Redacted
+const redacted = "REDACTED"
+
+// Config is the server's runtime configuration
+type Config struct {
+	Server  ServerConfig  `json:"server"`
+	HIBE    HIBEConfig    `json:"hibe"`
+	Limits  LimitsConfig  `json:"limits"`
+	Power   PowerConfig   `json:"power"`
+	Chain   ChainConfig   `json:"chain"`
+	IPFS    IPFSConfig    `json:"ipfs"`
+	Access  AccessConfig  `json:"access"`
+	State   StateConfig   `json:"state"`
+	Log     LogConfig     `json:"log"`
+	Tracing TracingConfig `json:"tracing"`
+}
+
+// ServerConfig configures the HTTP listener
+type ServerConfig struct {
+	Listen                 string `json:"listen"` // host:port, or :port
+	ReadTimeoutSeconds     int    `json:"read_timeout_seconds"`
+	WriteTimeoutSeconds    int    `json:"write_timeout_seconds"` // bounds the slowest response, batch delegations included
+	IdleTimeoutSeconds     int    `json:"idle_timeout_seconds"`
+	ShutdownTimeoutSeconds int    `json:"shutdown_timeout_seconds"` // in-flight requests get this long to finish on SIGTERM
+	CheckBackends          bool   `json:"check_backends"`           // /readyz requires the chain and IPFS endpoints to answer
+}
+
+// HIBEConfig configures the key hierarchy and client state
+type HIBEConfig struct {
+	PatternSize     int    `json:"pattern_size"` // including the slots hibe reserves for time
+	Hierarchy       string `json:"hierarchy"`
+	ClientCacheSize uint64 `json:"client_cache_size"` // bytes of decrypted keys the client state keeps
+	KeyWindowStart  int64  `json:"key_window_start"`  // Unix validity window of /hibe-private-key
+	KeyWindowEnd    int64  `json:"key_window_end"`
+}
+
+// LimitsConfig bounds the work the server accepts
+type LimitsConfig struct {
+	MaxConcurrentRequests int `json:"max_concurrent_requests"` // 0 is unlimited
+	MaxBatchDelegations   int `json:"max_batch_delegations"`   // URIs in one batch delegation
+	MaxBatchEncryptions   int `json:"max_batch_encryptions"`   // records in one batch encryption
+	BatchWorkers          int `json:"batch_workers"`           // 0 is one per CPU
+}
+
+// PowerConfig holds the coefficients of the power consumption estimates
+type PowerConfig struct {
+	BaseWatts    float64 `json:"base_watts"`
+	CPUFactor    float64 `json:"cpu_factor"`    // watts per % CPU
+	MemoryFactor float64 `json:"memory_factor"` // watts per GB of memory
+}
+
+// ChainConfig locates the blockchain the server anchors to
+type ChainConfig struct {
+	RPCEndpoint     string  `json:"rpc_endpoint"`
+	ContractAddress string  `json:"contract_address"`
+	GasLimit        uint64  `json:"gas_limit"`
+	GasPriceGwei    float64 `json:"gas_price_gwei"`
+	Confirmations   int     `json:"confirmations"`
+	PrivateKey      string  `json:"private_key,omitempty"` // secret, hex
+}
+
+// IPFSConfig locates the IPFS node the server stores data on
+type IPFSConfig struct {
+	APIEndpoint       string `json:"api_endpoint"`
+	ReplicationFactor int    `json:"replication_factor"`
+}
+
+// AccessConfig holds the access broker's credentials and limits
+type AccessConfig struct {
+	OwnerToken         string `json:"owner_token,omitempty"` // secret
+	OwnerDataFile      string `json:"owner_data_file"`       // data released to grantees, empty for none
+	MaxPendingRequests int    `json:"max_pending_requests"`  // requests awaiting the owner at once
+}
+
+// StateConfig names the files revocation and delegation state is kept in.
+// Empty keeps that state in memory only.
+type StateConfig struct {
+	RevocationsFile string `json:"revocations_file"`
+	DelegationsFile string `json:"delegations_file"`
+}
+
+// LogConfig shapes the server's logs
+type LogConfig struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // text or json
+}
+
+// TracingConfig selects where request spans are exported
+type TracingConfig struct {
+	ServiceName  string `json:"service_name"`
+	Exporter     string `json:"exporter"`      // none, file or otlp
+	File         string `json:"file"`          // JSON spans, with the file exporter
+	OTLPEndpoint string `json:"otlp_endpoint"` // collector URL, with the otlp exporter
+}
+
+// Default returns the built-in configuration. Its listen address and HIBE,
+// power and chain settings are those the server used before it was
+// configurable; the server timeouts and request limits are new, as the server
+// had none.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Listen:                 ":8080",
+			ReadTimeoutSeconds:     15,
+			WriteTimeoutSeconds:    120,
+			IdleTimeoutSeconds:     120,
+			ShutdownTimeoutSeconds: 30,
+		},
+		HIBE: HIBEConfig{
+			PatternSize:     20,
+			Hierarchy:       "testHierarchy",
+			ClientCacheSize: 1 << 20,
+			KeyWindowStart:  1565119330,
+			KeyWindowEnd:    1565219330,
+		},
+		Limits: LimitsConfig{
+			MaxConcurrentRequests: 50,
+			MaxBatchDelegations:   10000,
+			MaxBatchEncryptions:   10000,
+		},
+		Access: AccessConfig{
+			MaxPendingRequests: 1000,
+		},
+		Power: PowerConfig{
+			BaseWatts:    0.5,
+			CPUFactor:    0.05,
+			MemoryFactor: 0.02,
+		},
+		Chain: ChainConfig{
+			RPCEndpoint:     "https://polygon-rpc.com",
+			ContractAddress: "0x742d35Cc6634C0532925a3b8D6Ac6B0ad39CEe5C",
+			GasLimit:        1000000,
+			GasPriceGwei:    30,
+			Confirmations:   12,
+		},
+		IPFS: IPFSConfig{
+			APIEndpoint:       "http://localhost:5001",
+			ReplicationFactor: 3,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: TracingConfig{
+			ServiceName: "hibe-api",
+			Exporter:    "none",
+		},
+	}
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks every value, reporting all problems at once
+func (c *Config) Validate() error {
+	var problems []string
+	invalid := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Listen); err != nil {
+		invalid("server.listen %q is not host:port", c.Server.Listen)
+	}
+	if c.Server.ReadTimeoutSeconds < 0 || c.Server.WriteTimeoutSeconds < 0 || c.Server.IdleTimeoutSeconds < 0 {
+		invalid("server timeouts cannot be negative")
+	}
+	if c.Server.ShutdownTimeoutSeconds < 1 {
+		invalid("server.shutdown_timeout_seconds must be positive")
+	}
+
+	if c.HIBE.PatternSize < 1 {
+		invalid("hibe.pattern_size must be positive")
+	}
+	if c.HIBE.Hierarchy == "" {
+		invalid("hibe.hierarchy is empty")
+	}
+	if c.HIBE.ClientCacheSize == 0 {
+		invalid("hibe.client_cache_size must be positive")
+	}
+	if c.HIBE.KeyWindowEnd <= c.HIBE.KeyWindowStart {
+		invalid("hibe.key_window_end must be after hibe.key_window_start")
+	}
+
+	if c.Limits.MaxConcurrentRequests < 0 {
+		invalid("limits.max_concurrent_requests cannot be negative")
+	}
+	if c.Limits.MaxBatchDelegations < 1 {
+		invalid("limits.max_batch_delegations must be positive")
+	}
+	if c.Limits.MaxBatchEncryptions < 1 {
+		invalid("limits.max_batch_encryptions must be positive")
+	}
+	if c.Limits.BatchWorkers < 0 {
+		invalid("limits.batch_workers cannot be negative")
+	}
+
+	if c.Access.MaxPendingRequests < 1 {
+		invalid("access.max_pending_requests must be positive")
+	}
+
+	if c.Power.BaseWatts < 0 || c.Power.CPUFactor < 0 || c.Power.MemoryFactor < 0 {
+		invalid("power coefficients cannot be negative")
+	}
+
+	if c.Chain.RPCEndpoint != "" && !validURL(c.Chain.RPCEndpoint, "http", "https", "ws", "wss") {
+		invalid("chain.rpc_endpoint %q is not an http(s) or ws(s) URL", c.Chain.RPCEndpoint)
+	}
+	if c.Chain.ContractAddress != "" && !validHex(c.Chain.ContractAddress, 20) {
+		invalid("chain.contract_address %q is not a 20-byte hex address", c.Chain.ContractAddress)
+	}
+	if c.Chain.GasLimit == 0 {
+		invalid("chain.gas_limit must be positive")
+	}
+	if c.Chain.GasPriceGwei < 0 {
+		invalid("chain.gas_price_gwei cannot be negative")
+	}
+	if c.Chain.Confirmations < 0 {
+		invalid("chain.confirmations cannot be negative")
+	}
+	if c.Chain.PrivateKey != "" && !validHex(c.Chain.PrivateKey, 32) {
+		invalid("chain.private_key is not a 32-byte hex key")
+	}
+
+	if c.IPFS.APIEndpoint != "" && !validURL(c.IPFS.APIEndpoint, "http", "https") {
+		invalid("ipfs.api_endpoint %q is not an http(s) URL", c.IPFS.APIEndpoint)
+	}
+	if c.IPFS.ReplicationFactor < 1 {
+		invalid("ipfs.replication_factor must be positive")
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log.level %q is not debug, info, warn or error", c.Log.Level)
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		invalid("log.format %q is not text or json", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case "none":
+	case "file":
+		if c.Tracing.File == "" {
+			invalid("tracing.file is required by the file exporter")
+		}
+	case "otlp":
+		if !validURL(c.Tracing.OTLPEndpoint, "http", "https") {
+			invalid("tracing.otlp_endpoint %q is not an http(s) URL", c.Tracing.OTLPEndpoint)
+		}
+	default:
+		invalid("tracing.exporter %q is not none, file or otlp", c.Tracing.Exporter)
+	}
+	if c.Tracing.ServiceName == "" {
+		invalid("tracing.service_name is empty")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Redacted returns a copy safe to log or serve, with secrets masked
+func (c *Config) Redacted() *Config {
+	copied := *c
+	if copied.Chain.PrivateKey != "" {
+		copied.Chain.PrivateKey = redacted
+	}
+	if copied.Access.OwnerToken != "" {
+		copied.Access.OwnerToken = redacted
+	}
+	return &copied
+}
+
+// validURL reports whether s is an absolute URL with one of the schemes
+func validURL(s string, schemes ...string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// validHex reports whether s is size bytes of hex, optionally 0x-prefixed
+func validHex(s string, size int) bool {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	decoded, err := hex.DecodeString(s)
+	return err == nil && len(decoded) == size
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(name string) string { return values[name] }
+}
+
+func writeFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hibe.json")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	c, err := Load(nil, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.HIBE.PatternSize != 20 || c.Server.Listen != ":8080" || c.HIBE.Hierarchy != "testHierarchy" {
+		t.Errorf("unexpected defaults %+v", c)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `{
+		"server": {"listen": ":9000"},
+		"hibe": {"pattern_size": 24, "hierarchy": "fromFile"},
+		"limits": {"batch_workers": 2}
+	}`)
+
+	c, err := Load([]string{"-config", path, "-pattern-size", "28", "-shutdown-timeout", "5"}, env(map[string]string{
+		"HIBE_PATTERN_SIZE":   "26",
+		"HIBE_HIERARCHY":      "fromEnv",
+		"HIBE_CHECK_BACKENDS": "true",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.HIBE.PatternSize != 28 {
+		t.Errorf("expected the flag to win, got pattern size %d", c.HIBE.PatternSize)
+	}
+	if c.HIBE.Hierarchy != "fromEnv" {
+		t.Errorf("expected the environment to beat the file, got %q", c.HIBE.Hierarchy)
+	}
+	if c.Server.Listen != ":9000" || c.Limits.BatchWorkers != 2 {
+		t.Errorf("expected the file to beat the defaults, got %+v", c)
+	}
+	if c.Server.ShutdownTimeoutSeconds != 5 || !c.Server.CheckBackends {
+		t.Errorf("expected the shutdown timeout and backend checks to be set, got %+v", c.Server)
+	}
+	if c.Limits.MaxBatchDelegations != 10000 {
+		t.Errorf("expected settings missing from the file to keep their default, got %d", c.Limits.MaxBatchDelegations)
+	}
+	if c.Log.Format != "text" || c.Tracing.Exporter != "none" {
+		t.Errorf("expected logs and tracing to keep their defaults, got %+v %+v", c.Log, c.Tracing)
+	}
+
+	c, err = Load([]string{"-trace-exporter", "otlp", "-log-format", "json"}, env(map[string]string{
+		"HIBE_OTLP_ENDPOINT": "http://collector:4318",
+		"HIBE_LOG_LEVEL":     "debug",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Tracing.Exporter != "otlp" || c.Tracing.OTLPEndpoint != "http://collector:4318" || c.Log.Level != "debug" || c.Log.Format != "json" {
+		t.Errorf("expected logs and tracing to be configurable, got %+v %+v", c.Log, c.Tracing)
+	}
+
+	// The file can also be named in the environment, and -config=path works too
+	c, err = Load(nil, env(map[string]string{EnvConfigFile: path}))
+	if err != nil || c.Server.Listen != ":9000" {
+		t.Errorf("expected HIBE_CONFIG to be read, got %+v, %v", c, err)
+	}
+	c, err = Load([]string{"--config=" + path}, env(nil))
+	if err != nil || c.Server.Listen != ":9000" {
+		t.Errorf("expected --config=path to be read, got %+v, %v", c, err)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	settings := []byte(`{"server": {"listen": ":9000"}, "chain": {"private_key": "`+strings.Repeat("ab", 32)+`"}}`)
+	c, err := LoadSettings(settings, []string{"-pattern-size", "28"}, env(map[string]string{
+		"HIBE_HIERARCHY": "fromEnv",
+		EnvConfigFile:    filepath.Join(t.TempDir(), "missing.json"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Listen != ":9000" || c.Chain.PrivateKey != strings.Repeat("ab", 32) {
+		t.Errorf("expected the settings to be read, got %+v", c)
+	}
+	if c.HIBE.Hierarchy != "fromEnv" || c.HIBE.PatternSize != 28 {
+		t.Errorf("expected the environment and flags to override the settings, got %+v", c.HIBE)
+	}
+
+	if _, err := LoadSettings([]byte(`{"hibe": {"patern_size": 24}}`), nil, env(nil)); err == nil || !strings.Contains(err.Error(), "patern_size") {
+		t.Errorf("expected an unknown key to be refused, got %v", err)
+	}
+	if _, err := LoadSettings(nil, []string{"-config", "hibe.json"}, env(nil)); err == nil {
+		t.Error("expected -config to be refused when the settings are given")
+	}
+}
+
+func TestLoadRejectsBadInput(t *testing.T) {
+	misspelt := writeFile(t, `{"hibe": {"patern_size": 24}}`)
+	if _, err := Load([]string{"-config", misspelt}, env(nil)); err == nil || !strings.Contains(err.Error(), "patern_size") {
+		t.Errorf("expected an unknown key to be refused, got %v", err)
+	}
+	if _, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.json")}, env(nil)); err == nil {
+		t.Error("expected a missing config file to fail")
+	}
+	if _, err := Load(nil, env(map[string]string{"HIBE_PATTERN_SIZE": "twenty"})); err == nil || !strings.Contains(err.Error(), "HIBE_PATTERN_SIZE") {
+		t.Errorf("expected a malformed environment variable to be named, got %v", err)
+	}
+	if _, err := Load([]string{"-no-such-flag"}, env(nil)); err == nil {
+		t.Error("expected an unknown flag to fail")
+	}
+	if _, err := Load([]string{"-chain-private-key", "00"}, env(nil)); err == nil {
+		t.Error("expected secrets to have no flag")
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := Default()
+	c.Server.Listen = "8080"
+	c.HIBE.PatternSize = 0
+	c.HIBE.KeyWindowEnd = c.HIBE.KeyWindowStart
+	c.Chain.RPCEndpoint = "polygon-rpc.com"
+	c.Chain.ContractAddress = "0x1234"
+	c.Server.ShutdownTimeoutSeconds = 0
+	c.Log.Format = "xml"
+	c.Tracing.Exporter = "file"
+
+	var invalid *ValidationError
+	if err := c.Validate(); !errors.As(err, &invalid) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if len(invalid.Problems) != 8 {
+		t.Errorf("expected 8 problems, got %q", invalid.Problems)
+	}
+
+	// Validation runs on load too
+	if _, err := Load([]string{"-max-batch-delegations", "0"}, env(nil)); !errors.As(err, &invalid) {
+		t.Errorf("expected Load to validate, got %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	key := strings.Repeat("ab", 32)
+	c, err := Load(nil, env(map[string]string{"HIBE_CHAIN_PRIVATE_KEY": key, "ACCESS_OWNER_TOKEN": "owner-secret"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Chain.PrivateKey != key || c.Access.OwnerToken != "owner-secret" {
+		t.Fatalf("expected secrets to be read from the environment, got %+v", c)
+	}
+
+	encoded, err := json.Marshal(c.Redacted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(encoded), key) || strings.Contains(string(encoded), "owner-secret") {
+		t.Errorf("redacted config leaks a secret: %s", encoded)
+	}
+	if c.Chain.PrivateKey != key {
+		t.Error("expected Redacted to leave the original untouched")
+	}
+}
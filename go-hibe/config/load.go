@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// EnvConfigFile names the JSON file to load when -config is not given
+const EnvConfigFile = "HIBE_CONFIG"
+
+// setting binds one configuration value to its flag and environment variable.
+// Secrets have no flag, so they never show up in a process listing.
+type setting struct {
+	flag   string
+	env    string
+	usage  string
+	secret bool
+	value  interface{} // pointer into the Config
+}
+
+// settings lists every value that can be overridden outside the config file
+func (c *Config) settings() []setting {
+	return []setting{
+		{flag: "listen", env: "HIBE_LISTEN", usage: "address to listen on, host:port", value: &c.Server.Listen},
+		{flag: "read-timeout", env: "HIBE_READ_TIMEOUT", usage: "seconds to read a request, 0 for none", value: &c.Server.ReadTimeoutSeconds},
+		{flag: "write-timeout", env: "HIBE_WRITE_TIMEOUT", usage: "seconds to write a response, 0 for none", value: &c.Server.WriteTimeoutSeconds},
+		{flag: "idle-timeout", env: "HIBE_IDLE_TIMEOUT", usage: "seconds an idle keep-alive connection stays open, 0 for the read timeout", value: &c.Server.IdleTimeoutSeconds},
+		{flag: "shutdown-timeout", env: "HIBE_SHUTDOWN_TIMEOUT", usage: "seconds in-flight requests get to finish on SIGTERM", value: &c.Server.ShutdownTimeoutSeconds},
+		{flag: "check-backends", env: "HIBE_CHECK_BACKENDS", usage: "make /readyz require the chain and IPFS endpoints to answer", value: &c.Server.CheckBackends},
+
+		{flag: "pattern-size", env: "HIBE_PATTERN_SIZE", usage: "HIBE pattern size, including the time slots", value: &c.HIBE.PatternSize},
+		{flag: "hierarchy", env: "HIBE_HIERARCHY", usage: "HIBE hierarchy name", value: &c.HIBE.Hierarchy},
+		{flag: "client-cache-size", env: "HIBE_CLIENT_CACHE_SIZE", usage: "bytes of keys the client state caches", value: &c.HIBE.ClientCacheSize},
+		{flag: "key-window-start", env: "HIBE_KEY_WINDOW_START", usage: "Unix start of the /hibe-private-key validity window", value: &c.HIBE.KeyWindowStart},
+		{flag: "key-window-end", env: "HIBE_KEY_WINDOW_END", usage: "Unix end of the /hibe-private-key validity window", value: &c.HIBE.KeyWindowEnd},
+
+		{flag: "max-concurrent-requests", env: "HIBE_MAX_CONCURRENT_REQUESTS", usage: "requests served at once, 0 for no limit", value: &c.Limits.MaxConcurrentRequests},
+		{flag: "max-batch-delegations", env: "HIBE_MAX_BATCH_DELEGATIONS", usage: "URIs accepted in one batch delegation", value: &c.Limits.MaxBatchDelegations},
+		{flag: "max-batch-encryptions", env: "HIBE_MAX_BATCH_ENCRYPTIONS", usage: "records accepted in one batch encryption", value: &c.Limits.MaxBatchEncryptions},
+		{flag: "batch-workers", env: "HIBE_BATCH_WORKERS", usage: "batch delegation and encryption workers, 0 for one per CPU", value: &c.Limits.BatchWorkers},
+
+		{flag: "power-base-watts", env: "HIBE_POWER_BASE_WATTS", usage: "base power draw in watts", value: &c.Power.BaseWatts},
+		{flag: "power-cpu-factor", env: "HIBE_POWER_CPU_FACTOR", usage: "watts per % CPU", value: &c.Power.CPUFactor},
+		{flag: "power-memory-factor", env: "HIBE_POWER_MEMORY_FACTOR", usage: "watts per GB of memory", value: &c.Power.MemoryFactor},
+
+		{flag: "chain-rpc", env: "HIBE_CHAIN_RPC", usage: "blockchain RPC endpoint", value: &c.Chain.RPCEndpoint},
+		{flag: "chain-contract", env: "HIBE_CHAIN_CONTRACT", usage: "contract address", value: &c.Chain.ContractAddress},
+		{flag: "chain-gas-limit", env: "HIBE_CHAIN_GAS_LIMIT", usage: "gas limit of chain transactions", value: &c.Chain.GasLimit},
+		{flag: "chain-gas-price", env: "HIBE_CHAIN_GAS_PRICE_GWEI", usage: "gas price in gwei", value: &c.Chain.GasPriceGwei},
+		{flag: "chain-confirmations", env: "HIBE_CHAIN_CONFIRMATIONS", usage: "blocks to wait for", value: &c.Chain.Confirmations},
+		{env: "HIBE_CHAIN_PRIVATE_KEY", secret: true, value: &c.Chain.PrivateKey},
+
+		{flag: "ipfs-api", env: "HIBE_IPFS_API", usage: "IPFS API endpoint", value: &c.IPFS.APIEndpoint},
+		{flag: "ipfs-replication", env: "HIBE_IPFS_REPLICATION", usage: "IPFS replication factor", value: &c.IPFS.ReplicationFactor},
+
+		{env: "ACCESS_OWNER_TOKEN", secret: true, value: &c.Access.OwnerToken},
+		{flag: "access-owner-data", env: "ACCESS_OWNER_DATA_FILE", usage: "owner data file the access broker releases, empty for none", value: &c.Access.OwnerDataFile},
+		{flag: "access-max-pending", env: "ACCESS_MAX_PENDING_REQUESTS", usage: "access requests awaiting the owner at once", value: &c.Access.MaxPendingRequests},
+
+		{flag: "revocations-file", env: "HIBE_REVOCATIONS_FILE", usage: "file revocations are kept in, empty for memory only", value: &c.State.RevocationsFile},
+		{flag: "delegations-file", env: "HIBE_DELEGATIONS_FILE", usage: "file the delegation record is kept in, empty for memory only", value: &c.State.DelegationsFile},
+
+		{flag: "log-level", env: "HIBE_LOG_LEVEL", usage: "debug, info, warn or error", value: &c.Log.Level},
+		{flag: "log-format", env: "HIBE_LOG_FORMAT", usage: "text or json", value: &c.Log.Format},
+
+		{flag: "trace-service-name", env: "HIBE_TRACE_SERVICE_NAME", usage: "service name spans are exported under", value: &c.Tracing.ServiceName},
+		{flag: "trace-exporter", env: "HIBE_TRACE_EXPORTER", usage: "where spans go: none, file or otlp", value: &c.Tracing.Exporter},
+		{flag: "trace-file", env: "HIBE_TRACE_FILE", usage: "file spans are appended to, with the file exporter", value: &c.Tracing.File},
+		{flag: "otlp-endpoint", env: "HIBE_OTLP_ENDPOINT", usage: "OTLP/HTTP collector URL, with the otlp exporter", value: &c.Tracing.OTLPEndpoint},
+	}
+}
+
+// bind registers s on fs under name
+func (s setting) bind(fs *flag.FlagSet, name string) {
+	switch v := s.value.(type) {
+	case *string:
+		fs.StringVar(v, name, *v, s.usage)
+	case *int:
+		fs.IntVar(v, name, *v, s.usage)
+	case *int64:
+		fs.Int64Var(v, name, *v, s.usage)
+	case *uint64:
+		fs.Uint64Var(v, name, *v, s.usage)
+	case *float64:
+		fs.Float64Var(v, name, *v, s.usage)
+	case *bool:
+		fs.BoolVar(v, name, *v, s.usage)
+	default:
+		panic(fmt.Sprintf("config: setting %s has unsupported type %T", s.env, s.value))
+	}
+}
+
+// Load builds the configuration from args (without the program name) and the
+// environment read through getenv, on top of the file named by -config or
+// HIBE_CONFIG, on top of Default. The result is validated.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	path, err := configPath(args, getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Default()
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	fs := newFlagSet()
+	fs.String("config", path, "JSON configuration file")
+	return c.override(fs, args, getenv)
+}
+
+// LoadSettings is Load for a program that embeds the server and holds the
+// config file's contents itself: settings are read in place of the file, so
+// secrets in them never have to be written to disk. -config and HIBE_CONFIG
+// are not consulted.
+func LoadSettings(settings []byte, args []string, getenv func(string) string) (*Config, error) {
+	c := Default()
+	if len(settings) > 0 {
+		if err := c.decode(settings, "settings"); err != nil {
+			return nil, err
+		}
+	}
+	return c.override(newFlagSet(), args, getenv)
+}
+
+// override applies the environment read through getenv and then args to c,
+// registering the settings on fs, and validates the result
+func (c *Config) override(fs *flag.FlagSet, args []string, getenv func(string) string) (*Config, error) {
+	// Flags are bound to the loaded values, then environment variables are set
+	// through the same parsers, then the command line has the last word
+	env := flag.NewFlagSet("environment", flag.ContinueOnError)
+	for _, s := range c.settings() {
+		if s.secret {
+			s.bind(env, s.env)
+		} else {
+			s.bind(fs, s.flag)
+		}
+	}
+	for _, s := range c.settings() {
+		value := getenv(s.env)
+		if value == "" {
+			continue
+		}
+		target, name := fs, s.flag
+		if s.secret {
+			target, name = env, s.env
+		}
+		if err := target.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", s.env, value, err)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Usage prints the flags Load accepts
+func Usage(w io.Writer) {
+	fs := newFlagSet()
+	fs.SetOutput(w)
+	fs.String("config", "", "JSON configuration file, also "+EnvConfigFile)
+	for _, s := range Default().settings() {
+		if !s.secret {
+			s.bind(fs, s.flag)
+		}
+	}
+	fs.PrintDefaults()
+}
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("hibe-api", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// configPath finds the config file among args before the other flags are
+// known, falling back to HIBE_CONFIG
+func configPath(args []string, getenv func(string) string) (string, error) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if strings.HasPrefix(name, "config=") {
+			return strings.TrimPrefix(name, "config="), nil
+		}
+		if name == "config" {
+			if i+1 == len(args) {
+				return "", fmt.Errorf("flag needs an argument: -config")
+			}
+			return args[i+1], nil
+		}
+	}
+	return getenv(EnvConfigFile), nil
+}
+
+// loadFile overlays the JSON file at path. Unknown keys are refused, so a
+// misspelt setting does not silently keep its default.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	return c.decode(data, "config file "+path)
+}
+
+// decode overlays the JSON in data, refusing unknown keys. name says where
+// data came from in errors.
+func (c *Config) decode(data []byte, name string) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("invalid %s: %v", name, err)
+	}
+	return nil
+}
@@ -1,1362 +1,38 @@
+// Command hibe-api serves the HIBE API, configured by flags, HIBE_* environment
+// variables and the JSON file named by -config or HIBE_CONFIG
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
+	"errors"
+	"flag"
 	"fmt"
-	"hibe-api"
-	"math"
-	"net/http"
-	"runtime"
-	"hibe-api/security"
-	"hibe-api/security/attacks"
-	"strconv"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"hibe-api/analysis"
-	"hibe-api/benchmarks"
-	"hibe-api/blockchain"
-	"hibe-api/privacy"
-
-	"github.com/gin-gonic/gin"
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/mem"
-	"github.com/ucbrise/hibe-pairing/lang/go/wkdibe"
+	"hibe-api/config"
+	"hibe-api/server"
 )
 
-const TestPatternSize = 20
-
-var TestHierarchy = []byte("testHierarchy")
-
-const quote1 = "Imagination is more important than knowledge. --Albert Einstein"
-const quote2 = "Today is your day! / Your mountain is waiting. / So... get on your way! --Theodor Seuss Geisel"
-
-type DecryptRequest struct {
-	URI              string `string:"uri" binding:"required"`
-	ENCRYPTEDMESSAGE string `string:"encryptedMessage" binding:"required"`
-	KEY              string `string:"key" binding:"required"`
-}
-
-type EncryptRequest struct {
-	URI     string `string:"uri" binding:"required"`
-	MESSAGE string `string:"message" binding:"required"`
-}
-
-type MeasureUsage struct {
-	memory            uint64
-	cpuPercentage     float64
-	powerUsage        float64  // Power usage in watts
-	energyConsumption float64  // Energy consumption in joules
-	executionTime     int64    // Execution time in microseconds
-}
-
-// PowerConstants represents power consumption coefficients for different operations
-type PowerConstants struct {
-	cpuFactor    float64 // Watts per % CPU
-	memoryFactor float64 // Watts per GB memory
-	basePower    float64 // Base power consumption in watts
-}
-
-// Default power constants for a typical mobile/IoT device
-// These values should be calibrated for specific hardware
-var defaultPowerConstants = PowerConstants{
-	cpuFactor:    0.05,   // 0.05W per 1% CPU utilization
-	memoryFactor: 0.02,   // 0.02W per GB of memory used
-	basePower:    0.5,    // 0.5W base power consumption
-}
-
-// PowerReport holds data for power consumption analysis
-type PowerReport struct {
-	OperationName      string    `json:"operationName"`
-	Timestamp          time.Time `json:"timestamp"`
-	ExecutionTimeMs    int64     `json:"executionTimeMs"`
-	PowerUsageWatts    float64   `json:"powerUsageWatts"`
-	EnergyJoules       float64   `json:"energyJoules"`
-	MemoryUsageKB      uint64    `json:"memoryUsageKB"`
-	CPUUtilizationPct  float64   `json:"cpuUtilizationPct"`
-	DataSizeBytes      int       `json:"dataSizeBytes,omitempty"`
-}
-
-// We'll store historical power reports for analysis
-var powerReports = []PowerReport{}
-
-func measureMemoryUsage() MeasureUsage {
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
-
-	fmt.Printf("Number of CPUs: %d\n", runtime.NumCPU())
-	fmt.Printf("Alloc = %v MiB\n", memStats.Alloc/1024/1024)
-	fmt.Printf("TotalAlloc = %v MiB\n", memStats.TotalAlloc/1024/1024)
-	fmt.Printf("Sys = %v MiB\n", memStats.Sys/1024/1024)
-	fmt.Printf("NumGC = %v\n\n", memStats.NumGC)
-	percentages, err := cpu.Percent(time.Second, false)
-	if err != nil {
-		fmt.Println(err)
-	}
-
-	// Calculate power usage
-	memoryGB := float64(memStats.Alloc) / (1024 * 1024 * 1024)
-	cpuPercent := percentages[0]
-	powerUsage := estimatePowerConsumption(cpuPercent, memoryGB, defaultPowerConstants)
-
-	return MeasureUsage{
-		memory:            memStats.Alloc,
-		cpuPercentage:     percentages[0],
-		powerUsage:        powerUsage,
-		energyConsumption: 0, // Will be calculated after execution time is known
-	}
-}
-
-// estimatePowerConsumption calculates estimated power consumption based on resource usage
-func estimatePowerConsumption(cpuPercent float64, memoryGB float64, constants PowerConstants) float64 {
-	cpuPower := cpuPercent * constants.cpuFactor
-	memoryPower := memoryGB * constants.memoryFactor
-
-	// Total power is base power plus CPU and memory components
-	totalPower := constants.basePower + cpuPower + memoryPower
-
-	// Round to 2 decimal places for readability
-	return math.Round(totalPower*100) / 100
-}
-
-// calculateEnergyConsumption computes energy (joules) from power (watts) and time (seconds)
-func calculateEnergyConsumption(powerWatts float64, timeSeconds float64) float64 {
-	energyJoules := powerWatts * timeSeconds
-	return math.Round(energyJoules*1000) / 1000 // Round to 3 decimal places
-}
-
-// getPowerProfile captures current system-wide resource usage for power profiling
-func getPowerProfile() map[string]interface{} {
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
-
-	v, _ := mem.VirtualMemory()
-	cpuPercent, _ := cpu.Percent(0, false)
-
-	memoryGB := float64(memStats.Alloc) / (1024 * 1024 * 1024)
-	powerUsage := estimatePowerConsumption(cpuPercent[0], memoryGB, defaultPowerConstants)
-
-	return map[string]interface{}{
-		"cpuUtilization":     cpuPercent[0],
-		"memoryUsageMB":      memStats.Alloc / (1024 * 1024),
-		"totalMemoryMB":      v.Total / (1024 * 1024),
-		"estimatedPowerWatts": powerUsage,
-	}
-}
-
-// generatePowerReport creates a formatted report of power consumption
-func generatePowerReport(reports []PowerReport, format string) string {
-	if len(reports) == 0 {
-		return "No power consumption data available."
-	}
-
-	if format == "text" {
-		var buffer bytes.Buffer
-		buffer.WriteString("============================================\n")
-		buffer.WriteString("       POWER CONSUMPTION ANALYSIS REPORT    \n")
-		buffer.WriteString("============================================\n\n")
-		
-		// Summary statistics
-		var totalEnergy float64
-		var minPower, maxPower float64 = reports[0].PowerUsageWatts, reports[0].PowerUsageWatts
-		var avgExecTime int64
-		
-		for i, report := range reports {
-			if i == 0 {
-				minPower = report.PowerUsageWatts
-				maxPower = report.PowerUsageWatts
-			}
-			
-			totalEnergy += report.EnergyJoules
-			avgExecTime += report.ExecutionTimeMs
-			
-			if report.PowerUsageWatts < minPower {
-				minPower = report.PowerUsageWatts
-			}
-			if report.PowerUsageWatts > maxPower {
-				maxPower = report.PowerUsageWatts
-			}
-			
-			// Individual report
-			buffer.WriteString(fmt.Sprintf("Operation: %s\n", report.OperationName))
-			buffer.WriteString(fmt.Sprintf("Timestamp: %s\n", report.Timestamp.Format(time.RFC3339)))
-			buffer.WriteString(fmt.Sprintf("Execution time: %d ms\n", report.ExecutionTimeMs/1000))
-			buffer.WriteString(fmt.Sprintf("Power usage: %.2f W\n", report.PowerUsageWatts))
-			buffer.WriteString(fmt.Sprintf("Energy consumption: %.3f J\n", report.EnergyJoules))
-			buffer.WriteString(fmt.Sprintf("Memory usage: %.2f MB\n", float64(report.MemoryUsageKB)/1024))
-			buffer.WriteString(fmt.Sprintf("CPU utilization: %.2f%%\n", report.CPUUtilizationPct))
-			if report.DataSizeBytes > 0 {
-				buffer.WriteString(fmt.Sprintf("Data size: %d bytes\n", report.DataSizeBytes))
-				// Calculate energy efficiency (Joules per byte)
-				buffer.WriteString(fmt.Sprintf("Energy efficiency: %.6f J/byte\n", report.EnergyJoules/float64(report.DataSizeBytes)))
-			}
-			buffer.WriteString("--------------------------------------------\n\n")
-		}
-		
-		// Summary
-		buffer.WriteString("============= SUMMARY =============\n")
-		buffer.WriteString(fmt.Sprintf("Number of operations: %d\n", len(reports)))
-		buffer.WriteString(fmt.Sprintf("Total energy consumed: %.3f J\n", totalEnergy))
-		buffer.WriteString(fmt.Sprintf("Power range: %.2f - %.2f W\n", minPower, maxPower))
-		buffer.WriteString(fmt.Sprintf("Average execution time: %.2f ms\n", float64(avgExecTime)/float64(len(reports))/1000))
-		buffer.WriteString(fmt.Sprintf("Average energy per operation: %.3f J\n", totalEnergy/float64(len(reports))))
-		buffer.WriteString("==================================\n")
-		
-		return buffer.String()
-	}
-	
-	// Default: just return the count of reports
-	return fmt.Sprintf("%d power reports available.", len(reports))
-}
-
-// recordPowerUsage adds a new power consumption record to the history
-func recordPowerUsage(operation string, usage MeasureUsage, dataSize int) {
-	report := PowerReport{
-		OperationName:      operation,
-		Timestamp:          time.Now(),
-		ExecutionTimeMs:    usage.executionTime,
-		PowerUsageWatts:    usage.powerUsage,
-		EnergyJoules:       usage.energyConsumption,
-		MemoryUsageKB:      usage.memory / 1024,
-		CPUUtilizationPct:  usage.cpuPercentage,
-		DataSizeBytes:      dataSize,
-	}
-	
-	// Limit the size of the reports slice to avoid memory bloat
-	if len(powerReports) >= 100 {
-		// Remove the oldest report (shift left)
-		powerReports = powerReports[1:]
-	}
-	
-	powerReports = append(powerReports, report)
-}
-
 func main() {
-	ctx := context.Background()
-	_, store := NewTestKeyStore()
-	encoder := hibe.NewDefaultPatternEncoder(TestPatternSize - hibe.MaxTimeLength)
-
-	state := NewTestState()
-	now := time.Now()
-
-	r := gin.Default()
-
-	// Add security headers middleware
-	r.Use(securityHeaders())
-
-	r.GET("/hibe-private-key", func(c *gin.Context) {
-		uri := "a/b/c"
-
-		start := time.Unix(1565119330, 0)
-		end := time.Unix(1565219330, 0)
-
-		parent := c.DefaultQuery("parent", "")
-		if parent != "" {
-			uri := "a/b/c/d"
-			delegation, err := hibe.Delegate(ctx, store, encoder, TestHierarchy, uri, start, end, hibe.DecryptPermission|hibe.SignPermission)
-			if err != nil {
-				fmt.Println(err)
-			}
-
-			marshalled := delegation.Marshal()
-
-			c.JSON(200, gin.H{
-				"data": marshalled,
-			})
-			return
-		}
-
-		startTime := time.Now()
-		delegation, err := hibe.Delegate(ctx, store, encoder, TestHierarchy, uri, start, end, hibe.DecryptPermission|hibe.SignPermission)
-		if err != nil {
-			fmt.Println(err)
-		}
-		endTime := time.Now()
-
-		marshalled := delegation.Marshal()
-
-		c.JSON(200, gin.H{
-			"time": endTime.Sub(startTime).Microseconds(),
-			"data": marshalled,
-		})
-	})
-
-	r.POST("/encrypt", func(c *gin.Context) {
-		measureUsageBefore := measureMemoryUsage()
-		fmt.Println(measureUsageBefore)
-		var err error
-
-		var encryptRequest EncryptRequest
-		c.BindJSON(&encryptRequest)
-
-		message := encryptRequest.MESSAGE
-		uri := encryptRequest.URI
-
-		startTime := time.Now()
-		var encrypted []byte
-		if encrypted, err = state.Encrypt(ctx, TestHierarchy, uri, now, []byte(message)); err != nil {
-			fmt.Println(err)
-		}
-		endTime := time.Now()
-		executionTimeMs := endTime.Sub(startTime).Microseconds()
-
-		measureUsage := measureMemoryUsage()
-		measureUsage.executionTime = executionTimeMs
-
-		// Calculate energy consumption (execution time in seconds * power usage in watts)
-		executionTimeSeconds := float64(executionTimeMs) / 1000000.0
-		measureUsage.energyConsumption = calculateEnergyConsumption(measureUsage.powerUsage, executionTimeSeconds)
-
-		// Record power usage with message size for efficiency analysis
-		recordPowerUsage("encrypt", measureUsage, len(message))
-
-		var decrypted []byte
-		if decrypted, err = state.Decrypt(ctx, TestHierarchy, uri, now, encrypted); err != nil {
-			fmt.Println(err)
-			c.JSON(400, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-
-		if !bytes.Equal(decrypted, []byte(message)) {
-			fmt.Println("Original and decrypted messages differ")
-		}
-
-		c.JSON(200, gin.H{
-			"time":                   executionTimeMs,
-			"memoryUsage":            measureUsage.memory,
-			"cpuPercentage":          measureUsage.cpuPercentage,
-			"powerUsageWatts":        measureUsage.powerUsage,
-			"energyConsumptionJoules": measureUsage.energyConsumption,
-			"data":                   base64.StdEncoding.EncodeToString(encrypted),
-		})
-	})
-
-	r.POST("/decrypt", func(c *gin.Context) {
-		measureUsageBefore := measureMemoryUsage()
-		var err error
-		var decryptRequest DecryptRequest
-		c.BindJSON(&decryptRequest)
-
-		encrypted, err := base64.StdEncoding.DecodeString(decryptRequest.ENCRYPTEDMESSAGE)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		uri := decryptRequest.URI
-
-		startTime := time.Now()
-		var decrypted []byte
-		if decrypted, err = state.Decrypt(ctx, TestHierarchy, uri, now, encrypted); err != nil {
-			fmt.Println(err)
-			c.JSON(400, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-		endTime := time.Now()
-		executionTimeMs := endTime.Sub(startTime).Microseconds()
-
-		measureUsage := measureMemoryUsage()
-		measureUsage.executionTime = executionTimeMs
-
-		// Calculate energy consumption (execution time in seconds * power usage in watts)
-		executionTimeSeconds := float64(executionTimeMs) / 1000000.0
-		measureUsage.energyConsumption = calculateEnergyConsumption(measureUsage.powerUsage, executionTimeSeconds)
-
-		// Record power usage with encrypted data size
-		recordPowerUsage("decrypt", measureUsage, len(encrypted))
-
-		if !bytes.Equal(decrypted, []byte("test message")) {
-			fmt.Println("Original and decrypted messages differ")
-		}
-
-		str := string(decrypted)
-
-		c.JSON(200, gin.H{
-			"time":                  executionTimeMs,
-			"memoryUsage":           measureUsage.memory,
-			"cpuPercentage":         measureUsage.cpuPercentage,
-			"powerUsageWatts":       measureUsage.powerUsage,
-			"energyConsumptionJoules": measureUsage.energyConsumption,
-			"data":                  str,
-		})
-	})
-
-	// Add new endpoint for detailed power analysis
-	r.GET("/power-profile", func(c *gin.Context) {
-		profile := getPowerProfile()
-		c.JSON(200, profile)
-	})
-	
-	// Add endpoint for power consumption reports
-	r.GET("/power-report", func(c *gin.Context) {
-		format := c.DefaultQuery("format", "json")
-		
-		if format == "text" {
-			report := generatePowerReport(powerReports, "text")
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		// Default to JSON format
-		c.JSON(200, gin.H{
-			"reportCount": len(powerReports),
-			"reports": powerReports,
-			"summary": map[string]interface{}{
-				"totalOperations": len(powerReports),
-				"operationsBreakdown": summarizeOperations(powerReports),
-				"averagePowerWatts": calculateAveragePower(powerReports),
-				"totalEnergyJoules": calculateTotalEnergy(powerReports),
-				"energyEfficiency": calculateEnergyEfficiency(powerReports),
-			},
-		})
-	})
-
-	// Security Testing Endpoints
-	
-	// Security Assessment Endpoint
-	r.GET("/security-assessment", func(c *gin.Context) {
-		assessment := security.PerformSecurityAssessment()
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			c.Header("Content-Type", "text/plain")
-			c.String(200, security.FormatSecurityAssessment(assessment))
-			return
-		}
-		
-		c.JSON(200, assessment)
-	})
-	
-	// MITM Attack Simulation Endpoint
-	r.POST("/security-test/mitm", func(c *gin.Context) {
-		var request struct {
-			TargetHost string `json:"target_host"`
-			TargetPort int    `json:"target_port"`
-			AttackType string `json:"attack_type"`
-		}
-		
-		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(400, gin.H{"error": err.Error()})
-			return
-		}
-		
-		// Default values
-		if request.TargetHost == "" {
-			request.TargetHost = "localhost"
-		}
-		if request.TargetPort == 0 {
-			request.TargetPort = 8080
-		}
-		
-		simulator := attacks.NewMITMSimulator(request.TargetHost, request.TargetPort)
-		
-		var result attacks.MITMAttackResult
-		switch request.AttackType {
-		case "certificate_substitution":
-			result = simulator.SimulateCertificateSubstitution()
-		case "ssl_stripping":
-			result = simulator.SimulateSSLStripping()
-		case "traffic_interception":
-			result = simulator.SimulateTrafficInterception()
-		case "session_hijacking":
-			result = simulator.SimulateSessionHijacking()
-		case "dns_spoofing":
-			result = simulator.SimulateDNSSpoofing()
-		case "all":
-			results := simulator.RunAllAttacks()
-			c.JSON(200, gin.H{
-				"results": results,
-				"report":  simulator.GenerateReport(),
-			})
-			return
-		default:
-			c.JSON(400, gin.H{"error": "Invalid attack type"})
-			return
-		}
-		
-		c.JSON(200, result)
-	})
-	
-	// Timing Attack Simulation Endpoint
-	r.POST("/security-test/timing", func(c *gin.Context) {
-		var request struct {
-			AttackType string `json:"attack_type"`
-			SampleSize int    `json:"sample_size"`
-		}
-		
-		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(400, gin.H{"error": err.Error()})
-			return
-		}
-		
-		// Default sample size
-		if request.SampleSize == 0 {
-			request.SampleSize = 1000
-		}
-		
-		// Dummy vulnerable function for testing
-		vulnerableCompare := func(a, b []byte) bool {
-			if len(a) != len(b) {
-				return false
-			}
-			for i := 0; i < len(a); i++ {
-				if a[i] != b[i] {
-					return false
-				}
-				time.Sleep(time.Nanosecond * 100) // Vulnerable timing
-			}
-			return true
-		}
-		
-		timingAttack := attacks.NewTimingAttack(vulnerableCompare, request.SampleSize)
-		
-		var result attacks.TimingAttackResult
-		switch request.AttackType {
-		case "password":
-			result = timingAttack.SimulatePasswordTimingAttack()
-		case "key_comparison":
-			result = timingAttack.SimulateKeyComparisonAttack()
-		case "hash_comparison":
-			result = timingAttack.SimulateHashComparisonAttack()
-		case "remote_timing":
-			result = timingAttack.SimulateRemoteTimingAttack()
-		case "all":
-			results := timingAttack.RunAllTimingAttacks()
-			c.JSON(200, gin.H{
-				"results": results,
-				"report":  timingAttack.GenerateTimingReport(),
-			})
-			return
-		default:
-			c.JSON(400, gin.H{"error": "Invalid attack type"})
-			return
-		}
-		
-		c.JSON(200, result)
-	})
-	
-	// Power Analysis Attack Simulation Endpoint
-	r.POST("/security-test/power", func(c *gin.Context) {
-		var request struct {
-			AttackType string `json:"attack_type"`
-			SampleSize int    `json:"sample_size"`
-			DeviceType string `json:"device_type"`
-		}
-		
-		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(400, gin.H{"error": err.Error()})
-			return
-		}
-		
-		// Default values
-		if request.SampleSize == 0 {
-			request.SampleSize = 1000
-		}
-		if request.DeviceType == "" {
-			request.DeviceType = "mobile"
-		}
-		
-		powerAttack := attacks.NewPowerAnalysisAttack(request.SampleSize, request.DeviceType)
-		
-		var result attacks.PowerAnalysisResult
-		switch request.AttackType {
-		case "spa":
-			result = powerAttack.SimulateSimplePowerAnalysis()
-		case "dpa":
-			result = powerAttack.SimulateDifferentialPowerAnalysis()
-		case "cpa":
-			result = powerAttack.SimulateCorrelationPowerAnalysis()
-		case "ema":
-			result = powerAttack.SimulateElectromagneticAnalysis()
-		case "all":
-			results := powerAttack.RunAllPowerAttacks()
-			c.JSON(200, gin.H{
-				"results": results,
-				"report":  powerAttack.GeneratePowerReport(),
-			})
-			return
-		default:
-			c.JSON(400, gin.H{"error": "Invalid attack type"})
-			return
-		}
-		
-		c.JSON(200, result)
-	})
-	
-	// Comprehensive Security Test Endpoint
-	r.POST("/security-test/comprehensive", func(c *gin.Context) {
-		var request struct {
-			SampleSize int    `json:"sample_size"`
-			DeviceType string `json:"device_type"`
-			TargetHost string `json:"target_host"`
-			TargetPort int    `json:"target_port"`
-		}
-		
-		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(400, gin.H{"error": err.Error()})
-			return
-		}
-		
-		// Default values
-		if request.SampleSize == 0 {
-			request.SampleSize = 1000
-		}
-		if request.DeviceType == "" {
-			request.DeviceType = "mobile"
-		}
-		if request.TargetHost == "" {
-			request.TargetHost = "localhost"
-		}
-		if request.TargetPort == 0 {
-			request.TargetPort = 8080
-		}
-		
-		// Run comprehensive security tests
-		start := time.Now()
-		
-		// Security Assessment
-		assessment := security.PerformSecurityAssessment()
-		
-		// MITM Attack Simulation
-		mitmSimulator := attacks.NewMITMSimulator(request.TargetHost, request.TargetPort)
-		mitmResults := mitmSimulator.RunAllAttacks()
-		
-		// Timing Attack Simulation
-		vulnerableCompare := func(a, b []byte) bool {
-			if len(a) != len(b) {
-				return false
-			}
-			for i := 0; i < len(a); i++ {
-				if a[i] != b[i] {
-					return false
-				}
-				time.Sleep(time.Nanosecond * 100)
-			}
-			return true
-		}
-		timingAttack := attacks.NewTimingAttack(vulnerableCompare, request.SampleSize)
-		timingResults := timingAttack.RunAllTimingAttacks()
-		
-		// Power Analysis Simulation
-		powerAttack := attacks.NewPowerAnalysisAttack(request.SampleSize, request.DeviceType)
-		powerResults := powerAttack.RunAllPowerAttacks()
-		
-		totalTime := time.Since(start)
-		
-		// Generate comprehensive report
-		report := generateComprehensiveSecurityReport(assessment, mitmResults, timingResults, powerResults, totalTime)
-		
-		c.JSON(200, gin.H{
-			"assessment":      assessment,
-			"mitm_results":    mitmResults,
-			"timing_results":  timingResults,
-			"power_results":   powerResults,
-			"execution_time":  totalTime,
-			"report":          report,
-		})
-	})
-	
-	// Security Monitoring Endpoint
-	r.GET("/security-monitor", func(c *gin.Context) {
-		// Real-time security monitoring
-		interval := c.DefaultQuery("interval", "5s")
-		duration, err := time.ParseDuration(interval)
-		if err != nil {
-			duration = 5 * time.Second
-		}
-		
-		// Collect security metrics
-		metrics := collectSecurityMetrics()
-		
-		c.JSON(200, gin.H{
-			"timestamp":        time.Now(),
-			"monitoring_interval": duration,
-			"security_metrics":   metrics,
-			"alerts":            checkSecurityAlerts(metrics),
-		})
-	})
-	
-	// Security Test Results Export Endpoint
-	r.GET("/security-test/export", func(c *gin.Context) {
-		format := c.DefaultQuery("format", "json")
-		
-		// Collect all available test results
-		results := collectAllTestResults()
-		
-		switch format {
-		case "csv":
-			c.Header("Content-Type", "text/csv")
-			c.Header("Content-Disposition", "attachment; filename=security_test_results.csv")
-			c.String(200, exportResultsAsCSV(results))
-		case "xml":
-			c.Header("Content-Type", "application/xml")
-			c.String(200, exportResultsAsXML(results))
-		default:
-			c.JSON(200, results)
-		}
-	})
-	
-	// Security Configuration Endpoint
-	r.GET("/security-config", func(c *gin.Context) {
-		config := map[string]interface{}{
-			"tls_version":        "1.3",
-			"cipher_suites":      []string{"TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256"},
-			"certificate_pinning": true,
-			"hsts_enabled":       true,
-			"security_headers":   true,
-			"rate_limiting":      true,
-			"input_validation":   true,
-			"session_security":   true,
-			"power_analysis_protection": true,
-			"timing_attack_protection":  true,
-			"mitm_protection":           true,
-		}
-		
-		c.JSON(200, config)
-	})
-	
-	// === NEW BENCHMARK AND ANALYSIS ENDPOINTS ===
-	
-	// Comparative Analysis Endpoint
-	r.GET("/benchmarks/comparative-analysis", func(c *gin.Context) {
-		iterations := 5
-		if iter := c.Query("iterations"); iter != "" {
-			if i, err := strconv.Atoi(iter); err == nil && i > 0 {
-				iterations = i
-			}
-		}
-		
-		comparison := benchmarks.NewSchemeComparison()
-		results, err := comparison.RunFullComparison(ctx, iterations)
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
-			return
-		}
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			report := comparison.GenerateComparisonReport(results)
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		c.JSON(200, gin.H{
-			"results": results,
-			"report":  comparison.GenerateComparisonReport(results),
-		})
-	})
-	
-	// Wildcard Performance Endpoint
-	r.GET("/benchmarks/wildcard-performance", func(c *gin.Context) {
-		benchmark := benchmarks.NewWildcardBenchmark()
-		
-		testType := c.DefaultQuery("test_type", "complex_hierarchies")
-		format := c.DefaultQuery("format", "json")
-		
-		var results []benchmarks.WildcardResult
-		var err error
-		
-		switch testType {
-		case "complex_hierarchies":
-			results, err = benchmark.BenchmarkComplexHierarchies(ctx)
-		case "mobile_simulation":
-			deviceType := c.DefaultQuery("device_type", "mobile")
-			result, mobileErr := benchmark.BenchmarkMobileDeviceSimulation(ctx, deviceType)
-			if mobileErr != nil {
-				err = mobileErr
-			} else {
-				results = []benchmarks.WildcardResult{*result}
-			}
-		case "algorithm3":
-			iterations := 1000
-			if iter := c.Query("iterations"); iter != "" {
-				if i, err := strconv.Atoi(iter); err == nil && i > 0 {
-					iterations = i
-				}
-			}
-			result, algoErr := benchmark.BenchmarkAlgorithm3Performance(ctx, iterations)
-			if algoErr != nil {
-				err = algoErr
-			} else {
-				results = []benchmarks.WildcardResult{*result}
-			}
-		default:
-			// Default to single URI comparison
-			uri := c.DefaultQuery("uri", "facility/cardiology/bin123/ecg")
-			results, err = benchmark.BenchmarkWildcardVsNonWildcard(ctx, uri)
-		}
-		
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
-			return
-		}
-		
-		if format == "text" {
-			report := benchmark.GenerateWildcardReport(results)
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		c.JSON(200, gin.H{
-			"test_type": testType,
-			"results":   results,
-			"report":    benchmark.GenerateWildcardReport(results),
-		})
-	})
-	
-	// Advanced Security Analysis Endpoint
-	r.GET("/security/advanced-analysis", func(c *gin.Context) {
-		analysis := security.NewAdvancedSecurityAnalysis()
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			report := analysis.GenerateAdvancedSecurityReport()
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		c.JSON(200, analysis)
-	})
-	
-	// IPFS-Blockchain Integration Security Endpoint
-	r.GET("/security/ipfs-blockchain", func(c *gin.Context) {
-		ibs := security.NewIPFSBlockchainSecurity()
-		
-		audit := ibs.PerformSecurityAudit()
-		report := ibs.GenerateSecurityReport()
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		c.JSON(200, gin.H{
-			"audit":  audit,
-			"report": report,
-		})
-	})
-	
-	// Privacy Technology Integration Endpoint
-	r.GET("/privacy/technologies", func(c *gin.Context) {
-		pt := privacy.NewPrivacyTechnology()
-		
-		dataSize := 1000
-		if size := c.Query("data_size"); size != "" {
-			if s, err := strconv.Atoi(size); err == nil && s > 0 {
-				dataSize = s
-			}
-		}
-		
-		results, err := pt.BenchmarkPrivacyTechnologies(dataSize)
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
-			return
-		}
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			report := pt.GeneratePrivacyReport(results)
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		c.JSON(200, gin.H{
-			"results": results,
-			"report":  pt.GeneratePrivacyReport(results),
-		})
-	})
-	
-	// Blockchain Optimization Analysis Endpoint
-	r.GET("/blockchain/optimization", func(c *gin.Context) {
-		boa := blockchain.NewBlockchainOptimizationAnalysis()
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			report := boa.GenerateOptimizationReport()
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		scenarios := boa.SimulateOptimizationScenarios()
-		waste-managementOpts := boa.OptimizeForWasteManagementUseCase()
-		
-		c.JSON(200, gin.H{
-			"analysis":            boa,
-			"scenarios":           scenarios,
-			"waste-management_optimizations": waste-managementOpts,
-			"report":              boa.GenerateOptimizationReport(),
-		})
-	})
-	
-	// Hyperparameters Documentation Endpoint
-	r.GET("/analysis/hyperparameters", func(c *gin.Context) {
-		configType := c.DefaultQuery("config", "default")
-		
-		var params *analysis.SystemHyperparameters
-		switch configType {
-		case "mobile":
-			params = analysis.MobileDeviceHyperparameters()
-		case "iot":
-			params = analysis.IoTDeviceHyperparameters()
-		case "high_performance":
-			params = analysis.HighPerformanceHyperparameters()
-		default:
-			params = analysis.DefaultHyperparameters()
-		}
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			table := analysis.GenerateHyperparametersTable(params)
-			c.Header("Content-Type", "text/plain")
-			c.String(200, table)
-			return
-		}
-		
-		// Validate parameters
-		errors := analysis.ValidateHyperparameters(params)
-		
-		// Create reproducibility package
-		reproPackage, err := analysis.ExportForReproducibility(params)
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
-			return
-		}
-		
-		c.JSON(200, gin.H{
-			"hyperparameters":        params,
-			"validation_errors":      errors,
-			"reproducibility_package": reproPackage,
-		})
-	})
-	
-	// Comprehensive Benchmark Suite Endpoint
-	r.GET("/benchmarks/comprehensive", func(c *gin.Context) {
-		// Run all benchmark modules
-		start := time.Now()
-		
-		// Comparative Analysis
-		comparison := benchmarks.NewSchemeComparison()
-		compResults, _ := comparison.RunFullComparison(ctx, 3)
-		
-		// Wildcard Performance
-		wildcardBench := benchmarks.NewWildcardBenchmark()
-		wildcardResults, _ := wildcardBench.BenchmarkComplexHierarchies(ctx)
-		
-		// Privacy Technologies
-		pt := privacy.NewPrivacyTechnology()
-		privacyResults, _ := pt.BenchmarkPrivacyTechnologies(500)
-		
-		// Security Analysis
-		securityAnalysis := security.NewAdvancedSecurityAnalysis()
-		
-		// Blockchain Optimization
-		blockchainOpt := blockchain.NewBlockchainOptimizationAnalysis()
-		scenarios := blockchainOpt.SimulateOptimizationScenarios()
-		
-		totalTime := time.Since(start)
-		
-		format := c.DefaultQuery("format", "json")
-		if format == "text" {
-			report := "=== COMPREHENSIVE BENCHMARK SUITE REPORT ===\n\n"
-			report += fmt.Sprintf("Execution Time: %v\n\n", totalTime)
-			report += comparison.GenerateComparisonReport(compResults)
-			report += "\n" + wildcardBench.GenerateWildcardReport(wildcardResults)
-			report += "\n" + pt.GeneratePrivacyReport(privacyResults)
-			report += "\n" + securityAnalysis.GenerateAdvancedSecurityReport()
-			report += "\n" + blockchainOpt.GenerateOptimizationReport()
-			
-			c.Header("Content-Type", "text/plain")
-			c.String(200, report)
-			return
-		}
-		
-		c.JSON(200, gin.H{
-			"execution_time_ms": totalTime.Milliseconds(),
-			"comparative_analysis": gin.H{
-				"results": compResults,
-				"report":  comparison.GenerateComparisonReport(compResults),
-			},
-			"wildcard_performance": gin.H{
-				"results": wildcardResults,
-				"report":  wildcardBench.GenerateWildcardReport(wildcardResults),
-			},
-			"privacy_technologies": gin.H{
-				"results": privacyResults,
-				"report":  pt.GeneratePrivacyReport(privacyResults),
-			},
-			"security_analysis": securityAnalysis,
-			"blockchain_optimization": gin.H{
-				"analysis": blockchainOpt,
-				"scenarios": scenarios,
-			},
-		})
-	})
-	
-	// Performance Monitoring Endpoint
-	r.GET("/monitoring/performance", func(c *gin.Context) {
-		// Collect real-time performance metrics
-		var memStats runtime.MemStats
-		runtime.ReadMemStats(&memStats)
-		
-		cpuPercent, _ := cpu.Percent(0, false)
-		
-		// Get power profile
-		powerProfile := getPowerProfile()
-		
-		// Get blockchain performance metrics
-		blockchainOpt := blockchain.NewBlockchainOptimizationAnalysis()
-		perfMetrics := blockchainOpt.MonitorPerformanceMetrics()
-		
-		c.JSON(200, gin.H{
-			"timestamp": time.Now(),
-			"system_metrics": gin.H{
-				"memory_usage_mb":   memStats.Alloc / (1024 * 1024),
-				"cpu_utilization":   cpuPercent[0],
-				"goroutines":        runtime.NumGoroutine(),
-				"gc_cycles":         memStats.NumGC,
-			},
-			"power_profile":         powerProfile,
-			"blockchain_metrics":    perfMetrics,
-			"power_reports_count":   len(powerReports),
-		})
-	})
-
-	r.Run() // listen and serve on 0.0.0.0:8080
-	fmt.Println("DONE!")
-}
-
-// Add security headers to all responses to improve security posture
-func securityHeaders() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("X-Content-Type-Options", "nosniff")
-		c.Header("X-Frame-Options", "DENY")
-		c.Header("X-XSS-Protection", "1; mode=block")
-		c.Header("Content-Security-Policy", "default-src 'self'")
-		c.Header("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
-		c.Next()
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stderr)
+		return
 	}
-}
-
-type TestPublicInfo struct {
-	params *wkdibe.Params
-}
-
-type TestKeyStore struct {
-	params *wkdibe.Params
-	master *wkdibe.MasterKey
-}
-
-func (tpi *TestPublicInfo) ParamsForHierarchy(ctx context.Context, hierarchy []byte) (*wkdibe.Params, error) {
-	return tpi.params, nil
-}
-
-func testMessageTransfer(state *hibe.ClientState, hierarchy []byte, uri string, timestamp time.Time, message string) {
-	var err error
-	ctx := context.Background()
-
-	var encrypted []byte
-	if encrypted, err = state.Encrypt(ctx, hierarchy, uri, timestamp, []byte(message)); err != nil {
-		fmt.Println(err)
-	}
-
-	var decrypted []byte
-	if decrypted, err = state.Decrypt(ctx, hierarchy, uri, timestamp, encrypted); err != nil {
-		fmt.Println(err)
-	}
-
-	if !bytes.Equal(decrypted, []byte(message)) {
-		fmt.Println("Original and decrypted messages differ")
-	}
-}
-
-func NewTestKeyStore() (*TestPublicInfo, *TestKeyStore) {
-	tks := new(TestKeyStore)
-	tks.params, tks.master = wkdibe.Setup(TestPatternSize, true)
-	tpi := new(TestPublicInfo)
-	tpi.params = tks.params
-	return tpi, tks
-}
-
-func (tks *TestKeyStore) KeyForPattern(ctx context.Context, hierarchy []byte, pattern hibe.Pattern) (*wkdibe.Params, *wkdibe.SecretKey, error) {
-	empty := make(hibe.Pattern, TestPatternSize)
-	return tks.params, wkdibe.KeyGen(tks.params, tks.master, empty.ToAttrs()), nil
-}
-
-func NewTestState() *hibe.ClientState {
-	info, store := NewTestKeyStore()
-	encoder := hibe.NewDefaultPatternEncoder(TestPatternSize - hibe.MaxTimeLength)
-	return hibe.NewClientState(info, store, encoder, 1<<20)
-}
-
-// Helper functions for report generation
-
-func summarizeOperations(reports []PowerReport) map[string]int {
-	result := make(map[string]int)
-	for _, report := range reports {
-		result[report.OperationName]++
-	}
-	return result
-}
-
-func calculateAveragePower(reports []PowerReport) float64 {
-	if len(reports) == 0 {
-		return 0
-	}
-	
-	var total float64
-	for _, report := range reports {
-		total += report.PowerUsageWatts
-	}
-	return math.Round((total/float64(len(reports)))*100) / 100
-}
-
-func calculateTotalEnergy(reports []PowerReport) float64 {
-	var total float64
-	for _, report := range reports {
-		total += report.EnergyJoules
-	}
-	return math.Round(total*1000) / 1000
-}
-
-func calculateEnergyEfficiency(reports []PowerReport) map[string]float64 {
-	result := make(map[string]float64)
-	counts := make(map[string]int)
-	
-	for _, report := range reports {
-		if report.DataSizeBytes > 0 {
-			efficiency := report.EnergyJoules / float64(report.DataSizeBytes)
-			op := report.OperationName
-			result[op] = (result[op]*float64(counts[op]) + efficiency) / float64(counts[op]+1)
-			counts[op]++
-		}
-	}
-	
-	return result
-}
-
-// Helper functions for security testing endpoints
-
-func generateComprehensiveSecurityReport(assessment security.SecurityAssessment, mitmResults []attacks.MITMAttackResult, timingResults []attacks.TimingAttackResult, powerResults []attacks.PowerAnalysisResult, totalTime time.Duration) string {
-	report := "=== COMPREHENSIVE SECURITY ANALYSIS REPORT ===\n\n"
-	
-	// Summary
-	report += fmt.Sprintf("Test execution time: %v\n", totalTime)
-	report += fmt.Sprintf("Overall security rating: %s\n\n", assessment.OverallRating)
-	
-	// MITM Attack Results
-	report += "=== MITM ATTACK RESULTS ===\n"
-	mitmSuccessful := 0
-	for _, result := range mitmResults {
-		if result.Success {
-			mitmSuccessful++
-		}
-	}
-	report += fmt.Sprintf("MITM attacks tested: %d\n", len(mitmResults))
-	report += fmt.Sprintf("Successful attacks: %d\n", mitmSuccessful)
-	report += fmt.Sprintf("Success rate: %.2f%%\n\n", float64(mitmSuccessful)/float64(len(mitmResults))*100)
-	
-	// Timing Attack Results
-	report += "=== TIMING ATTACK RESULTS ===\n"
-	timingSuccessful := 0
-	for _, result := range timingResults {
-		if result.Success {
-			timingSuccessful++
-		}
-	}
-	report += fmt.Sprintf("Timing attacks tested: %d\n", len(timingResults))
-	report += fmt.Sprintf("Successful attacks: %d\n", timingSuccessful)
-	report += fmt.Sprintf("Success rate: %.2f%%\n\n", float64(timingSuccessful)/float64(len(timingResults))*100)
-	
-	// Power Analysis Results
-	report += "=== POWER ANALYSIS RESULTS ===\n"
-	powerSuccessful := 0
-	for _, result := range powerResults {
-		if result.Success {
-			powerSuccessful++
-		}
-	}
-	report += fmt.Sprintf("Power analysis attacks tested: %d\n", len(powerResults))
-	report += fmt.Sprintf("Successful attacks: %d\n", powerSuccessful)
-	report += fmt.Sprintf("Success rate: %.2f%%\n\n", float64(powerSuccessful)/float64(len(powerResults))*100)
-	
-	// Overall Assessment
-	totalAttacks := len(mitmResults) + len(timingResults) + len(powerResults)
-	totalSuccessful := mitmSuccessful + timingSuccessful + powerSuccessful
-	overallSuccessRate := float64(totalSuccessful) / float64(totalAttacks) * 100
-	
-	report += "=== OVERALL ASSESSMENT ===\n"
-	report += fmt.Sprintf("Total attacks tested: %d\n", totalAttacks)
-	report += fmt.Sprintf("Total successful attacks: %d\n", totalSuccessful)
-	report += fmt.Sprintf("Overall success rate: %.2f%%\n", overallSuccessRate)
-	
-	if overallSuccessRate < 5.0 {
-		report += "SECURITY STATUS: EXCELLENT\n"
-	} else if overallSuccessRate < 10.0 {
-		report += "SECURITY STATUS: GOOD\n"
-	} else if overallSuccessRate < 20.0 {
-		report += "SECURITY STATUS: FAIR\n"
-	} else {
-		report += "SECURITY STATUS: POOR - IMMEDIATE ACTION REQUIRED\n"
-	}
-	
-	return report
-}
-
-func collectSecurityMetrics() map[string]interface{} {
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
-	
-	cpuPercent, _ := cpu.Percent(0, false)
-	memInfo, _ := mem.VirtualMemory()
-	
-	return map[string]interface{}{
-		"system_metrics": map[string]interface{}{
-			"cpu_usage":      cpuPercent[0],
-			"memory_usage":   memStats.Alloc,
-			"memory_percent": memInfo.UsedPercent,
-			"goroutines":     runtime.NumGoroutine(),
-		},
-		"security_metrics": map[string]interface{}{
-			"active_connections": getActiveConnections(),
-			"failed_auth_attempts": getFailedAuthAttempts(),
-			"suspicious_requests": getSuspiciousRequests(),
-			"security_events":    getSecurityEvents(),
-		},
-		"performance_metrics": map[string]interface{}{
-			"request_rate":       getRequestRate(),
-			"response_time":      getAverageResponseTime(),
-			"error_rate":         getErrorRate(),
-			"throughput":         getThroughput(),
-		},
-	}
-}
-
-func checkSecurityAlerts(metrics map[string]interface{}) []string {
-	alerts := make([]string, 0)
-	
-	// Check system metrics
-	if systemMetrics, ok := metrics["system_metrics"].(map[string]interface{}); ok {
-		if cpuUsage, ok := systemMetrics["cpu_usage"].(float64); ok && cpuUsage > 80.0 {
-			alerts = append(alerts, "HIGH CPU USAGE: "+strconv.FormatFloat(cpuUsage, 'f', 2, 64)+"%")
-		}
-		
-		if memPercent, ok := systemMetrics["memory_percent"].(float64); ok && memPercent > 90.0 {
-			alerts = append(alerts, "HIGH MEMORY USAGE: "+strconv.FormatFloat(memPercent, 'f', 2, 64)+"%")
-		}
-		
-		if goroutines, ok := systemMetrics["goroutines"].(int); ok && goroutines > 10000 {
-			alerts = append(alerts, "HIGH GOROUTINE COUNT: "+strconv.Itoa(goroutines))
-		}
-	}
-	
-	// Check security metrics
-	if securityMetrics, ok := metrics["security_metrics"].(map[string]interface{}); ok {
-		if failedAuth, ok := securityMetrics["failed_auth_attempts"].(int); ok && failedAuth > 100 {
-			alerts = append(alerts, "HIGH FAILED AUTH ATTEMPTS: "+strconv.Itoa(failedAuth))
-		}
-		
-		if suspiciousReq, ok := securityMetrics["suspicious_requests"].(int); ok && suspiciousReq > 50 {
-			alerts = append(alerts, "HIGH SUSPICIOUS REQUESTS: "+strconv.Itoa(suspiciousReq))
-		}
-	}
-	
-	return alerts
-}
-
-func collectAllTestResults() map[string]interface{} {
-	return map[string]interface{}{
-		"test_summary": map[string]interface{}{
-			"total_tests_run":      getTotalTestsRun(),
-			"successful_attacks":   getSuccessfulAttacks(),
-			"failed_attacks":       getFailedAttacks(),
-			"test_coverage":        getTestCoverage(),
-			"last_test_timestamp":  getLastTestTimestamp(),
-		},
-		"mitm_results":    getMITMTestResults(),
-		"timing_results":  getTimingTestResults(),
-		"power_results":   getPowerTestResults(),
-		"benchmark_results": getBenchmarkResults(),
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hibe-api: %v\n", err)
+		os.Exit(2)
 	}
-}
 
-func exportResultsAsCSV(results map[string]interface{}) string {
-	csv := "TestType,AttackType,Success,ExecutionTime,Details\n"
-	
-	// Add CSV rows for each test type
-	if mitmResults, ok := results["mitm_results"].([]interface{}); ok {
-		for _, result := range mitmResults {
-			if r, ok := result.(map[string]interface{}); ok {
-				csv += fmt.Sprintf("MITM,%s,%v,%v,%s\n", 
-					r["attack_type"], r["success"], r["execution_time"], r["details"])
-			}
-		}
-	}
-	
-	if timingResults, ok := results["timing_results"].([]interface{}); ok {
-		for _, result := range timingResults {
-			if r, ok := result.(map[string]interface{}); ok {
-				csv += fmt.Sprintf("Timing,%s,%v,%v,%s\n", 
-					r["attack_type"], r["success"], r["execution_time"], r["details"])
-			}
-		}
-	}
-	
-	if powerResults, ok := results["power_results"].([]interface{}); ok {
-		for _, result := range powerResults {
-			if r, ok := result.(map[string]interface{}); ok {
-				csv += fmt.Sprintf("Power,%s,%v,%v,%s\n", 
-					r["attack_type"], r["success"], r["execution_time"], r["details"])
-			}
-		}
-	}
-	
-	return csv
-}
+	// SIGTERM ends the server context, so long-running streams and batches stop
+	// and the grant expiry loop exits while in-flight requests drain
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-func exportResultsAsXML(results map[string]interface{}) string {
-	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"
-	xml += "<SecurityTestResults>\n"
-	
-	// Convert results to XML format
-	if summary, ok := results["test_summary"].(map[string]interface{}); ok {
-		xml += "  <TestSummary>\n"
-		for key, value := range summary {
-			xml += fmt.Sprintf("    <%s>%v</%s>\n", key, value, key)
-		}
-		xml += "  </TestSummary>\n"
+	if err := server.Run(ctx, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "hibe-api: %v\n", err)
+		os.Exit(1)
 	}
-	
-	xml += "</SecurityTestResults>\n"
-	return xml
 }
-
-// Placeholder functions for metrics collection
-func getActiveConnections() int { return 42 }
-func getFailedAuthAttempts() int { return 5 }
-func getSuspiciousRequests() int { return 2 }
-func getSecurityEvents() int { return 8 }
-func getRequestRate() float64 { return 150.5 }
-func getAverageResponseTime() float64 { return 25.3 }
-func getErrorRate() float64 { return 0.02 }
-func getThroughput() float64 { return 1250.0 }
-func getTotalTestsRun() int { return 250 }
-func getSuccessfulAttacks() int { return 12 }
-func getFailedAttacks() int { return 238 }
-func getTestCoverage() float64 { return 0.95 }
-func getLastTestTimestamp() string { return time.Now().Format(time.RFC3339) }
-func getMITMTestResults() []interface{} { return []interface{}{} }
-func getTimingTestResults() []interface{} { return []interface{}{} }
-func getPowerTestResults() []interface{} { return []interface{}{} }
-func getBenchmarkResults() []interface{} { return []interface{}{} }
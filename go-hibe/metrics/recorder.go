@@ -0,0 +1,281 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "hibe"
+
+// Operation names used for the latency histograms
+const (
+	OperationDelegate = "delegate"
+	OperationEncrypt  = "encrypt"
+	OperationDecrypt  = "decrypt"
+)
+
+// Revocation scopes
+const (
+	RevocationScopeKey = "key"
+	RevocationScopeURI = "uri"
+)
+
+// Security event kinds
+const (
+	EventFailedAuth        = "failed_auth"
+	EventSuspiciousRequest = "suspicious_request"
+)
+
+// Recorder tracks HIBE server activity. Everything it records is exposed in
+// Prometheus/OpenMetrics format through Handler, and the recent request window
+// backs the JSON monitoring endpoints through Snapshot.
+type Recorder struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+	operations      *prometheus.HistogramVec
+	operationErrors *prometheus.CounterVec
+	revocations     *prometheus.CounterVec
+	securityEvents  *prometheus.CounterVec
+
+	window     *slidingWindow
+	active     int64
+	failedAuth int64
+	suspicious int64
+	events     int64
+}
+
+// Snapshot summarizes recent server activity for the JSON monitoring endpoints
+type Snapshot struct {
+	ActiveConnections   int     `json:"active_connections"`
+	FailedAuthAttempts  int     `json:"failed_auth_attempts"`
+	SuspiciousRequests  int     `json:"suspicious_requests"`
+	SecurityEvents      int     `json:"security_events"`
+	RequestRate         float64 `json:"request_rate"`          // requests per second
+	AverageResponseTime float64 `json:"average_response_time"` // milliseconds
+	ErrorRate           float64 `json:"error_rate"`            // fraction of responses with status >= 400
+	Throughput          float64 `json:"throughput"`            // response bytes per second
+}
+
+// NewRecorder creates a recorder with its own registry
+func NewRecorder() *Recorder {
+	r := &Recorder{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP response latency by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		operations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of delegate, encrypt and decrypt operations.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 16), // 100µs to ~3.3s
+		}, []string{"operation"}),
+		operationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operation_errors_total",
+			Help:      "Failed delegate, encrypt and decrypt operations.",
+		}, []string{"operation"}),
+		revocations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "revocations_total",
+			Help:      "Keys revoked, by how the revocation was requested.",
+		}, []string{"scope"}),
+		securityEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "security_events_total",
+			Help:      "Security-relevant events by kind.",
+		}, []string{"kind"}),
+		window: newSlidingWindow(time.Minute),
+	}
+
+	r.registry.MustRegister(
+		r.requests,
+		r.requestDuration,
+		r.inFlight,
+		r.operations,
+		r.operationErrors,
+		r.revocations,
+		r.securityEvents,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return r
+}
+
+// Handler serves the registry, negotiating OpenMetrics when the scraper accepts it
+func (r *Recorder) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// Middleware records request counts, latency and concurrency, counting 401/403
+// responses as failed authentication and requests for unknown routes as suspicious
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		atomic.AddInt64(&r.active, 1)
+		r.inFlight.Inc()
+		defer func() {
+			atomic.AddInt64(&r.active, -1)
+			r.inFlight.Dec()
+		}()
+
+		c.Next()
+
+		// Unmatched paths share one label so scans cannot inflate cardinality
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+			r.RecordSecurityEvent(EventSuspiciousRequest)
+		}
+		status := c.Writer.Status()
+		elapsed := time.Since(start)
+
+		r.requests.WithLabelValues(route, c.Request.Method, strconv.Itoa(status)).Inc()
+		r.requestDuration.WithLabelValues(route, c.Request.Method).Observe(elapsed.Seconds())
+		r.window.record(start, elapsed, status >= http.StatusBadRequest, c.Writer.Size())
+
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			r.RecordSecurityEvent(EventFailedAuth)
+		}
+	}
+}
+
+// ObserveOperation records the latency and outcome of a HIBE operation
+func (r *Recorder) ObserveOperation(operation string, elapsed time.Duration, err error) {
+	r.operations.WithLabelValues(operation).Observe(elapsed.Seconds())
+	if err != nil {
+		r.operationErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// RecordRevocations records keys revoked individually or by URI
+func (r *Recorder) RecordRevocations(scope string, count int) {
+	r.revocations.WithLabelValues(scope).Add(float64(count))
+}
+
+// RecordSecurityEvent records a security event of the given kind
+func (r *Recorder) RecordSecurityEvent(kind string) {
+	r.securityEvents.WithLabelValues(kind).Inc()
+	atomic.AddInt64(&r.events, 1)
+
+	switch kind {
+	case EventFailedAuth:
+		atomic.AddInt64(&r.failedAuth, 1)
+	case EventSuspiciousRequest:
+		atomic.AddInt64(&r.suspicious, 1)
+	}
+}
+
+// Snapshot returns connection and security counts and rates over the last minute
+func (r *Recorder) Snapshot() Snapshot {
+	requests, errors, latency, bytes, span := r.window.totals(time.Now())
+
+	snapshot := Snapshot{
+		ActiveConnections:  int(atomic.LoadInt64(&r.active)),
+		FailedAuthAttempts: int(atomic.LoadInt64(&r.failedAuth)),
+		SuspiciousRequests: int(atomic.LoadInt64(&r.suspicious)),
+		SecurityEvents:     int(atomic.LoadInt64(&r.events)),
+	}
+	if requests > 0 {
+		snapshot.RequestRate = float64(requests) / span.Seconds()
+		snapshot.AverageResponseTime = float64(latency.Microseconds()) / float64(requests) / 1000
+		snapshot.ErrorRate = float64(errors) / float64(requests)
+		snapshot.Throughput = float64(bytes) / span.Seconds()
+	}
+	return snapshot
+}
+
+// slidingWindow aggregates request activity into one-second buckets
+type slidingWindow struct {
+	buckets []windowBucket
+	started time.Time
+	mu      sync.Mutex
+}
+
+type windowBucket struct {
+	second   int64
+	requests int64
+	errors   int64
+	latency  time.Duration
+	bytes    int64
+}
+
+func newSlidingWindow(length time.Duration) *slidingWindow {
+	return &slidingWindow{
+		buckets: make([]windowBucket, int(length/time.Second)),
+		started: time.Now(),
+	}
+}
+
+func (sw *slidingWindow) record(at time.Time, latency time.Duration, failed bool, bytes int) {
+	second := at.Unix()
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	bucket := &sw.buckets[second%int64(len(sw.buckets))]
+	if bucket.second != second {
+		*bucket = windowBucket{second: second}
+	}
+	bucket.requests++
+	bucket.latency += latency
+	if failed {
+		bucket.errors++
+	}
+	if bytes > 0 {
+		bucket.bytes += int64(bytes)
+	}
+}
+
+// totals sums the buckets inside the window and returns the span they cover
+func (sw *slidingWindow) totals(now time.Time) (requests, errors int64, latency time.Duration, bytes int64, span time.Duration) {
+	cutoff := now.Unix() - int64(len(sw.buckets))
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	for _, bucket := range sw.buckets {
+		if bucket.second <= cutoff {
+			continue
+		}
+		requests += bucket.requests
+		errors += bucket.errors
+		latency += bucket.latency
+		bytes += bucket.bytes
+	}
+
+	// A recently started server has not filled the window yet
+	span = time.Duration(len(sw.buckets)) * time.Second
+	if uptime := now.Sub(sw.started); uptime < span {
+		span = uptime
+		if span < time.Second {
+			span = time.Second
+		}
+	}
+	return requests, errors, latency, bytes, span
+}
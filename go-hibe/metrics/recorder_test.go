@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestEngine(recorder *Recorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(recorder.Middleware())
+	r.GET("/metrics", gin.WrapH(recorder.Handler()))
+	r.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	r.GET("/denied", func(c *gin.Context) { c.Status(http.StatusForbidden) })
+	return r
+}
+
+func serve(engine *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestSnapshotReflectsTraffic(t *testing.T) {
+	recorder := NewRecorder()
+	engine := newTestEngine(recorder)
+
+	for i := 0; i < 3; i++ {
+		serve(engine, "/ok")
+	}
+	serve(engine, "/denied")
+	serve(engine, "/wp-login.php")
+
+	snapshot := recorder.Snapshot()
+	if snapshot.FailedAuthAttempts != 1 {
+		t.Errorf("expected 1 failed auth attempt, got %d", snapshot.FailedAuthAttempts)
+	}
+	if snapshot.SuspiciousRequests != 1 {
+		t.Errorf("expected 1 suspicious request, got %d", snapshot.SuspiciousRequests)
+	}
+	if snapshot.SecurityEvents != 2 {
+		t.Errorf("expected 2 security events, got %d", snapshot.SecurityEvents)
+	}
+	if snapshot.ErrorRate != 0.4 {
+		t.Errorf("expected error rate 0.4, got %f", snapshot.ErrorRate)
+	}
+	if snapshot.RequestRate <= 0 || snapshot.Throughput <= 0 {
+		t.Errorf("expected positive request rate and throughput, got %+v", snapshot)
+	}
+	if snapshot.ActiveConnections != 0 {
+		t.Errorf("expected no active connections after requests complete, got %d", snapshot.ActiveConnections)
+	}
+}
+
+func TestMetricsEndpointServesOpenMetrics(t *testing.T) {
+	recorder := NewRecorder()
+	engine := newTestEngine(recorder)
+
+	serve(engine, "/ok")
+	recorder.ObserveOperation(OperationEncrypt, 3*time.Millisecond, nil)
+	recorder.ObserveOperation(OperationDecrypt, 2*time.Millisecond, errors.New("bad ciphertext"))
+	recorder.RecordRevocations(RevocationScopeURI, 4)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	engine.ServeHTTP(w, req)
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Errorf("expected OpenMetrics content type, got %q", contentType)
+	}
+
+	body, _ := io.ReadAll(w.Body)
+	expected := []string{
+		`hibe_http_requests_total{code="200",method="GET",route="/ok"} 1`,
+		`hibe_operation_duration_seconds_count{operation="encrypt"} 1`,
+		`hibe_operation_errors_total{operation="decrypt"} 1`,
+		`hibe_revocations_total{scope="uri"} 4`,
+		"# EOF",
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("expected scrape to contain %q", line)
+		}
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
-	"security/attacks"
+	"hibe-api/security/attacks"
 	"sort"
 	"time"
 )
@@ -9,7 +9,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ucbrise/hibe-pairing/lang/go/wkdibe"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
 )
 
 // IPFSBlockchainSecurity handles the security aspects of IPFS-blockchain integration
@@ -0,0 +1,676 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessRequestStatus is where an access request is in its lifecycle
+type AccessRequestStatus string
+
+const (
+	AccessPending  AccessRequestStatus = "pending"
+	AccessApproved AccessRequestStatus = "approved"
+	AccessDenied   AccessRequestStatus = "denied"
+	AccessExpired  AccessRequestStatus = "expired"
+	AccessRevoked  AccessRequestStatus = "revoked"
+)
+
+// maxAccessWindow bounds how long a single grant may last
+const maxAccessWindow = 366 * 24 * time.Hour
+
+// defaultMaxPendingAccessRequests bounds the requests Submit holds for the owner
+// unless the broker is given another limit
+const defaultMaxPendingAccessRequests = 1000
+
+// accessExpiryInterval is how often the server's broker expires passed grants
+const accessExpiryInterval = time.Minute
+
+// AccessRequest is a requester's application for access to one URI over a window.
+// Once the owner approves it, it carries the grant's key ID in the DelegationRegistry.
+type AccessRequest struct {
+	ID        string              `json:"id"`
+	Requester string              `json:"requester"`
+	URI       string              `json:"uri"`
+	Hierarchy string              `json:"hierarchy"`
+	StartTime time.Time           `json:"startTime"`
+	EndTime   time.Time           `json:"endTime"`
+	Purpose   string              `json:"purpose,omitempty"`
+	Status    AccessRequestStatus `json:"status"`
+	CreatedAt time.Time           `json:"createdAt"`
+	DecidedAt time.Time           `json:"decidedAt,omitempty"`
+	Reason    string              `json:"reason,omitempty"`
+	KeyID     string              `json:"keyId,omitempty"`
+
+	tokenHash []byte
+	key       []byte
+	approving bool // Approve is delegating its key
+}
+
+// NewAccessRequest is the body of POST /access-requests
+type NewAccessRequest struct {
+	Requester string `json:"requester" binding:"required"`
+	URI       string `json:"uri" binding:"required"`
+	Hierarchy string `json:"hierarchy"`
+	StartTime int64  `json:"startTime"`                  // Unix timestamp, now when omitted
+	EndTime   int64  `json:"endTime" binding:"required"` // Unix timestamp the grant expires at
+	Purpose   string `json:"purpose"`
+}
+
+// DelegateFunc delegates a decryption key for uri over [start, end] and returns it marshalled
+type DelegateFunc func(ctx context.Context, hierarchy []byte, uri string, start, end time.Time) ([]byte, error)
+
+// OwnerDataPoint is one item of the owner's data, in TrustAuthority's user-data.json format
+type OwnerDataPoint struct {
+	Type           string    `json:"type"`
+	StartTimestamp time.Time `json:"startTimestamp"`
+	EndTimestamp   time.Time `json:"endTimestamp"`
+	URL            string    `json:"url"`
+}
+
+// OwnerPolicy lists the data types released to holders of a grant for URI
+type OwnerPolicy struct {
+	URI         string          `json:"uri"`
+	Permissions map[string]bool `json:"permissions"`
+}
+
+// OwnerData is the data an owner shares through the broker
+type OwnerData struct {
+	Name       string           `json:"name"`
+	Policies   []OwnerPolicy    `json:"policies"`
+	DataPoints []OwnerDataPoint `json:"dataPoints"`
+}
+
+// LoadOwnerData reads an owner's data file such as TrustAuthority/user-data.json
+func LoadOwnerData(path string) (*OwnerData, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read owner data: %v", err)
+	}
+	var data OwnerData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse owner data %s: %v", path, err)
+	}
+	for _, policy := range data.Policies {
+		if err := validateAccessURI(policy.URI); err != nil {
+			return nil, fmt.Errorf("owner data %s: policy %v", path, err)
+		}
+	}
+	return &data, nil
+}
+
+// Release returns the data points the policy for uri permits. Without a policy
+// for exactly that URI nothing is released.
+func (od *OwnerData) Release(uri string) []OwnerDataPoint {
+	released := []OwnerDataPoint{}
+	for _, policy := range od.Policies {
+		if policy.URI != uri {
+			continue
+		}
+		for _, point := range od.DataPoints {
+			if policy.Permissions[point.Type] {
+				released = append(released, point)
+			}
+		}
+	}
+	return released
+}
+
+// accessOwnerData is the data the server's broker releases, read from
+// access.owner_data_file at startup; nil releases none
+var accessOwnerData *OwnerData
+
+// AccessBroker takes access requests, delegates keys for those the owner approves
+// and releases the owner's data only to holders of a live, matching grant.
+// Grants are recorded in the DelegationRegistry and expire into the RevocationList.
+type AccessBroker struct {
+	mu          sync.Mutex
+	requests    map[string]*AccessRequest
+	maxPending  int // requests awaiting the owner at once; Submit refuses more
+	delegate    DelegateFunc
+	registry    *DelegationRegistry
+	revocations *RevocationList
+	data        *OwnerData
+	ownerToken  []byte
+	now         func() time.Time
+}
+
+// NewAccessBroker creates a broker. ownerToken authenticates approvals; when it is
+// empty every owner action is refused.
+func NewAccessBroker(delegate DelegateFunc, registry *DelegationRegistry, revocations *RevocationList, data *OwnerData, ownerToken string) *AccessBroker {
+	var hashed []byte
+	if ownerToken != "" {
+		sum := sha256.Sum256([]byte(ownerToken))
+		hashed = sum[:]
+	}
+	return &AccessBroker{
+		requests:    make(map[string]*AccessRequest),
+		maxPending:  defaultMaxPendingAccessRequests,
+		delegate:    delegate,
+		registry:    registry,
+		revocations: revocations,
+		data:        data,
+		ownerToken:  hashed,
+		now:         time.Now,
+	}
+}
+
+// Submit records a pending request and returns it with the token the requester
+// must present to collect the key and the data
+func (ab *AccessBroker) Submit(req *NewAccessRequest) (*AccessRequest, string, error) {
+	if err := validateAccessURI(req.URI); err != nil {
+		return nil, "", withKind(errInvalidRequest, err)
+	}
+
+	now := ab.now()
+	start := now
+	if req.StartTime != 0 {
+		start = time.Unix(req.StartTime, 0)
+	}
+	end := time.Unix(req.EndTime, 0)
+	if !end.After(start) || !end.After(now) {
+		return nil, "", withKind(errInvalidRequest, errors.New("endTime must be after startTime and in the future"))
+	}
+	if end.Sub(start) > maxAccessWindow {
+		return nil, "", withKind(errInvalidRequest, fmt.Errorf("access window of %v exceeds the %v limit", end.Sub(start), maxAccessWindow))
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	tokenHash := sha256.Sum256([]byte(token))
+
+	hierarchy := req.Hierarchy
+	if hierarchy == "" {
+		hierarchy = string(TestHierarchy)
+	}
+	request := &AccessRequest{
+		ID:        id,
+		Requester: req.Requester,
+		URI:       req.URI,
+		Hierarchy: hierarchy,
+		StartTime: start,
+		EndTime:   end,
+		Purpose:   req.Purpose,
+		Status:    AccessPending,
+		CreatedAt: now,
+		tokenHash: tokenHash[:],
+	}
+
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	pending := 0
+	for _, existing := range ab.requests {
+		if existing.Status == AccessPending {
+			pending++
+		}
+	}
+	if pending >= ab.maxPending {
+		return nil, "", errTooManyAccessRequests
+	}
+	ab.requests[id] = request
+	return request.snapshot(), token, nil
+}
+
+// Approve delegates a key scoped to the request's URI and window and records the
+// grant. The broker is not locked while the key is delegated; the request is
+// marked as approving instead, so it cannot be approved or denied twice.
+func (ab *AccessBroker) Approve(ctx context.Context, id string) (*AccessRequest, error) {
+	ab.mu.Lock()
+	request, err := ab.pendingLocked(id)
+	if err != nil {
+		ab.mu.Unlock()
+		return nil, err
+	}
+	if !ab.now().Before(request.EndTime) {
+		request.Status = AccessExpired
+		ab.mu.Unlock()
+		return nil, withKind(errConflict, fmt.Errorf("access request %s expired before it was approved", id))
+	}
+	request.approving = true
+	hierarchy := []byte(request.Hierarchy)
+	uri, start, end := request.URI, request.StartTime, request.EndTime
+	ab.mu.Unlock()
+
+	keyID, err := checkDelegationRevocation(ab.revocations, hierarchy, uri, start, end)
+	var key []byte
+	if err == nil {
+		if key, err = ab.delegate(ctx, hierarchy, uri, start, end); err != nil {
+			err = fmt.Errorf("delegation failed: %w", err)
+		}
+	}
+
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	request.approving = false
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != AccessPending {
+		return nil, withKind(errConflict, fmt.Errorf("access request %s became %s while its key was delegated", id, request.Status))
+	}
+
+	now := ab.now()
+	request.Status = AccessApproved
+	request.DecidedAt = now
+	request.KeyID = keyID
+	request.key = key
+	ab.registry.RecordDelegation(&DelegationInfo{
+		KeyID:     keyID,
+		URI:       request.URI,
+		Hierarchy: request.Hierarchy,
+		StartTime: request.StartTime,
+		EndTime:   request.EndTime,
+		CreatedAt: now,
+		Grantee:   request.Requester,
+	})
+	return request.snapshot(), nil
+}
+
+// Deny refuses a pending request
+func (ab *AccessBroker) Deny(id, reason string) (*AccessRequest, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	request, err := ab.pendingLocked(id)
+	if err != nil {
+		return nil, err
+	}
+	request.Status = AccessDenied
+	request.DecidedAt = ab.now()
+	request.Reason = reason
+	return request.snapshot(), nil
+}
+
+// Revoke ends an approved grant early and revokes its key
+func (ab *AccessBroker) Revoke(id, revokedBy, reason string) (*AccessRequest, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	request, exists := ab.requests[id]
+	if !exists {
+		return nil, withKind(errNotFound, fmt.Errorf("access request %s not found", id))
+	}
+	if request.Status == AccessRevoked {
+		return nil, withKind(errAlreadyRevoked, fmt.Errorf("access request %s is already revoked", id))
+	}
+	if request.Status != AccessApproved {
+		return nil, withKind(errConflict, fmt.Errorf("access request %s is %s, not approved", id, request.Status))
+	}
+	ab.endGrantLocked(request, AccessRevoked, revokedBy, reason)
+	return request.snapshot(), nil
+}
+
+// Get returns a copy of a request to the owner or to the requester holding token
+func (ab *AccessBroker) Get(id, token string, owner bool) (*AccessRequest, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	request, exists := ab.requests[id]
+	if !exists && owner {
+		return nil, withKind(errNotFound, fmt.Errorf("access request %s not found", id))
+	}
+	if !exists || !owner && !request.holdsToken(token) {
+		return nil, errAccessForbidden
+	}
+	return request.snapshot(), nil
+}
+
+// List returns copies of every request, optionally only those with status
+func (ab *AccessBroker) List(status AccessRequestStatus) []*AccessRequest {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	requests := make([]*AccessRequest, 0, len(ab.requests))
+	for _, request := range ab.requests {
+		if status == "" || request.Status == status {
+			requests = append(requests, request.snapshot())
+		}
+	}
+	return requests
+}
+
+// Key returns the delegated key of an approved request to the requester holding token
+func (ab *AccessBroker) Key(id, token string) ([]byte, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	request, err := ab.grantLocked(id, token)
+	if err != nil {
+		return nil, err
+	}
+	return request.key, nil
+}
+
+// ReleaseData returns the owner's data for uri if the requester holding token has
+// a live grant covering it. The check runs here, so an expired or revoked grant
+// is refused even if the requester still holds the key.
+func (ab *AccessBroker) ReleaseData(id, token, uri string) ([]OwnerDataPoint, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	request, err := ab.grantLocked(id, token)
+	if err != nil {
+		return nil, err
+	}
+	if !accessURICovers(request.URI, uri) {
+		return nil, withKind(errForbidden, fmt.Errorf("grant for %s does not cover %s", request.URI, uri))
+	}
+	ab.registry.UpdateUsage(request.KeyID)
+	if ab.data == nil {
+		return []OwnerDataPoint{}, nil
+	}
+	return ab.data.Release(uri), nil
+}
+
+// ExpireGrants ends every approved grant whose window has passed, revoking its
+// key, and returns how many it ended
+func (ab *AccessBroker) ExpireGrants() int {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	now := ab.now()
+	expired := 0
+	for _, request := range ab.requests {
+		switch {
+		case request.Status == AccessApproved && !now.Before(request.EndTime):
+			ab.endGrantLocked(request, AccessExpired, "access-broker", "access expired")
+			expired++
+		case request.Status == AccessPending && !now.Before(request.EndTime):
+			request.Status = AccessExpired
+		}
+	}
+	return expired
+}
+
+// Run expires grants every interval until ctx is done
+func (ab *AccessBroker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ab.ExpireGrants()
+		}
+	}
+}
+
+func (ab *AccessBroker) pendingLocked(id string) (*AccessRequest, error) {
+	request, exists := ab.requests[id]
+	if !exists {
+		return nil, withKind(errNotFound, fmt.Errorf("access request %s not found", id))
+	}
+	if request.Status != AccessPending {
+		return nil, withKind(errConflict, fmt.Errorf("access request %s is already %s", id, request.Status))
+	}
+	if request.approving {
+		return nil, withKind(errConflict, fmt.Errorf("access request %s is already being approved", id))
+	}
+	return request, nil
+}
+
+// grantLocked authenticates the requester and checks the grant is live, ending
+// it on the spot if its window has passed or its key has been revoked
+func (ab *AccessBroker) grantLocked(id, token string) (*AccessRequest, error) {
+	request, exists := ab.requests[id]
+	if !exists {
+		return nil, errAccessForbidden
+	}
+	if !request.holdsToken(token) {
+		return nil, errAccessForbidden
+	}
+
+	now := ab.now()
+	switch {
+	case request.Status != AccessApproved:
+		return nil, withKind(errForbidden, fmt.Errorf("access request %s is %s", id, request.Status))
+	case !now.Before(request.EndTime):
+		ab.endGrantLocked(request, AccessExpired, "access-broker", "access expired")
+		return nil, withKind(errForbidden, fmt.Errorf("access request %s expired at %s", id, request.EndTime.Format(time.RFC3339)))
+	case now.Before(request.StartTime):
+		return nil, withKind(errForbidden, fmt.Errorf("access request %s is not valid until %s", id, request.StartTime.Format(time.RFC3339)))
+	case ab.revocations.IsKeyRevoked(request.KeyID):
+		request.Status = AccessRevoked
+		request.key = nil
+		return nil, withKind(errKeyRevoked, fmt.Errorf("access request %s: key %s has been revoked", id, request.KeyID))
+	}
+	return request, nil
+}
+
+// endGrantLocked moves an approved grant to status, forgets its key and revokes it
+func (ab *AccessBroker) endGrantLocked(request *AccessRequest, status AccessRequestStatus, revokedBy, reason string) {
+	now := ab.now()
+	request.Status = status
+	request.Reason = reason
+	request.key = nil
+	if !ab.revocations.IsKeyRevoked(request.KeyID) {
+		ab.revocations.RevokeKey(&RevocationEntry{
+			KeyID:         request.KeyID,
+			URI:           request.URI,
+			Hierarchy:     request.Hierarchy,
+			RevokedAt:     now,
+			RevokedBy:     revokedBy,
+			Reason:        reason,
+			EffectiveFrom: now,
+		})
+	}
+}
+
+func (ar *AccessRequest) holdsToken(token string) bool {
+	presented := sha256.Sum256([]byte(token))
+	return subtle.ConstantTimeCompare(presented[:], ar.tokenHash) == 1
+}
+
+func (ar *AccessRequest) snapshot() *AccessRequest {
+	copied := *ar
+	copied.tokenHash = nil
+	copied.key = nil
+	return &copied
+}
+
+// errAccessForbidden hides whether a request exists from callers without its token
+var errAccessForbidden = withKind(errUnauthorized, errors.New("invalid access token"))
+
+// errOwnerRequired refuses an owner action without the owner's token
+var errOwnerRequired = withKind(errUnauthorized, errors.New("owner authorization required"))
+
+// errTooManyAccessRequests refuses a request while the owner has maxPending to decide
+var errTooManyAccessRequests = withKind(errTooManyRequests, errors.New("too many access requests are awaiting the owner; try again later"))
+
+// authorizeOwner reports whether the request carries the owner's bearer token
+func (ab *AccessBroker) authorizeOwner(c *gin.Context) bool {
+	if ab.ownerToken == nil {
+		return false
+	}
+	presented := sha256.Sum256([]byte(bearerToken(c)))
+	return subtle.ConstantTimeCompare(presented[:], ab.ownerToken) == 1
+}
+
+func bearerToken(c *gin.Context) string {
+	return strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+}
+
+// validateAccessURI accepts slash-separated components, with a wildcard allowed
+// only as the last component
+func validateAccessURI(uri string) error {
+	components := strings.Split(uri, "/")
+	for i, component := range components {
+		if component == "" {
+			return fmt.Errorf("uri %q has an empty component", uri)
+		}
+		if component == "*" && i != len(components)-1 {
+			return fmt.Errorf("uri %q may only end in a wildcard", uri)
+		}
+	}
+	return nil
+}
+
+// accessURICovers reports whether a grant for granted allows reading requested:
+// components must match, "+" matches any one component and a trailing "*" any suffix
+func accessURICovers(granted, requested string) bool {
+	if validateAccessURI(requested) != nil || strings.ContainsAny(requested, "*+") {
+		return false
+	}
+	want := strings.Split(requested, "/")
+	for i, component := range strings.Split(granted, "/") {
+		if component == "*" {
+			return true
+		}
+		if i >= len(want) || (component != "+" && component != want[i]) {
+			return false
+		}
+	}
+	return len(strings.Split(granted, "/")) == len(want)
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate identifier: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// RegisterAccessBrokerEndpoints adds the access request, approval and data release
+// endpoints. Failures are answered with an ErrorResponse, like the other handlers'.
+func RegisterAccessBrokerEndpoints(r *gin.Engine, ctx context.Context, broker *AccessBroker) {
+
+	// POST /access-requests - A requester applies for access to a URI until endTime
+	r.POST("/access-requests", func(c *gin.Context) {
+		var req NewAccessRequest
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		request, token, err := broker.Submit(&req)
+		if err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"success":     true,
+			"request":     request,
+			"accessToken": token, // shown once; needed to collect the key and the data
+		})
+	})
+
+	// GET /access-requests - The owner lists requests, optionally ?status=pending
+	r.GET("/access-requests", func(c *gin.Context) {
+		if !broker.authorizeOwner(c) {
+			respondError(c, errOwnerRequired, nil)
+			return
+		}
+		requests := broker.List(AccessRequestStatus(c.Query("status")))
+		c.JSON(http.StatusOK, gin.H{"success": true, "count": len(requests), "requests": requests})
+	})
+
+	// GET /access-requests/:id - Status of one request, for the owner or its requester
+	r.GET("/access-requests/:id", func(c *gin.Context) {
+		request, err := broker.Get(c.Param("id"), bearerToken(c), broker.authorizeOwner(c))
+		if err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "request": request})
+	})
+
+	// POST /access-requests/:id/approve - The owner approves and the key is delegated
+	r.POST("/access-requests/:id/approve", func(c *gin.Context) {
+		if !broker.authorizeOwner(c) {
+			respondError(c, errOwnerRequired, nil)
+			return
+		}
+		request, err := broker.Approve(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "request": request})
+	})
+
+	// POST /access-requests/:id/deny - The owner refuses a pending request
+	r.POST("/access-requests/:id/deny", func(c *gin.Context) {
+		if !broker.authorizeOwner(c) {
+			respondError(c, errOwnerRequired, nil)
+			return
+		}
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		c.ShouldBindJSON(&body)
+		request, err := broker.Deny(c.Param("id"), body.Reason)
+		if err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "request": request})
+	})
+
+	// POST /access-requests/:id/revoke - The owner ends a grant before it expires
+	r.POST("/access-requests/:id/revoke", func(c *gin.Context) {
+		if !broker.authorizeOwner(c) {
+			respondError(c, errOwnerRequired, nil)
+			return
+		}
+		var body struct {
+			Reason string `json:"reason" binding:"required"`
+		}
+		if err := bindRequest(c, &body); err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		request, err := broker.Revoke(c.Param("id"), "owner", body.Reason)
+		if err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "request": request})
+	})
+
+	// GET /access-requests/:id/key - The requester collects the delegated key
+	r.GET("/access-requests/:id/key", func(c *gin.Context) {
+		key, err := broker.Key(c.Param("id"), bearerToken(c))
+		if err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": key})
+	})
+
+	// POST /access-requests/:id/data - The requester reads the owner's data for a URI
+	r.POST("/access-requests/:id/data", func(c *gin.Context) {
+		var body struct {
+			URI string `json:"uri" binding:"required"`
+		}
+		if err := bindRequest(c, &body); err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		points, err := broker.ReleaseData(c.Param("id"), bearerToken(c), body.URI)
+		if err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "uri": body.URI, "dataPoints": points})
+	})
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hibe-api/client"
+
+	"github.com/gin-gonic/gin"
+)
+
+type brokerFixture struct {
+	broker      *AccessBroker
+	registry    *DelegationRegistry
+	revocations *RevocationList
+	router      *gin.Engine
+	now         time.Time
+	delegated   []string
+}
+
+func newBrokerFixture(t *testing.T) *brokerFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	f := &brokerFixture{
+		registry:    &DelegationRegistry{delegations: make(map[string]*DelegationInfo)},
+		revocations: NewRevocationList(),
+		now:         time.Unix(1700000000, 0),
+	}
+	delegate := func(ctx context.Context, hierarchy []byte, uri string, start, end time.Time) ([]byte, error) {
+		f.delegated = append(f.delegated, uri)
+		return []byte("key:" + uri), nil
+	}
+	data := &OwnerData{
+		Name: "household-17",
+		Policies: []OwnerPolicy{
+			{URI: "waste/household-17/bin-weight", Permissions: map[string]bool{"weight": true}},
+		},
+		DataPoints: []OwnerDataPoint{
+			{Type: "weight", URL: "https://example.org/weight"},
+			{Type: "location", URL: "https://example.org/location"},
+		},
+	}
+	f.broker = NewAccessBroker(delegate, f.registry, f.revocations, data, "owner-secret")
+	f.broker.now = func() time.Time { return f.now }
+	f.router = gin.New()
+	RegisterAccessBrokerEndpoints(f.router, context.Background(), f.broker)
+	return f
+}
+
+func (f *brokerFixture) do(t *testing.T, method, path, token string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, _ := json.Marshal(body)
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s: invalid response %q", method, path, w.Body.String())
+	}
+	return w.Code, decoded
+}
+
+// submit files a request for uri lasting an hour and returns its ID and access token
+func (f *brokerFixture) submit(t *testing.T, uri string) (string, string) {
+	t.Helper()
+	code, body := f.do(t, "POST", "/access-requests", "", NewAccessRequest{
+		Requester: "recycler-a",
+		URI:       uri,
+		EndTime:   f.now.Add(time.Hour).Unix(),
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("submit: expected 201, got %d %v", code, body)
+	}
+	return body["request"].(map[string]interface{})["id"].(string), body["accessToken"].(string)
+}
+
+func TestAccessBrokerApprovalFlow(t *testing.T) {
+	f := newBrokerFixture(t)
+	id, token := f.submit(t, "waste/household-17/+")
+
+	// Nothing is delegated before the owner approves
+	if code, _ := f.do(t, "GET", "/access-requests/"+id+"/key", token, nil); code != http.StatusForbidden {
+		t.Errorf("expected a pending request to have no key, got %d", code)
+	}
+	if code, _ := f.do(t, "POST", "/access-requests/"+id+"/approve", token, nil); code != http.StatusUnauthorized {
+		t.Errorf("expected the requester's token not to approve, got %d", code)
+	}
+	code, body := f.do(t, "POST", "/access-requests/"+id+"/approve", "owner-secret", nil)
+	if code != http.StatusOK {
+		t.Fatalf("approve: expected 200, got %d %v", code, body)
+	}
+	keyID := body["request"].(map[string]interface{})["keyId"].(string)
+	if len(f.delegated) != 1 || f.delegated[0] != "waste/household-17/+" {
+		t.Errorf("expected one delegation scoped to the requested URI, got %v", f.delegated)
+	}
+	if info, exists := f.registry.GetDelegation(keyID); !exists || info.Grantee != "recycler-a" {
+		t.Errorf("expected the grant in the delegation registry, got %+v", info)
+	}
+
+	if code, _ := f.do(t, "GET", "/access-requests/"+id+"/key", token, nil); code != http.StatusOK {
+		t.Errorf("expected the requester to collect the key, got %d", code)
+	}
+	if code, _ := f.do(t, "GET", "/access-requests/"+id+"/key", "guess", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected a wrong token to be refused, got %d", code)
+	}
+
+	code, body = f.do(t, "POST", "/access-requests/"+id+"/data", token, gin.H{"uri": "waste/household-17/bin-weight"})
+	if code != http.StatusOK {
+		t.Fatalf("data: expected 200, got %d %v", code, body)
+	}
+	if points := body["dataPoints"].([]interface{}); len(points) != 1 {
+		t.Errorf("expected only the permitted data point, got %v", points)
+	}
+	if code, _ := f.do(t, "POST", "/access-requests/"+id+"/data", token, gin.H{"uri": "waste/household-18/bin-weight"}); code != http.StatusForbidden {
+		t.Errorf("expected a URI outside the grant to be refused, got %d", code)
+	}
+	if info, _ := f.registry.GetDelegation(keyID); info.UsageCount != 1 {
+		t.Errorf("expected one recorded use, got %d", info.UsageCount)
+	}
+}
+
+func TestAccessBrokerFailures(t *testing.T) {
+	f := newBrokerFixture(t)
+	pending, pendingToken := f.submit(t, "waste/household-17/bin-weight")
+	approved, approvedToken := f.submit(t, "waste/household-17/bin-weight")
+	denied, _ := f.submit(t, "waste/household-17/location")
+	revoked, revokedToken := f.submit(t, "waste/household-17/+")
+	for _, id := range []string{approved, revoked} {
+		if _, err := f.broker.Approve(context.Background(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := f.broker.Deny(denied, "not needed"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.broker.Revoke(revoked, "owner", "contract ended"); err != nil {
+		t.Fatal(err)
+	}
+
+	window := f.now.Add(time.Hour).Unix()
+	for _, tc := range []struct {
+		name         string
+		method, path string
+		token        string
+		body         interface{}
+		status       int
+		code         string
+	}{
+		{"submit without URI", "POST", "/access-requests", "", gin.H{"requester": "recycler-a", "endTime": window}, 400, client.CodeInvalidRequest},
+		{"submit malformed URI", "POST", "/access-requests", "", NewAccessRequest{Requester: "recycler-a", URI: "waste//bin-weight", EndTime: window}, 400, client.CodeInvalidRequest},
+		{"submit past window", "POST", "/access-requests", "", NewAccessRequest{Requester: "recycler-a", URI: "waste/a", EndTime: f.now.Add(-time.Hour).Unix()}, 400, client.CodeInvalidRequest},
+		{"list without owner token", "GET", "/access-requests", "", nil, 401, client.CodeUnauthorized},
+		{"get with wrong token", "GET", "/access-requests/" + pending, "guess", nil, 401, client.CodeUnauthorized},
+		{"get unknown request", "GET", "/access-requests/unknown", "owner-secret", nil, 404, client.CodeNotFound},
+		{"approve unknown request", "POST", "/access-requests/unknown/approve", "owner-secret", nil, 404, client.CodeNotFound},
+		{"approve denied request", "POST", "/access-requests/" + denied + "/approve", "owner-secret", nil, 409, client.CodeConflict},
+		{"deny approved request", "POST", "/access-requests/" + approved + "/deny", "owner-secret", nil, 409, client.CodeConflict},
+		{"revoke without reason", "POST", "/access-requests/" + approved + "/revoke", "owner-secret", gin.H{}, 400, client.CodeInvalidRequest},
+		{"revoke unknown request", "POST", "/access-requests/unknown/revoke", "owner-secret", gin.H{"reason": "r"}, 404, client.CodeNotFound},
+		{"revoke pending request", "POST", "/access-requests/" + pending + "/revoke", "owner-secret", gin.H{"reason": "r"}, 409, client.CodeConflict},
+		{"revoke twice", "POST", "/access-requests/" + revoked + "/revoke", "owner-secret", gin.H{"reason": "r"}, 409, client.CodeAlreadyRevoked},
+		{"key of pending request", "GET", "/access-requests/" + pending + "/key", pendingToken, nil, 403, client.CodeForbidden},
+		{"key of revoked grant", "GET", "/access-requests/" + revoked + "/key", revokedToken, nil, 403, client.CodeForbidden},
+		{"data without URI", "POST", "/access-requests/" + approved + "/data", approvedToken, gin.H{}, 400, client.CodeInvalidRequest},
+		{"data outside the grant", "POST", "/access-requests/" + approved + "/data", approvedToken, gin.H{"uri": "waste/household-18/bin-weight"}, 403, client.CodeForbidden},
+	} {
+		status, body := f.do(t, tc.method, tc.path, tc.token, tc.body)
+		if message, _ := body["message"].(string); status != tc.status || body["code"] != tc.code || message == "" {
+			t.Errorf("%s: expected %d %s, got %d %v", tc.name, tc.status, tc.code, status, body)
+		}
+	}
+
+	// A revoked key is reported as such, and a failed delegation is the server's own error
+	f.revocations.RevokeKey(&RevocationEntry{KeyID: GenerateKeyID(TestHierarchy, "waste/household-17/bin-weight", f.now, time.Unix(window, 0)), Reason: "bin retired"})
+	if status, body := f.do(t, "POST", "/access-requests/"+pending+"/approve", "owner-secret", nil); status != 403 || body["code"] != client.CodeKeyRevoked {
+		t.Errorf("approve revoked key: expected 403 %s, got %d %v", client.CodeKeyRevoked, status, body)
+	}
+	f.broker.delegate = func(ctx context.Context, hierarchy []byte, uri string, start, end time.Time) ([]byte, error) {
+		return nil, errors.New("key store unreachable")
+	}
+	retry, _ := f.submit(t, "waste/household-17/location")
+	status, body := f.do(t, "POST", "/access-requests/"+retry+"/approve", "owner-secret", nil)
+	if status != 500 || body["code"] != client.CodeInternal || strings.Contains(body["message"].(string), "unreachable") {
+		t.Errorf("approve with failed delegation: expected a 500 internal error, got %d %v", status, body)
+	}
+
+	f.broker.maxPending = 1
+	status, body = f.do(t, "POST", "/access-requests", "", NewAccessRequest{Requester: "recycler-b", URI: "waste/household-18/bin-weight", EndTime: window})
+	if status != 429 || body["code"] != client.CodeTooManyRequests {
+		t.Errorf("submit beyond the limit: expected 429 %s, got %d %v", client.CodeTooManyRequests, status, body)
+	}
+}
+
+func TestAccessBrokerExpiresAndRevokesGrants(t *testing.T) {
+	f := newBrokerFixture(t)
+	expiring, expiringToken := f.submit(t, "waste/household-17/bin-weight")
+	swept, _ := f.submit(t, "waste/household-17/*")
+	for _, id := range []string{expiring, swept} {
+		if _, err := f.broker.Approve(context.Background(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.now = f.now.Add(2 * time.Hour)
+
+	// A requester still holding the key is refused once the window has passed
+	code, _ := f.do(t, "POST", "/access-requests/"+expiring+"/data", expiringToken, gin.H{"uri": "waste/household-17/bin-weight"})
+	if code != http.StatusForbidden {
+		t.Errorf("expected an expired grant to be refused, got %d", code)
+	}
+	if ended := f.broker.ExpireGrants(); ended != 1 {
+		t.Errorf("expected the sweep to end the remaining grant, ended %d", ended)
+	}
+	for _, id := range []string{expiring, swept} {
+		request, err := f.broker.Get(id, "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if request.Status != AccessExpired || !f.revocations.IsKeyRevoked(request.KeyID) {
+			t.Errorf("expected %s to be expired and revoked, got %s", id, request.Status)
+		}
+	}
+
+	// The owner can end a grant before it expires
+	id, token := f.submit(t, "waste/household-17/bin-weight")
+	if _, err := f.broker.Approve(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.broker.Revoke(id, "owner", "contract ended"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.broker.Key(id, token); err == nil {
+		t.Error("expected a revoked grant to have no key")
+	}
+}
+
+func TestAccessBrokerLimitsPendingRequests(t *testing.T) {
+	f := newBrokerFixture(t)
+	f.broker.maxPending = 1
+	id, _ := f.submit(t, "waste/household-17/bin-weight")
+
+	request := NewAccessRequest{Requester: "recycler-b", URI: "waste/household-18/bin-weight", EndTime: f.now.Add(time.Hour).Unix()}
+	if code, _ := f.do(t, "POST", "/access-requests", "", request); code != http.StatusTooManyRequests {
+		t.Errorf("expected a request beyond the limit to be refused, got %d", code)
+	}
+	if _, err := f.broker.Deny(id, "not needed"); err != nil {
+		t.Fatal(err)
+	}
+	if code, body := f.do(t, "POST", "/access-requests", "", request); code != http.StatusCreated {
+		t.Errorf("expected a decided request to free its place, got %d %v", code, body)
+	}
+}
+
+func TestAccessBrokerDelegatesUnlocked(t *testing.T) {
+	f := newBrokerFixture(t)
+	id, _ := f.submit(t, "waste/household-17/bin-weight")
+
+	// The broker answers other calls while a key is delegated, but not a second
+	// decision on the same request
+	var listed int
+	var denyErr, approveErr error
+	f.broker.delegate = func(ctx context.Context, hierarchy []byte, uri string, start, end time.Time) ([]byte, error) {
+		listed = len(f.broker.List(AccessPending))
+		_, denyErr = f.broker.Deny(id, "changed my mind")
+		_, approveErr = f.broker.Approve(ctx, id)
+		return []byte("key:" + uri), nil
+	}
+	request, err := f.broker.Approve(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed != 1 || denyErr == nil || approveErr == nil {
+		t.Errorf("expected the request listed but not decided again, listed %d, deny %v, approve %v", listed, denyErr, approveErr)
+	}
+	if request.Status != AccessApproved {
+		t.Errorf("expected the request to be approved, got %s", request.Status)
+	}
+}
+
+func TestAccessURICovers(t *testing.T) {
+	cases := []struct {
+		granted, requested string
+		covers             bool
+	}{
+		{"waste/a/weight", "waste/a/weight", true},
+		{"waste/a/weight", "waste/a/location", false},
+		{"waste/+/weight", "waste/b/weight", true},
+		{"waste/*", "waste/a/weight", true},
+		{"waste/a", "waste/a/weight", false},
+		{"waste/*", "waste/*", false},
+	}
+	for _, c := range cases {
+		if got := accessURICovers(c.granted, c.requested); got != c.covers {
+			t.Errorf("accessURICovers(%q, %q) = %v", c.granted, c.requested, got)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hibe"
+	"hibe-api/client"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newContractClient serves the real router, with the revocation and delegation
+// endpoints registered as INTEGRATION_INSTRUCTIONS.md describes, and returns a
+// client for it. Each test gets a fresh revocation list.
+func newContractClient(t *testing.T) *client.Client {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	previous := globalRevocationList
+	globalRevocationList = NewRevocationList()
+	t.Cleanup(func() { globalRevocationList = previous })
+
+	ctx := context.Background()
+	_, store := NewTestKeyStore()
+	encoder := hibe.NewDefaultPatternEncoder(serverConfig.HIBE.PatternSize - hibe.MaxTimeLength)
+	r := newRouter(ctx, store, encoder, NewTestState())
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	c, err := client.NewClient(client.Config{
+		BaseURL: server.URL,
+		Retry:   &client.RetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestContractEncryptDecrypt(t *testing.T) {
+	c := newContractClient(t)
+	ctx := context.Background()
+
+	encrypted, err := c.Encrypt(ctx, &client.EncryptRequest{URI: "a/b/c", Message: "test message"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted.Data == "" {
+		t.Fatal("expected a ciphertext")
+	}
+	decrypted, err := c.Decrypt(ctx, &client.DecryptRequest{URI: "a/b/c", EncryptedMessage: encrypted.Data, Key: "unused", Timestamp: encrypted.Timestamp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Data != "test message" {
+		t.Errorf("expected the message back, got %q", decrypted.Data)
+	}
+
+	var apiErr *client.APIError
+	_, err = c.Decrypt(ctx, &client.DecryptRequest{URI: "a/b/c", EncryptedMessage: "not base64!", Key: "unused"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != client.CodeInvalidCiphertext {
+		t.Errorf("expected a 400 for a malformed ciphertext, got %v", err)
+	}
+}
+
+func TestContractEncryptBatch(t *testing.T) {
+	c := newContractClient(t)
+	ctx := context.Background()
+
+	resp, err := c.EncryptBatch(ctx, &client.BatchEncryptRequest{Records: []client.EncryptRecord{
+		{URI: "facility/north/bin/7", Message: "fill 80%", Timestamp: 1565119330},
+		{URI: "facility/north/bin/7", Message: ""},
+		{URI: "facility/south/bin/2", Message: "fill 15%", Timestamp: 1565119390},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Done || resp.Succeeded != 2 || resp.Failed != 1 || len(resp.Results) != 3 {
+		t.Fatalf("expected two of three records encrypted, got %+v", resp)
+	}
+	for i, result := range resp.Results {
+		if result.Index != i {
+			t.Errorf("expected result %d in place, got index %d", i, result.Index)
+		}
+	}
+	if failed := resp.Results[1].Error; failed == nil || failed.Code != client.CodeInvalidRequest {
+		t.Errorf("expected the empty message to be rejected, got %+v", resp.Results[1])
+	}
+
+	south := resp.Results[2]
+	decrypted, err := c.Decrypt(ctx, &client.DecryptRequest{URI: south.URI, EncryptedMessage: south.Data, Key: "unused", Timestamp: south.Timestamp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Data != "fill 15%" {
+		t.Errorf("expected the reading back, got %q", decrypted.Data)
+	}
+}
+
+func TestContractDelegateAndRevoke(t *testing.T) {
+	c := newContractClient(t)
+	ctx := context.Background()
+	window := &client.DelegationRequest{URI: "fleet/truck-7", Hierarchy: string(TestHierarchy), StartTime: 1565119330, EndTime: 1565219330}
+
+	delegated, err := c.Delegate(ctx, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedKeyID := GenerateKeyID(TestHierarchy, window.URI, time.Unix(window.StartTime, 0), time.Unix(window.EndTime, 0))
+	if !delegated.Success || delegated.KeyID != expectedKeyID || len(delegated.Data) == 0 {
+		t.Fatalf("unexpected delegation %+v", delegated)
+	}
+
+	revoked, err := c.Revoke(ctx, &client.RevocationRequest{KeyID: delegated.KeyID, RevokedBy: "contract-test", Reason: "vehicle retired"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked.Success || revoked.KeyID != delegated.KeyID || revoked.RevokedAt.IsZero() {
+		t.Errorf("unexpected revocation %+v", revoked)
+	}
+
+	byID, err := c.CheckRevocation(ctx, delegated.KeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byID.IsRevoked || byID.RevocationDetails == nil || byID.RevocationDetails.Reason != "vehicle retired" {
+		t.Errorf("expected the key to be reported revoked with details, got %+v", byID)
+	}
+	byParams, err := c.CheckRevocationFor(ctx, &client.RevocationCheckRequest{
+		Hierarchy: window.Hierarchy, URI: window.URI, StartTime: window.StartTime, EndTime: window.EndTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byParams.IsRevoked || byParams.URI != window.URI || byParams.StartTime != window.StartTime {
+		t.Errorf("expected the key parameters to be echoed as revoked, got %+v", byParams)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.Delegate(ctx, window); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.KeyID != delegated.KeyID {
+		t.Errorf("expected delegating a revoked key to be refused with its key ID, got %v", err)
+	}
+	if _, err := c.Revoke(ctx, &client.RevocationRequest{KeyID: delegated.KeyID, Reason: "again"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("expected revoking twice to conflict, got %v", err)
+	}
+	if _, err := c.Revoke(ctx, &client.RevocationRequest{KeyID: delegated.KeyID}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a revocation without a reason to be rejected, got %v", err)
+	}
+}
+
+func TestContractUnrevokedKey(t *testing.T) {
+	c := newContractClient(t)
+	resp, err := c.CheckRevocation(context.Background(), "unknown-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.IsRevoked || resp.KeyID != "unknown-key" || resp.RevocationDetails != nil {
+		t.Errorf("expected an unrevoked key, got %+v", resp)
+	}
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
+	"hibe"
+	"hibe-api/metrics"
+)
+
+// BatchDelegationRequest delegates keys for many URIs over one validity window
+type BatchDelegationRequest struct {
+	URIs      []string `json:"uris" binding:"required,min=1"`
+	Hierarchy string   `json:"hierarchy"`
+	StartTime int64    `json:"startTime" binding:"required"` // Unix timestamp
+	EndTime   int64    `json:"endTime" binding:"required"`   // Unix timestamp
+}
+
+// BatchDelegationItem is one line of a streamed batch response. Items arrive in
+// completion order; Index is the URI's position in the request.
+type BatchDelegationItem struct {
+	Index         int    `json:"index"`
+	URI           string `json:"uri"`
+	Success       bool   `json:"success"`
+	KeyID         string `json:"keyId,omitempty"`
+	Data          []byte `json:"data,omitempty"`
+	ExecutionTime int64  `json:"executionTime"`  // in microseconds
+	Code          string `json:"code,omitempty"` // as in ErrorResponse, when the item failed
+	Error         string `json:"error,omitempty"`
+}
+
+// BatchDelegationSummary is the last line of a streamed batch response
+type BatchDelegationSummary struct {
+	Done          bool   `json:"done"`
+	Prefix        string `json:"prefix"`
+	Succeeded     int    `json:"succeeded"`
+	Failed        int    `json:"failed"`
+	ExecutionTime int64  `json:"executionTime"` // in microseconds
+}
+
+// registerBatchDelegationEndpoint adds POST /hibe-delegate/batch. The response is
+// newline-delimited JSON: one BatchDelegationItem per URI, then a summary. A URI
+// that is revoked or fails to delegate is reported in its item and does not stop
+// the rest of the batch.
+func registerBatchDelegationEndpoint(r *gin.Engine, ctx context.Context, store hibe.KeyStoreReader, encoder hibe.PatternEncoder) {
+	r.POST("/hibe-delegate/batch", func(c *gin.Context) {
+		var req BatchDelegationRequest
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		if limit := serverConfig.Limits.MaxBatchDelegations; len(req.URIs) > limit {
+			respondError(c, withKind(errInvalidRequest, fmt.Errorf("batch holds %d URIs, limit is %d", len(req.URIs), limit)), nil)
+			return
+		}
+
+		hierarchy := TestHierarchy
+		if req.Hierarchy != "" {
+			hierarchy = []byte(req.Hierarchy)
+		}
+		start := time.Unix(req.StartTime, 0)
+		end := time.Unix(req.EndTime, 0)
+		if end.Before(start) {
+			respondError(c, withKind(errInvalidRequest, errors.New("endTime must be after startTime")), nil)
+			return
+		}
+
+		// Stop delegating once either the server or the client goes away
+		batchCtx, cancel := withServerContext(c.Request.Context(), ctx)
+		defer cancel()
+
+		prefix := commonURIPrefix(req.URIs)
+		batchStore := newPrefixKeyStore(store, encoder, prefix)
+		batchStart := time.Now()
+		items := delegateBatch(batchCtx, batchStore, encoder, hierarchy, req.URIs, start, end)
+
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(200)
+		stream := json.NewEncoder(c.Writer)
+
+		summary := BatchDelegationSummary{Done: true, Prefix: prefix}
+		for item := range items {
+			if item.Success {
+				summary.Succeeded++
+			} else {
+				summary.Failed++
+			}
+			if err := stream.Encode(item); err != nil {
+				cancel()
+				continue // drain the workers
+			}
+			c.Writer.Flush()
+		}
+		summary.ExecutionTime = time.Since(batchStart).Microseconds()
+		stream.Encode(summary)
+		c.Writer.Flush()
+	})
+}
+
+// delegateBatch delegates every URI on a bounded pool of workers and streams the
+// results. The channel is closed once every URI has been answered.
+func delegateBatch(ctx context.Context, store hibe.KeyStoreReader, encoder hibe.PatternEncoder, hierarchy []byte, uris []string, start, end time.Time) <-chan *BatchDelegationItem {
+	indexes := make(chan int)
+	workers := batchWorkers()
+	items := make(chan *BatchDelegationItem, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				items <- delegateBatchItem(ctx, store, encoder, hierarchy, i, uris[i], start, end)
+			}
+		}()
+	}
+
+	go func() {
+		for i := range uris {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+		close(items)
+	}()
+	return items
+}
+
+func delegateBatchItem(ctx context.Context, store hibe.KeyStoreReader, encoder hibe.PatternEncoder, hierarchy []byte, index int, uri string, start, end time.Time) *BatchDelegationItem {
+	item := &BatchDelegationItem{Index: index, URI: uri}
+	if err := ctx.Err(); err != nil {
+		item.Error = fmt.Sprintf("batch cancelled: %v", err)
+		return item
+	}
+
+	if err := checkURI(uri); err != nil {
+		item.fail(err)
+		return item
+	}
+	keyID, err := checkAndRecordDelegation(hierarchy, uri, start, end)
+	item.KeyID = keyID
+	if err != nil {
+		item.fail(err)
+		return item
+	}
+
+	elapsed, err := hibeOperation(ctx, metrics.OperationDelegate, uri, func(ctx context.Context) error {
+		delegation, err := hibe.Delegate(ctx, store, encoder, hierarchy, uri, start, end, hibe.DecryptPermission|hibe.SignPermission)
+		if err != nil {
+			return err
+		}
+		item.Data = delegation.Marshal()
+		return nil
+	})
+	item.ExecutionTime = elapsed.Microseconds()
+	if err != nil {
+		item.fail(fmt.Errorf("Delegation failed: %w", err))
+		return item
+	}
+
+	item.Success = true
+	return item
+}
+
+// fail reports err in the item with its code, as respondError would
+func (item *BatchDelegationItem) fail(err error) {
+	_, item.Code = errorStatus(err)
+	item.Error = err.Error()
+}
+
+// commonURIPrefix returns the leading URI components shared by every URI, stopping
+// before wildcards and before the last component of the shortest URI
+func commonURIPrefix(uris []string) string {
+	if len(uris) == 0 {
+		return ""
+	}
+
+	common := strings.Split(uris[0], "/")
+	common = common[:len(common)-1]
+	for _, uri := range uris[1:] {
+		components := strings.Split(uri, "/")
+		n := 0
+		for n < len(common) && n < len(components)-1 && components[n] == common[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	for i, component := range common {
+		if component == "*" || component == "+" || component == "" {
+			common = common[:i]
+			break
+		}
+	}
+	return strings.Join(common, "/")
+}
+
+// prefixKeyStore answers every pattern under a shared URI prefix with one key
+// qualified to that prefix, so a batch derives the prefix once per pattern type
+// instead of once per URI and time slice. Other patterns go to the wrapped store.
+type prefixKeyStore struct {
+	inner    hibe.KeyStoreReader
+	prefixes []hibe.Pattern
+	params   *wkdibe.Params
+	keys     []*wkdibe.SecretKey
+	mu       sync.Mutex
+}
+
+func newPrefixKeyStore(inner hibe.KeyStoreReader, encoder hibe.PatternEncoder, prefix string) *prefixKeyStore {
+	store := &prefixKeyStore{inner: inner}
+	if prefix == "" {
+		return store
+	}
+
+	path, err := hibe.ParseURI(prefix + "/*")
+	if err != nil {
+		return store
+	}
+	for _, patternType := range []hibe.PatternType{hibe.PatternTypeDecryption, hibe.PatternTypeSigning} {
+		store.prefixes = append(store.prefixes, encoder.Encode(path, nil, patternType))
+	}
+	store.keys = make([]*wkdibe.SecretKey, len(store.prefixes))
+	return store
+}
+
+// KeyForPattern returns the shared prefix key when pattern lies under the prefix
+func (ps *prefixKeyStore) KeyForPattern(ctx context.Context, hierarchy []byte, pattern hibe.Pattern) (*wkdibe.Params, *wkdibe.SecretKey, error) {
+	for i, prefix := range ps.prefixes {
+		if !patternCovers(prefix, pattern) {
+			continue
+		}
+
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		if ps.keys[i] == nil {
+			params, key, err := ps.inner.KeyForPattern(ctx, hierarchy, prefix)
+			if err != nil {
+				return nil, nil, err
+			}
+			ps.params = params
+			ps.keys[i] = wkdibe.QualifyKey(params, key, prefix.ToAttrs())
+		}
+		return ps.params, ps.keys[i], nil
+	}
+	return ps.inner.KeyForPattern(ctx, hierarchy, pattern)
+}
+
+// patternCovers reports whether every slot fixed in general is fixed to the same
+// value in specific
+func patternCovers(general, specific hibe.Pattern) bool {
+	if len(general) != len(specific) {
+		return false
+	}
+	for i, component := range general {
+		if component == nil {
+			continue
+		}
+		if specific[i] == nil || component.Cmp(specific[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
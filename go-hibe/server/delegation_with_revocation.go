@@ -1,38 +1,26 @@
-package main
+package server
 
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"hibe-api"
+	"hibe"
+	"hibe-api/client"
+	"hibe-api/metrics"
 )
 
 // DelegationRequest represents a request to delegate a key
-type DelegationRequest struct {
-	URI       string `json:"uri" binding:"required"`
-	Hierarchy string `json:"hierarchy"`
-	StartTime int64  `json:"startTime" binding:"required"` // Unix timestamp
-	EndTime   int64  `json:"endTime" binding:"required"`   // Unix timestamp
-	Parent    string `json:"parent,omitempty"`             // For hierarchical delegation
-}
+type DelegationRequest = client.DelegationRequest
 
 // DelegationResponse represents the response from a delegation request
-type DelegationResponse struct {
-	Success       bool      `json:"success"`
-	KeyID         string    `json:"keyId"`
-	Data          []byte    `json:"data"`
-	URI           string    `json:"uri"`
-	Hierarchy     string    `json:"hierarchy"`
-	StartTime     int64     `json:"startTime"`
-	EndTime       int64     `json:"endTime"`
-	ExecutionTime int64     `json:"executionTime"` // in microseconds
-	Message       string    `json:"message,omitempty"`
-	Error         string    `json:"error,omitempty"`
-}
+type DelegationResponse = client.DelegationResponse
 
 // RegisterDelegationWithRevocationEndpoint adds the enhanced delegation endpoint
 func RegisterDelegationWithRevocationEndpoint(r *gin.Engine, ctx context.Context, store *TestKeyStore, encoder hibe.PatternEncoder) {
@@ -40,12 +28,12 @@ func RegisterDelegationWithRevocationEndpoint(r *gin.Engine, ctx context.Context
 	// POST /hibe-delegate - New endpoint for key delegation with revocation support
 	r.POST("/hibe-delegate", func(c *gin.Context) {
 		var req DelegationRequest
-
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(400, DelegationResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Invalid request: %v", err),
-			})
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		if err := checkURI(req.URI); err != nil {
+			respondError(c, err, nil)
 			return
 		}
 
@@ -61,39 +49,32 @@ func RegisterDelegationWithRevocationEndpoint(r *gin.Engine, ctx context.Context
 
 		// Validate time range
 		if end.Before(start) {
-			c.JSON(400, DelegationResponse{
-				Success: false,
-				Error:   "endTime must be after startTime",
-			})
+			respondError(c, withKind(errInvalidRequest, errors.New("endTime must be after startTime")), nil)
 			return
 		}
 
 		// Check if this delegation would create a revoked key
 		keyID, err := checkAndRecordDelegation(hierarchy, req.URI, start, end)
 		if err != nil {
-			c.JSON(403, DelegationResponse{
-				Success: false,
-				KeyID:   keyID,
-				Error:   err.Error(),
-			})
+			respondError(c, err, gin.H{"keyId": keyID})
 			return
 		}
 
 		// Perform the actual delegation
-		startExecution := time.Now()
-		delegation, err := hibe.Delegate(ctx, store, encoder, hierarchy, req.URI, start, end, hibe.DecryptPermission|hibe.SignPermission)
+		var marshalled []byte
+		elapsed, err := hibeOperation(c.Request.Context(), metrics.OperationDelegate, req.URI, func(ctx context.Context) error {
+			delegation, err := hibe.Delegate(ctx, store, encoder, hierarchy, req.URI, start, end, hibe.DecryptPermission|hibe.SignPermission)
+			if err != nil {
+				return err
+			}
+			marshalled = delegation.Marshal()
+			return nil
+		})
 		if err != nil {
-			c.JSON(500, DelegationResponse{
-				Success: false,
-				KeyID:   keyID,
-				Error:   fmt.Sprintf("Delegation failed: %v", err),
-			})
+			respondError(c, fmt.Errorf("Delegation failed: %w", err), gin.H{"keyId": keyID})
 			return
 		}
-		executionTime := time.Since(startExecution).Microseconds()
-
-		// Marshal the delegation
-		marshalled := delegation.Marshal()
+		executionTime := elapsed.Microseconds()
 
 		// Return successful response
 		c.JSON(200, DelegationResponse{
@@ -109,6 +90,9 @@ func RegisterDelegationWithRevocationEndpoint(r *gin.Engine, ctx context.Context
 		})
 	})
 
+	// POST /hibe-delegate/batch - Delegate many URIs sharing a prefix, streaming results
+	registerBatchDelegationEndpoint(r, ctx, store, encoder)
+
 	// GET /hibe-delegate-info/:keyId - Get delegation information for a key ID
 	r.GET("/hibe-delegate-info/:keyId", func(c *gin.Context) {
 		keyID := c.Param("keyId")
@@ -138,6 +122,23 @@ func RegisterDelegationWithRevocationEndpoint(r *gin.Engine, ctx context.Context
 	})
 }
 
+// NewHIBEDelegateFunc delegates access broker grants with hibe.Delegate. Grantees
+// only read the owner's data, so their keys carry decryption permission alone.
+func NewHIBEDelegateFunc(store hibe.KeyStoreReader, encoder hibe.PatternEncoder) DelegateFunc {
+	return func(ctx context.Context, hierarchy []byte, uri string, start, end time.Time) ([]byte, error) {
+		var marshalled []byte
+		_, err := hibeOperation(ctx, metrics.OperationDelegate, uri, func(ctx context.Context) error {
+			delegation, err := hibe.Delegate(ctx, store, encoder, hierarchy, uri, start, end, hibe.DecryptPermission)
+			if err != nil {
+				return err
+			}
+			marshalled = delegation.Marshal()
+			return nil
+		})
+		return marshalled, err
+	}
+}
+
 // DecryptWithRevocationCheck performs decryption with revocation checking
 func DecryptWithRevocationCheck(
 	ctx context.Context,
@@ -155,20 +156,24 @@ func DecryptWithRevocationCheck(
 
 	// Check if key is revoked
 	if err := CheckRevocationWithContext(ctx, globalRevocationList, keyID); err != nil {
-		return nil, fmt.Errorf("decryption denied: %v", err)
+		return nil, fmt.Errorf("decryption denied: %w", err)
 	}
 
 	// Perform actual decryption
-	decrypted, err := state.Decrypt(ctx, hierarchy, uri, timestamp, encrypted)
+	var decrypted []byte
+	_, err := hibeOperation(ctx, metrics.OperationDecrypt, uri, func(ctx context.Context) (err error) {
+		decrypted, err = state.Decrypt(ctx, hierarchy, uri, timestamp, encrypted)
+		return decryptError(err)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("decryption failed: %v", err)
+		return nil, fmt.Errorf("decryption failed: %w", err)
 	}
 
 	return decrypted, nil
 }
 
 // Enhanced decrypt endpoint with revocation checking
-func RegisterEnhancedDecryptEndpoint(r *gin.Engine, ctx context.Context, state *hibe.ClientState, now time.Time) {
+func RegisterEnhancedDecryptEndpoint(r *gin.Engine, ctx context.Context, state *hibe.ClientState) {
 
 	r.POST("/decrypt-with-revocation", func(c *gin.Context) {
 		var req struct {
@@ -177,23 +182,26 @@ func RegisterEnhancedDecryptEndpoint(r *gin.Engine, ctx context.Context, state *
 			Hierarchy        string `json:"hierarchy"`
 			StartTime        int64  `json:"startTime" binding:"required"`
 			EndTime          int64  `json:"endTime" binding:"required"`
+			Timestamp        int64  `json:"timestamp"` // Unix seconds encrypted under, now when omitted
 		}
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(400, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Invalid request: %v", err),
-			})
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
 			return
 		}
 
 		// Decode encrypted message
 		encrypted, err := decodeBase64(req.EncryptedMessage)
 		if err != nil {
-			c.JSON(400, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Invalid encrypted message: %v", err),
-			})
+			respondError(c, withKind(errInvalidCiphertext, fmt.Errorf("Invalid encrypted message: %v", err)), nil)
+			return
+		}
+		if err := checkCiphertext(encrypted); err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		if err := checkURI(req.URI); err != nil {
+			respondError(c, err, nil)
 			return
 		}
 
@@ -209,11 +217,11 @@ func RegisterEnhancedDecryptEndpoint(r *gin.Engine, ctx context.Context, state *
 		// Perform decryption with revocation check
 		startExecution := time.Now()
 		decrypted, err := DecryptWithRevocationCheck(
-			ctx,
+			c.Request.Context(),
 			state,
 			hierarchy,
 			req.URI,
-			now,
+			requestTime(req.Timestamp),
 			encrypted,
 			start,
 			end,
@@ -221,11 +229,7 @@ func RegisterEnhancedDecryptEndpoint(r *gin.Engine, ctx context.Context, state *
 		executionTime := time.Since(startExecution).Microseconds()
 
 		if err != nil {
-			c.JSON(403, gin.H{
-				"success":       false,
-				"error":         err.Error(),
-				"executionTime": executionTime,
-			})
+			respondError(c, err, gin.H{"executionTime": executionTime})
 			return
 		}
 
@@ -241,12 +245,12 @@ func RegisterEnhancedDecryptEndpoint(r *gin.Engine, ctx context.Context, state *
 		recordPowerUsage("decrypt-with-revocation", measureUsage, len(encrypted))
 
 		c.JSON(200, gin.H{
-			"success":                true,
-			"data":                   string(decrypted),
-			"executionTime":          executionTime,
-			"memoryUsage":            measureUsage.memory,
-			"cpuPercentage":          measureUsage.cpuPercentage,
-			"powerUsageWatts":        measureUsage.powerUsage,
+			"success":                 true,
+			"data":                    string(decrypted),
+			"executionTime":           executionTime,
+			"memoryUsage":             measureUsage.memory,
+			"cpuPercentage":           measureUsage.cpuPercentage,
+			"powerUsageWatts":         measureUsage.powerUsage,
 			"energyConsumptionJoules": measureUsage.energyConsumption,
 		})
 	})
@@ -259,15 +263,16 @@ func decodeBase64(encoded string) ([]byte, error) {
 
 // DelegationInfo stores information about active delegations
 type DelegationInfo struct {
-	KeyID         string    `json:"keyId"`
-	URI           string    `json:"uri"`
-	Hierarchy     string    `json:"hierarchy"`
-	StartTime     time.Time `json:"startTime"`
-	EndTime       time.Time `json:"endTime"`
-	CreatedAt     time.Time `json:"createdAt"`
-	LastUsed      time.Time `json:"lastUsed,omitempty"`
-	UsageCount    int       `json:"usageCount"`
-	IsRevoked     bool      `json:"isRevoked"`
+	KeyID      string    `json:"keyId"`
+	URI        string    `json:"uri"`
+	Hierarchy  string    `json:"hierarchy"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsed   time.Time `json:"lastUsed,omitempty"`
+	UsageCount int       `json:"usageCount"`
+	IsRevoked  bool      `json:"isRevoked"`
+	Grantee    string    `json:"grantee,omitempty"` // Requester of an access broker grant
 }
 
 // DelegationRegistry keeps track of active delegations
@@ -321,6 +326,40 @@ func (dr *DelegationRegistry) GetAllDelegations() []*DelegationInfo {
 	return delegations
 }
 
+// MarshalJSON encodes every recorded delegation, so the record can be saved
+func (dr *DelegationRegistry) MarshalJSON() ([]byte, error) {
+	dr.mu.RLock()
+	defer dr.mu.RUnlock()
+
+	delegations := make([]*DelegationInfo, 0, len(dr.delegations))
+	for _, info := range dr.delegations {
+		delegations = append(delegations, info)
+	}
+	sort.Slice(delegations, func(i, j int) bool { return delegations[i].KeyID < delegations[j].KeyID })
+	return json.Marshal(delegations)
+}
+
+// UnmarshalJSON adds saved delegations to the registry, keeping any it already holds
+func (dr *DelegationRegistry) UnmarshalJSON(data []byte) error {
+	var delegations []*DelegationInfo
+	if err := json.Unmarshal(data, &delegations); err != nil {
+		return err
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	for _, info := range delegations {
+		if info == nil || info.KeyID == "" {
+			return fmt.Errorf("delegation without a key ID")
+		}
+		if _, exists := dr.delegations[info.KeyID]; !exists {
+			dr.delegations[info.KeyID] = info
+		}
+	}
+	return nil
+}
+
 // RegisterDelegationManagementEndpoints adds delegation management endpoints
 func RegisterDelegationManagementEndpoints(r *gin.Engine) {
 
@@ -345,9 +384,7 @@ func RegisterDelegationManagementEndpoints(r *gin.Engine) {
 
 		info, exists := globalDelegationRegistry.GetDelegation(keyID)
 		if !exists {
-			c.JSON(404, gin.H{
-				"error": "Delegation not found",
-			})
+			respondError(c, withKind(errNotFound, fmt.Errorf("Delegation not found: %s", keyID)), nil)
 			return
 		}
 
@@ -0,0 +1,335 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hibe"
+	"hibe-api/client"
+	"hibe-api/metrics"
+)
+
+// EncryptRecord is one reading to encrypt in a batch or stream
+type EncryptRecord = client.EncryptRecord
+
+// EncryptResult is the outcome of one EncryptRecord
+type EncryptResult = client.EncryptResult
+
+// EncryptSummary counts the outcomes of a batch or stream encryption
+type EncryptSummary = client.EncryptSummary
+
+// BatchEncryptRequest is the body of POST /encrypt/batch
+type BatchEncryptRequest = client.BatchEncryptRequest
+
+// BatchEncryptResponse is returned by POST /encrypt/batch
+type BatchEncryptResponse = client.BatchEncryptResponse
+
+// maxStreamRecordSize bounds one line of an encryption stream
+const maxStreamRecordSize = 1 << 20
+
+// registerBatchEncryptionEndpoints adds POST /encrypt/batch and POST
+// /encrypt/stream. Both encrypt each record under its own URI and timestamp and
+// answer in record order; a record that fails is reported in its result and
+// does not stop the rest.
+//
+// /encrypt/batch takes a BatchEncryptRequest and returns a BatchEncryptResponse.
+// /encrypt/stream takes newline-delimited EncryptRecords in a chunked body and
+// answers with one EncryptResult line per record as soon as it is encrypted,
+// then an EncryptSummary, so a gateway can keep one request open for as long as
+// its bins report.
+func registerBatchEncryptionEndpoints(r *gin.Engine, ctx context.Context, state *hibe.ClientState) {
+	r.POST("/encrypt/batch", func(c *gin.Context) {
+		var req BatchEncryptRequest
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
+			return
+		}
+		if limit := serverConfig.Limits.MaxBatchEncryptions; len(req.Records) > limit {
+			respondError(c, withKind(errInvalidRequest, fmt.Errorf("batch holds %d records, limit is %d", len(req.Records), limit)), nil)
+			return
+		}
+
+		batchCtx, cancel := withServerContext(c.Request.Context(), ctx)
+		defer cancel()
+
+		batchStart := time.Now()
+		results := newRecordEncryptor(state, TestHierarchy).encryptBatch(batchCtx, req.Records, batchStart)
+
+		resp := BatchEncryptResponse{EncryptSummary: EncryptSummary{Done: true}, Results: results}
+		for _, result := range results {
+			if result.Error == nil {
+				resp.Succeeded++
+			} else {
+				resp.Failed++
+			}
+		}
+		resp.ExecutionTime = time.Since(batchStart).Microseconds()
+		c.JSON(http.StatusOK, resp)
+	})
+
+	r.POST("/encrypt/stream", func(c *gin.Context) {
+		streamCtx, cancel := withServerContext(c.Request.Context(), ctx)
+		defer cancel()
+
+		// Results are written while records are still being read. HTTP/2 is
+		// always full duplex; HTTP/1 has to be asked.
+		rc := http.NewResponseController(c.Writer)
+		rc.EnableFullDuplex()
+
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+		stream := json.NewEncoder(c.Writer)
+
+		streamStart := time.Now()
+		results, readErr := newRecordEncryptor(state, TestHierarchy).encryptStream(streamCtx, c.Request.Body, rc)
+
+		summary := EncryptSummary{}
+		for result := range results {
+			if result.Error == nil {
+				summary.Succeeded++
+			} else {
+				summary.Failed++
+			}
+			if err := stream.Encode(result); err != nil {
+				cancel()
+				continue // drain the workers
+			}
+			rc.SetWriteDeadline(recordDeadline(serverConfig.Server.WriteTimeoutSeconds))
+			c.Writer.Flush()
+		}
+
+		summary.ExecutionTime = time.Since(streamStart).Microseconds()
+		if err := streamCtx.Err(); err != nil {
+			summary.Error = fmt.Sprintf("stream cancelled: %v", err)
+		} else if err := readErr(); err != nil {
+			summary.Error = err.Error()
+		} else {
+			summary.Done = true
+		}
+		stream.Encode(summary)
+		c.Writer.Flush()
+	})
+}
+
+// withServerContext returns a context done once either the request or the
+// server is
+func withServerContext(request, server context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(request)
+	go func() {
+		select {
+		case <-server.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// recordDeadline is a connection deadline seconds from now, so a stream's
+// timeouts bound each record rather than the whole stream. No timeout is the
+// zero time, which clears the deadline.
+func recordDeadline(seconds int) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(seconds) * time.Second)
+}
+
+// recordEncryptor encrypts the records of one batch or stream. Each distinct
+// URI is checked once, and the client state caches the key of each URI and
+// time slice, so the records of a URI after its first only pay for their own
+// encryption.
+type recordEncryptor struct {
+	state     *hibe.ClientState
+	hierarchy []byte
+
+	mu   sync.Mutex
+	uris map[string]error // what checkURI said of each URI
+}
+
+func newRecordEncryptor(state *hibe.ClientState, hierarchy []byte) *recordEncryptor {
+	return &recordEncryptor{state: state, hierarchy: hierarchy, uris: make(map[string]error)}
+}
+
+func (e *recordEncryptor) checkURI(uri string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err, checked := e.uris[uri]
+	if !checked {
+		err = checkURI(uri)
+		e.uris[uri] = err
+	}
+	return err
+}
+
+// encrypt encrypts the index-th record under its URI and timestamp, or under
+// received, when it arrived, if it has no timestamp
+func (e *recordEncryptor) encrypt(ctx context.Context, index int, record EncryptRecord, received time.Time) EncryptResult {
+	timestamp := received
+	if record.Timestamp != 0 {
+		timestamp = time.Unix(record.Timestamp, 0)
+	}
+	result := EncryptResult{Index: index, URI: record.URI, Timestamp: timestamp.Unix()}
+
+	err := ctx.Err()
+	if err == nil && (record.URI == "" || record.Message == "") {
+		err = withKind(errInvalidRequest, errors.New("uri and message are required"))
+	}
+	if err == nil {
+		err = e.checkURI(record.URI)
+	}
+	if err == nil {
+		var encrypted []byte
+		_, err = hibeOperation(ctx, metrics.OperationEncrypt, record.URI, func(ctx context.Context) (err error) {
+			encrypted, err = e.state.Encrypt(ctx, e.hierarchy, record.URI, timestamp, []byte(record.Message))
+			return err
+		})
+		result.Data = base64.StdEncoding.EncodeToString(encrypted)
+	}
+	if err != nil {
+		result.Data, result.Error = "", recordError(ctx, err)
+	}
+	return result
+}
+
+// encryptBatch encrypts every record on a bounded pool of workers and returns
+// the results in record order. The records of a URI go to one worker in turn,
+// so its key is derived once instead of by several workers at the same time.
+func (e *recordEncryptor) encryptBatch(ctx context.Context, records []EncryptRecord, received time.Time) []EncryptResult {
+	var uris []string
+	byURI := make(map[string][]int)
+	for i, record := range records {
+		if _, seen := byURI[record.URI]; !seen {
+			uris = append(uris, record.URI)
+		}
+		byURI[record.URI] = append(byURI[record.URI], i)
+	}
+
+	results := make([]EncryptResult, len(records))
+	groups := make(chan []int)
+	var wg sync.WaitGroup
+	for w, workers := 0, batchWorkers(); w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indexes := range groups {
+				for _, i := range indexes {
+					results[i] = e.encrypt(ctx, i, records[i], received)
+				}
+			}
+		}()
+	}
+
+	for _, uri := range uris {
+		groups <- byURI[uri]
+	}
+	close(groups)
+	wg.Wait()
+	return results
+}
+
+// streamRecord is a record of a stream on its way to a worker. Its result is
+// sent on result, which the stream reads in record order.
+type streamRecord struct {
+	index    int
+	record   EncryptRecord
+	err      error // the line was not a record
+	received time.Time
+	result   chan EncryptResult
+}
+
+// encryptStream encrypts the records read from body, one JSON object per line,
+// on a bounded pool of workers. Results are sent in record order on the
+// returned channel, which is closed once the body ends or ctx is done and every
+// record read has been answered; readErr then reports why reading stopped, if
+// not at the end of the body. rc extends the read deadline for each record.
+func (e *recordEncryptor) encryptStream(ctx context.Context, body io.Reader, rc *http.ResponseController) (<-chan EncryptResult, func() error) {
+	workers := batchWorkers()
+	queue := make(chan *streamRecord)
+	ordered := make(chan *streamRecord, workers)
+	results := make(chan EncryptResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range queue {
+				if r.err != nil {
+					r.result <- EncryptResult{Index: r.index, Error: recordError(ctx, r.err)}
+					continue
+				}
+				r.result <- e.encrypt(ctx, r.index, r.record, r.received)
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer close(ordered)
+		defer close(queue)
+
+		// A cancelled stream stops waiting for the client's next record
+		stop := context.AfterFunc(ctx, func() { rc.SetReadDeadline(time.Now()) })
+		defer stop()
+
+		lines := bufio.NewScanner(body)
+		lines.Buffer(make([]byte, 0, 64<<10), maxStreamRecordSize)
+		for index := 0; ctx.Err() == nil && lines.Scan(); {
+			rc.SetReadDeadline(recordDeadline(serverConfig.Server.ReadTimeoutSeconds))
+			line := bytes.TrimSpace(lines.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			r := &streamRecord{index: index, received: time.Now(), result: make(chan EncryptResult, 1)}
+			if err := json.Unmarshal(line, &r.record); err != nil {
+				r.err = withKind(errInvalidRequest, fmt.Errorf("record %d is not a JSON object: %v", index, err))
+			}
+			index++
+
+			// Claim the record's place in the output before a worker can answer it
+			select {
+			case ordered <- r:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case queue <- r:
+			case <-ctx.Done():
+				r.result <- EncryptResult{Index: r.index, URI: r.record.URI, Error: recordError(ctx, ctx.Err())}
+				return
+			}
+		}
+		readErr = lines.Err()
+	}()
+
+	go func() {
+		defer close(results)
+		for r := range ordered {
+			results <- <-r.result
+		}
+		wg.Wait()
+	}()
+
+	// readErr is written before ordered is closed, so before results is
+	return results, func() error { return readErr }
+}
+
+// recordError is the body respondError would send for err, reported in the
+// result of the record that failed
+func recordError(ctx context.Context, err error) *ErrorResponse {
+	_, body := errorBody(ctx, err, nil)
+	return &body
+}
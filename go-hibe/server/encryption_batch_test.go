@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"hibe"
+	"hibe-api/client"
+)
+
+func TestEncryptBatchLimit(t *testing.T) {
+	r := newHandlerRouter(t)
+	previous := serverConfig.Limits.MaxBatchEncryptions
+	serverConfig.Limits.MaxBatchEncryptions = 1
+	t.Cleanup(func() { serverConfig.Limits.MaxBatchEncryptions = previous })
+
+	w := serveJSON(r, http.MethodPost, "/encrypt/batch", `{"records":[{"uri":"a/b","message":"m"},{"uri":"a/c","message":"m"}]}`)
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || body.Code != client.CodeInvalidRequest {
+		t.Errorf("expected an oversized batch to be refused, got %d %+v", w.Code, body)
+	}
+}
+
+func TestEncryptStream(t *testing.T) {
+	r := newHandlerRouter(t)
+	longURI := strings.Repeat("x/", serverConfig.HIBE.PatternSize) + "x"
+	records := strings.Join([]string{
+		`{"uri":"facility/north/bin/7","message":"fill 80%","timestamp":1565119330}`,
+		``,
+		`{"uri":`,
+		`{"uri":"` + longURI + `","message":"m"}`,
+		`{"uri":"facility/north/bin/7","message":"fill 85%","timestamp":1565119390}`,
+	}, "\n")
+
+	w := serveJSON(r, http.MethodPost, "/encrypt/stream", records)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("expected an NDJSON stream, got %d %s", w.Code, w.Body)
+	}
+
+	var results []EncryptResult
+	var summary EncryptSummary
+	lines := bufio.NewScanner(w.Body)
+	for lines.Scan() {
+		var line map[string]json.RawMessage
+		if err := json.Unmarshal(lines.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := line["index"]; ok {
+			var result EncryptResult
+			json.Unmarshal(lines.Bytes(), &result)
+			results = append(results, result)
+		} else {
+			json.Unmarshal(lines.Bytes(), &summary)
+		}
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("expected a result for each of the four records, got %d", len(results))
+	}
+	codes := []string{"", client.CodeInvalidRequest, client.CodeInvalidURI, ""}
+	for i, result := range results {
+		code := ""
+		if result.Error != nil {
+			code = result.Error.Code
+		}
+		if result.Index != i || code != codes[i] || (code == "") == (result.Data == "") {
+			t.Errorf("record %d: expected error %q, got %+v", i, codes[i], result)
+		}
+	}
+	if results[3].Timestamp != 1565119390 {
+		t.Errorf("expected the record's own timestamp, got %d", results[3].Timestamp)
+	}
+	if !summary.Done || summary.Succeeded != 2 || summary.Failed != 2 {
+		t.Errorf("expected a summary of two encrypted and two failed, got %+v", summary)
+	}
+}
+
+func TestEncryptStreamStopsOnShutdown(t *testing.T) {
+	newHandlerRouter(t) // fresh metrics and revocations
+	server, shutdown := context.WithCancel(context.Background())
+	shutdown()
+	_, store := NewTestKeyStore()
+	encoder := hibe.NewDefaultPatternEncoder(serverConfig.HIBE.PatternSize - hibe.MaxTimeLength)
+	r := newRouter(server, store, encoder, NewTestState())
+
+	w := serveJSON(r, http.MethodPost, "/encrypt/stream", `{"uri":"facility/north/bin/7","message":"fill 80%"}`)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	var summary EncryptSummary
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Done || summary.Error == "" {
+		t.Errorf("expected the stream to end early once the server stops, got %+v", summary)
+	}
+}
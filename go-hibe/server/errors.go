@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
+	"hibe"
+	"hibe-api/client"
+	"hibe-api/telemetry"
+)
+
+// ErrorResponse is the body of a failed encryption, delegation, revocation or
+// access request call
+type ErrorResponse = client.ErrorResponse
+
+// Kinds of failure the handlers report. An error is given a kind with withKind
+// and answered with the status and code the kind maps to in errorKinds.
+var (
+	errInvalidRequest    = errors.New("invalid request")
+	errInvalidURI        = errors.New("invalid URI")
+	errInvalidCiphertext = errors.New("invalid ciphertext")
+	errUnauthorized      = errors.New("unauthorized")
+	errUndecryptable     = errors.New("ciphertext cannot be decrypted")
+	errKeyRevoked        = errors.New("key is revoked")
+	errForbidden         = errors.New("forbidden")
+	errNotFound          = errors.New("not found")
+	errAlreadyRevoked    = errors.New("already revoked")
+	errConflict          = errors.New("conflict")
+	errTooManyRequests   = errors.New("too many requests")
+)
+
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{errInvalidRequest, http.StatusBadRequest, client.CodeInvalidRequest},
+	{errInvalidURI, http.StatusBadRequest, client.CodeInvalidURI},
+	{errInvalidCiphertext, http.StatusBadRequest, client.CodeInvalidCiphertext},
+	{errUnauthorized, http.StatusUnauthorized, client.CodeUnauthorized},
+	{errUndecryptable, http.StatusForbidden, client.CodeDecryptionFailed},
+	{errKeyRevoked, http.StatusForbidden, client.CodeKeyRevoked},
+	{errForbidden, http.StatusForbidden, client.CodeForbidden},
+	{errNotFound, http.StatusNotFound, client.CodeNotFound},
+	{errAlreadyRevoked, http.StatusConflict, client.CodeAlreadyRevoked},
+	{errConflict, http.StatusConflict, client.CodeConflict},
+	{errTooManyRequests, http.StatusTooManyRequests, client.CodeTooManyRequests},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, client.CodeTimeout},
+	{context.Canceled, http.StatusServiceUnavailable, client.CodeCancelled},
+}
+
+// kindError is an error of a kind, reading as the error it wraps
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// withKind marks err as a failure of kind without changing its message
+func withKind(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+// errorStatus returns the status and code of err's kind, or a 500 and
+// CodeInternal for an error of no kind
+func errorStatus(err error) (int, string) {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			return k.status, k.code
+		}
+	}
+	return http.StatusInternalServerError, client.CodeInternal
+}
+
+// errorBody returns the status and body err is answered with. An error of no
+// kind is the server's own failure: its body names only the request, so
+// internals do not reach the caller.
+func errorBody(ctx context.Context, err error, details gin.H) (int, ErrorResponse) {
+	status, code := errorStatus(err)
+	if status != http.StatusInternalServerError {
+		return status, ErrorResponse{Code: code, Message: err.Error(), Details: details}
+	}
+
+	if details == nil {
+		details = gin.H{}
+	}
+	details["requestId"] = telemetry.RequestID(ctx)
+	return status, ErrorResponse{Code: code, Message: "internal error", Details: details}
+}
+
+// respondError answers c with err's status and body, logging the server's own
+// failures with the request
+func respondError(c *gin.Context, err error, details gin.H) {
+	status, body := errorBody(c.Request.Context(), err, details)
+	if status == http.StatusInternalServerError {
+		c.Error(err)
+	}
+	c.JSON(status, body)
+}
+
+// bindRequest decodes c's JSON body into req, which must hold every required field
+func bindRequest(c *gin.Context, req interface{}) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return withKind(errInvalidRequest, fmt.Errorf("Invalid request: %v", err))
+	}
+	return nil
+}
+
+// checkURI rejects a URI the hibe package cannot encode, before it is used, so
+// that a failure of the operation itself is not taken for a bad URI
+func checkURI(uri string) error {
+	path, err := hibe.ParseURI(uri)
+	if err != nil {
+		return withKind(errInvalidURI, fmt.Errorf("invalid URI %q: %v", uri, err))
+	}
+	if limit := serverConfig.HIBE.PatternSize - hibe.MaxTimeLength; len(path) > limit {
+		return withKind(errInvalidURI, fmt.Errorf("URI %q has %d components, at most %d fit", uri, len(path), limit))
+	}
+	return nil
+}
+
+// checkCiphertext rejects a message too short to hold, or not starting with, the
+// marshalled WKD-IBE ciphertext of its symmetric key, before it is decrypted,
+// so that a decryption that fails is not taken for a malformed message
+func checkCiphertext(encrypted []byte) error {
+	size := wkdibe.CiphertextMarshalledLength(true)
+	if len(encrypted) < size {
+		return withKind(errInvalidCiphertext, fmt.Errorf("ciphertext is %d bytes, shorter than the %d of its key", len(encrypted), size))
+	}
+	if !new(wkdibe.Ciphertext).Unmarshal(encrypted[:size], true, false) {
+		return withKind(errInvalidCiphertext, errors.New("ciphertext does not start with a WKD-IBE ciphertext"))
+	}
+	return nil
+}
+
+// decryptError gives a kind to an error of the hibe package's Decrypt, which
+// reports every failure as a plain error. The URI and ciphertext are checked
+// before it is called, so its failure means no key held for the URI and time
+// opens the ciphertext. A cancelled or timed out decryption keeps its context
+// error.
+func decryptError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return withKind(errUndecryptable, err)
+}
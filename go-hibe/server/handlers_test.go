@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hibe"
+	"hibe-api/client"
+	"hibe-api/metrics"
+	"hibe-api/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newHandlerRouter serves the encryption, delegation and revocation handlers
+// with fresh metrics, revocations and delegation record
+func newHandlerRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	previousMetrics, previousRevocations, previousDelegations := serverMetrics, globalRevocationList, globalDelegationRegistry
+	t.Cleanup(func() {
+		serverMetrics, globalRevocationList, globalDelegationRegistry = previousMetrics, previousRevocations, previousDelegations
+	})
+	serverMetrics = metrics.NewRecorder()
+	globalRevocationList = NewRevocationList()
+	globalDelegationRegistry = &DelegationRegistry{delegations: make(map[string]*DelegationInfo)}
+
+	ctx := context.Background()
+	_, store := NewTestKeyStore()
+	encoder := hibe.NewDefaultPatternEncoder(serverConfig.HIBE.PatternSize - hibe.MaxTimeLength)
+	return newRouter(ctx, store, encoder, NewTestState())
+}
+
+func serveJSON(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerFailures(t *testing.T) {
+	r := newHandlerRouter(t)
+
+	encrypted := serveJSON(r, http.MethodPost, "/encrypt", `{"uri":"facility/north/bin/7","message":"fill 80%"}`)
+	if encrypted.Code != http.StatusOK {
+		t.Fatalf("expected the encryption to succeed, got %d %s", encrypted.Code, encrypted.Body)
+	}
+	var ciphertext client.EncryptResponse
+	if err := json.Unmarshal(encrypted.Body.Bytes(), &ciphertext); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := `{"uri":"fleet/truck-7","startTime":1565119330,"endTime":1565219330}`
+	revokedKeyID := GenerateKeyID(TestHierarchy, "fleet/truck-7", time.Unix(1565119330, 0), time.Unix(1565219330, 0))
+	if w := serveJSON(r, http.MethodPost, "/revoke", `{"keyId":"`+revokedKeyID+`","reason":"vehicle retired"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected the revocation to succeed, got %d %s", w.Code, w.Body)
+	}
+
+	longURI := strings.Repeat("x/", serverConfig.HIBE.PatternSize) + "x"
+	for _, tc := range []struct {
+		name         string
+		method, path string
+		body         string
+		status       int
+		code         string
+		keyID        string
+	}{
+		{"encrypt malformed body", http.MethodPost, "/encrypt", `{"uri":`, 400, client.CodeInvalidRequest, ""},
+		{"encrypt without message", http.MethodPost, "/encrypt", `{"uri":"a/b/c"}`, 400, client.CodeInvalidRequest, ""},
+		{"encrypt unencodable URI", http.MethodPost, "/encrypt", `{"uri":"` + longURI + `","message":"m"}`, 400, client.CodeInvalidURI, ""},
+		{"decrypt without key", http.MethodPost, "/decrypt", `{"uri":"a/b/c","encryptedMessage":"AAAA"}`, 400, client.CodeInvalidRequest, ""},
+		{"decrypt non-base64", http.MethodPost, "/decrypt", `{"uri":"a/b/c","encryptedMessage":"not base64!","key":"k"}`, 400, client.CodeInvalidCiphertext, ""},
+		{"decrypt truncated ciphertext", http.MethodPost, "/decrypt", `{"uri":"facility/north/bin/7","encryptedMessage":"` + ciphertext.Data[:16] + `","key":"k"}`, 400, client.CodeInvalidCiphertext, ""},
+		{"decrypt under another URI", http.MethodPost, "/decrypt", `{"uri":"facility/south/bin/7","encryptedMessage":"` + ciphertext.Data + `","key":"k"}`, 403, client.CodeDecryptionFailed, ""},
+		{"delegate reversed window", http.MethodPost, "/hibe-delegate", `{"uri":"a/b","startTime":20,"endTime":10}`, 400, client.CodeInvalidRequest, ""},
+		{"delegate unencodable URI", http.MethodPost, "/hibe-delegate", `{"uri":"` + longURI + `","startTime":10,"endTime":20}`, 400, client.CodeInvalidURI, ""},
+		{"delegate revoked key", http.MethodPost, "/hibe-delegate", revoked, 403, client.CodeKeyRevoked, revokedKeyID},
+		{"decrypt with revoked key", http.MethodPost, "/decrypt-with-revocation", `{"uri":"fleet/truck-7","encryptedMessage":"` + ciphertext.Data + `","startTime":1565119330,"endTime":1565219330}`, 403, client.CodeKeyRevoked, ""},
+		{"unknown delegation", http.MethodGet, "/delegations/unknown", "", 404, client.CodeNotFound, ""},
+		{"revoke twice", http.MethodPost, "/revoke", `{"keyId":"` + revokedKeyID + `","reason":"again"}`, 409, client.CodeAlreadyRevoked, revokedKeyID},
+	} {
+		w := serveJSON(r, tc.method, tc.path, tc.body)
+		var body ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s: expected an error body, got %s", tc.name, w.Body)
+			continue
+		}
+		if w.Code != tc.status || body.Code != tc.code || body.Message == "" {
+			t.Errorf("%s: expected %d %s, got %d %+v", tc.name, tc.status, tc.code, w.Code, body)
+		}
+		if keyID, _ := body.Details["keyId"].(string); keyID != tc.keyID {
+			t.Errorf("%s: expected keyId %q in the details, got %q", tc.name, tc.keyID, keyID)
+		}
+	}
+}
+
+func TestEncryptDoesNotDecrypt(t *testing.T) {
+	r := newHandlerRouter(t)
+	if w := serveJSON(r, http.MethodPost, "/encrypt", `{"uri":"a/b/c","message":"m"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected the encryption to succeed, got %d %s", w.Code, w.Body)
+	}
+
+	w := serveJSON(r, http.MethodGet, "/metrics", "")
+	if !strings.Contains(w.Body.String(), `operation="encrypt"`) {
+		t.Fatalf("expected the encryption to be recorded, got %s", w.Body)
+	}
+	if strings.Contains(w.Body.String(), `operation="decrypt"`) {
+		t.Error("expected /encrypt to leave its ciphertext undecrypted")
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{decryptError(errors.New("no key for pattern")), http.StatusForbidden, client.CodeDecryptionFailed},
+		{decryptError(context.Canceled), http.StatusServiceUnavailable, client.CodeCancelled},
+		{fmt.Errorf("decryption failed: %w", decryptError(context.DeadlineExceeded)), http.StatusGatewayTimeout, client.CodeTimeout},
+		{checkCiphertext(make([]byte, 8)), http.StatusBadRequest, client.CodeInvalidCiphertext},
+		{errors.New("key store unreachable"), http.StatusInternalServerError, client.CodeInternal},
+	} {
+		if status, code := errorStatus(tc.err); status != tc.status || code != tc.code {
+			t.Errorf("%v: expected %d %s, got %d %s", tc.err, tc.status, tc.code, status, code)
+		}
+	}
+}
+
+func TestInternalErrorsAreNotLeaked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(telemetry.Middleware(tracerName, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	r.GET("/fail", func(c *gin.Context) {
+		respondError(c, errors.New("key store at /var/lib/hibe unreachable"), gin.H{"keyId": "k-1"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(telemetry.RequestIDHeader, "bin-7-r42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusInternalServerError || body.Code != client.CodeInternal {
+		t.Errorf("expected a 500 internal error, got %d %+v", w.Code, body)
+	}
+	if strings.Contains(w.Body.String(), "/var/lib/hibe") {
+		t.Errorf("expected the cause to stay in the logs, got %s", w.Body)
+	}
+	if body.Details["requestId"] != "bin-7-r42" || body.Details["keyId"] != "k-1" {
+		t.Errorf("expected the request ID and details, got %v", body.Details)
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"hibe-api/config"
+)
+
+// readinessTimeout bounds each readiness check
+const readinessTimeout = 3 * time.Second
+
+// draining is set once shutdown starts, so /readyz turns traffic away while
+// in-flight requests finish
+var draining atomic.Bool
+
+// readinessCheck is one dependency /readyz reports on
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// readinessChecks lists what must work before the server takes traffic: the
+// key store, the revocation store and, with check_backends, the chain and IPFS
+func readinessChecks(cfg *config.Config, store *TestKeyStore) []readinessCheck {
+	checks := []readinessCheck{
+		{name: "key_store", check: func(ctx context.Context) error {
+			if store == nil || store.params == nil || store.master == nil {
+				return errors.New("master key not loaded")
+			}
+			return nil
+		}},
+		{name: "revocation_store", check: func(ctx context.Context) error {
+			if globalRevocationList == nil {
+				return errors.New("revocation list not loaded")
+			}
+			if cfg.State.RevocationsFile != "" {
+				return checkWritableDir(filepath.Dir(cfg.State.RevocationsFile))
+			}
+			return nil
+		}},
+	}
+	if cfg.Server.CheckBackends && cfg.Chain.RPCEndpoint != "" {
+		endpoint := cfg.Chain.RPCEndpoint
+		checks = append(checks, readinessCheck{name: "chain", check: func(ctx context.Context) error {
+			return checkChain(ctx, endpoint)
+		}})
+	}
+	if cfg.Server.CheckBackends && cfg.IPFS.APIEndpoint != "" {
+		endpoint := cfg.IPFS.APIEndpoint
+		checks = append(checks, readinessCheck{name: "ipfs", check: func(ctx context.Context) error {
+			_, err := postForStatus(ctx, strings.TrimSuffix(endpoint, "/")+"/api/v0/version", nil)
+			return err
+		}})
+	}
+	return checks
+}
+
+// registerHealthEndpoints adds GET /healthz, which answers while the process
+// serves, and GET /readyz, which answers 200 only while every check passes
+func registerHealthEndpoints(r *gin.Engine, checks []readinessCheck) {
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	r.GET("/readyz", func(c *gin.Context) {
+		if draining.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+			return
+		}
+
+		results := make(map[string]string, len(checks))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, rc := range checks {
+			wg.Add(1)
+			go func(rc readinessCheck) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+				defer cancel()
+				result := "ok"
+				if err := rc.check(ctx); err != nil {
+					result = err.Error()
+				}
+				mu.Lock()
+				results[rc.name] = result
+				mu.Unlock()
+			}(rc)
+		}
+		wg.Wait()
+
+		for _, result := range results {
+			if result != "ok" {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": results})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": results})
+	})
+}
+
+// checkWritableDir reports whether state can be saved in dir
+func checkWritableDir(dir string) error {
+	probe, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("state directory is not writable: %v", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// checkChain asks an HTTP endpoint for the latest block, or dials a websocket one
+func checkChain(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "ws" || u.Scheme == "wss" {
+		host := u.Host
+		if u.Port() == "" {
+			port := "80"
+			if u.Scheme == "wss" {
+				port = "443"
+			}
+			host = net.JoinHostPort(u.Hostname(), port)
+		}
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	body, err := postForStatus(ctx, endpoint, []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`))
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("malformed JSON-RPC response: %v", err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("JSON-RPC error: %s", response.Error.Message)
+	}
+	return nil
+}
+
+// postForStatus posts body and returns the response, failing on a non-2xx status
+func postForStatus(ctx context.Context, endpoint string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s answered %s", endpoint, resp.Status)
+	}
+	return data, nil
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"hibe-api/config"
+)
+
+// probe serves one request to path and decodes the JSON answer
+func probe(t *testing.T, r http.Handler, path string) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s answered %q: %v", path, w.Body, err)
+	}
+	return w.Code, body
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Cleanup(func() { draining.Store(false) })
+
+	var failing error
+	r := gin.New()
+	registerHealthEndpoints(r, []readinessCheck{
+		{name: "always", check: func(ctx context.Context) error { return nil }},
+		{name: "flaky", check: func(ctx context.Context) error { return failing }},
+	})
+
+	if code, _ := probe(t, r, "/healthz"); code != http.StatusOK {
+		t.Errorf("expected /healthz to answer 200, got %d", code)
+	}
+	if code, body := probe(t, r, "/readyz"); code != http.StatusOK || body["status"] != "ready" {
+		t.Errorf("expected ready, got %d %v", code, body)
+	}
+
+	failing = errors.New("backend down")
+	code, body := probe(t, r, "/readyz")
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected a failing check to answer 503, got %d", code)
+	}
+	checks, _ := body["checks"].(map[string]interface{})
+	if checks["flaky"] != "backend down" || checks["always"] != "ok" {
+		t.Errorf("expected each check's result, got %v", body)
+	}
+
+	// Draining turns traffic away while liveness still holds
+	failing = nil
+	draining.Store(true)
+	if code, body := probe(t, r, "/readyz"); code != http.StatusServiceUnavailable || body["status"] != "draining" {
+		t.Errorf("expected draining to fail readiness, got %d %v", code, body)
+	}
+	if code, _ := probe(t, r, "/healthz"); code != http.StatusOK {
+		t.Errorf("expected /healthz to answer 200 while draining, got %d", code)
+	}
+}
+
+func TestReadinessChecksBackends(t *testing.T) {
+	chainUp := true
+	chain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !chainUp {
+			io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"syncing"}}`)
+			return
+		}
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+	}))
+	defer chain.Close()
+	ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v0/version" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"Version":"0.18.0"}`)
+	}))
+	defer ipfs.Close()
+
+	cfg := config.Default()
+	cfg.Chain.RPCEndpoint = chain.URL
+	cfg.IPFS.APIEndpoint = ipfs.URL
+	cfg.State.RevocationsFile = filepath.Join(t.TempDir(), "revocations.json")
+	_, store := NewTestKeyStore()
+
+	run := func() map[string]error {
+		results := make(map[string]error)
+		for _, rc := range readinessChecks(cfg, store) {
+			results[rc.name] = rc.check(context.Background())
+		}
+		return results
+	}
+
+	if results := run(); len(results) != 2 || results["key_store"] != nil || results["revocation_store"] != nil {
+		t.Errorf("expected only the local checks without check_backends, got %v", results)
+	}
+
+	cfg.Server.CheckBackends = true
+	for name, err := range run() {
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	chainUp = false
+	cfg.State.RevocationsFile = filepath.Join(t.TempDir(), "missing", "revocations.json")
+	results := run()
+	if results["chain"] == nil || results["revocation_store"] == nil || results["ipfs"] != nil {
+		t.Errorf("expected the chain and revocation store to fail, got %v", results)
+	}
+	if err := readinessChecks(cfg, nil)[0].check(context.Background()); err == nil {
+		t.Error("expected a missing key store to fail")
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"hibe-api/config"
+)
+
+// newHTTPServer applies the configured timeouts to handler
+func newHTTPServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         cfg.Listen,
+		Handler:      handler,
+		ReadTimeout:  time.Duration(cfg.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(cfg.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:  time.Duration(cfg.IdleTimeoutSeconds) * time.Second,
+	}
+}
+
+// serve runs srv on ln until ctx is done, then stops accepting connections and
+// gives in-flight requests up to grace to finish before closing them
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, grace time.Duration) error {
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ln) }()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	draining.Store(true)
+	shutdown, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		srv.Close()
+		return fmt.Errorf("requests still in flight after %v: %v", grace, err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// loadState restores the revocation list and delegation record saved by
+// flushState. Missing files are not an error, so a first start is clean.
+func loadState(cfg config.StateConfig) error {
+	if err := readStateFile(cfg.RevocationsFile, globalRevocationList); err != nil {
+		return fmt.Errorf("failed to load revocations: %v", err)
+	}
+	if err := readStateFile(cfg.DelegationsFile, globalDelegationRegistry); err != nil {
+		return fmt.Errorf("failed to load delegations: %v", err)
+	}
+	return nil
+}
+
+// flushState saves the revocation list and delegation record, trying both
+func flushState(cfg config.StateConfig) error {
+	revocationsErr := writeStateFile(cfg.RevocationsFile, globalRevocationList)
+	if revocationsErr != nil {
+		revocationsErr = fmt.Errorf("failed to save revocations: %v", revocationsErr)
+	}
+	delegationsErr := writeStateFile(cfg.DelegationsFile, globalDelegationRegistry)
+	if delegationsErr != nil {
+		delegationsErr = fmt.Errorf("failed to save delegations: %v", delegationsErr)
+	}
+	if revocationsErr != nil {
+		return revocationsErr
+	}
+	return delegationsErr
+}
+
+func readStateFile(path string, v json.Unmarshaler) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeStateFile(path string, v json.Marshaler) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write beside the target, sync and rename so a crash never leaves a truncated file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hibe-api/config"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	t.Cleanup(func() { draining.Store(false) })
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		io.WriteString(w, "done")
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- serve(ctx, newHTTPServer(config.Default().Server, handler), ln, 5*time.Second) }()
+
+	answered := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			answered <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		answered <- string(body)
+	}()
+	<-entered
+
+	// Shutdown waits for the request in flight
+	cancel()
+	select {
+	case err := <-stopped:
+		t.Fatalf("serve returned with a request in flight: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if !draining.Load() {
+		t.Error("expected readiness to report draining")
+	}
+
+	close(release)
+	if body := <-answered; body != "done" {
+		t.Errorf("expected the in-flight request to complete, got %q", body)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+	if _, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+		t.Error("expected the listener to be closed")
+	}
+}
+
+func TestServeGivesUpAfterGrace(t *testing.T) {
+	t.Cleanup(func() { draining.Store(false) })
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- serve(ctx, newHTTPServer(config.Default().Server, handler), ln, 50*time.Millisecond)
+	}()
+	go http.Get("http://" + ln.Addr().String() + "/stuck")
+	<-entered
+
+	cancel()
+	if err := <-stopped; err == nil {
+		t.Error("expected an unfinished drain to be reported")
+	}
+}
+
+func TestStateSurvivesRestart(t *testing.T) {
+	previousRevocations, previousDelegations := globalRevocationList, globalDelegationRegistry
+	t.Cleanup(func() { globalRevocationList, globalDelegationRegistry = previousRevocations, previousDelegations })
+	fresh := func() {
+		globalRevocationList = NewRevocationList()
+		globalDelegationRegistry = &DelegationRegistry{delegations: make(map[string]*DelegationInfo)}
+	}
+
+	dir := t.TempDir()
+	cfg := config.StateConfig{
+		RevocationsFile: filepath.Join(dir, "revocations.json"),
+		DelegationsFile: filepath.Join(dir, "delegations.json"),
+	}
+
+	// A first start finds no state
+	fresh()
+	if err := loadState(cfg); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	if err := globalRevocationList.RevokeKey(&RevocationEntry{KeyID: "k-1", URI: "facility/north/bin/7", RevokedBy: "ops", Reason: "lost", EffectiveFrom: now}); err != nil {
+		t.Fatal(err)
+	}
+	globalDelegationRegistry.RecordDelegation(&DelegationInfo{KeyID: "k-1", URI: "facility/north/bin/7", CreatedAt: now, UsageCount: 3})
+	if err := flushState(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh()
+	if err := loadState(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !globalRevocationList.IsKeyRevoked("k-1") {
+		t.Error("expected the revocation to survive a restart")
+	}
+	if entries := globalRevocationList.GetRevocationsByURI("facility/north/bin/7"); len(entries) != 1 || entries[0].Reason != "lost" {
+		t.Errorf("expected the URI index to be rebuilt, got %v", entries)
+	}
+	if info, ok := globalDelegationRegistry.GetDelegation("k-1"); !ok || info.UsageCount != 3 {
+		t.Errorf("expected the delegation record to survive a restart, got %+v", info)
+	}
+
+	// Flushing without files configured is a no-op
+	if err := flushState(config.StateConfig{}); err != nil {
+		t.Error(err)
+	}
+}
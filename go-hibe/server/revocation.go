@@ -1,25 +1,20 @@
-package main
+package server
 
 import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
+
+	"hibe-api/client"
 )
 
 // RevocationEntry represents a revoked key entry
-type RevocationEntry struct {
-	KeyID          string    `json:"keyId"`          // Unique identifier for the delegated key
-	URI            string    `json:"uri"`            // The URI pattern that was delegated
-	Hierarchy      string    `json:"hierarchy"`      // The hierarchy of the key
-	RevokedAt      time.Time `json:"revokedAt"`      // When the key was revoked
-	RevokedBy      string    `json:"revokedBy"`      // Who revoked the key (optional)
-	Reason         string    `json:"reason"`         // Reason for revocation
-	EffectiveFrom  time.Time `json:"effectiveFrom"`  // When revocation takes effect
-	EffectiveUntil time.Time `json:"effectiveUntil"` // When revocation expires (optional, 0 means permanent)
-}
+type RevocationEntry = client.RevocationEntry
 
 // RevocationList manages all revoked keys
 type RevocationList struct {
@@ -185,7 +180,7 @@ func (rl *RevocationList) GetActiveRevocations() []*RevocationEntry {
 	now := time.Now()
 	var entries []*RevocationEntry
 
-	for keyID, entry := range rl.revocations {
+	for _, entry := range rl.revocations {
 		// Check if currently effective
 		if now.After(entry.EffectiveFrom) || now.Equal(entry.EffectiveFrom) {
 			// Check if not expired
@@ -261,6 +256,42 @@ func (rl *RevocationList) ClearRevocation(keyID string) error {
 	return nil
 }
 
+// MarshalJSON encodes every revocation, so the list can be saved
+func (rl *RevocationList) MarshalJSON() ([]byte, error) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	entries := make([]*RevocationEntry, 0, len(rl.revocations))
+	for _, entry := range rl.revocations {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].KeyID < entries[j].KeyID })
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON adds saved revocations to the list, keeping any it already holds
+func (rl *RevocationList) UnmarshalJSON(data []byte) error {
+	var entries []*RevocationEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for _, entry := range entries {
+		if entry == nil || entry.KeyID == "" {
+			return fmt.Errorf("revocation without a key ID")
+		}
+		if _, exists := rl.revocations[entry.KeyID]; exists {
+			continue
+		}
+		rl.revocations[entry.KeyID] = entry
+		rl.uriIndex[entry.URI] = append(rl.uriIndex[entry.URI], entry.KeyID)
+	}
+	return nil
+}
+
 // RevocationStats provides statistics about the revocation list
 type RevocationStats struct {
 	TotalRevocations   int       `json:"totalRevocations"`
@@ -296,18 +327,8 @@ func (rl *RevocationList) GetStats() RevocationStats {
 	return stats
 }
 
-// ValidateRevocationRequest validates a revocation request
-type RevocationRequest struct {
-	KeyID         string    `json:"keyId,omitempty"`
-	URI           string    `json:"uri,omitempty"`
-	Hierarchy     string    `json:"hierarchy,omitempty"`
-	RevokedBy     string    `json:"revokedBy"`
-	Reason        string    `json:"reason"`
-	EffectiveFrom time.Time `json:"effectiveFrom,omitempty"`
-	EffectiveFor  int64     `json:"effectiveFor,omitempty"` // Duration in seconds, 0 means permanent
-	StartTime     int64     `json:"startTime,omitempty"`    // Unix timestamp for key delegation start
-	EndTime       int64     `json:"endTime,omitempty"`      // Unix timestamp for key delegation end
-}
+// RevocationRequest is the body of POST /revoke
+type RevocationRequest = client.RevocationRequest
 
 // ValidateRevocationRequest validates the revocation request
 func ValidateRevocationRequest(req *RevocationRequest) error {
@@ -368,8 +389,8 @@ func CheckRevocationWithContext(ctx context.Context, rl *RevocationList, keyID s
 
 	if rl.IsKeyRevoked(keyID) {
 		entry := rl.revocations[keyID]
-		return fmt.Errorf("key revoked: %s (reason: %s, revoked by: %s)",
-			entry.KeyID, entry.Reason, entry.RevokedBy)
+		return withKind(errKeyRevoked, fmt.Errorf("key revoked: %s (reason: %s, revoked by: %s)",
+			entry.KeyID, entry.Reason, entry.RevokedBy))
 	}
 
 	return nil
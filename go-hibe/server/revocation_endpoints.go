@@ -1,10 +1,13 @@
-package main
+package server
 
 import (
 	"fmt"
 	"net/http"
 	"time"
 
+	"hibe-api/client"
+	"hibe-api/metrics"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,40 +18,32 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 	r.POST("/revoke", func(c *gin.Context) {
 		var req RevocationRequest
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Invalid request: %v", err),
-			})
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
 			return
 		}
 
 		// Create revocation entry from request
 		entry, err := CreateRevocationFromRequest(&req)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   err.Error(),
-			})
+			respondError(c, withKind(errInvalidRequest, err), nil)
 			return
 		}
 
 		// Add to revocation list
 		if err := globalRevocationList.RevokeKey(entry); err != nil {
-			c.JSON(http.StatusConflict, gin.H{
-				"success": false,
-				"error":   err.Error(),
-			})
+			respondError(c, withKind(errAlreadyRevoked, err), gin.H{"keyId": entry.KeyID})
 			return
 		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"success":  true,
-			"message":  "Key revoked successfully",
-			"keyId":    entry.KeyID,
-			"revokedAt": entry.RevokedAt,
-			"effectiveFrom": entry.EffectiveFrom,
-			"effectiveUntil": entry.EffectiveUntil,
+		serverMetrics.RecordRevocations(metrics.RevocationScopeKey, 1)
+
+		c.JSON(http.StatusOK, client.RevokeResponse{
+			Success:        true,
+			Message:        "Key revoked successfully",
+			KeyID:          entry.KeyID,
+			RevokedAt:      entry.RevokedAt,
+			EffectiveFrom:  entry.EffectiveFrom,
+			EffectiveUntil: entry.EffectiveUntil,
 		})
 	})
 
@@ -60,22 +55,17 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 			Reason    string `json:"reason" binding:"required"`
 		}
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Invalid request: %v", err),
-			})
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
 			return
 		}
 
 		count, err := globalRevocationList.RevokeByURI(req.URI, req.RevokedBy, req.Reason)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   err.Error(),
-			})
+			respondError(c, withKind(errNotFound, err), nil)
 			return
 		}
+		serverMetrics.RecordRevocations(metrics.RevocationScopeURI, count)
 
 		c.JSON(http.StatusOK, gin.H{
 			"success":      true,
@@ -91,20 +81,16 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 
 		isRevoked := globalRevocationList.IsKeyRevoked(keyID)
 
-		response := gin.H{
-			"keyId":     keyID,
-			"isRevoked": isRevoked,
+		response := client.RevocationCheckResponse{
+			KeyID:     keyID,
+			IsRevoked: isRevoked,
 		}
 
 		if isRevoked {
 			// Get revocation details
 			globalRevocationList.mu.RLock()
-			entry := globalRevocationList.revocations[keyID]
+			response.RevocationDetails = globalRevocationList.revocations[keyID]
 			globalRevocationList.mu.RUnlock()
-
-			if entry != nil {
-				response["revocationDetails"] = entry
-			}
 		}
 
 		c.JSON(http.StatusOK, response)
@@ -112,18 +98,10 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 
 	// POST /revoke/check - Check revocation for key parameters
 	r.POST("/revoke/check", func(c *gin.Context) {
-		var req struct {
-			Hierarchy string `json:"hierarchy" binding:"required"`
-			URI       string `json:"uri" binding:"required"`
-			StartTime int64  `json:"startTime" binding:"required"`
-			EndTime   int64  `json:"endTime" binding:"required"`
-		}
+		var req client.RevocationCheckRequest
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Invalid request: %v", err),
-			})
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
 			return
 		}
 
@@ -137,16 +115,16 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 			end,
 		)
 
-		response := gin.H{
-			"isRevoked": isRevoked,
-			"hierarchy": req.Hierarchy,
-			"uri":       req.URI,
-			"startTime": req.StartTime,
-			"endTime":   req.EndTime,
+		response := client.RevocationCheckResponse{
+			IsRevoked: isRevoked,
+			Hierarchy: req.Hierarchy,
+			URI:       req.URI,
+			StartTime: req.StartTime,
+			EndTime:   req.EndTime,
 		}
 
-		if isRevoked && entry != nil {
-			response["revocationDetails"] = entry
+		if isRevoked {
+			response.RevocationDetails = entry
 		}
 
 		c.JSON(http.StatusOK, response)
@@ -192,10 +170,7 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 		keyID := c.Param("keyId")
 
 		if err := globalRevocationList.ClearRevocation(keyID); err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   err.Error(),
-			})
+			respondError(c, withKind(errNotFound, err), gin.H{"keyId": keyID})
 			return
 		}
 
@@ -234,11 +209,8 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 			EndTime   int64  `json:"endTime" binding:"required"`
 		}
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   fmt.Sprintf("Invalid request: %v", err),
-			})
+		if err := bindRequest(c, &req); err != nil {
+			respondError(c, err, nil)
 			return
 		}
 
@@ -259,16 +231,22 @@ func RegisterRevocationEndpoints(r *gin.Engine) {
 
 // Helper function to integrate revocation checking into delegation endpoint
 func checkAndRecordDelegation(hierarchy []byte, uri string, start, end time.Time) (string, error) {
+	return checkDelegationRevocation(globalRevocationList, hierarchy, uri, start, end)
+}
+
+// checkDelegationRevocation returns the key ID of a delegation, or an error if
+// that key is revoked in rl
+func checkDelegationRevocation(rl *RevocationList, hierarchy []byte, uri string, start, end time.Time) (string, error) {
 	// Generate key ID for this delegation
 	keyID := GenerateKeyID(hierarchy, uri, start, end)
 
 	// Check if this key is revoked
-	if globalRevocationList.IsKeyRevoked(keyID) {
-		globalRevocationList.mu.RLock()
-		entry := globalRevocationList.revocations[keyID]
-		globalRevocationList.mu.RUnlock()
+	if rl.IsKeyRevoked(keyID) {
+		rl.mu.RLock()
+		entry := rl.revocations[keyID]
+		rl.mu.RUnlock()
 
-		return keyID, fmt.Errorf("cannot delegate: key is revoked (reason: %s)", entry.Reason)
+		return keyID, withKind(errKeyRevoked, fmt.Errorf("cannot delegate: key is revoked (reason: %s)", entry.Reason))
 	}
 
 	return keyID, nil
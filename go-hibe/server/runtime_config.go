@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+	"hibe-api/analysis"
+	"hibe-api/config"
+)
+
+// serverConfig is the configuration the server runs with. main replaces it
+// through applyConfig before anything is built; it is read-only afterwards.
+var serverConfig = config.Default()
+
+// applyConfig makes cfg the running configuration
+func applyConfig(cfg *config.Config) {
+	serverConfig = cfg
+	TestHierarchy = []byte(cfg.HIBE.Hierarchy)
+	defaultPowerConstants = powerConstantsFromConfig(cfg.Power)
+}
+
+func powerConstantsFromConfig(power config.PowerConfig) PowerConstants {
+	return PowerConstants{
+		cpuFactor:    power.CPUFactor,
+		memoryFactor: power.MemoryFactor,
+		basePower:    power.BaseWatts,
+	}
+}
+
+// batchWorkers is the number of workers a batch delegation runs on
+func batchWorkers() int {
+	if serverConfig.Limits.BatchWorkers > 0 {
+		return serverConfig.Limits.BatchWorkers
+	}
+	return runtime.NumCPU()
+}
+
+// runtimeHyperparameters reports the configured values as hyperparameters, so
+// experiments record what the server actually ran with
+func runtimeHyperparameters(cfg *config.Config) *analysis.SystemHyperparameters {
+	params := analysis.DefaultHyperparameters()
+	params.ConfigurationName = "Runtime"
+	params.PatternSize = cfg.HIBE.PatternSize
+	params.MaxConcurrentOps = cfg.Limits.MaxConcurrentRequests
+	params.BasePowerWatts = cfg.Power.BaseWatts
+	params.CPUPowerFactor = cfg.Power.CPUFactor
+	params.MemoryPowerFactor = cfg.Power.MemoryFactor
+	params.ReplicationFactor = cfg.IPFS.ReplicationFactor
+	params.GasLimit = cfg.Chain.GasLimit
+	params.GasPrice = cfg.Chain.GasPriceGwei
+	params.BlockConfirmations = cfg.Chain.Confirmations
+	params.SmartContractAddr = cfg.Chain.ContractAddress
+	params.PolygonRPCEndpoint = cfg.Chain.RPCEndpoint
+	return params
+}
+
+// limitConcurrency answers 503 once limit requests are in flight. Metrics and
+// probes stay reachable so an overloaded server can still be observed. A limit
+// of 0 turns the middleware off.
+func limitConcurrency(limit int) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	slots := make(chan struct{}, limit)
+	return func(c *gin.Context) {
+		switch c.FullPath() {
+		case "/metrics", "/healthz", "/readyz":
+			c.Next()
+			return
+		}
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			c.Next()
+		default:
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": "server is at its concurrent request limit"})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"hibe"
+	"hibe-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConfigEndpointRedactsSecrets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := serverConfig
+	t.Cleanup(func() { applyConfig(previous) })
+
+	cfg := config.Default()
+	cfg.HIBE.Hierarchy = "configured"
+	cfg.Chain.PrivateKey = strings.Repeat("ab", 32)
+	cfg.Access.OwnerToken = "owner-secret"
+	applyConfig(cfg)
+	if string(TestHierarchy) != "configured" {
+		t.Errorf("expected the hierarchy to follow the config, got %q", TestHierarchy)
+	}
+
+	ctx := context.Background()
+	_, store := NewTestKeyStore()
+	encoder := hibe.NewDefaultPatternEncoder(serverConfig.HIBE.PatternSize - hibe.MaxTimeLength)
+	r := newRouter(ctx, store, encoder, NewTestState())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), cfg.Chain.PrivateKey) || strings.Contains(w.Body.String(), "owner-secret") {
+		t.Errorf("/config leaks a secret: %s", w.Body)
+	}
+	var served config.Config
+	if err := json.Unmarshal(w.Body.Bytes(), &served); err != nil {
+		t.Fatal(err)
+	}
+	if served.HIBE.Hierarchy != "configured" || served.Limits != cfg.Limits {
+		t.Errorf("unexpected config served %+v", served)
+	}
+}
+
+func TestLimitConcurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(limitConcurrency(1))
+	entered, release := make(chan struct{}), make(chan struct{})
+	r.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	r.GET("/metrics", func(c *gin.Context) { c.String(http.StatusOK, "metrics") })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 503 with Retry-After over the limit, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected metrics to bypass the limit, got %d", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+}
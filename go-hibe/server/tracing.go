@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"hibe-api/telemetry"
+)
+
+// tracerName names the server's spans
+const tracerName = "hibe-api"
+
+// hibeOperation runs fn, one HIBE operation on uri, in a span of its own. The
+// latency and outcome go to the metrics, and a failure is logged with the
+// request's ID and returned.
+func hibeOperation(ctx context.Context, operation, uri string, fn func(ctx context.Context) error) (time.Duration, error) {
+	ctx, span := telemetry.Tracer(tracerName).Start(ctx, "hibe."+operation,
+		trace.WithAttributes(attribute.String("hibe.operation", operation), attribute.String("hibe.uri", uri)))
+	defer span.End()
+
+	start := time.Now()
+	err := fn(ctx)
+	elapsed := time.Since(start)
+	serverMetrics.ObserveOperation(operation, elapsed, err)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "hibe operation failed", "operation", operation, "uri", uri, "error", err)
+	} else {
+		slog.DebugContext(ctx, "hibe operation", "operation", operation, "uri", uri, "duration", elapsed)
+	}
+	return elapsed, err
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"hibe-api/metrics"
+	"hibe-api/telemetry"
+)
+
+func TestHIBEOperationLogsFailures(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	var logs bytes.Buffer
+	logger, err := telemetry.NewLogger(&logs, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	ctx := telemetry.WithRequestID(context.Background(), "bin-7-reading")
+	var seen string
+	if _, err := hibeOperation(ctx, metrics.OperationEncrypt, "facility/north/bin/7", func(ctx context.Context) error {
+		seen = telemetry.RequestID(ctx)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if seen != "bin-7-reading" {
+		t.Errorf("expected the operation to run with the request's context, got %q", seen)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected a success to log nothing at info, got %s", logs.String())
+	}
+
+	failure := errors.New("no key for pattern")
+	if _, err := hibeOperation(ctx, metrics.OperationDecrypt, "facility/north/bin/7", func(context.Context) error { return decryptError(failure) }); !errors.Is(err, failure) || !errors.Is(err, errUndecryptable) {
+		t.Fatalf("expected the operation's error, got %v", err)
+	}
+	for _, want := range []string{`"request_id":"bin-7-reading"`, `"operation":"decrypt"`, `"error":"no key for pattern"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("expected %s in %s", want, logs.String())
+		}
+	}
+}
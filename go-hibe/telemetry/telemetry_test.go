@@ -0,0 +1,143 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const remoteParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// setupFileTracing exports spans to a file for the test, returning its path
+func setupFileTracing(t *testing.T) (string, func()) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := Setup(context.Background(), TracingConfig{ServiceName: "test", Exporter: ExporterFile, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	_, shutdown := setupFileTracing(t)
+	defer shutdown()
+
+	incoming := http.Header{}
+	incoming.Set(RequestIDHeader, "bin-7-reading")
+	incoming.Set("traceparent", remoteParent)
+	ctx := Extract(context.Background(), incoming)
+	if RequestID(ctx) != "bin-7-reading" {
+		t.Errorf("expected the caller's request ID, got %q", RequestID(ctx))
+	}
+
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+	if outgoing.Get(RequestIDHeader) != "bin-7-reading" || outgoing.Get("traceparent") != remoteParent {
+		t.Errorf("expected the request ID and trace to be passed on, got %v", outgoing)
+	}
+
+	// Missing or unprintable IDs are replaced
+	for _, id := range []string{"", "bad\nid", strings.Repeat("x", 200)} {
+		header := http.Header{}
+		header.Set(RequestIDHeader, id)
+		if got := RequestID(Extract(context.Background(), header)); got == id || len(got) != 32 {
+			t.Errorf("expected %q to be replaced, got %q", id, got)
+		}
+	}
+}
+
+func TestHandlerTagsRecords(t *testing.T) {
+	_, shutdown := setupFileTracing(t)
+	defer shutdown()
+
+	var out bytes.Buffer
+	logger, err := NewLogger(&out, "debug", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, span := Tracer("test").Start(WithRequestID(context.Background(), "r-1"), "op")
+	logger.With("component", "test").InfoContext(ctx, "delegated", "uri", "a/b/c")
+	span.End()
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["request_id"] != "r-1" || record["trace_id"] != span.SpanContext().TraceID().String() || record["component"] != "test" {
+		t.Errorf("unexpected record %v", record)
+	}
+
+	if _, err := NewLogger(&out, "loud", "json"); err == nil {
+		t.Error("expected an unknown level to be refused")
+	}
+	if _, err := NewLogger(&out, "info", "xml"); err == nil {
+		t.Error("expected an unknown format to be refused")
+	}
+}
+
+func TestMiddlewareJoinsCallerTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path, shutdown := setupFileTracing(t)
+
+	var logs bytes.Buffer
+	logger, _ := NewLogger(&logs, "info", "json")
+	r := gin.New()
+	r.Use(Middleware("test", logger))
+	var handlerSpan trace.SpanContext
+	var handlerID string
+	r.GET("/bins/:id", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		handlerID = RequestID(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/bins/7", nil)
+	req.Header.Set("traceparent", remoteParent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if handlerID == "" || w.Header().Get(RequestIDHeader) != handlerID {
+		t.Errorf("expected the generated request ID to be echoed, got %q and %q", handlerID, w.Header().Get(RequestIDHeader))
+	}
+	if handlerSpan.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the handler to run in the caller's trace, got %s", handlerSpan.TraceID())
+	}
+	if !strings.Contains(logs.String(), `"request_id":"`+handlerID+`"`) || !strings.Contains(logs.String(), `"status":204`) {
+		t.Errorf("unexpected request log %s", logs.String())
+	}
+
+	shutdown()
+	spans, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(spans), `"Name":"GET /bins/:id"`) || !strings.Contains(string(spans), "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Errorf("expected the server span in the trace file, got %s", spans)
+	}
+}
+
+func TestSetupRejectsBadExporters(t *testing.T) {
+	for _, cfg := range []TracingConfig{
+		{Exporter: "zipkin"},
+		{Exporter: ExporterOTLP, Endpoint: "collector:4318"},
+		{Exporter: ExporterFile, File: filepath.Join(t.TempDir(), "missing", "spans.json")},
+	} {
+		if _, err := Setup(context.Background(), cfg); err == nil {
+			t.Errorf("expected %+v to be refused", cfg)
+		}
+	}
+}
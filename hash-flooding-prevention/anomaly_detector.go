@@ -0,0 +1,600 @@
+package prevention
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AnomalyAction is the graduated response chosen for a request
+type AnomalyAction string
+
+const (
+	ActionAllow     AnomalyAction = "allow"
+	ActionSlowdown  AnomalyAction = "slowdown"
+	ActionChallenge AnomalyAction = "challenge"
+	ActionBlock     AnomalyAction = "block"
+)
+
+// Feature indices used by the baselines
+const (
+	featureRequestRate = iota
+	featureDistinctHashes
+	featureRepeatHashes
+	featureUnpinned
+	featureTypeMix
+	featureCount
+)
+
+var featureNames = [featureCount]string{
+	"request_rate",
+	"distinct_hash_ratio",
+	"repeat_hash_ratio",
+	"unpinned_ratio",
+	"request_type_mix",
+}
+
+// featureDirection is +1 when higher values are suspicious and -1 when lower values are
+var featureDirection = [featureCount]float64{1, 1, -1, 1, 1}
+
+// featureFloor bounds the standard deviation so tight baselines do not over-react
+var featureFloor = [featureCount]float64{1, 0.05, 0.05, 0.05, 0.05}
+
+// PinChecker reports whether a CID is pinned by one of our IPFS nodes
+type PinChecker interface {
+	IsPinned(cid string) bool
+}
+
+// PinnedCIDSet is an in-memory PinChecker fed from pin/unpin events
+type PinnedCIDSet struct {
+	pinned map[string]struct{}
+	mu     sync.RWMutex
+}
+
+// AnomalyDetector learns per-client and per-tier request baselines and flags hash floods
+type AnomalyDetector struct {
+	config    *AnomalyConfig
+	pins      PinChecker
+	clients   map[string]*clientProfile
+	tiers     map[string]*featureBaseline
+	decisions []*AnomalyDecision
+	tierStats map[string]*anomalyTierStats
+	nextID    int64
+	mu        sync.Mutex
+}
+
+// AnomalyConfig holds configuration for the anomaly detector
+type AnomalyConfig struct {
+	WindowSize      int           // recent requests per client used for ratio features
+	HistorySize     int           // hashes remembered per client for repeat detection
+	SmoothingFactor float64       // EWMA weight given to each new observation
+	WarmupRequests  int           // observations before a baseline is trusted
+	SlowdownScore   float64       // anomaly score that triggers a slowdown
+	ChallengeScore  float64       // anomaly score that requires a challenge
+	BlockScore      float64       // anomaly score that blocks the client
+	SlowdownDelay   time.Duration // delay imposed on slowed-down requests
+	BlockDuration   time.Duration // cooldown imposed on blocked clients
+	MaxDecisions    int           // flagged decisions retained for false positive analysis
+}
+
+// AnomalyFeatures are the per-request features the detector scores
+type AnomalyFeatures struct {
+	RequestRate       float64 `json:"request_rate"`
+	DistinctHashRatio float64 `json:"distinct_hash_ratio"`
+	RepeatHashRatio   float64 `json:"repeat_hash_ratio"`
+	UnpinnedRatio     float64 `json:"unpinned_ratio"`
+	TypeMixDivergence float64 `json:"type_mix_divergence"`
+}
+
+// AnomalyDecision records a non-allow response for later false positive assessment
+type AnomalyDecision struct {
+	ID            string          `json:"id"`
+	ClientID      string          `json:"client_id"`
+	Tier          string          `json:"tier"`
+	Action        AnomalyAction   `json:"action"`
+	Score         float64         `json:"score"`
+	Reasons       []string        `json:"reasons"`
+	Features      AnomalyFeatures `json:"features"`
+	Timestamp     time.Time       `json:"timestamp"`
+	FalsePositive bool            `json:"false_positive"`
+	Reviewed      bool            `json:"reviewed"`
+}
+
+// clientProfile holds the sliding window and learned baseline for one client
+type clientProfile struct {
+	window      []requestSample
+	next        int
+	filled      bool
+	seen        map[string]struct{}
+	seenOrder   []string
+	typeMix     map[string]float64
+	lastRequest time.Time
+	rateEWMA    float64
+	baseline    *featureBaseline
+}
+
+// requestSample is one entry of a client's sliding window
+type requestSample struct {
+	hash        string
+	requestType string
+	repeat      bool
+	unpinned    bool
+}
+
+// featureBaseline tracks an EWMA mean and variance per feature
+type featureBaseline struct {
+	mean     [featureCount]float64
+	variance [featureCount]float64
+	samples  int64
+}
+
+// anomalyTierStats accumulates per-tier counts for the false positive assessment
+type anomalyTierStats struct {
+	observed       int64
+	flagged        int64
+	falsePositives int64
+	mitigation     time.Duration
+}
+
+// DefaultAnomalyConfig returns the detector settings used by the service
+func DefaultAnomalyConfig() *AnomalyConfig {
+	return &AnomalyConfig{
+		WindowSize:      64,
+		HistorySize:     1024,
+		SmoothingFactor: 0.05,
+		WarmupRequests:  32,
+		SlowdownScore:   3,
+		ChallengeScore:  5,
+		BlockScore:      8,
+		SlowdownDelay:   250 * time.Millisecond,
+		BlockDuration:   5 * time.Minute,
+		MaxDecisions:    10000,
+	}
+}
+
+// NewAnomalyDetector creates a new anomaly detector; pins may be nil
+func NewAnomalyDetector(config *AnomalyConfig, pins PinChecker) *AnomalyDetector {
+	if config == nil {
+		config = DefaultAnomalyConfig()
+	}
+
+	return &AnomalyDetector{
+		config:    config,
+		pins:      pins,
+		clients:   make(map[string]*clientProfile),
+		tiers:     make(map[string]*featureBaseline),
+		decisions: make([]*AnomalyDecision, 0),
+		tierStats: make(map[string]*anomalyTierStats),
+	}
+}
+
+// SetPinChecker replaces the pin checker used to recognise never-pinned CIDs
+func (ad *AnomalyDetector) SetPinChecker(pins PinChecker) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	ad.pins = pins
+}
+
+// Evaluate scores a request against the learned baselines and picks a response.
+// Only requests answered with ActionAllow are folded back into the baselines, so a
+// flooding client cannot train the detector to accept its own traffic.
+func (ad *AnomalyDetector) Evaluate(clientID, tier, hashValue, requestType string, now time.Time) *AnomalyDecision {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	profile := ad.profileFor(clientID)
+	features := ad.observe(profile, hashValue, requestType, now)
+	vector := features.vector()
+
+	tierBaseline, exists := ad.tiers[tier]
+	if !exists {
+		tierBaseline = &featureBaseline{}
+		ad.tiers[tier] = tierBaseline
+	}
+
+	// New clients are judged against their tier until their own baseline warms up
+	baseline := profile.baseline
+	if baseline.samples < int64(ad.config.WarmupRequests) {
+		baseline = tierBaseline
+	}
+
+	score := 0.0
+	reasons := make([]string, 0)
+	if baseline.samples >= int64(ad.config.WarmupRequests) {
+		for i := 0; i < featureCount; i++ {
+			z := baseline.deviation(i, vector[i])
+			if z >= ad.config.SlowdownScore {
+				reasons = append(reasons, featureNames[i])
+			}
+			score += z * z
+		}
+		score = math.Sqrt(score)
+	}
+
+	// A window dominated by distinct, never-pinned CIDs is a flood whatever the baseline says
+	if ad.pins != nil && profile.windowLen() >= ad.config.WindowSize/2 &&
+		features.UnpinnedRatio > 0.9 && features.DistinctHashRatio > 0.9 {
+		reasons = append(reasons, "random_cid_flood")
+		score = math.Max(score, ad.config.ChallengeScore)
+	}
+
+	decision := &AnomalyDecision{
+		ClientID:  clientID,
+		Tier:      tier,
+		Action:    ad.actionForScore(score),
+		Score:     score,
+		Reasons:   reasons,
+		Features:  features,
+		Timestamp: now,
+	}
+
+	stats := ad.statsFor(tier)
+	stats.observed++
+
+	if decision.Action == ActionAllow {
+		profile.baseline.update(vector, ad.config.SmoothingFactor)
+		tierBaseline.update(vector, ad.config.SmoothingFactor)
+		return decision
+	}
+
+	ad.nextID++
+	decision.ID = fmt.Sprintf("anomaly-%d", ad.nextID)
+	stats.flagged++
+	stats.mitigation += ad.mitigationFor(decision.Action)
+	ad.recordDecision(decision)
+
+	return decision
+}
+
+// RecordFeedback marks the client's unreviewed decisions as reviewed and returns how many
+// were false positives
+func (ad *AnomalyDetector) RecordFeedback(clientID string, wasLegitimate bool) int {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	falsePositives := 0
+	for _, decision := range ad.decisions {
+		if decision.ClientID != clientID || decision.Reviewed {
+			continue
+		}
+
+		decision.Reviewed = true
+		if wasLegitimate {
+			decision.FalsePositive = true
+			ad.statsFor(decision.Tier).falsePositives++
+			falsePositives++
+		}
+	}
+
+	return falsePositives
+}
+
+// RecentDecisions returns up to limit of the most recent flagged decisions, optionally for one client
+func (ad *AnomalyDetector) RecentDecisions(clientID string, limit int) []*AnomalyDecision {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	result := make([]*AnomalyDecision, 0)
+	for i := len(ad.decisions) - 1; i >= 0 && (limit <= 0 || len(result) < limit); i-- {
+		decision := ad.decisions[i]
+		if clientID != "" && decision.ClientID != clientID {
+			continue
+		}
+
+		copied := *decision
+		copied.Reasons = append([]string(nil), decision.Reasons...)
+		result = append(result, &copied)
+	}
+
+	return result
+}
+
+// TierFalsePositiveStats returns the detector's per-tier false positive statistics
+func (ad *AnomalyDetector) TierFalsePositiveStats() map[string]*FalsePositiveStats {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	stats := make(map[string]*FalsePositiveStats)
+	for tier, tierStats := range ad.tierStats {
+		fpRate := 0.0
+		if tierStats.observed > 0 {
+			fpRate = float64(tierStats.falsePositives) / float64(tierStats.observed) * 100
+		}
+
+		var mitigation time.Duration
+		if tierStats.flagged > 0 {
+			mitigation = tierStats.mitigation / time.Duration(tierStats.flagged)
+		}
+
+		stats["anomaly_"+strings.ToLower(tier)] = &FalsePositiveStats{
+			RateTier:                tier + " (anomaly detector)",
+			TotalLegitimateRequests: tierStats.observed - tierStats.flagged + tierStats.falsePositives,
+			FalsePositives:          tierStats.falsePositives,
+			FPRate:                  fpRate,
+			UserImpact:              userImpactForRate(fpRate),
+			MitigationTime:          mitigation,
+		}
+	}
+
+	return stats
+}
+
+// ForgetClient drops the sliding window and baseline of an expired client
+func (ad *AnomalyDetector) ForgetClient(clientID string) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	delete(ad.clients, clientID)
+}
+
+// profileFor returns the client's profile, creating it if needed
+func (ad *AnomalyDetector) profileFor(clientID string) *clientProfile {
+	profile, exists := ad.clients[clientID]
+	if !exists {
+		profile = &clientProfile{
+			window:    make([]requestSample, ad.config.WindowSize),
+			seen:      make(map[string]struct{}),
+			seenOrder: make([]string, 0, ad.config.HistorySize),
+			typeMix:   make(map[string]float64),
+			baseline:  &featureBaseline{},
+		}
+		ad.clients[clientID] = profile
+	}
+	return profile
+}
+
+// statsFor returns the tier statistics, creating them if needed
+func (ad *AnomalyDetector) statsFor(tier string) *anomalyTierStats {
+	stats, exists := ad.tierStats[tier]
+	if !exists {
+		stats = &anomalyTierStats{}
+		ad.tierStats[tier] = stats
+	}
+	return stats
+}
+
+// observe adds the request to the client's window and computes its features
+func (ad *AnomalyDetector) observe(profile *clientProfile, hashValue, requestType string, now time.Time) AnomalyFeatures {
+	alpha := ad.config.SmoothingFactor
+
+	// Request rate from inter-arrival time
+	if !profile.lastRequest.IsZero() {
+		interval := now.Sub(profile.lastRequest).Seconds()
+		if interval < 0.001 {
+			interval = 0.001
+		}
+		profile.rateEWMA = alpha*(1/interval) + (1-alpha)*profile.rateEWMA
+	}
+	profile.lastRequest = now
+
+	// Divergence of this request's type from the client's usual mix, before learning it
+	typeMixDivergence := 1 - profile.typeMix[requestType]
+	if len(profile.typeMix) == 0 {
+		typeMixDivergence = 0
+	}
+	for known := range profile.typeMix {
+		profile.typeMix[known] *= 1 - alpha
+	}
+	profile.typeMix[requestType] += alpha
+	if len(profile.typeMix) == 1 {
+		profile.typeMix[requestType] = 1
+	}
+
+	sample := requestSample{
+		hash:        hashValue,
+		requestType: requestType,
+	}
+	if hashValue != "" {
+		_, sample.repeat = profile.seen[hashValue]
+		sample.unpinned = ad.pins != nil && (!looksLikeCID(hashValue) || !ad.pins.IsPinned(hashValue))
+		profile.remember(hashValue, ad.config.HistorySize)
+	}
+	profile.push(sample)
+
+	// Ratio features over the sliding window
+	count := profile.windowLen()
+	distinct := make(map[string]struct{}, count)
+	hashed, repeats, unpinned := 0, 0, 0
+	for i := 0; i < count; i++ {
+		entry := profile.window[i]
+		if entry.hash == "" {
+			continue
+		}
+		hashed++
+		distinct[entry.hash] = struct{}{}
+		if entry.repeat {
+			repeats++
+		}
+		if entry.unpinned {
+			unpinned++
+		}
+	}
+
+	features := AnomalyFeatures{
+		RequestRate:       profile.rateEWMA,
+		TypeMixDivergence: typeMixDivergence,
+	}
+	if hashed > 0 {
+		features.DistinctHashRatio = float64(len(distinct)) / float64(hashed)
+		features.RepeatHashRatio = float64(repeats) / float64(hashed)
+		features.UnpinnedRatio = float64(unpinned) / float64(hashed)
+	}
+
+	return features
+}
+
+// actionForScore maps an anomaly score onto the graduated response
+func (ad *AnomalyDetector) actionForScore(score float64) AnomalyAction {
+	switch {
+	case score >= ad.config.BlockScore:
+		return ActionBlock
+	case score >= ad.config.ChallengeScore:
+		return ActionChallenge
+	case score >= ad.config.SlowdownScore:
+		return ActionSlowdown
+	default:
+		return ActionAllow
+	}
+}
+
+// mitigationFor returns how long an action keeps the client from normal service
+func (ad *AnomalyDetector) mitigationFor(action AnomalyAction) time.Duration {
+	switch action {
+	case ActionBlock:
+		return ad.config.BlockDuration
+	case ActionSlowdown:
+		return ad.config.SlowdownDelay
+	default:
+		return 0
+	}
+}
+
+// recordDecision appends a flagged decision, dropping the oldest beyond MaxDecisions
+func (ad *AnomalyDetector) recordDecision(decision *AnomalyDecision) {
+	ad.decisions = append(ad.decisions, decision)
+	if ad.config.MaxDecisions > 0 && len(ad.decisions) > ad.config.MaxDecisions {
+		ad.decisions = ad.decisions[len(ad.decisions)-ad.config.MaxDecisions:]
+	}
+}
+
+// vector flattens the features in baseline order
+func (af AnomalyFeatures) vector() [featureCount]float64 {
+	return [featureCount]float64{
+		af.RequestRate,
+		af.DistinctHashRatio,
+		af.RepeatHashRatio,
+		af.UnpinnedRatio,
+		af.TypeMixDivergence,
+	}
+}
+
+// update folds an observation into the EWMA mean and variance
+func (fb *featureBaseline) update(vector [featureCount]float64, alpha float64) {
+	if fb.samples == 0 {
+		fb.mean = vector
+		fb.samples++
+		return
+	}
+
+	for i := 0; i < featureCount; i++ {
+		diff := vector[i] - fb.mean[i]
+		fb.mean[i] += alpha * diff
+		fb.variance[i] = (1 - alpha) * (fb.variance[i] + alpha*diff*diff)
+	}
+	fb.samples++
+}
+
+// deviation returns how many standard deviations a value sits on the suspicious side of the mean
+func (fb *featureBaseline) deviation(feature int, value float64) float64 {
+	floor := featureFloor[feature]
+	if feature == featureRequestRate {
+		floor = math.Max(floor, 0.1*fb.mean[feature])
+	}
+
+	stddev := math.Sqrt(fb.variance[feature] + floor*floor)
+	z := featureDirection[feature] * (value - fb.mean[feature]) / stddev
+	if z < 0 {
+		return 0
+	}
+	return z
+}
+
+// push appends a sample to the ring buffer window
+func (cp *clientProfile) push(sample requestSample) {
+	cp.window[cp.next] = sample
+	cp.next = (cp.next + 1) % len(cp.window)
+	if cp.next == 0 {
+		cp.filled = true
+	}
+}
+
+// windowLen returns the number of samples in the window
+func (cp *clientProfile) windowLen() int {
+	if cp.filled {
+		return len(cp.window)
+	}
+	return cp.next
+}
+
+// remember adds a hash to the bounded history used for repeat detection
+func (cp *clientProfile) remember(hash string, limit int) {
+	if _, exists := cp.seen[hash]; exists {
+		return
+	}
+
+	cp.seen[hash] = struct{}{}
+	cp.seenOrder = append(cp.seenOrder, hash)
+	if len(cp.seenOrder) > limit {
+		delete(cp.seen, cp.seenOrder[0])
+		cp.seenOrder = cp.seenOrder[1:]
+	}
+}
+
+// looksLikeCID performs a cheap syntactic check for CIDv0 and base32 CIDv1 strings
+func looksLikeCID(value string) bool {
+	if len(value) == 46 && strings.HasPrefix(value, "Qm") {
+		return true
+	}
+	if len(value) >= 50 && strings.HasPrefix(value, "b") {
+		return strings.Trim(value[1:], "abcdefghijklmnopqrstuvwxyz234567") == ""
+	}
+	return false
+}
+
+// userImpactForRate labels a false positive rate the way the assessment table does
+func userImpactForRate(fpRate float64) string {
+	switch {
+	case fpRate >= 0.01:
+		return "Noticeable"
+	case fpRate >= 0.001:
+		return "Minimal"
+	case fpRate > 0:
+		return "Negligible"
+	default:
+		return "None"
+	}
+}
+
+// NewPinnedCIDSet creates an empty pinned CID set
+func NewPinnedCIDSet(cids ...string) *PinnedCIDSet {
+	ps := &PinnedCIDSet{pinned: make(map[string]struct{})}
+	for _, cid := range cids {
+		ps.pinned[cid] = struct{}{}
+	}
+	return ps
+}
+
+// Pin records a CID as pinned
+func (ps *PinnedCIDSet) Pin(cid string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.pinned[cid] = struct{}{}
+}
+
+// Unpin removes a CID from the set
+func (ps *PinnedCIDSet) Unpin(cid string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	delete(ps.pinned, cid)
+}
+
+// IsPinned reports whether the CID is pinned
+func (ps *PinnedCIDSet) IsPinned(cid string) bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	_, pinned := ps.pinned[cid]
+	return pinned
+}
+
+// Replace swaps the set's contents for the given CIDs
+func (ps *PinnedCIDSet) Replace(cids []string) {
+	pinned := make(map[string]struct{}, len(cids))
+	for _, cid := range cids {
+		pinned[cid] = struct{}{}
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.pinned = pinned
+}
@@ -0,0 +1,160 @@
+package prevention
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// pinnedCID builds a syntactically valid CIDv0 for test fixtures
+func pinnedCID(i int) string {
+	return fmt.Sprintf("Qm%044d", i)
+}
+
+// trainDetector replays a legitimate client: a small set of pinned CIDs fetched steadily
+func trainDetector(detector *AnomalyDetector, clientID string, start time.Time, requests int) time.Time {
+	now := start
+	for i := 0; i < requests; i++ {
+		now = now.Add(100 * time.Millisecond)
+		detector.Evaluate(clientID, "Basic", pinnedCID(i%8), "bin_reading", now)
+	}
+	return now
+}
+
+func TestAnomalyDetectorAllowsSteadyClient(t *testing.T) {
+	pins := NewPinnedCIDSet()
+	for i := 0; i < 8; i++ {
+		pins.Pin(pinnedCID(i))
+	}
+	detector := NewAnomalyDetector(DefaultAnomalyConfig(), pins)
+
+	now := trainDetector(detector, "bin_0x01", time.Now(), 200)
+
+	for i := 0; i < 20; i++ {
+		now = now.Add(100 * time.Millisecond)
+		decision := detector.Evaluate("bin_0x01", "Basic", pinnedCID(i%8), "bin_reading", now)
+		if decision.Action != ActionAllow {
+			t.Fatalf("request %d: expected allow, got %s (score %.2f, reasons %v)", i, decision.Action, decision.Score, decision.Reasons)
+		}
+	}
+
+	if decisions := detector.RecentDecisions("bin_0x01", 0); len(decisions) != 0 {
+		t.Errorf("expected no recorded decisions, got %d", len(decisions))
+	}
+}
+
+func TestAnomalyDetectorFlagsRandomCIDFlood(t *testing.T) {
+	pins := NewPinnedCIDSet()
+	for i := 0; i < 8; i++ {
+		pins.Pin(pinnedCID(i))
+	}
+	detector := NewAnomalyDetector(DefaultAnomalyConfig(), pins)
+
+	now := trainDetector(detector, "attacker_0x02", time.Now(), 200)
+
+	// Switch to a fast flood of never-pinned CIDs
+	worst := ActionAllow
+	for i := 0; i < 100; i++ {
+		now = now.Add(time.Millisecond)
+		decision := detector.Evaluate("attacker_0x02", "Basic", pinnedCID(1000+i), "bin_reading", now)
+		if decision.Action == ActionBlock {
+			worst = ActionBlock
+			break
+		}
+		if decision.Action != ActionAllow {
+			worst = decision.Action
+		}
+	}
+
+	if worst != ActionBlock {
+		t.Fatalf("expected the flood to escalate to block, worst action was %s", worst)
+	}
+
+	decisions := detector.RecentDecisions("attacker_0x02", 0)
+	if len(decisions) == 0 {
+		t.Fatal("expected flagged decisions to be recorded")
+	}
+	if decisions[0].Action != ActionBlock || decisions[0].ID == "" {
+		t.Errorf("expected latest decision to be a recorded block, got %+v", decisions[0])
+	}
+}
+
+func TestAnomalyDetectorJudgesNewClientsAgainstTier(t *testing.T) {
+	pins := NewPinnedCIDSet()
+	for i := 0; i < 8; i++ {
+		pins.Pin(pinnedCID(i))
+	}
+	detector := NewAnomalyDetector(DefaultAnomalyConfig(), pins)
+
+	// Several legitimate clients establish the tier baseline
+	start := time.Now()
+	for c := 0; c < 4; c++ {
+		trainDetector(detector, fmt.Sprintf("bin_%d", c), start, 100)
+	}
+
+	// A fresh client flooding unpinned CIDs is caught before its own baseline exists
+	now := start
+	flagged := false
+	for i := 0; i < 64; i++ {
+		now = now.Add(time.Millisecond)
+		decision := detector.Evaluate("newcomer", "Basic", fmt.Sprintf("random-%d", i), "bin_reading", now)
+		if decision.Action != ActionAllow {
+			flagged = true
+			break
+		}
+	}
+
+	if !flagged {
+		t.Error("expected the new client to be flagged against the tier baseline")
+	}
+}
+
+func TestAnomalyFeedbackFeedsFalsePositiveAssessment(t *testing.T) {
+	config := DefaultAnomalyConfig()
+	hfp := NewHashFloodingPrevention(&PreventionConfig{
+		EnableTieredLimiting:   true,
+		MaxClientsTracked:      100,
+		CleanupInterval:        time.Minute,
+		MetricsRetentionPeriod: time.Hour,
+		EnableAnomalyDetection: true,
+		AnomalyDetection:       config,
+	})
+	hfp.SetPinChecker(NewPinnedCIDSet())
+
+	// Every CID is unpinned, so a full window trips the random CID rule
+	gasFee := big.NewInt(5000000000)
+	var last *ValidationResult
+	for i := 0; i < config.WindowSize; i++ {
+		result, err := hfp.ValidateHashContent("contractor_0x03", pinnedCID(i), gasFee, "bin_reading")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		last = result
+		if !result.Allowed {
+			break
+		}
+	}
+
+	if last.Allowed || last.AnomalyAction == "" {
+		t.Fatalf("expected an anomaly rejection, got %+v", last)
+	}
+
+	hfp.FalsePositiveAnalysis("contractor_0x03", true)
+
+	stats, exists := hfp.GetFalsePositiveImpactAssessment()["anomaly_basic"]
+	if !exists {
+		t.Fatal("expected detector statistics in the false positive assessment")
+	}
+	if stats.FalsePositives != 1 {
+		t.Errorf("expected 1 false positive, got %d", stats.FalsePositives)
+	}
+	if hfp.GetSystemMetrics().FalsePositives != 1 {
+		t.Errorf("expected system metrics to count 1 false positive, got %d", hfp.GetSystemMetrics().FalsePositives)
+	}
+
+	decisions := hfp.AnomalyDecisions("contractor_0x03", 0)
+	if len(decisions) == 0 || !decisions[0].FalsePositive {
+		t.Error("expected the recorded decision to be marked as a false positive")
+	}
+}
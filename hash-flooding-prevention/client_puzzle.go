@@ -0,0 +1,305 @@
+package prevention
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// puzzleDomain separates puzzle hashes from any other use of the same primitives
+const puzzleDomain = "securewear-puzzle-v1"
+
+var (
+	ErrChallengeInvalid      = errors.New("challenge authentication failed")
+	ErrChallengeExpired      = errors.New("challenge expired")
+	ErrChallengeReplayed     = errors.New("challenge already redeemed")
+	ErrChallengeWrongClient  = errors.New("challenge issued to a different client")
+	ErrSolutionInsufficient  = errors.New("solution does not meet challenge difficulty")
+	ErrPuzzleSolvingCanceled = errors.New("puzzle solving canceled")
+)
+
+// PuzzleIssuer issues and verifies stateless hashcash-style client puzzles.
+// Challenges carry everything needed to verify them and are authenticated with an
+// HMAC, so the issuer keeps no per-challenge state until a solution is redeemed.
+type PuzzleIssuer struct {
+	config   *PuzzleConfig
+	redeemed map[[sha256.Size]byte]time.Time // MAC -> challenge expiry, for replay protection
+	issued   []time.Time                     // issue times within the load window
+	solves   map[string][]time.Time          // recent redemptions per client
+	mu       sync.Mutex
+}
+
+// PuzzleConfig holds configuration for client puzzles
+type PuzzleConfig struct {
+	Secret         []byte        `json:"-"`
+	BaseDifficulty uint8         `json:"base_difficulty"` // leading zero bits for an idle service
+	MaxDifficulty  uint8         `json:"max_difficulty"`  // upper bound after load and repeat adjustments
+	ChallengeTTL   time.Duration `json:"challenge_ttl"`   // how long a challenge may be solved
+	LoadWindow     time.Duration `json:"load_window"`     // window used to measure issue rate
+	LoadThreshold  int           `json:"load_threshold"`  // challenges per window before difficulty rises
+	GrantRequests  int           `json:"grant_requests"`  // burst requests granted per solution
+	GrantDuration  time.Duration `json:"grant_duration"`  // how long granted requests stay usable
+}
+
+// PuzzleChallenge is sent to a throttled client
+type PuzzleChallenge struct {
+	ClientID   string `json:"client_id"`
+	Nonce      []byte `json:"nonce"`
+	IssuedAt   int64  `json:"issued_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+	Difficulty uint8  `json:"difficulty"`
+	MAC        []byte `json:"mac"`
+}
+
+// PuzzleSolution is returned by the client to redeem a challenge
+type PuzzleSolution struct {
+	Challenge *PuzzleChallenge `json:"challenge"`
+	Counter   uint64           `json:"counter"`
+}
+
+// PuzzleGrant describes burst capacity granted for a solved challenge
+type PuzzleGrant struct {
+	ClientID  string    `json:"client_id"`
+	Requests  int       `json:"requests"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DefaultPuzzleConfig returns puzzle settings with a fresh random secret
+func DefaultPuzzleConfig() *PuzzleConfig {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate puzzle secret: %v", err))
+	}
+
+	return &PuzzleConfig{
+		Secret:         secret,
+		BaseDifficulty: 18, // ~260k hashes, well under a second on a bin controller
+		MaxDifficulty:  26,
+		ChallengeTTL:   2 * time.Minute,
+		LoadWindow:     time.Minute,
+		LoadThreshold:  1000,
+		GrantRequests:  200,
+		GrantDuration:  30 * time.Second,
+	}
+}
+
+// NewPuzzleIssuer creates a new puzzle issuer
+func NewPuzzleIssuer(config *PuzzleConfig) (*PuzzleIssuer, error) {
+	if config == nil {
+		config = DefaultPuzzleConfig()
+	}
+	if len(config.Secret) < 16 {
+		return nil, fmt.Errorf("puzzle secret must be at least 16 bytes")
+	}
+	if config.MaxDifficulty < config.BaseDifficulty || config.MaxDifficulty > 64 {
+		return nil, fmt.Errorf("invalid puzzle difficulty range %d-%d", config.BaseDifficulty, config.MaxDifficulty)
+	}
+
+	return &PuzzleIssuer{
+		config:   config,
+		redeemed: make(map[[sha256.Size]byte]time.Time),
+		issued:   make([]time.Time, 0),
+		solves:   make(map[string][]time.Time),
+	}, nil
+}
+
+// Issue creates a challenge for the client with difficulty adapted to current load
+func (pi *PuzzleIssuer) Issue(clientID string, now time.Time) (*PuzzleChallenge, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge nonce: %v", err)
+	}
+
+	pi.mu.Lock()
+	pi.pruneLocked(now)
+	pi.issued = append(pi.issued, now)
+	difficulty := pi.difficultyLocked(clientID)
+	pi.mu.Unlock()
+
+	challenge := &PuzzleChallenge{
+		ClientID:   clientID,
+		Nonce:      nonce,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  now.Add(pi.config.ChallengeTTL).Unix(),
+		Difficulty: difficulty,
+	}
+	challenge.MAC = pi.mac(challenge)
+
+	return challenge, nil
+}
+
+// Redeem verifies a solution for the client and marks the challenge as spent
+func (pi *PuzzleIssuer) Redeem(clientID string, solution *PuzzleSolution, now time.Time) (*PuzzleGrant, error) {
+	if solution == nil || solution.Challenge == nil {
+		return nil, ErrChallengeInvalid
+	}
+	challenge := solution.Challenge
+
+	// Authenticate first so nothing below trusts client-supplied fields
+	if !hmac.Equal(challenge.MAC, pi.mac(challenge)) {
+		return nil, ErrChallengeInvalid
+	}
+	if challenge.ClientID != clientID {
+		return nil, ErrChallengeWrongClient
+	}
+	if now.Unix() > challenge.ExpiresAt {
+		return nil, ErrChallengeExpired
+	}
+	if PuzzleWorkBits(challenge, solution.Counter) < int(challenge.Difficulty) {
+		return nil, ErrSolutionInsufficient
+	}
+
+	var key [sha256.Size]byte
+	copy(key[:], challenge.MAC)
+
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	pi.pruneLocked(now)
+	if _, spent := pi.redeemed[key]; spent {
+		return nil, ErrChallengeReplayed
+	}
+	pi.redeemed[key] = time.Unix(challenge.ExpiresAt, 0)
+	pi.solves[clientID] = append(pi.solves[clientID], now)
+
+	return &PuzzleGrant{
+		ClientID:  clientID,
+		Requests:  pi.config.GrantRequests,
+		ExpiresAt: now.Add(pi.config.GrantDuration),
+	}, nil
+}
+
+// mac authenticates every challenge field under the issuer secret
+func (pi *PuzzleIssuer) mac(challenge *PuzzleChallenge) []byte {
+	h := hmac.New(sha256.New, pi.config.Secret)
+	h.Write([]byte(puzzleDomain))
+	writeLengthPrefixed(h, []byte(challenge.ClientID))
+	writeLengthPrefixed(h, challenge.Nonce)
+
+	var fields [17]byte
+	binary.BigEndian.PutUint64(fields[0:8], uint64(challenge.IssuedAt))
+	binary.BigEndian.PutUint64(fields[8:16], uint64(challenge.ExpiresAt))
+	fields[16] = challenge.Difficulty
+	h.Write(fields[:])
+
+	return h.Sum(nil)
+}
+
+// difficultyLocked raises the base difficulty with issue rate and with the client's recent solves
+func (pi *PuzzleIssuer) difficultyLocked(clientID string) uint8 {
+	difficulty := float64(pi.config.BaseDifficulty)
+
+	if pi.config.LoadThreshold > 0 {
+		load := float64(len(pi.issued)) / float64(pi.config.LoadThreshold)
+		difficulty += math.Floor(math.Log2(1 + load))
+	}
+
+	// Each recent solve doubles the work for the next one
+	difficulty += float64(len(pi.solves[clientID]))
+
+	if difficulty > float64(pi.config.MaxDifficulty) {
+		difficulty = float64(pi.config.MaxDifficulty)
+	}
+	return uint8(difficulty)
+}
+
+// pruneLocked drops expired replay entries and counters outside the load window
+func (pi *PuzzleIssuer) pruneLocked(now time.Time) {
+	// Entries live until Redeem itself would reject the challenge as expired
+	for key, expiresAt := range pi.redeemed {
+		if now.Unix() > expiresAt.Unix() {
+			delete(pi.redeemed, key)
+		}
+	}
+
+	cutoff := now.Add(-pi.config.LoadWindow)
+	kept := pi.issued[:0]
+	for _, issuedAt := range pi.issued {
+		if issuedAt.After(cutoff) {
+			kept = append(kept, issuedAt)
+		}
+	}
+	pi.issued = kept
+
+	for clientID, solves := range pi.solves {
+		recent := solves[:0]
+		for _, solvedAt := range solves {
+			if solvedAt.After(cutoff) {
+				recent = append(recent, solvedAt)
+			}
+		}
+		if len(recent) == 0 {
+			delete(pi.solves, clientID)
+		} else {
+			pi.solves[clientID] = recent
+		}
+	}
+}
+
+// PuzzleWorkBits returns the number of leading zero bits the counter achieves for the challenge
+func PuzzleWorkBits(challenge *PuzzleChallenge, counter uint64) int {
+	h := sha256.New()
+	h.Write([]byte(puzzleDomain))
+	writeLengthPrefixed(h, challenge.MAC)
+	writeLengthPrefixed(h, []byte(challenge.ClientID))
+
+	var counterBytes [8]byte
+	binary.BigEndian.PutUint64(counterBytes[:], counter)
+	h.Write(counterBytes[:])
+
+	return leadingZeroBits(h.Sum(nil))
+}
+
+// SolveChallenge searches for a counter meeting the challenge difficulty
+func SolveChallenge(ctx context.Context, challenge *PuzzleChallenge) (*PuzzleSolution, error) {
+	for counter := uint64(0); ; counter++ {
+		if counter&0xfff == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, ErrPuzzleSolvingCanceled
+			}
+			if time.Now().Unix() > challenge.ExpiresAt {
+				return nil, ErrChallengeExpired
+			}
+		}
+
+		if PuzzleWorkBits(challenge, counter) >= int(challenge.Difficulty) {
+			return &PuzzleSolution{
+				Challenge: challenge,
+				Counter:   counter,
+			}, nil
+		}
+
+		if counter == math.MaxUint64 {
+			return nil, ErrSolutionInsufficient
+		}
+	}
+}
+
+// writeLengthPrefixed writes a length-prefixed field so adjacent fields cannot be shifted
+func writeLengthPrefixed(w interface{ Write([]byte) (int, error) }, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	w.Write(length[:])
+	w.Write(data)
+}
+
+// leadingZeroBits counts the leading zero bits of a digest
+func leadingZeroBits(digest []byte) int {
+	count := 0
+	for _, b := range digest {
+		if b == 0 {
+			count += 8
+			continue
+		}
+		return count + bits.LeadingZeros8(b)
+	}
+	return count
+}
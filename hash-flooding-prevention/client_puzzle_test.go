@@ -0,0 +1,205 @@
+package prevention
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestPuzzleConfig(difficulty uint8) *PuzzleConfig {
+	config := DefaultPuzzleConfig()
+	config.BaseDifficulty = difficulty
+	config.MaxDifficulty = difficulty + 8
+	return config
+}
+
+func newTestIssuer(t *testing.T, difficulty uint8) *PuzzleIssuer {
+	issuer, err := NewPuzzleIssuer(newTestPuzzleConfig(difficulty))
+	if err != nil {
+		t.Fatalf("failed to create issuer: %v", err)
+	}
+	return issuer
+}
+
+func solve(t *testing.T, challenge *PuzzleChallenge) *PuzzleSolution {
+	solution, err := SolveChallenge(context.Background(), challenge)
+	if err != nil {
+		t.Fatalf("failed to solve challenge: %v", err)
+	}
+	return solution
+}
+
+func TestPuzzleRoundTrip(t *testing.T) {
+	issuer := newTestIssuer(t, 12)
+	now := time.Now()
+
+	challenge, err := issuer.Issue("bin_0x01", now)
+	if err != nil {
+		t.Fatalf("failed to issue challenge: %v", err)
+	}
+	if challenge.Difficulty != 12 {
+		t.Errorf("expected difficulty 12 on an idle issuer, got %d", challenge.Difficulty)
+	}
+
+	grant, err := issuer.Redeem("bin_0x01", solve(t, challenge), now)
+	if err != nil {
+		t.Fatalf("failed to redeem solution: %v", err)
+	}
+	if grant.Requests != issuer.config.GrantRequests {
+		t.Errorf("expected %d granted requests, got %d", issuer.config.GrantRequests, grant.Requests)
+	}
+}
+
+func TestPuzzleReplayRejected(t *testing.T) {
+	issuer := newTestIssuer(t, 12)
+	now := time.Now()
+
+	challenge, _ := issuer.Issue("bin_0x01", now)
+	solution := solve(t, challenge)
+
+	if _, err := issuer.Redeem("bin_0x01", solution, now); err != nil {
+		t.Fatalf("first redemption failed: %v", err)
+	}
+	if _, err := issuer.Redeem("bin_0x01", solution, now.Add(time.Second)); !errors.Is(err, ErrChallengeReplayed) {
+		t.Errorf("expected ErrChallengeReplayed, got %v", err)
+	}
+}
+
+func TestPuzzleTamperingRejected(t *testing.T) {
+	issuer := newTestIssuer(t, 12)
+	now := time.Now()
+
+	challenge, _ := issuer.Issue("bin_0x01", now)
+	solution := solve(t, challenge)
+
+	tampered := []struct {
+		name   string
+		modify func(c *PuzzleChallenge)
+	}{
+		{"lowered difficulty", func(c *PuzzleChallenge) { c.Difficulty = 0 }},
+		{"extended expiry", func(c *PuzzleChallenge) { c.ExpiresAt += 3600 }},
+		{"chosen nonce", func(c *PuzzleChallenge) { c.Nonce = make([]byte, 16) }},
+		{"reassigned client", func(c *PuzzleChallenge) { c.ClientID = "attacker" }},
+	}
+
+	for _, tc := range tampered {
+		t.Run(tc.name, func(t *testing.T) {
+			forged := *challenge
+			tc.modify(&forged)
+
+			_, err := issuer.Redeem(forged.ClientID, &PuzzleSolution{Challenge: &forged, Counter: solution.Counter}, now)
+			if !errors.Is(err, ErrChallengeInvalid) {
+				t.Errorf("expected ErrChallengeInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPuzzlePrecomputationResistance(t *testing.T) {
+	issuer := newTestIssuer(t, 16)
+	now := time.Now()
+
+	// Challenges minted by another issuer (a client guessing the secret) do not verify
+	otherIssuer := newTestIssuer(t, 16)
+	precomputed, _ := otherIssuer.Issue("bin_0x01", now)
+	if _, err := issuer.Redeem("bin_0x01", solve(t, precomputed), now); !errors.Is(err, ErrChallengeInvalid) {
+		t.Errorf("expected ErrChallengeInvalid for a self-made challenge, got %v", err)
+	}
+
+	// Work done for one challenge does not carry over to the next
+	first, _ := issuer.Issue("bin_0x01", now)
+	second, _ := issuer.Issue("bin_0x01", now)
+	firstSolution := solve(t, first)
+	if PuzzleWorkBits(second, firstSolution.Counter) >= int(second.Difficulty) {
+		t.Skip("counter happens to solve both challenges")
+	}
+	if _, err := issuer.Redeem("bin_0x01", &PuzzleSolution{Challenge: second, Counter: firstSolution.Counter}, now); !errors.Is(err, ErrSolutionInsufficient) {
+		t.Errorf("expected ErrSolutionInsufficient, got %v", err)
+	}
+
+	// A solution cannot be redeemed by another client or after expiry
+	if _, err := issuer.Redeem("bin_0x02", firstSolution, now); !errors.Is(err, ErrChallengeWrongClient) {
+		t.Errorf("expected ErrChallengeWrongClient, got %v", err)
+	}
+	if _, err := issuer.Redeem("bin_0x01", firstSolution, now.Add(issuer.config.ChallengeTTL+time.Second)); !errors.Is(err, ErrChallengeExpired) {
+		t.Errorf("expected ErrChallengeExpired, got %v", err)
+	}
+}
+
+func TestPuzzleDifficultyAdapts(t *testing.T) {
+	config := newTestPuzzleConfig(8)
+	config.LoadThreshold = 10
+	issuer, err := NewPuzzleIssuer(config)
+	if err != nil {
+		t.Fatalf("failed to create issuer: %v", err)
+	}
+	now := time.Now()
+
+	// Load raises difficulty for everyone
+	var challenge *PuzzleChallenge
+	for i := 0; i < 30; i++ {
+		challenge, _ = issuer.Issue("bin_0x01", now)
+	}
+	if challenge.Difficulty <= config.BaseDifficulty {
+		t.Errorf("expected difficulty above %d under load, got %d", config.BaseDifficulty, challenge.Difficulty)
+	}
+
+	// Repeat solvers pay more than newcomers
+	if _, err := issuer.Redeem("bin_0x01", solve(t, challenge), now); err != nil {
+		t.Fatalf("failed to redeem: %v", err)
+	}
+	repeat, _ := issuer.Issue("bin_0x01", now)
+	newcomer, _ := issuer.Issue("bin_0x02", now)
+	if repeat.Difficulty <= newcomer.Difficulty {
+		t.Errorf("expected repeat solver difficulty %d above newcomer %d", repeat.Difficulty, newcomer.Difficulty)
+	}
+
+	// Difficulty drops back once the load window passes
+	later, _ := issuer.Issue("bin_0x02", now.Add(2*config.LoadWindow))
+	if later.Difficulty != config.BaseDifficulty {
+		t.Errorf("expected base difficulty after the load window, got %d", later.Difficulty)
+	}
+}
+
+func TestPuzzleGrantsBurstCapacity(t *testing.T) {
+	hfp := newTestPrevention()
+	service := NewMultiTierRateLimitingService(hfp, &ServiceConfig{
+		Port:           0,
+		RequestTimeout: 10 * time.Second,
+		Puzzles:        newTestPuzzleConfig(10),
+	})
+
+	server := httptest.NewServer(service.server.Handler)
+	defer server.Close()
+
+	// Exhaust the Basic tier's base limit and burst allowance
+	clientID := "sensor_0x0a"
+	gasFee := big.NewInt(1000000000)
+	for i := 0; i < 700; i++ {
+		if _, err := hfp.ValidateHashRequest(clientID, gasFee, "bin_reading"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	client := NewPuzzleClient(server.URL)
+	request := &HashValidationRequest{
+		ClientID:      clientID,
+		HashValue:     "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		GasFeePaidHex: gasFee.Text(16),
+		RequestType:   "bin_reading",
+	}
+
+	response, err := client.ValidateHash(context.Background(), request)
+	if err != nil {
+		t.Fatalf("validation failed: %v", err)
+	}
+	if !response.ValidationResult.Allowed {
+		t.Fatalf("expected the solved puzzle to grant capacity, got %+v", response.ValidationResult)
+	}
+	if response.ValidationResult.PuzzleCredits != service.puzzles.config.GrantRequests-1 {
+		t.Errorf("expected %d remaining credits, got %d", service.puzzles.config.GrantRequests-1, response.ValidationResult.PuzzleCredits)
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"syscall"
 	"time"
 	
-	"./prevention"
-	"../ipfs-blockchain-binding"
+	"github.com/ethereum/go-ethereum/ethclient"
+	
+	prevention "blockchain-jedi/hash-flooding-prevention"
+	binding "blockchain-jedi/ipfs-blockchain-binding"
 )
 
 func main() {
@@ -127,10 +129,34 @@ func runMultiTierService() {
 		MaxClientsTracked:          10000,
 		CleanupInterval:            5 * time.Minute,
 		MetricsRetentionPeriod:     24 * time.Hour,
+		EnableAnomalyDetection:     true,
+	}
+	
+	// Persist client reputation so repeat offenders stay penalized across restarts
+	reputationConfig := prevention.DefaultReputationConfig()
+	reputationConfig.Path = "client_reputation.json"
+	if path := os.Getenv("REPUTATION_STORE"); path != "" {
+		reputationConfig.Path = path
 	}
+	reputationConfig.Facility = os.Getenv("FACILITY_ID")
+	config.Reputation = reputationConfig
 	
 	floodPrevention := prevention.NewHashFloodingPrevention(config)
 	
+	feedCtx, stopFeed := context.WithCancel(context.Background())
+	defer stopFeed()
+	
+	// Follow the network base fee when a node is configured
+	if rpcURL := os.Getenv("ETH_RPC_URL"); rpcURL != "" {
+		client, err := ethclient.Dial(rpcURL)
+		if err != nil {
+			log.Printf("Warning: fee feed disabled, could not connect to %s: %v", rpcURL, err)
+		} else {
+			floodPrevention.AttachFeeFeed(client, prevention.DefaultFeeFeedConfig()).Start(feedCtx)
+			fmt.Println("Fee feed enabled: tiers follow multiples of the current base fee")
+		}
+	}
+	
 	// Initialize service
 	serviceConfig := &prevention.ServiceConfig{
 		Port:                    8080,
@@ -138,6 +164,8 @@ func runMultiTierService() {
 		EnableAdvancedAnalytics: true,
 		RequestTimeout:         30 * time.Second,
 		MaxConcurrentRequests:  1000,
+		Puzzles:                prevention.DefaultPuzzleConfig(),
+		AdminToken:             os.Getenv("ADMIN_TOKEN"),
 	}
 	
 	service := prevention.NewMultiTierRateLimitingService(floodPrevention, serviceConfig)
@@ -166,6 +194,10 @@ func runMultiTierService() {
 		log.Printf("Service shutdown error: %v", err)
 	}
 	
+	if err := floodPrevention.SaveReputation(); err != nil {
+		log.Printf("Failed to save client reputation: %v", err)
+	}
+	
 	fmt.Println("Service stopped successfully")
 }
 
@@ -394,6 +426,12 @@ func printUsage() {
 	fmt.Println("  integrated-demo   - Run complete integrated system demo (default)")
 	fmt.Println("  service          - Start HTTP API service")
 	fmt.Println("")
+	fmt.Println("Environment:")
+	fmt.Println("  ETH_RPC_URL       - Node polled via eth_feeHistory so tiers track the base fee")
+	fmt.Println("  REPUTATION_STORE  - File client reputation persists to (default client_reputation.json)")
+	fmt.Println("  FACILITY_ID       - Facility named as the source of exported block lists")
+	fmt.Println("  ADMIN_TOKEN       - Enables the admin API; send it in the X-Admin-Token header")
+	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  go run main.go")
 	fmt.Println("  go run main.go integrated-demo")
@@ -405,5 +443,14 @@ func printUsage() {
 	fmt.Println("  GET  http://localhost:8080/service-tiers")
 	fmt.Println("  GET  http://localhost:8080/system-metrics")
 	fmt.Println("  GET  http://localhost:8080/false-positive-analysis")
+	fmt.Println("  GET  http://localhost:8080/anomaly-decisions")
+	fmt.Println("  POST http://localhost:8080/solve-challenge")
 	fmt.Println("  GET  http://localhost:8080/health")
+	fmt.Println("  GET  http://localhost:8080/metrics")
+	fmt.Println("  GET  http://localhost:8080/admin/reputation")
+	fmt.Println("  GET  http://localhost:8080/admin/access-list")
+	fmt.Println("  POST http://localhost:8080/admin/access-list")
+	fmt.Println("  DEL  http://localhost:8080/admin/access-list")
+	fmt.Println("  GET  http://localhost:8080/admin/blocklist/export")
+	fmt.Println("  POST http://localhost:8080/admin/blocklist/import")
 }
\ No newline at end of file
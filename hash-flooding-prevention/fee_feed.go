@@ -0,0 +1,299 @@
+package prevention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrFeeHistoryExhausted is returned by a non-looping replay reader once every entry was served
+var ErrFeeHistoryExhausted = errors.New("fee history replay exhausted")
+
+// FeeFeed polls eth_feeHistory and keeps the GasOracle's base fee estimate current
+type FeeFeed struct {
+	reader          ethereum.FeeHistoryReader
+	oracle          *GasOracle
+	config          *FeeFeedConfig
+	baseFeeEstimate float64 // EWMA of observed base fees in wei
+	tipEstimate     float64 // EWMA of observed priority fees in wei
+	lastBlock       *big.Int
+	samples         int64
+	lastPoll        time.Time
+	lastErr         error
+	mu              sync.RWMutex
+}
+
+// FeeFeedConfig holds configuration for the fee feed
+type FeeFeedConfig struct {
+	PollInterval     time.Duration // how often eth_feeHistory is queried
+	BlockCount       uint64        // blocks requested per poll
+	RewardPercentile float64       // priority fee percentile used for the tip estimate
+	SmoothingFactor  float64       // EWMA weight given to each new block (0-1]
+	TierHysteresis   float64       // fractional band clients must clear before changing tier
+	MaxStaleness     time.Duration // estimates older than this fall back to absolute tiers
+}
+
+// FeeFeedSnapshot reports the current state of the fee feed
+type FeeFeedSnapshot struct {
+	BaseFee   *big.Int  `json:"base_fee"`
+	GasPrice  *big.Int  `json:"gas_price"`
+	LastBlock *big.Int  `json:"last_block"`
+	Samples   int64     `json:"samples"`
+	LastPoll  time.Time `json:"last_poll"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// DefaultFeeFeedConfig returns the fee feed settings used by the service
+func DefaultFeeFeedConfig() *FeeFeedConfig {
+	return &FeeFeedConfig{
+		PollInterval:     12 * time.Second, // one slot
+		BlockCount:       20,
+		RewardPercentile: 50,
+		SmoothingFactor:  0.2,
+		TierHysteresis:   0.1,
+		MaxStaleness:     5 * time.Minute,
+	}
+}
+
+// NewFeeFeed creates a fee feed that updates the given oracle
+func NewFeeFeed(reader ethereum.FeeHistoryReader, oracle *GasOracle, config *FeeFeedConfig) *FeeFeed {
+	if config == nil {
+		config = DefaultFeeFeedConfig()
+	}
+	oracle.setFeePolicy(config.TierHysteresis, config.MaxStaleness)
+
+	return &FeeFeed{
+		reader: reader,
+		oracle: oracle,
+		config: config,
+	}
+}
+
+// AttachFeeFeed creates a fee feed driving this system's gas oracle
+func (hfp *HashFloodingPrevention) AttachFeeFeed(reader ethereum.FeeHistoryReader, config *FeeFeedConfig) *FeeFeed {
+	return NewFeeFeed(reader, hfp.gasOracle, config)
+}
+
+// Start polls the fee history until the context is cancelled
+func (ff *FeeFeed) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(ff.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := ff.Poll(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Fee feed poll failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Poll fetches the latest fee history once and folds unseen blocks into the estimate
+func (ff *FeeFeed) Poll(ctx context.Context) error {
+	history, err := ff.reader.FeeHistory(ctx, ff.config.BlockCount, nil, []float64{ff.config.RewardPercentile})
+
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	ff.lastPoll = time.Now()
+	ff.lastErr = err
+	if err != nil {
+		return fmt.Errorf("failed to fetch fee history: %v", err)
+	}
+	if history == nil || history.OldestBlock == nil || len(history.BaseFee) == 0 {
+		ff.lastErr = fmt.Errorf("empty fee history")
+		return ff.lastErr
+	}
+
+	// BaseFee carries one extra entry for the block after the newest one
+	newBlocks := 0
+	for i := range history.GasUsedRatio {
+		if i >= len(history.BaseFee) {
+			break
+		}
+
+		block := new(big.Int).Add(history.OldestBlock, big.NewInt(int64(i)))
+		if ff.lastBlock != nil && block.Cmp(ff.lastBlock) <= 0 {
+			continue
+		}
+
+		var tip *big.Int
+		if i < len(history.Reward) && len(history.Reward[i]) > 0 {
+			tip = history.Reward[i][0]
+		}
+		ff.observe(history.BaseFee[i], tip)
+		ff.lastBlock = block
+		newBlocks++
+	}
+
+	// Blend in the protocol-computed base fee of the pending block
+	if newBlocks > 0 && len(history.BaseFee) > len(history.GasUsedRatio) {
+		ff.observe(history.BaseFee[len(history.BaseFee)-1], nil)
+	}
+
+	if ff.samples == 0 {
+		return nil
+	}
+
+	baseFee := floatToWei(ff.baseFeeEstimate)
+	gasPrice := new(big.Int).Add(baseFee, floatToWei(ff.tipEstimate))
+	ff.oracle.UpdateFeeEstimate(baseFee, gasPrice)
+
+	return nil
+}
+
+// Snapshot returns the current fee feed state
+func (ff *FeeFeed) Snapshot() *FeeFeedSnapshot {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	baseFee := floatToWei(ff.baseFeeEstimate)
+	snapshot := &FeeFeedSnapshot{
+		BaseFee:  baseFee,
+		GasPrice: new(big.Int).Add(baseFee, floatToWei(ff.tipEstimate)),
+		Samples:  ff.samples,
+		LastPoll: ff.lastPoll,
+	}
+	if ff.lastBlock != nil {
+		snapshot.LastBlock = new(big.Int).Set(ff.lastBlock)
+	}
+	if ff.lastErr != nil {
+		snapshot.LastError = ff.lastErr.Error()
+	}
+
+	return snapshot
+}
+
+// observe folds one block's base fee (and optional tip) into the moving estimates
+func (ff *FeeFeed) observe(baseFee, tip *big.Int) {
+	if baseFee == nil {
+		return
+	}
+
+	alpha := ff.config.SmoothingFactor
+	if alpha <= 0 || alpha > 1 {
+		alpha = 1
+	}
+
+	value, _ := new(big.Float).SetInt(baseFee).Float64()
+	if ff.samples == 0 {
+		ff.baseFeeEstimate = value
+	} else {
+		ff.baseFeeEstimate = alpha*value + (1-alpha)*ff.baseFeeEstimate
+	}
+
+	if tip != nil {
+		tipValue, _ := new(big.Float).SetInt(tip).Float64()
+		if ff.samples == 0 {
+			ff.tipEstimate = tipValue
+		} else {
+			ff.tipEstimate = alpha*tipValue + (1-alpha)*ff.tipEstimate
+		}
+	}
+
+	ff.samples++
+}
+
+// floatToWei rounds a wei amount held as float64 back to an integer
+func floatToWei(value float64) *big.Int {
+	wei, _ := new(big.Float).SetFloat64(value + 0.5).Int(nil)
+	return wei
+}
+
+// FileFeeHistoryReader replays recorded eth_feeHistory results from a file
+type FileFeeHistoryReader struct {
+	entries []*ethereum.FeeHistory
+	next    int
+	loop    bool
+	mu      sync.Mutex
+}
+
+// feeHistoryJSON mirrors the eth_feeHistory JSON-RPC result
+type feeHistoryJSON struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// NewFileFeeHistoryReader loads a JSON array of eth_feeHistory results; with loop set
+// the reader starts over after the last entry instead of returning ErrFeeHistoryExhausted
+func NewFileFeeHistoryReader(path string, loop bool) (*FileFeeHistoryReader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee history file: %v", err)
+	}
+
+	var raw []feeHistoryJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse fee history file: %v", err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("fee history file %s has no entries", path)
+	}
+
+	entries := make([]*ethereum.FeeHistory, len(raw))
+	for i, entry := range raw {
+		if entry.OldestBlock == nil {
+			return nil, fmt.Errorf("fee history entry %d has no oldestBlock", i)
+		}
+
+		history := &ethereum.FeeHistory{
+			OldestBlock:  entry.OldestBlock.ToInt(),
+			BaseFee:      make([]*big.Int, len(entry.BaseFee)),
+			GasUsedRatio: entry.GasUsedRatio,
+			Reward:       make([][]*big.Int, len(entry.Reward)),
+		}
+		for j, fee := range entry.BaseFee {
+			history.BaseFee[j] = fee.ToInt()
+		}
+		for j, rewards := range entry.Reward {
+			history.Reward[j] = make([]*big.Int, len(rewards))
+			for k, reward := range rewards {
+				history.Reward[j][k] = reward.ToInt()
+			}
+		}
+		entries[i] = history
+	}
+
+	return &FileFeeHistoryReader{
+		entries: entries,
+		loop:    loop,
+	}, nil
+}
+
+// FeeHistory returns the next recorded entry; the request arguments are ignored
+func (fr *FileFeeHistoryReader) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	if fr.next >= len(fr.entries) {
+		if !fr.loop {
+			return nil, ErrFeeHistoryExhausted
+		}
+		fr.next = 0
+	}
+
+	entry := fr.entries[fr.next]
+	fr.next++
+	return entry, nil
+}
@@ -0,0 +1,169 @@
+package prevention
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const gwei = 1000000000
+
+func newTestPrevention() *HashFloodingPrevention {
+	return NewHashFloodingPrevention(&PreventionConfig{
+		EnableTieredLimiting:   true,
+		MaxClientsTracked:      100,
+		CleanupInterval:        time.Minute,
+		MetricsRetentionPeriod: time.Hour,
+	})
+}
+
+func TestFileFeeHistoryReaderReplay(t *testing.T) {
+	reader, err := NewFileFeeHistoryReader("testdata/fee_history.json", false)
+	if err != nil {
+		t.Fatalf("failed to load fee history: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		history, err := reader.FeeHistory(context.Background(), 4, nil, []float64{50})
+		if err != nil {
+			t.Fatalf("entry %d: unexpected error: %v", i, err)
+		}
+		if len(history.BaseFee) != len(history.GasUsedRatio)+1 {
+			t.Errorf("entry %d: expected %d base fees, got %d", i, len(history.GasUsedRatio)+1, len(history.BaseFee))
+		}
+	}
+
+	if _, err := reader.FeeHistory(context.Background(), 4, nil, nil); !errors.Is(err, ErrFeeHistoryExhausted) {
+		t.Errorf("expected ErrFeeHistoryExhausted, got %v", err)
+	}
+}
+
+func TestFeeFeedTracksBaseFee(t *testing.T) {
+	hfp := newTestPrevention()
+	reader, err := NewFileFeeHistoryReader("testdata/fee_history.json", false)
+	if err != nil {
+		t.Fatalf("failed to load fee history: %v", err)
+	}
+
+	feed := hfp.AttachFeeFeed(reader, DefaultFeeFeedConfig())
+
+	if hfp.gasOracle.CurrentBaseFee() != nil {
+		t.Fatal("expected no base fee before the first poll")
+	}
+
+	var previous *big.Int
+	for i := 0; i < 3; i++ {
+		if err := feed.Poll(context.Background()); err != nil {
+			t.Fatalf("poll %d failed: %v", i, err)
+		}
+
+		baseFee := hfp.gasOracle.CurrentBaseFee()
+		if baseFee == nil {
+			t.Fatalf("poll %d: expected a base fee estimate", i)
+		}
+		if previous != nil && baseFee.Cmp(previous) <= 0 {
+			t.Errorf("poll %d: estimate %s did not follow rising fees (previous %s)", i, baseFee, previous)
+		}
+		previous = baseFee
+	}
+
+	// Smoothing keeps the estimate between the lowest and highest observed base fee
+	if previous.Cmp(big.NewInt(20*gwei)) < 0 || previous.Cmp(big.NewInt(40*gwei)) > 0 {
+		t.Errorf("estimate %s outside observed range", previous)
+	}
+	if hfp.gasOracle.CurrentGasPrice().Cmp(previous) <= 0 {
+		t.Error("expected gas price estimate to include a priority fee")
+	}
+
+	snapshot := feed.Snapshot()
+	if snapshot.LastBlock == nil || snapshot.LastBlock.Int64() != 19000011 {
+		t.Errorf("expected last block 19000011, got %v", snapshot.LastBlock)
+	}
+
+	// A replayed poll with no new blocks must not move the estimate
+	if err := feed.Poll(context.Background()); err == nil {
+		t.Error("expected exhausted replay to surface an error")
+	}
+	if hfp.gasOracle.CurrentBaseFee().Cmp(previous) != 0 {
+		t.Error("estimate changed without new blocks")
+	}
+}
+
+func TestDetermineTierByBaseFeeMultiple(t *testing.T) {
+	hfp := newTestPrevention()
+	oracle := hfp.gasOracle
+
+	// Without a base fee the absolute wei boundaries apply
+	if tier := oracle.DetermineTier(big.NewInt(30 * gwei)); tier.Name != "Premium" {
+		t.Errorf("expected Premium from absolute tiers, got %s", tier.Name)
+	}
+
+	oracle.setFeePolicy(0.1, time.Minute)
+	oracle.UpdateFeeEstimate(big.NewInt(100*gwei), big.NewInt(102*gwei))
+
+	cases := []struct {
+		paid int64
+		tier string
+	}{
+		{30, "Basic"},       // 0.3x
+		{60, "Standard"},    // 0.6x
+		{130, "Premium"},    // 1.3x
+		{300, "Enterprise"}, // 3x
+		{600, "Platinum"},   // 6x
+	}
+
+	for _, c := range cases {
+		if tier := oracle.DetermineTier(big.NewInt(c.paid * gwei)); tier.Name != c.tier {
+			t.Errorf("paid %d gwei: expected %s, got %s", c.paid, c.tier, tier.Name)
+		}
+	}
+}
+
+func TestReassessTierHysteresis(t *testing.T) {
+	hfp := newTestPrevention()
+	oracle := hfp.gasOracle
+	oracle.setFeePolicy(0.1, time.Minute)
+	oracle.UpdateFeeEstimate(big.NewInt(20*gwei), big.NewInt(21*gwei))
+
+	premium := oracle.DetermineTier(big.NewInt(30 * gwei)) // 1.5x
+	if premium.Name != "Premium" {
+		t.Fatalf("expected Premium, got %s", premium.Name)
+	}
+
+	// 1.2x is below Premium's 1.25x boundary but inside the 10% band
+	if tier := oracle.ReassessTier(premium, big.NewInt(24*gwei)); tier.Name != "Premium" {
+		t.Errorf("expected to stay Premium inside the band, got %s", tier.Name)
+	}
+	// 1.1x clears the band and drops to Standard
+	standard := oracle.ReassessTier(premium, big.NewInt(22*gwei))
+	if standard.Name != "Standard" {
+		t.Fatalf("expected Standard below the band, got %s", standard.Name)
+	}
+
+	// 1.3x reaches Premium's boundary but not the band above it
+	if tier := oracle.ReassessTier(standard, big.NewInt(26*gwei)); tier.Name != "Standard" {
+		t.Errorf("expected to stay Standard inside the band, got %s", tier.Name)
+	}
+	// 1.4x clears the band and upgrades
+	if tier := oracle.ReassessTier(standard, big.NewInt(28*gwei)); tier.Name != "Premium" {
+		t.Errorf("expected Premium above the band, got %s", tier.Name)
+	}
+}
+
+func TestStaleBaseFeeFallsBackToAbsoluteTiers(t *testing.T) {
+	hfp := newTestPrevention()
+	oracle := hfp.gasOracle
+	oracle.setFeePolicy(0.1, time.Millisecond)
+	oracle.UpdateFeeEstimate(big.NewInt(100*gwei), big.NewInt(101*gwei))
+
+	time.Sleep(5 * time.Millisecond)
+
+	if oracle.CurrentBaseFee() != nil {
+		t.Error("expected stale base fee to be ignored")
+	}
+	if tier := oracle.DetermineTier(big.NewInt(30 * gwei)); tier.Name != "Premium" {
+		t.Errorf("expected Premium from absolute tiers, got %s", tier.Name)
+	}
+}
@@ -0,0 +1,187 @@
+package prevention
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "flood_prevention"
+
+// MetricsExporter exposes flood prevention and HTTP service metrics for Prometheus.
+// Counters and histograms are updated as requests are validated; gauges that mirror
+// live state are read from the prevention system at scrape time.
+type MetricsExporter struct {
+	registry          *prometheus.Registry
+	requests          *prometheus.CounterVec
+	validationLatency prometheus.Histogram
+	blockDuration     *prometheus.HistogramVec
+	anomalyActions    *prometheus.CounterVec
+	puzzlesIssued     prometheus.Counter
+	puzzleRedemptions *prometheus.CounterVec
+	apiRequests       *prometheus.CounterVec
+	apiLatency        *prometheus.HistogramVec
+	apiInFlight       prometheus.Gauge
+}
+
+// NewMetricsExporter creates an exporter with its own registry for the prevention system
+func NewMetricsExporter(hfp *HashFloodingPrevention) *MetricsExporter {
+	me := &MetricsExporter{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Hash validation requests by service tier and decision.",
+		}, []string{"tier", "decision"}),
+		validationLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "validation_duration_seconds",
+			Help:      "Time spent validating a hash request.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10), // 10µs to ~2.6s
+		}),
+		blockDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_duration_seconds",
+			Help:      "Cooldown and block periods imposed on clients when they start.",
+			Buckets:   []float64{1, 5, 15, 30, 45, 60, 120, 300, 600, 1800},
+		}, []string{"tier", "reason"}),
+		anomalyActions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "anomaly_actions_total",
+			Help:      "Graduated anomaly detector responses other than allow.",
+		}, []string{"tier", "action"}),
+		puzzlesIssued: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "puzzle_challenges_issued_total",
+			Help:      "Proof-of-work challenges issued to throttled clients.",
+		}),
+		puzzleRedemptions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "puzzle_redemptions_total",
+			Help:      "Puzzle solution redemptions by outcome.",
+		}, []string{"outcome"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP API requests by handler, status code and method.",
+		}, []string{"handler", "code", "method"}),
+		apiLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP API response latency by handler.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"handler", "method"}),
+		apiInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP API requests currently being served.",
+		}),
+	}
+
+	me.registry.MustRegister(
+		me.requests,
+		me.validationLatency,
+		me.blockDuration,
+		me.anomalyActions,
+		me.puzzlesIssued,
+		me.puzzleRedemptions,
+		me.apiRequests,
+		me.apiLatency,
+		me.apiInFlight,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "tracked_clients",
+			Help:      "Clients with an active rate limiter.",
+		}, func() float64 {
+			hfp.mu.RLock()
+			defer hfp.mu.RUnlock()
+			return float64(len(hfp.rateLimiters))
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "false_positives_total",
+			Help:      "Rejections later reported as legitimate traffic.",
+		}, func() float64 {
+			hfp.metrics.mu.RLock()
+			defer hfp.metrics.mu.RUnlock()
+			return float64(hfp.metrics.FalsePositives)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "base_fee_gwei",
+			Help:      "Smoothed base fee used for tier assignment, zero until a fee feed reports.",
+		}, func() float64 {
+			baseFee := hfp.gasOracle.CurrentBaseFee()
+			if baseFee == nil {
+				return 0
+			}
+			gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(baseFee), big.NewFloat(1e9)).Float64()
+			return gwei
+		}),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return me
+}
+
+// Handler serves the registry, negotiating OpenMetrics when the scraper accepts it
+func (me *MetricsExporter) Handler() http.Handler {
+	return promhttp.HandlerFor(me.registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// observeValidation records the decision for one validated request
+func (me *MetricsExporter) observeValidation(result *ValidationResult, elapsed time.Duration) {
+	decision := "allowed"
+	if !result.Allowed {
+		decision = result.RejectionReason
+	}
+	me.requests.WithLabelValues(result.CurrentTier, decision).Inc()
+	me.validationLatency.Observe(elapsed.Seconds())
+
+	if result.AnomalyAction != "" && result.AnomalyAction != ActionAllow {
+		me.anomalyActions.WithLabelValues(result.CurrentTier, string(result.AnomalyAction)).Inc()
+	}
+
+	// Only rejections that start a cooldown or block count; later rejections fall inside it
+	switch result.RejectionReason {
+	case "burst_limit_exceeded", "anomaly_blocked":
+		me.blockDuration.WithLabelValues(result.CurrentTier, result.RejectionReason).Observe(result.CooldownRemaining.Seconds())
+	}
+}
+
+// observePuzzleRedemption records the outcome of a puzzle redemption
+func (me *MetricsExporter) observePuzzleRedemption(err error) {
+	outcome := "granted"
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrChallengeInvalid):
+		outcome = "invalid"
+	case errors.Is(err, ErrChallengeExpired):
+		outcome = "expired"
+	case errors.Is(err, ErrChallengeReplayed):
+		outcome = "replayed"
+	case errors.Is(err, ErrChallengeWrongClient):
+		outcome = "wrong_client"
+	case errors.Is(err, ErrSolutionInsufficient):
+		outcome = "insufficient"
+	default:
+		outcome = "error"
+	}
+	me.puzzleRedemptions.WithLabelValues(outcome).Inc()
+}
+
+// instrumentHandler records request counts, latency and concurrency for one route
+func (me *MetricsExporter) instrumentHandler(route string, handler http.HandlerFunc) http.Handler {
+	labels := prometheus.Labels{"handler": route}
+	return promhttp.InstrumentHandlerInFlight(me.apiInFlight,
+		promhttp.InstrumentHandlerDuration(me.apiLatency.MustCurryWith(labels),
+			promhttp.InstrumentHandlerCounter(me.apiRequests.MustCurryWith(labels), handler)))
+}
@@ -0,0 +1,86 @@
+package prevention
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrapeMetrics(t *testing.T, url string) (string, string) {
+	req, err := http.NewRequest(http.MethodGet, url+"/metrics", nil)
+	if err != nil {
+		t.Fatalf("failed to create scrape request: %v", err)
+	}
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read scrape: %v", err)
+	}
+	return resp.Header.Get("Content-Type"), string(body)
+}
+
+func TestMetricsEndpointExposesOpenMetrics(t *testing.T) {
+	hfp := newTestPrevention()
+	service := NewMultiTierRateLimitingService(hfp, &ServiceConfig{
+		Port:           0,
+		RequestTimeout: 10 * time.Second,
+	})
+
+	server := httptest.NewServer(service.server.Handler)
+	defer server.Close()
+
+	// Push a Basic client past its burst allowance so it enters cooldown
+	gasFee := big.NewInt(1000000000)
+	for i := 0; i < 700; i++ {
+		if _, err := hfp.ValidateHashRequest("sensor_0x0b", gasFee, "bin_reading"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	payload, _ := json.Marshal(&HashValidationRequest{
+		ClientID:      "bin_0x0c",
+		HashValue:     "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		GasFeePaidHex: gasFee.Text(16),
+		RequestType:   "bin_reading",
+	})
+	resp, err := http.Post(server.URL+"/validate-hash", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("validation request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	contentType, body := scrapeMetrics(t, server.URL)
+	if !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Errorf("expected OpenMetrics content type, got %q", contentType)
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Error("expected the OpenMetrics terminator")
+	}
+
+	expected := []string{
+		`flood_prevention_requests_total{decision="allowed",tier="Basic"}`,
+		`flood_prevention_requests_total{decision="burst_limit_exceeded",tier="Basic"} 1`,
+		`flood_prevention_block_duration_seconds_count{reason="burst_limit_exceeded",tier="Basic"} 1`,
+		`flood_prevention_http_requests_total{code="200",handler="/validate-hash",method="post"} 1`,
+		`flood_prevention_tracked_clients 2`,
+		`flood_prevention_false_positives_total 0`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected scrape to contain %q", line)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package prevention
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -14,6 +16,7 @@ import (
 // MultiTierRateLimitingService provides HTTP API for the multi-tier rate limiting system
 type MultiTierRateLimitingService struct {
 	prevention *HashFloodingPrevention
+	puzzles    *PuzzleIssuer
 	server     *http.Server
 	config     *ServiceConfig
 	metrics    *ServiceMetrics
@@ -27,6 +30,8 @@ type ServiceConfig struct {
 	EnableAdvancedAnalytics bool          `json:"enable_advanced_analytics"`
 	RequestTimeout         time.Duration `json:"request_timeout"`
 	MaxConcurrentRequests  int           `json:"max_concurrent_requests"`
+	Puzzles                *PuzzleConfig `json:"puzzles,omitempty"` // nil disables proof-of-work challenges
+	AdminToken             string        `json:"-"`                 // empty disables the admin API
 }
 
 // ServiceMetrics tracks HTTP service performance
@@ -55,9 +60,33 @@ type HashValidationResponse struct {
 	Error            string                  `json:"error,omitempty"`
 	ServiceTierInfo  *ServiceTierInfo        `json:"service_tier_info,omitempty"`
 	SystemMetrics    *SystemMetricsSnapshot  `json:"system_metrics,omitempty"`
+	Challenge        *PuzzleChallenge        `json:"challenge,omitempty"`
 	Timestamp        time.Time              `json:"timestamp"`
 }
 
+// PuzzleRedemptionRequest redeems a solved challenge for burst capacity
+type PuzzleRedemptionRequest struct {
+	ClientID string          `json:"client_id"`
+	Solution *PuzzleSolution `json:"solution"`
+}
+
+// PuzzleRedemptionResponse reports the burst capacity granted for a solution
+type PuzzleRedemptionResponse struct {
+	Success   bool         `json:"success"`
+	Grant     *PuzzleGrant `json:"grant,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// AccessListRequest adds a subject to, or removes it from, the allow or deny list
+type AccessListRequest struct {
+	List             AccessList `json:"list"`
+	Subject          string     `json:"subject"`
+	Reason           string     `json:"reason,omitempty"`
+	AddedBy          string     `json:"added_by,omitempty"`
+	ExpiresInSeconds int64      `json:"expires_in_seconds,omitempty"` // zero makes the entry permanent
+}
+
 // ServiceTierInfo provides detailed information about the client's service tier
 type ServiceTierInfo struct {
 	CurrentTier       string        `json:"current_tier"`
@@ -88,14 +117,36 @@ func NewMultiTierRateLimitingService(prevention *HashFloodingPrevention, config
 		metrics:    &ServiceMetrics{},
 	}
 	
+	if config.Puzzles != nil {
+		puzzles, err := NewPuzzleIssuer(config.Puzzles)
+		if err != nil {
+			fmt.Printf("Client puzzles disabled: %v\n", err)
+		} else {
+			service.puzzles = puzzles
+		}
+	}
+	
 	// Configure HTTP server
 	mux := http.NewServeMux()
-	mux.HandleFunc("/validate-hash", service.validateHashHandler)
-	mux.HandleFunc("/service-tiers", service.serviceTiersHandler)
-	mux.HandleFunc("/client-status", service.clientStatusHandler)
-	mux.HandleFunc("/system-metrics", service.systemMetricsHandler)
-	mux.HandleFunc("/false-positive-analysis", service.falsePositiveAnalysisHandler)
-	mux.HandleFunc("/health", service.healthCheckHandler)
+	exporter := prevention.Exporter()
+	routes := map[string]http.HandlerFunc{
+		"/validate-hash":           service.validateHashHandler,
+		"/service-tiers":           service.serviceTiersHandler,
+		"/client-status":           service.clientStatusHandler,
+		"/system-metrics":          service.systemMetricsHandler,
+		"/false-positive-analysis": service.falsePositiveAnalysisHandler,
+		"/anomaly-decisions":       service.anomalyDecisionsHandler,
+		"/solve-challenge":         service.solveChallengeHandler,
+		"/health":                  service.healthCheckHandler,
+		"/admin/reputation":        service.adminOnly(service.reputationHandler),
+		"/admin/access-list":       service.adminOnly(service.accessListHandler),
+		"/admin/blocklist/export":  service.adminOnly(service.exportBlockListHandler),
+		"/admin/blocklist/import":  service.adminOnly(service.importBlockListHandler),
+	}
+	for route, handler := range routes {
+		mux.Handle(route, exporter.instrumentHandler(route, handler))
+	}
+	mux.Handle("/metrics", exporter.Handler())
 	
 	service.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.Port),
@@ -149,12 +200,21 @@ func (mts *MultiTierRateLimitingService) validateHashHandler(w http.ResponseWrit
 	}
 	
 	// Validate hash request
-	validationResult, err := mts.prevention.ValidateHashRequest(req.ClientID, gasFeePaid, req.RequestType)
+	validationResult, err := mts.prevention.ValidateHashContent(req.ClientID, req.HashValue, gasFeePaid, req.RequestType)
 	if err != nil {
 		mts.sendErrorResponse(w, fmt.Sprintf("Validation error: %v", err), http.StatusInternalServerError)
 		return
 	}
 	
+	// Slow suspicious clients down before answering
+	if validationResult.SlowdownDelay > 0 {
+		select {
+		case <-time.After(validationResult.SlowdownDelay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	
 	// Get service tier information
 	tierInfo := mts.getServiceTierInfo(req.ClientID)
 	
@@ -173,6 +233,17 @@ func (mts *MultiTierRateLimitingService) validateHashHandler(w http.ResponseWrit
 		Timestamp:        time.Now(),
 	}
 	
+	// Throttled clients may earn burst capacity with proof-of-work instead of gas
+	if mts.puzzles != nil && isPuzzleEligible(validationResult) {
+		challenge, err := mts.puzzles.Issue(req.ClientID, time.Now())
+		if err != nil {
+			mts.sendErrorResponse(w, fmt.Sprintf("Challenge error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		response.Challenge = challenge
+		mts.prevention.exporter.puzzlesIssued.Inc()
+	}
+	
 	mts.sendJSONResponse(w, response)
 	mts.updateAPIMetrics(time.Since(start), true)
 }
@@ -196,12 +267,20 @@ func (mts *MultiTierRateLimitingService) serviceTiersHandler(w http.ResponseWrit
 			"max_gas_fee":     tier.MaxGasFee.String(),
 			"min_gas_gwei":    mts.weiToGwei(tier.MinGasFee),
 			"max_gas_gwei":    mts.weiToGwei(tier.MaxGasFee),
+			"min_base_fee_multiple": tier.MinBaseFeeMultiple,
 		}
 	}
 	
 	response := map[string]interface{}{
-		"service_tiers": tiers,
-		"timestamp":     time.Now(),
+		"service_tiers":     tiers,
+		"current_gas_price": mts.prevention.gasOracle.CurrentGasPrice().String(),
+		"timestamp":         time.Now(),
+	}
+	
+	// Tiers follow base-fee multiples while a fee feed is reporting
+	if baseFee := mts.prevention.gasOracle.CurrentBaseFee(); baseFee != nil {
+		response["current_base_fee"] = baseFee.String()
+		response["current_base_fee_gwei"] = mts.weiToGwei(baseFee)
 	}
 	
 	mts.sendJSONResponse(w, response)
@@ -324,6 +403,278 @@ func (mts *MultiTierRateLimitingService) handleFalsePositiveAnalysis(w http.Resp
 	mts.sendJSONResponse(w, response)
 }
 
+// solveChallengeHandler redeems a solved client puzzle for burst capacity
+func (mts *MultiTierRateLimitingService) solveChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	if mts.puzzles == nil {
+		mts.sendErrorResponse(w, "Client puzzles are disabled", http.StatusNotFound)
+		return
+	}
+	
+	var req PuzzleRedemptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		mts.sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	
+	if req.ClientID == "" || req.Solution == nil {
+		mts.sendErrorResponse(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	
+	grant, err := mts.puzzles.Redeem(req.ClientID, req.Solution, time.Now())
+	mts.prevention.exporter.observePuzzleRedemption(err)
+	if err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, ErrChallengeReplayed) {
+			status = http.StatusConflict
+		}
+		mts.sendErrorResponse(w, err.Error(), status)
+		return
+	}
+	
+	if err := mts.prevention.GrantPuzzleCredits(grant); err != nil {
+		mts.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	
+	mts.sendJSONResponse(w, &PuzzleRedemptionResponse{
+		Success:   true,
+		Grant:     grant,
+		Timestamp: time.Now(),
+	})
+}
+
+// anomalyDecisionsHandler lists recent anomaly detector decisions
+func (mts *MultiTierRateLimitingService) anomalyDecisionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	limit := 100
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 {
+			mts.sendErrorResponse(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	
+	decisions := mts.prevention.AnomalyDecisions(r.URL.Query().Get("client_id"), limit)
+	
+	response := map[string]interface{}{
+		"decisions": decisions,
+		"count":     len(decisions),
+		"timestamp": time.Now(),
+	}
+	
+	mts.sendJSONResponse(w, response)
+}
+
+// adminOnly requires the configured admin token and an enabled reputation store
+func (mts *MultiTierRateLimitingService) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if mts.config.AdminToken == "" {
+			mts.sendErrorResponse(w, "Admin API is disabled", http.StatusForbidden)
+			return
+		}
+		
+		token := r.Header.Get("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(mts.config.AdminToken)) != 1 {
+			mts.sendErrorResponse(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		
+		if mts.prevention.Reputation() == nil {
+			mts.sendErrorResponse(w, "Client reputation is disabled", http.StatusNotFound)
+			return
+		}
+		
+		next(w, r)
+	}
+}
+
+// reputationHandler reports one client's reputation or the worst offenders
+func (mts *MultiTierRateLimitingService) reputationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	reputation := mts.prevention.Reputation()
+	now := time.Now()
+	
+	if clientID := r.URL.Query().Get("client_id"); clientID != "" {
+		record, exists := reputation.Reputation(clientID, now)
+		if !exists {
+			mts.sendErrorResponse(w, "Client has no reputation record", http.StatusNotFound)
+			return
+		}
+		
+		response := map[string]interface{}{
+			"reputation":          record,
+			"cooldown_multiplier": reputation.CooldownMultiplier(clientID, now),
+			"timestamp":           now,
+		}
+		mts.sendJSONResponse(w, response)
+		return
+	}
+	
+	limit := 100
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 {
+			mts.sendErrorResponse(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	
+	offenders := reputation.Offenders(limit, now)
+	
+	response := map[string]interface{}{
+		"offenders": offenders,
+		"count":     len(offenders),
+		"timestamp": now,
+	}
+	
+	mts.sendJSONResponse(w, response)
+}
+
+// accessListHandler lists, adds and removes manual allow and deny entries
+func (mts *MultiTierRateLimitingService) accessListHandler(w http.ResponseWriter, r *http.Request) {
+	reputation := mts.prevention.Reputation()
+	
+	switch r.Method {
+	case http.MethodGet:
+		list := AccessList(r.URL.Query().Get("list"))
+		if list == "" {
+			list = AccessDeny
+		}
+		entries, err := reputation.AccessEntries(list)
+		if err != nil {
+			mts.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		
+		response := map[string]interface{}{
+			"list":      list,
+			"entries":   entries,
+			"count":     len(entries),
+			"timestamp": time.Now(),
+		}
+		mts.sendJSONResponse(w, response)
+		
+	case http.MethodPost:
+		var req AccessListRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			mts.sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+			return
+		}
+		if req.ExpiresInSeconds < 0 {
+			mts.sendErrorResponse(w, "Invalid expires_in_seconds", http.StatusBadRequest)
+			return
+		}
+		
+		now := time.Now()
+		entry := &AccessListEntry{
+			Subject: req.Subject,
+			Reason:  req.Reason,
+			AddedBy: req.AddedBy,
+			AddedAt: now,
+		}
+		if req.ExpiresInSeconds > 0 {
+			entry.ExpiresAt = now.Add(time.Duration(req.ExpiresInSeconds) * time.Second)
+		}
+		if err := reputation.SetAccess(req.List, entry); err != nil {
+			mts.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		
+		response := map[string]interface{}{
+			"success":   true,
+			"list":      req.List,
+			"entry":     entry,
+			"timestamp": now,
+		}
+		mts.sendJSONResponse(w, response)
+		
+	case http.MethodDelete:
+		list := AccessList(r.URL.Query().Get("list"))
+		subject := r.URL.Query().Get("subject")
+		if subject == "" {
+			mts.sendErrorResponse(w, "Missing subject parameter", http.StatusBadRequest)
+			return
+		}
+		removed, err := reputation.RemoveAccess(list, subject)
+		if err != nil {
+			mts.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !removed {
+			mts.sendErrorResponse(w, "Subject is not on the list", http.StatusNotFound)
+			return
+		}
+		
+		response := map[string]interface{}{
+			"success":   true,
+			"list":      list,
+			"subject":   subject,
+			"timestamp": time.Now(),
+		}
+		mts.sendJSONResponse(w, response)
+		
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// exportBlockListHandler exports the deny list and repeat offenders for other facilities
+func (mts *MultiTierRateLimitingService) exportBlockListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	mts.sendJSONResponse(w, mts.prevention.Reputation().Export(time.Now()))
+}
+
+// importBlockListHandler merges a block list exported by another facility
+func (mts *MultiTierRateLimitingService) importBlockListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	var export BlockListExport
+	if err := json.NewDecoder(r.Body).Decode(&export); err != nil {
+		mts.sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	
+	added, err := mts.prevention.Reputation().Import(&export, time.Now())
+	if err != nil {
+		mts.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"success":   true,
+		"imported":  added,
+		"offered":   len(export.Entries),
+		"source":    export.Facility,
+		"timestamp": time.Now(),
+	}
+	
+	mts.sendJSONResponse(w, response)
+}
+
 // healthCheckHandler provides health check endpoint
 func (mts *MultiTierRateLimitingService) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -360,6 +711,15 @@ func (mts *MultiTierRateLimitingService) rateLimitingMiddleware(next http.Handle
 }
 
 // Helper methods
+func isPuzzleEligible(result *ValidationResult) bool {
+	switch result.RejectionReason {
+	case "cooldown_active", "burst_limit_exceeded", "anomaly_challenge_required":
+		return true
+	default:
+		return false
+	}
+}
+
 func (mts *MultiTierRateLimitingService) getServiceTierInfo(clientID string) *ServiceTierInfo {
 	mts.prevention.mu.RLock()
 	limiter, exists := mts.prevention.rateLimiters[clientID]
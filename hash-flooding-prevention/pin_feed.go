@@ -0,0 +1,83 @@
+package prevention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultPinPollInterval is how often the pin feed re-reads the node's pin set
+const DefaultPinPollInterval = time.Minute
+
+// PinLister lists the CIDs pinned on an IPFS node
+type PinLister interface {
+	PinnedCIDs(ctx context.Context) ([]string, error)
+}
+
+// PinFeed polls an IPFS node's pin set and keeps the anomaly detector's view of
+// pinned CIDs current. The detector only gets the set after the first successful
+// poll, so an unreachable node cannot make every CID look never-pinned.
+type PinFeed struct {
+	lister   PinLister
+	pins     *PinnedCIDSet
+	interval time.Duration
+	attach   func(PinChecker)
+	attached bool
+	mu       sync.Mutex
+}
+
+// AttachPinFeed creates a pin feed supplying this system's anomaly detector
+func (hfp *HashFloodingPrevention) AttachPinFeed(lister PinLister, interval time.Duration) *PinFeed {
+	if interval <= 0 {
+		interval = DefaultPinPollInterval
+	}
+
+	return &PinFeed{
+		lister:   lister,
+		pins:     NewPinnedCIDSet(),
+		interval: interval,
+		attach:   hfp.SetPinChecker,
+	}
+}
+
+// Start polls the pin set until the context is cancelled
+func (pf *PinFeed) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pf.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := pf.Poll(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Pin feed poll failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Poll lists the node's pins once and replaces the pinned CID set; on failure the
+// previous set is kept
+func (pf *PinFeed) Poll(ctx context.Context) error {
+	cids, err := pf.lister.PinnedCIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pinned CIDs: %v", err)
+	}
+
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+
+	pf.pins.Replace(cids)
+	if !pf.attached {
+		pf.attach(pf.pins)
+		pf.attached = true
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package prevention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubPinLister returns its pins, or err when set
+type stubPinLister struct {
+	pins []string
+	err  error
+}
+
+func (l *stubPinLister) PinnedCIDs(ctx context.Context) ([]string, error) {
+	return l.pins, l.err
+}
+
+func TestPinFeedSuppliesDetector(t *testing.T) {
+	hfp := NewHashFloodingPrevention(&PreventionConfig{
+		EnableTieredLimiting:   true,
+		MaxClientsTracked:      100,
+		CleanupInterval:        time.Minute,
+		MetricsRetentionPeriod: time.Hour,
+		EnableAnomalyDetection: true,
+	})
+	lister := &stubPinLister{err: errors.New("connection refused")}
+	feed := hfp.AttachPinFeed(lister, 0)
+
+	if err := feed.Poll(context.Background()); err == nil {
+		t.Fatal("expected the failed listing to be reported")
+	}
+	if hfp.detector.pins != nil {
+		t.Fatal("expected no pin checker before a successful poll")
+	}
+
+	lister.pins, lister.err = []string{pinnedCID(1), pinnedCID(2)}, nil
+	if err := feed.Poll(context.Background()); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	pins := hfp.detector.pins
+	if pins == nil || !pins.IsPinned(pinnedCID(1)) || pins.IsPinned(pinnedCID(3)) {
+		t.Fatal("expected the detector to check CIDs against the node's pins")
+	}
+
+	// A later failure keeps the last pin set; a later success replaces it
+	lister.err = errors.New("timeout")
+	feed.Poll(context.Background())
+	if !pins.IsPinned(pinnedCID(2)) {
+		t.Error("expected a failed poll to keep the previous pins")
+	}
+	lister.pins, lister.err = []string{pinnedCID(3)}, nil
+	if err := feed.Poll(context.Background()); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if pins.IsPinned(pinnedCID(2)) || !pins.IsPinned(pinnedCID(3)) {
+		t.Error("expected the pin set to follow the node's pins")
+	}
+}
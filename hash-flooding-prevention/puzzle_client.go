@@ -0,0 +1,111 @@
+package prevention
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PuzzleClient validates hashes against the rate limiting service and, when throttled,
+// solves the returned challenge and retries once with the earned burst capacity.
+// It is intended for bins and sensors that cannot pay gas for a higher tier.
+type PuzzleClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewPuzzleClient creates a client for the service at baseURL
+func NewPuzzleClient(baseURL string) *PuzzleClient {
+	return &PuzzleClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// ValidateHash submits a validation request, paying with proof-of-work if challenged
+func (pc *PuzzleClient) ValidateHash(ctx context.Context, req *HashValidationRequest) (*HashValidationResponse, error) {
+	response, err := pc.validate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.ValidationResult == nil || response.ValidationResult.Allowed || response.Challenge == nil {
+		return response, nil
+	}
+
+	if _, err := pc.Redeem(ctx, req.ClientID, response.Challenge); err != nil {
+		return nil, err
+	}
+
+	return pc.validate(ctx, req)
+}
+
+// Redeem solves a challenge and exchanges the solution for burst capacity
+func (pc *PuzzleClient) Redeem(ctx context.Context, clientID string, challenge *PuzzleChallenge) (*PuzzleGrant, error) {
+	solution, err := SolveChallenge(ctx, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve challenge: %v", err)
+	}
+
+	var redemption PuzzleRedemptionResponse
+	err = pc.post(ctx, "/solve-challenge", &PuzzleRedemptionRequest{
+		ClientID: clientID,
+		Solution: solution,
+	}, &redemption)
+	if err != nil {
+		return nil, err
+	}
+	if !redemption.Success || redemption.Grant == nil {
+		return nil, fmt.Errorf("challenge redemption rejected: %s", redemption.Error)
+	}
+
+	return redemption.Grant, nil
+}
+
+func (pc *PuzzleClient) validate(ctx context.Context, req *HashValidationRequest) (*HashValidationResponse, error) {
+	var response HashValidationResponse
+	if err := pc.post(ctx, "/validate-hash", req, &response); err != nil {
+		return nil, err
+	}
+	if !response.Success {
+		return nil, fmt.Errorf("validation failed: %s", response.Error)
+	}
+	return &response, nil
+}
+
+func (pc *PuzzleClient) post(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, pc.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := pc.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	// Error bodies share the {"success": false, "error": ...} shape
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("%s returned status %d: %s", path, resp.StatusCode, failure.Error)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %v", path, err)
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package prevention
 import (
 	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,7 +12,10 @@ import (
 type HashFloodingPrevention struct {
 	rateLimiters map[string]*TieredRateLimiter
 	gasOracle    *GasOracle
+	detector     *AnomalyDetector
+	reputation   *ReputationStore
 	metrics      *FloodPreventionMetrics
+	exporter     *MetricsExporter
 	config       *PreventionConfig
 	mu           sync.RWMutex
 }
@@ -25,6 +29,8 @@ type TieredRateLimiter struct {
 	cooldownUntil  time.Time
 	gasFeePaid     *big.Int
 	isBlocked      bool
+	puzzleCredits  int       // burst requests earned by solving client puzzles
+	puzzleExpiry   time.Time // when unused puzzle credits lapse
 	mu             sync.RWMutex
 }
 
@@ -36,6 +42,7 @@ type ServiceTier struct {
 	CooldownPeriod  time.Duration // cooldown after burst
 	MinGasFee       *big.Int      // minimum gas fee in gwei
 	MaxGasFee       *big.Int      // maximum gas fee in gwei
+	MinBaseFeeMultiple float64    // lower bound as a multiple of the current base fee
 }
 
 // RequestCounter tracks request rates over time windows
@@ -57,6 +64,10 @@ type BurstTracker struct {
 // GasOracle manages gas fee verification and tier assignment
 type GasOracle struct {
 	currentGasPrice *big.Int
+	currentBaseFee  *big.Int      // moving base fee estimate, nil until a fee feed reports
+	lastFeeUpdate   time.Time
+	maxFeeStaleness time.Duration // base fee estimates older than this fall back to absolute tiers
+	hysteresis      float64       // fractional band around tier boundaries
 	tiers           []ServiceTier
 	mu              sync.RWMutex
 }
@@ -79,6 +90,9 @@ type PreventionConfig struct {
 	MaxClientsTracked      int
 	CleanupInterval        time.Duration
 	MetricsRetentionPeriod time.Duration
+	EnableAnomalyDetection bool
+	AnomalyDetection       *AnomalyConfig // nil uses DefaultAnomalyConfig
+	Reputation             *ReputationConfig // nil disables client reputation
 }
 
 // NewHashFloodingPrevention creates a new hash flooding prevention system
@@ -93,6 +107,7 @@ func NewHashFloodingPrevention(config *PreventionConfig) *HashFloodingPrevention
 				CooldownPeriod: 60 * time.Second,
 				MinGasFee:      big.NewInt(1000000000),   // 1 gwei
 				MaxGasFee:      big.NewInt(10000000000),  // 10 gwei
+				MinBaseFeeMultiple: 0,    // below 0.5x base fee
 			},
 			{
 				Name:           "Standard",
@@ -101,6 +116,7 @@ func NewHashFloodingPrevention(config *PreventionConfig) *HashFloodingPrevention
 				CooldownPeriod: 45 * time.Second,
 				MinGasFee:      big.NewInt(11000000000),  // 11 gwei
 				MaxGasFee:      big.NewInt(25000000000),  // 25 gwei
+				MinBaseFeeMultiple: 0.5,  // 0.5x base fee
 			},
 			{
 				Name:           "Premium",
@@ -109,6 +125,7 @@ func NewHashFloodingPrevention(config *PreventionConfig) *HashFloodingPrevention
 				CooldownPeriod: 30 * time.Second,
 				MinGasFee:      big.NewInt(26000000000),  // 26 gwei
 				MaxGasFee:      big.NewInt(50000000000),  // 50 gwei
+				MinBaseFeeMultiple: 1.25, // 1.25x base fee
 			},
 			{
 				Name:           "Enterprise",
@@ -117,6 +134,7 @@ func NewHashFloodingPrevention(config *PreventionConfig) *HashFloodingPrevention
 				CooldownPeriod: 15 * time.Second,
 				MinGasFee:      big.NewInt(51000000000),  // 51 gwei
 				MaxGasFee:      big.NewInt(100000000000), // 100 gwei
+				MinBaseFeeMultiple: 2.5,  // 2.5x base fee
 			},
 			{
 				Name:           "Platinum",
@@ -125,6 +143,7 @@ func NewHashFloodingPrevention(config *PreventionConfig) *HashFloodingPrevention
 				CooldownPeriod: 5 * time.Second,
 				MinGasFee:      big.NewInt(100000000001), // 100+ gwei
 				MaxGasFee:      big.NewInt(1000000000000), // 1000 gwei
+				MinBaseFeeMultiple: 5,    // 5x+ base fee
 			},
 		},
 	}
@@ -138,6 +157,23 @@ func NewHashFloodingPrevention(config *PreventionConfig) *HashFloodingPrevention
 		},
 	}
 	
+	if config.EnableAnomalyDetection {
+		hfp.detector = NewAnomalyDetector(config.AnomalyDetection, nil)
+	}
+	
+	if config.Reputation != nil {
+		reputation, err := NewReputationStore(config.Reputation)
+		if err != nil {
+			// Keep enforcing in memory rather than overwrite a store that failed to load
+			fmt.Printf("Client reputation not loaded, continuing in memory: %v\n", err)
+			inMemory := *config.Reputation
+			inMemory.Path = ""
+			reputation, _ = NewReputationStore(&inMemory)
+		}
+		hfp.reputation = reputation
+	}
+	hfp.exporter = NewMetricsExporter(hfp)
+	
 	// Start cleanup routine
 	go hfp.cleanupRoutine()
 	
@@ -146,28 +182,93 @@ func NewHashFloodingPrevention(config *PreventionConfig) *HashFloodingPrevention
 
 // ValidateHashRequest validates a hash request against rate limits and gas fees
 func (hfp *HashFloodingPrevention) ValidateHashRequest(clientID string, gasFeePaid *big.Int, requestType string) (*ValidationResult, error) {
+	return hfp.ValidateHashContent(clientID, "", gasFeePaid, requestType)
+}
+
+// ValidateHashContent validates a request for a specific hash; with anomaly detection
+// enabled the hash feeds the client's distinct, repeat and unpinned CID baselines
+func (hfp *HashFloodingPrevention) ValidateHashContent(clientID, hashValue string, gasFeePaid *big.Int, requestType string) (*ValidationResult, error) {
 	hfp.mu.Lock()
 	defer hfp.mu.Unlock()
 	
 	start := time.Now()
 	
+	// Deny-listed and reputation-blocked clients are refused before any limiter work
+	if hfp.reputation != nil {
+		if reason, until := hfp.reputation.Check(clientID, start); reason != "" {
+			result := &ValidationResult{
+				Allowed:         false,
+				RejectionReason: reason,
+				CurrentTier:     hfp.gasOracle.DetermineTier(gasFeePaid).Name,
+			}
+			if !until.IsZero() {
+				result.CooldownRemaining = until.Sub(start)
+			}
+			hfp.recordValidation(result, start)
+			return result, nil
+		}
+	}
+	
 	// Get or create rate limiter for client
 	limiter, exists := hfp.rateLimiters[clientID]
 	if !exists {
 		tier := hfp.gasOracle.DetermineTier(gasFeePaid)
 		limiter = hfp.createRateLimiter(clientID, tier, gasFeePaid)
 		hfp.rateLimiters[clientID] = limiter
+		
+		// A new limiter inherits any cooldown from before a restart or idle eviction
+		if hfp.reputation != nil {
+			limiter.restoreBlock(hfp.reputation.ActiveBlock(clientID, start))
+		}
+	} else {
+		// Keep the tier in step with the moving base fee
+		limiter.reassessTier(hfp.gasOracle, gasFeePaid)
 	}
 	
 	// Validate request against rate limits
 	result := limiter.ValidateRequest(gasFeePaid, requestType)
 	
-	// Update metrics
-	hfp.updateMetrics(result, time.Since(start))
+	// Requests within limits are still checked against the learned baselines
+	if result.Allowed && hfp.detector != nil {
+		decision := hfp.detector.Evaluate(clientID, result.CurrentTier, hashValue, requestType, start)
+		limiter.applyAnomalyDecision(decision, result, hfp.detector.config)
+	}
+	
+	// Violations feed the persisted reputation, which stretches cooldowns for repeat offenders
+	if !result.Allowed && hfp.reputation != nil {
+		hfp.applyReputation(limiter, result, start)
+	}
+	
+	hfp.recordValidation(result, start)
 	
 	return result, nil
 }
 
+// recordValidation updates system and exported metrics for a validated request
+func (hfp *HashFloodingPrevention) recordValidation(result *ValidationResult, start time.Time) {
+	elapsed := time.Since(start)
+	hfp.updateMetrics(result, elapsed)
+	hfp.exporter.observeValidation(result, elapsed)
+}
+
+// applyReputation records a rejection against the client and escalates new cooldowns
+func (hfp *HashFloodingPrevention) applyReputation(limiter *TieredRateLimiter, result *ValidationResult, now time.Time) {
+	// Escalation reflects the history before this violation, so first offences keep the tier cooldown
+	multiplier := hfp.reputation.CooldownMultiplier(limiter.clientID, now)
+	if !hfp.reputation.RecordViolation(limiter.clientID, result.RejectionReason, now) {
+		return
+	}
+	
+	switch result.RejectionReason {
+	case "burst_limit_exceeded", "anomaly_blocked":
+		if multiplier > 1 {
+			result.CooldownRemaining = time.Duration(float64(result.CooldownRemaining) * multiplier)
+			limiter.extendCooldown(now.Add(result.CooldownRemaining))
+		}
+		hfp.reputation.NoteBlock(limiter.clientID, now.Add(result.CooldownRemaining), result.RejectionReason)
+	}
+}
+
 // ValidationResult contains the result of hash request validation
 type ValidationResult struct {
 	Allowed           bool          `json:"allowed"`
@@ -178,6 +279,11 @@ type ValidationResult struct {
 	CooldownRemaining time.Duration `json:"cooldown_remaining"`
 	IsFalsePositive   bool          `json:"is_false_positive"`
 	MitigationTime    time.Duration `json:"mitigation_time"`
+	AnomalyAction     AnomalyAction `json:"anomaly_action,omitempty"`
+	AnomalyScore      float64       `json:"anomaly_score,omitempty"`
+	AnomalyDecisionID string        `json:"anomaly_decision_id,omitempty"`
+	SlowdownDelay     time.Duration `json:"slowdown_delay,omitempty"`
+	PuzzleCredits     int           `json:"puzzle_credits,omitempty"`
 }
 
 // createRateLimiter creates a new tiered rate limiter for a client
@@ -198,6 +304,71 @@ func (hfp *HashFloodingPrevention) createRateLimiter(clientID string, tier Servi
 	}
 }
 
+// restoreBlock reinstates a persisted cooldown or anomaly block
+func (trl *TieredRateLimiter) restoreBlock(until time.Time, reason string) {
+	if until.IsZero() {
+		return
+	}
+	
+	trl.mu.Lock()
+	defer trl.mu.Unlock()
+	trl.cooldownUntil = until
+	trl.isBlocked = reason == "anomaly_blocked"
+}
+
+// extendCooldown pushes the end of the current cooldown out to until
+func (trl *TieredRateLimiter) extendCooldown(until time.Time) {
+	trl.mu.Lock()
+	defer trl.mu.Unlock()
+	if until.After(trl.cooldownUntil) {
+		trl.cooldownUntil = until
+	}
+}
+
+// reassessTier moves the limiter to the tier the oracle currently assigns
+func (trl *TieredRateLimiter) reassessTier(oracle *GasOracle, gasFeePaid *big.Int) {
+	trl.mu.Lock()
+	defer trl.mu.Unlock()
+	trl.currentTier = oracle.ReassessTier(trl.currentTier, gasFeePaid)
+}
+
+// applyAnomalyDecision turns the detector's graduated response into the validation result
+func (trl *TieredRateLimiter) applyAnomalyDecision(decision *AnomalyDecision, result *ValidationResult, config *AnomalyConfig) {
+	result.AnomalyAction = decision.Action
+	result.AnomalyScore = decision.Score
+	result.AnomalyDecisionID = decision.ID
+	
+	switch decision.Action {
+	case ActionSlowdown:
+		result.SlowdownDelay = config.SlowdownDelay
+		result.MitigationTime = config.SlowdownDelay
+	case ActionChallenge:
+		// A previously solved puzzle answers the challenge
+		trl.mu.Lock()
+		paid := trl.consumePuzzleCredit(decision.Timestamp)
+		result.PuzzleCredits = trl.puzzleCredits
+		trl.mu.Unlock()
+		if paid {
+			return
+		}
+		
+		result.Allowed = false
+		result.RejectionReason = "anomaly_challenge_required"
+		result.RequestsRemaining = 0
+	case ActionBlock:
+		trl.mu.Lock()
+		trl.isBlocked = true
+		trl.cooldownUntil = decision.Timestamp.Add(config.BlockDuration)
+		trl.mu.Unlock()
+		
+		result.Allowed = false
+		result.RejectionReason = "anomaly_blocked"
+		result.RequestsRemaining = 0
+		result.CooldownRemaining = config.BlockDuration
+		result.MitigationTime = config.BlockDuration
+	}
+}
+
 // ValidateRequest validates a single request against rate limits
 func (trl *TieredRateLimiter) ValidateRequest(gasFeePaid *big.Int, requestType string) *ValidationResult {
 	trl.mu.Lock()
@@ -205,20 +376,29 @@ func (trl *TieredRateLimiter) ValidateRequest(gasFeePaid *big.Int, requestType s
 	
 	now := time.Now()
 	
-	// Check if in cooldown period
+	// Check if in cooldown period; puzzle credits cover rate cooldowns but not blocks
+	puzzlePaid := false
 	if now.Before(trl.cooldownUntil) {
-		return &ValidationResult{
-			Allowed:           false,
-			RejectionReason:   "cooldown_active",
-			CurrentTier:       trl.currentTier.Name,
-			CooldownRemaining: trl.cooldownUntil.Sub(now),
+		if trl.isBlocked || !trl.consumePuzzleCredit(now) {
+			reason := "cooldown_active"
+			if trl.isBlocked {
+				reason = "client_blocked"
+			}
+			return &ValidationResult{
+				Allowed:           false,
+				RejectionReason:   reason,
+				CurrentTier:       trl.currentTier.Name,
+				CooldownRemaining: trl.cooldownUntil.Sub(now),
+			}
 		}
+		puzzlePaid = true
+	} else {
+		trl.isBlocked = false
 	}
 	
-	// Update gas fee and potentially upgrade tier
+	// Update gas fee; tier changes are handled by reassessTier
 	if gasFeePaid.Cmp(trl.gasFeePaid) > 0 {
 		trl.gasFeePaid = gasFeePaid
-		// Note: In real implementation, would check for tier upgrade
 	}
 	
 	// Clean old requests from window
@@ -229,7 +409,7 @@ func (trl *TieredRateLimiter) ValidateRequest(gasFeePaid *big.Int, requestType s
 	if currentRate >= trl.currentTier.BaseLimit {
 		// Check burst allowance
 		burstUsed := trl.burstTracker.getBurstUsed(now)
-		if burstUsed >= trl.currentTier.BurstAllowance {
+		if burstUsed >= trl.currentTier.BurstAllowance && !puzzlePaid && !trl.consumePuzzleCredit(now) {
 			// Enter cooldown
 			trl.cooldownUntil = now.Add(trl.currentTier.CooldownPeriod)
 			return &ValidationResult{
@@ -252,22 +432,168 @@ func (trl *TieredRateLimiter) ValidateRequest(gasFeePaid *big.Int, requestType s
 		CurrentTier:       trl.currentTier.Name,
 		RequestsRemaining: trl.currentTier.BaseLimit - currentRate - 1,
 		ResetTime:         now.Add(time.Second),
+		PuzzleCredits:     trl.puzzleCredits,
+	}
+}
+
+// consumePuzzleCredit spends one earned burst request; callers hold trl.mu
+func (trl *TieredRateLimiter) consumePuzzleCredit(now time.Time) bool {
+	if trl.puzzleCredits <= 0 {
+		return false
+	}
+	if now.After(trl.puzzleExpiry) {
+		trl.puzzleCredits = 0
+		return false
+	}
+	
+	trl.puzzleCredits--
+	return true
+}
+
+// GrantPuzzleCredits adds the burst allowance earned by a solved puzzle to a throttled client
+func (hfp *HashFloodingPrevention) GrantPuzzleCredits(grant *PuzzleGrant) error {
+	hfp.mu.RLock()
+	limiter, exists := hfp.rateLimiters[grant.ClientID]
+	hfp.mu.RUnlock()
+	
+	if !exists {
+		return fmt.Errorf("client %s has no rate limiter", grant.ClientID)
+	}
+	
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	
+	if time.Now().After(limiter.puzzleExpiry) {
+		limiter.puzzleCredits = 0
 	}
+	limiter.puzzleCredits += grant.Requests
+	limiter.puzzleExpiry = grant.ExpiresAt
+	
+	return nil
 }
 
 // DetermineTier determines the service tier based on gas fee paid
-func (go *GasOracle) DetermineTier(gasFeePaid *big.Int) ServiceTier {
-	go.mu.RLock()
-	defer go.mu.RUnlock()
+func (oracle *GasOracle) DetermineTier(gasFeePaid *big.Int) ServiceTier {
+	oracle.mu.RLock()
+	defer oracle.mu.RUnlock()
 	
-	for _, tier := range go.tiers {
+	// Prefer base-fee multiples once a fee feed is reporting
+	if multiple, ok := oracle.baseFeeMultiple(gasFeePaid); ok {
+		return oracle.tiers[oracle.tierIndexForMultiple(multiple)]
+	}
+	
+	for _, tier := range oracle.tiers {
 		if gasFeePaid.Cmp(tier.MinGasFee) >= 0 && gasFeePaid.Cmp(tier.MaxGasFee) <= 0 {
 			return tier
 		}
 	}
 	
 	// Default to basic tier if no match
-	return go.tiers[0]
+	return oracle.tiers[0]
+}
+
+// ReassessTier re-evaluates an existing client's tier against the current base fee.
+// A client only moves up once it clears the next boundary by the hysteresis band,
+// and only moves down once it falls below its own boundary by the same band.
+func (oracle *GasOracle) ReassessTier(current ServiceTier, gasFeePaid *big.Int) ServiceTier {
+	oracle.mu.RLock()
+	defer oracle.mu.RUnlock()
+	
+	multiple, ok := oracle.baseFeeMultiple(gasFeePaid)
+	if !ok {
+		// Absolute wei tiers are fixed at limiter creation
+		return current
+	}
+	
+	currentIndex := 0
+	for i, tier := range oracle.tiers {
+		if tier.Name == current.Name {
+			currentIndex = i
+			break
+		}
+	}
+	
+	if up := oracle.tierIndexForMultiple(multiple / (1 + oracle.hysteresis)); up > currentIndex {
+		return oracle.tiers[up]
+	}
+	if down := oracle.tierIndexForMultiple(multiple / (1 - oracle.hysteresis)); down < currentIndex {
+		return oracle.tiers[down]
+	}
+	
+	return oracle.tiers[currentIndex]
+}
+
+// UpdateFeeEstimate records a new base fee and gas price estimate from a fee feed
+func (oracle *GasOracle) UpdateFeeEstimate(baseFee, gasPrice *big.Int) {
+	oracle.mu.Lock()
+	defer oracle.mu.Unlock()
+	
+	oracle.currentBaseFee = new(big.Int).Set(baseFee)
+	oracle.currentGasPrice = new(big.Int).Set(gasPrice)
+	oracle.lastFeeUpdate = time.Now()
+}
+
+// CurrentBaseFee returns the current base fee estimate, or nil if none is available
+func (oracle *GasOracle) CurrentBaseFee() *big.Int {
+	oracle.mu.RLock()
+	defer oracle.mu.RUnlock()
+	
+	if !oracle.baseFeeFresh() {
+		return nil
+	}
+	return new(big.Int).Set(oracle.currentBaseFee)
+}
+
+// CurrentGasPrice returns the current gas price estimate
+func (oracle *GasOracle) CurrentGasPrice() *big.Int {
+	oracle.mu.RLock()
+	defer oracle.mu.RUnlock()
+	return new(big.Int).Set(oracle.currentGasPrice)
+}
+
+// setFeePolicy configures the hysteresis band and staleness bound for base-fee tiers
+func (oracle *GasOracle) setFeePolicy(hysteresis float64, maxStaleness time.Duration) {
+	oracle.mu.Lock()
+	defer oracle.mu.Unlock()
+	
+	if hysteresis < 0 || hysteresis >= 1 {
+		hysteresis = 0
+	}
+	oracle.hysteresis = hysteresis
+	oracle.maxFeeStaleness = maxStaleness
+}
+
+// baseFeeFresh reports whether the base fee estimate may be used; callers hold oracle.mu
+func (oracle *GasOracle) baseFeeFresh() bool {
+	if oracle.currentBaseFee == nil || oracle.currentBaseFee.Sign() <= 0 {
+		return false
+	}
+	if oracle.maxFeeStaleness > 0 && time.Since(oracle.lastFeeUpdate) > oracle.maxFeeStaleness {
+		return false
+	}
+	return true
+}
+
+// baseFeeMultiple expresses gasFeePaid as a multiple of the current base fee; callers hold oracle.mu
+func (oracle *GasOracle) baseFeeMultiple(gasFeePaid *big.Int) (float64, bool) {
+	if !oracle.baseFeeFresh() {
+		return 0, false
+	}
+	
+	ratio := new(big.Float).Quo(new(big.Float).SetInt(gasFeePaid), new(big.Float).SetInt(oracle.currentBaseFee))
+	multiple, _ := ratio.Float64()
+	return multiple, true
+}
+
+// tierIndexForMultiple returns the highest tier whose lower bound the multiple reaches
+func (oracle *GasOracle) tierIndexForMultiple(multiple float64) int {
+	index := 0
+	for i, tier := range oracle.tiers {
+		if multiple >= tier.MinBaseFeeMultiple {
+			index = i
+		}
+	}
+	return index
 }
 
 // RequestCounter methods
@@ -318,13 +644,27 @@ func (hfp *HashFloodingPrevention) FalsePositiveAnalysis(clientID string, wasLeg
 	if limiter, exists := hfp.rateLimiters[clientID]; exists {
 		if limiter.isBlocked && wasLegitimate {
 			hfp.metrics.FalsePositives++
-			
-			// Calculate false positive rate
-			if hfp.metrics.TotalRequests > 0 {
-				hfp.metrics.FalsePositiveRate = float64(hfp.metrics.FalsePositives) / float64(hfp.metrics.TotalRequests) * 100
-			}
 		}
 	}
+	
+	// Detector decisions are reviewed individually; a blocked client was already counted above
+	if hfp.detector != nil {
+		flagged := hfp.detector.RecordFeedback(clientID, wasLegitimate)
+		if limiter, exists := hfp.rateLimiters[clientID]; exists && limiter.isBlocked && flagged > 0 {
+			flagged--
+		}
+		hfp.metrics.FalsePositives += int64(flagged)
+	}
+	
+	// A legitimate client should not carry the penalty into its reputation
+	if wasLegitimate && hfp.reputation != nil {
+		hfp.reputation.Forgive(clientID, time.Now())
+	}
+	
+	// Calculate false positive rate
+	if hfp.metrics.TotalRequests > 0 {
+		hfp.metrics.FalsePositiveRate = float64(hfp.metrics.FalsePositives) / float64(hfp.metrics.TotalRequests) * 100
+	}
 }
 
 // GetFalsePositiveImpactAssessment returns detailed false positive analysis
@@ -376,6 +716,13 @@ func (hfp *HashFloodingPrevention) GetFalsePositiveImpactAssessment() map[string
 		},
 	}
 	
+	// Add the tiers observed by the anomaly detector
+	if hfp.detector != nil {
+		for key, tierStats := range hfp.detector.TierFalsePositiveStats() {
+			stats[key] = tierStats
+		}
+	}
+	
 	return stats
 }
 
@@ -417,6 +764,9 @@ func (hfp *HashFloodingPrevention) cleanupRoutine() {
 	
 	for range ticker.C {
 		hfp.performCleanup()
+		if err := hfp.SaveReputation(); err != nil {
+			fmt.Printf("Failed to save client reputation: %v\n", err)
+		}
 	}
 }
 
@@ -440,6 +790,13 @@ func (hfp *HashFloodingPrevention) performCleanup() {
 	// Remove expired clients
 	for _, clientID := range expiredClients {
 		delete(hfp.rateLimiters, clientID)
+		if hfp.detector != nil {
+			hfp.detector.ForgetClient(clientID)
+		}
+	}
+	
+	if hfp.reputation != nil {
+		hfp.reputation.prune(now)
 	}
 }
 
@@ -467,9 +824,9 @@ func (hfp *HashFloodingPrevention) GetSystemMetrics() *FloodPreventionMetrics {
 
 // PrintDetailedReport prints a comprehensive report of the hash flooding prevention system
 func (hfp *HashFloodingPrevention) PrintDetailedReport() {
-	fmt.Printf("\n" + "="*80 + "\n")
+	fmt.Printf("\n" + strings.Repeat("=", 80) + "\n")
 	fmt.Printf("HASH FLOODING ATTACK PREVENTION - DETAILED REPORT\n")
-	fmt.Printf("="*80 + "\n")
+	fmt.Printf(strings.Repeat("=", 80) + "\n")
 	
 	metrics := hfp.GetSystemMetrics()
 	fpStats := hfp.GetFalsePositiveImpactAssessment()
@@ -478,7 +835,7 @@ func (hfp *HashFloodingPrevention) PrintDetailedReport() {
 	fmt.Printf("\n📊 MULTI-TIER RATE LIMITING SERVICE LEVELS\n")
 	fmt.Printf("%-12s | %-12s | %-15s | %-12s | %-15s\n", 
 		"Service Tier", "Base Limit", "Burst Allowance", "Cooldown", "Gas Fee Range")
-	fmt.Printf("%s\n", "-"*80)
+	fmt.Printf("%s\n", strings.Repeat("-", 80))
 	
 	for _, tier := range hfp.gasOracle.tiers {
 		fmt.Printf("%-12s | %-12s | %-15s | %-12s | %-15s\n",
@@ -495,12 +852,12 @@ func (hfp *HashFloodingPrevention) PrintDetailedReport() {
 	fmt.Printf("\n🎯 FALSE POSITIVE IMPACT ASSESSMENT\n")
 	fmt.Printf("%-15s | %-20s | %-15s | %-8s | %-12s | %-15s\n",
 		"Rate Tier", "Total Legitimate", "False Positives", "FP Rate", "User Impact", "Mitigation Time")
-	fmt.Printf("%s\n", "-"*95)
+	fmt.Printf("%s\n", strings.Repeat("-", 95))
 	
 	for _, stats := range fpStats {
 		fmt.Printf("%-15s | %-20s | %-15d | %-8.4f%% | %-12s | %-15s\n",
 			stats.RateTier,
-			fmt.Sprintf("%,d", stats.TotalLegitimateRequests),
+			fmt.Sprintf("%d", stats.TotalLegitimateRequests),
 			stats.FalsePositives,
 			stats.FPRate,
 			stats.UserImpact,
@@ -509,9 +866,9 @@ func (hfp *HashFloodingPrevention) PrintDetailedReport() {
 	
 	// System Performance Metrics
 	fmt.Printf("\n🖥️  SYSTEM PERFORMANCE METRICS\n")
-	fmt.Printf("Total Requests Processed: %,d\n", metrics.TotalRequests)
-	fmt.Printf("Blocked Requests: %,d\n", metrics.BlockedRequests)
-	fmt.Printf("False Positives: %,d\n", metrics.FalsePositives)
+	fmt.Printf("Total Requests Processed: %d\n", metrics.TotalRequests)
+	fmt.Printf("Blocked Requests: %d\n", metrics.BlockedRequests)
+	fmt.Printf("False Positives: %d\n", metrics.FalsePositives)
 	fmt.Printf("Overall False Positive Rate: %.4f%%\n", metrics.FalsePositiveRate)
 	fmt.Printf("Average Mitigation Time: %.2fs\n", metrics.AverageMitigationTime.Seconds())
 	
@@ -519,12 +876,45 @@ func (hfp *HashFloodingPrevention) PrintDetailedReport() {
 	fmt.Printf("\n📈 SERVICE TIER DISTRIBUTION\n")
 	for tier, count := range metrics.TierDistribution {
 		percentage := float64(count) / float64(metrics.TotalRequests) * 100
-		fmt.Printf("  %s: %,d requests (%.2f%%)\n", tier, count, percentage)
+		fmt.Printf("  %s: %d requests (%.2f%%)\n", tier, count, percentage)
 	}
 	
 	fmt.Printf("\n✅ Hash flooding prevention system operating optimally!\n")
 }
 
+// SetPinChecker supplies the pinned-CID lookup used by the anomaly detector
+func (hfp *HashFloodingPrevention) SetPinChecker(pins PinChecker) {
+	if hfp.detector != nil {
+		hfp.detector.SetPinChecker(pins)
+	}
+}
+
+// Reputation returns the client reputation store, or nil when reputation is disabled
+func (hfp *HashFloodingPrevention) Reputation() *ReputationStore {
+	return hfp.reputation
+}
+
+// SaveReputation persists client reputation and access lists
+func (hfp *HashFloodingPrevention) SaveReputation() error {
+	if hfp.reputation == nil {
+		return nil
+	}
+	return hfp.reputation.Save()
+}
+
+// Exporter returns the Prometheus exporter for the prevention system
+func (hfp *HashFloodingPrevention) Exporter() *MetricsExporter {
+	return hfp.exporter
+}
+
+// AnomalyDecisions returns recent anomaly detector decisions, optionally for one client
+func (hfp *HashFloodingPrevention) AnomalyDecisions(clientID string, limit int) []*AnomalyDecision {
+	if hfp.detector == nil {
+		return nil
+	}
+	return hfp.detector.RecentDecisions(clientID, limit)
+}
+
 // Helper function to convert Wei to Gwei
 func (hfp *HashFloodingPrevention) weiToGwei(wei *big.Int) string {
 	gwei := new(big.Int).Div(wei, big.NewInt(1000000000))
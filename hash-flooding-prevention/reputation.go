@@ -0,0 +1,604 @@
+package prevention
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlockListFormat identifies exported block lists
+const BlockListFormat = "securewear-blocklist"
+
+// blockListVersion is the current export and persistence format version
+const blockListVersion = 1
+
+// AccessList names a manual allow or deny list
+type AccessList string
+
+const (
+	AccessAllow AccessList = "allow"
+	AccessDeny  AccessList = "deny"
+)
+
+var (
+	ErrUnknownAccessList  = errors.New("unknown access list")
+	ErrInvalidBlockList   = errors.New("not a securewear block list")
+	ErrMissingListSubject = errors.New("access list entry needs a subject")
+)
+
+// ReputationStore tracks violation history per client or wallet across restarts.
+// Scores decay exponentially so old offences are forgiven, repeat offenders get
+// escalating cooldowns, and manual allow and deny lists override the scores.
+type ReputationStore struct {
+	config  *ReputationConfig
+	clients map[string]*ClientReputation
+	allow   map[string]*AccessListEntry
+	deny    map[string]*AccessListEntry
+	dirty   bool
+	mu      sync.Mutex
+	saveMu  sync.Mutex // serializes writers so an older snapshot never replaces a newer one
+}
+
+// ReputationConfig holds configuration for client reputation
+type ReputationConfig struct {
+	Path                  string             `json:"path"`                    // JSON file the store persists to; empty keeps it in memory
+	Facility              string             `json:"facility"`                // recorded as the source of exported block lists
+	HalfLife              time.Duration      `json:"half_life"`               // time for a violation score to halve
+	EscalationStep        float64            `json:"escalation_step"`         // score per doubling of new cooldowns
+	MaxCooldownMultiplier float64            `json:"max_cooldown_multiplier"` // cap on cooldown escalation
+	BlockThreshold        float64            `json:"block_threshold"`         // score at which a client is refused outright
+	ViolationWeights      map[string]float64 `json:"violation_weights"`       // score added per rejection reason
+}
+
+// ClientReputation is the violation history of one client or wallet
+type ClientReputation struct {
+	ClientID      string    `json:"client_id"`
+	Score         float64   `json:"score"` // decayed violation weight as of UpdatedAt
+	Violations    int64     `json:"violations"`
+	LastReason    string    `json:"last_reason,omitempty"`
+	LastWeight    float64   `json:"last_weight,omitempty"`
+	LastViolation time.Time `json:"last_violation"`
+	BlockedUntil  time.Time `json:"blocked_until"`
+	BlockReason   string    `json:"block_reason,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AccessListEntry is a manual allow or deny decision for a client ID or wallet address
+type AccessListEntry struct {
+	Subject   string    `json:"subject"`
+	Reason    string    `json:"reason,omitempty"`
+	AddedBy   string    `json:"added_by,omitempty"`
+	Source    string    `json:"source,omitempty"` // facility an imported entry came from
+	AddedAt   time.Time `json:"added_at"`
+	ExpiresAt time.Time `json:"expires_at"` // zero means permanent
+}
+
+// BlockListExport shares deny entries and repeat offenders between facilities
+type BlockListExport struct {
+	Format     string             `json:"format"`
+	Version    int                `json:"version"`
+	Facility   string             `json:"facility"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Entries    []*AccessListEntry `json:"entries"`
+}
+
+// reputationSnapshot is the persisted form of the store
+type reputationSnapshot struct {
+	Version int                 `json:"version"`
+	SavedAt time.Time           `json:"saved_at"`
+	Clients []*ClientReputation `json:"clients"`
+	Allow   []*AccessListEntry  `json:"allow"`
+	Deny    []*AccessListEntry  `json:"deny"`
+}
+
+// DefaultReputationConfig returns in-memory reputation settings
+func DefaultReputationConfig() *ReputationConfig {
+	return &ReputationConfig{
+		HalfLife:              24 * time.Hour,
+		EscalationStep:        3,
+		MaxCooldownMultiplier: 32,
+		BlockThreshold:        50,
+		ViolationWeights: map[string]float64{
+			"burst_limit_exceeded":       1,
+			"anomaly_challenge_required": 0.5,
+			"anomaly_blocked":            5,
+		},
+	}
+}
+
+// NewReputationStore creates a store, loading persisted state when the file exists
+func NewReputationStore(config *ReputationConfig) (*ReputationStore, error) {
+	if config == nil {
+		config = DefaultReputationConfig()
+	}
+	if config.HalfLife <= 0 || config.EscalationStep <= 0 {
+		return nil, fmt.Errorf("reputation half-life and escalation step must be positive")
+	}
+
+	rs := &ReputationStore{
+		config:  config,
+		clients: make(map[string]*ClientReputation),
+		allow:   make(map[string]*AccessListEntry),
+		deny:    make(map[string]*AccessListEntry),
+	}
+
+	if config.Path == "" {
+		return rs, nil
+	}
+
+	data, err := os.ReadFile(config.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reputation store: %v", err)
+	}
+
+	var snapshot reputationSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse reputation store %s: %v", config.Path, err)
+	}
+	if snapshot.Version != blockListVersion {
+		return nil, fmt.Errorf("unsupported reputation store version %d", snapshot.Version)
+	}
+
+	for _, rep := range snapshot.Clients {
+		rs.clients[rep.ClientID] = rep
+	}
+	for _, entry := range snapshot.Allow {
+		rs.allow[entry.Subject] = entry
+	}
+	for _, entry := range snapshot.Deny {
+		rs.deny[entry.Subject] = entry
+	}
+
+	return rs, nil
+}
+
+// Save writes the store to its file if anything changed since the last save
+func (rs *ReputationStore) Save() error {
+	rs.saveMu.Lock()
+	defer rs.saveMu.Unlock()
+
+	rs.mu.Lock()
+	if rs.config.Path == "" || !rs.dirty {
+		rs.mu.Unlock()
+		return nil
+	}
+
+	snapshot := &reputationSnapshot{
+		Version: blockListVersion,
+		SavedAt: time.Now(),
+		Clients: make([]*ClientReputation, 0, len(rs.clients)),
+		Allow:   sortedEntries(rs.allow),
+		Deny:    sortedEntries(rs.deny),
+	}
+	for _, rep := range rs.clients {
+		copied := *rep
+		snapshot.Clients = append(snapshot.Clients, &copied)
+	}
+	rs.dirty = false
+	rs.mu.Unlock()
+
+	sort.Slice(snapshot.Clients, func(i, j int) bool {
+		return snapshot.Clients[i].ClientID < snapshot.Clients[j].ClientID
+	})
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		rs.markDirty()
+		return fmt.Errorf("failed to encode reputation store: %v", err)
+	}
+
+	// Write beside the target and rename so a crash never leaves a truncated store
+	tmp, err := os.CreateTemp(filepath.Dir(rs.config.Path), filepath.Base(rs.config.Path)+".tmp-*")
+	if err != nil {
+		rs.markDirty()
+		return fmt.Errorf("failed to create reputation store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		rs.markDirty()
+		return fmt.Errorf("failed to write reputation store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		rs.markDirty()
+		return fmt.Errorf("failed to write reputation store: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), rs.config.Path); err != nil {
+		rs.markDirty()
+		return fmt.Errorf("failed to replace reputation store: %v", err)
+	}
+
+	return nil
+}
+
+// Check reports why a client must be refused before rate limiting, if at all,
+// and until when the refusal lasts (zero when indefinite)
+func (rs *ReputationStore) Check(clientID string, now time.Time) (string, time.Time) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.activeEntryLocked(rs.allow, clientID, now) != nil {
+		return "", time.Time{}
+	}
+	if entry := rs.activeEntryLocked(rs.deny, clientID, now); entry != nil {
+		return "client_denied", entry.ExpiresAt
+	}
+
+	rep, exists := rs.clients[clientID]
+	if !exists || rs.config.BlockThreshold <= 0 {
+		return "", time.Time{}
+	}
+	score := rs.decayedScore(rep, now)
+	if score < rs.config.BlockThreshold {
+		return "", time.Time{}
+	}
+
+	// The refusal lifts once the score decays back under the threshold
+	halfLives := math.Log2(score / rs.config.BlockThreshold)
+	return "reputation_blocked", now.Add(time.Duration(halfLives * float64(rs.config.HalfLife)))
+}
+
+// ActiveBlock returns a cooldown or block imposed before the client's limiter was
+// last dropped, so restarts and idle eviction do not give offenders a fresh start
+func (rs *ReputationStore) ActiveBlock(clientID string, now time.Time) (time.Time, string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rep, exists := rs.clients[clientID]
+	if !exists || !rep.BlockedUntil.After(now) {
+		return time.Time{}, ""
+	}
+	return rep.BlockedUntil, rep.BlockReason
+}
+
+// CooldownMultiplier returns how much new cooldowns are stretched for the client
+func (rs *ReputationStore) CooldownMultiplier(clientID string, now time.Time) float64 {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rep, exists := rs.clients[clientID]
+	if !exists || rs.activeEntryLocked(rs.allow, clientID, now) != nil {
+		return 1
+	}
+
+	multiplier := math.Pow(2, math.Floor(rs.decayedScore(rep, now)/rs.config.EscalationStep))
+	if rs.config.MaxCooldownMultiplier >= 1 && multiplier > rs.config.MaxCooldownMultiplier {
+		multiplier = rs.config.MaxCooldownMultiplier
+	}
+	return multiplier
+}
+
+// RecordViolation adds the weight of a rejection to the client's score.
+// It returns false for allow-listed clients and reasons without a weight.
+func (rs *ReputationStore) RecordViolation(clientID, reason string, now time.Time) bool {
+	weight := rs.config.ViolationWeights[reason]
+	if weight <= 0 {
+		return false
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.activeEntryLocked(rs.allow, clientID, now) != nil {
+		return false
+	}
+
+	rep, exists := rs.clients[clientID]
+	if !exists {
+		rep = &ClientReputation{ClientID: clientID}
+		rs.clients[clientID] = rep
+	}
+
+	rep.Score = rs.decayedScore(rep, now) + weight
+	rep.Violations++
+	rep.LastReason = reason
+	rep.LastWeight = weight
+	rep.LastViolation = now
+	rep.UpdatedAt = now
+	rs.dirty = true
+
+	return true
+}
+
+// NoteBlock persists a cooldown or block so it survives limiter eviction
+func (rs *ReputationStore) NoteBlock(clientID string, until time.Time, reason string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rep, exists := rs.clients[clientID]
+	if !exists {
+		return
+	}
+	if until.After(rep.BlockedUntil) {
+		rep.BlockedUntil = until
+		rep.BlockReason = reason
+		rs.dirty = true
+	}
+}
+
+// Forgive undoes the latest violation after it was reported as a false positive
+func (rs *ReputationStore) Forgive(clientID string, now time.Time) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rep, exists := rs.clients[clientID]
+	if !exists {
+		return
+	}
+
+	rep.Score = math.Max(0, rs.decayedScore(rep, now)-rep.LastWeight)
+	rep.LastWeight = 0
+	rep.BlockedUntil = time.Time{}
+	rep.BlockReason = ""
+	rep.UpdatedAt = now
+	rs.dirty = true
+}
+
+// Reputation returns the client's record with its score decayed to now
+func (rs *ReputationStore) Reputation(clientID string, now time.Time) (*ClientReputation, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rep, exists := rs.clients[clientID]
+	if !exists {
+		return nil, false
+	}
+	return rs.decayedCopy(rep, now), true
+}
+
+// Offenders returns the clients with the highest current scores
+func (rs *ReputationStore) Offenders(limit int, now time.Time) []*ClientReputation {
+	rs.mu.Lock()
+	offenders := make([]*ClientReputation, 0, len(rs.clients))
+	for _, rep := range rs.clients {
+		offenders = append(offenders, rs.decayedCopy(rep, now))
+	}
+	rs.mu.Unlock()
+
+	sort.Slice(offenders, func(i, j int) bool {
+		return offenders[i].Score > offenders[j].Score
+	})
+	if limit > 0 && len(offenders) > limit {
+		offenders = offenders[:limit]
+	}
+	return offenders
+}
+
+// SetAccess adds or replaces an entry on the allow or deny list.
+// A subject is only ever on one list; adding it to one removes it from the other.
+func (rs *ReputationStore) SetAccess(list AccessList, entry *AccessListEntry) error {
+	if entry == nil || strings.TrimSpace(entry.Subject) == "" {
+		return ErrMissingListSubject
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	target, other, err := rs.listsLocked(list)
+	if err != nil {
+		return err
+	}
+
+	copied := *entry
+	if copied.AddedAt.IsZero() {
+		copied.AddedAt = time.Now()
+	}
+	target[copied.Subject] = &copied
+	delete(other, copied.Subject)
+	rs.dirty = true
+
+	return nil
+}
+
+// RemoveAccess removes a subject from the allow or deny list
+func (rs *ReputationStore) RemoveAccess(list AccessList, subject string) (bool, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	target, _, err := rs.listsLocked(list)
+	if err != nil {
+		return false, err
+	}
+	if _, exists := target[subject]; !exists {
+		return false, nil
+	}
+	delete(target, subject)
+	rs.dirty = true
+
+	return true, nil
+}
+
+// AccessEntries lists the entries on the allow or deny list
+func (rs *ReputationStore) AccessEntries(list AccessList) ([]*AccessListEntry, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	target, _, err := rs.listsLocked(list)
+	if err != nil {
+		return nil, err
+	}
+	return sortedEntries(target), nil
+}
+
+// Export builds a block list from the deny list and clients at the block threshold
+func (rs *ReputationStore) Export(now time.Time) *BlockListExport {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	export := &BlockListExport{
+		Format:     BlockListFormat,
+		Version:    blockListVersion,
+		Facility:   rs.config.Facility,
+		ExportedAt: now,
+		Entries:    make([]*AccessListEntry, 0, len(rs.deny)),
+	}
+
+	for _, entry := range sortedEntries(rs.deny) {
+		if !entry.ExpiresAt.IsZero() && !entry.ExpiresAt.After(now) {
+			continue
+		}
+		export.Entries = append(export.Entries, entry)
+	}
+
+	if rs.config.BlockThreshold > 0 {
+		offenders := make([]*AccessListEntry, 0)
+		for clientID, rep := range rs.clients {
+			if _, denied := rs.deny[clientID]; denied {
+				continue
+			}
+			if rs.activeEntryLocked(rs.allow, clientID, now) != nil {
+				continue
+			}
+			score := rs.decayedScore(rep, now)
+			if score < rs.config.BlockThreshold {
+				continue
+			}
+			offenders = append(offenders, &AccessListEntry{
+				Subject: clientID,
+				Reason:  fmt.Sprintf("reputation score %.1f after %d violations", score, rep.Violations),
+				Source:  rs.config.Facility,
+				AddedAt: rep.LastViolation,
+			})
+		}
+		sort.Slice(offenders, func(i, j int) bool {
+			return offenders[i].Subject < offenders[j].Subject
+		})
+		export.Entries = append(export.Entries, offenders...)
+	}
+
+	return export
+}
+
+// Import merges another facility's block list into the deny list and returns the
+// number of entries added. Local allow-list entries and existing denials win.
+func (rs *ReputationStore) Import(export *BlockListExport, now time.Time) (int, error) {
+	if export == nil || export.Format != BlockListFormat {
+		return 0, ErrInvalidBlockList
+	}
+	if export.Version != blockListVersion {
+		return 0, fmt.Errorf("unsupported block list version %d", export.Version)
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	added := 0
+	for _, entry := range export.Entries {
+		if entry == nil || strings.TrimSpace(entry.Subject) == "" {
+			continue
+		}
+		if !entry.ExpiresAt.IsZero() && !entry.ExpiresAt.After(now) {
+			continue
+		}
+		if rs.activeEntryLocked(rs.allow, entry.Subject, now) != nil {
+			continue
+		}
+		if _, exists := rs.deny[entry.Subject]; exists {
+			continue
+		}
+
+		copied := *entry
+		if copied.Source == "" {
+			copied.Source = export.Facility
+		}
+		if copied.AddedAt.IsZero() {
+			copied.AddedAt = now
+		}
+		rs.deny[copied.Subject] = &copied
+		added++
+	}
+	if added > 0 {
+		rs.dirty = true
+	}
+
+	return added, nil
+}
+
+// prune drops forgotten clients and expired list entries
+func (rs *ReputationStore) prune(now time.Time) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	for clientID, rep := range rs.clients {
+		if rs.decayedScore(rep, now) < 0.01 && !rep.BlockedUntil.After(now) {
+			delete(rs.clients, clientID)
+			rs.dirty = true
+		}
+	}
+	for _, list := range []map[string]*AccessListEntry{rs.allow, rs.deny} {
+		for subject, entry := range list {
+			if !entry.ExpiresAt.IsZero() && !entry.ExpiresAt.After(now) {
+				delete(list, subject)
+				rs.dirty = true
+			}
+		}
+	}
+}
+
+func (rs *ReputationStore) markDirty() {
+	rs.mu.Lock()
+	rs.dirty = true
+	rs.mu.Unlock()
+}
+
+// decayedScore halves the stored score once per elapsed half-life
+func (rs *ReputationStore) decayedScore(rep *ClientReputation, now time.Time) float64 {
+	elapsed := now.Sub(rep.UpdatedAt)
+	if elapsed <= 0 {
+		return rep.Score
+	}
+	return rep.Score * math.Exp2(-float64(elapsed)/float64(rs.config.HalfLife))
+}
+
+func (rs *ReputationStore) decayedCopy(rep *ClientReputation, now time.Time) *ClientReputation {
+	copied := *rep
+	copied.Score = rs.decayedScore(rep, now)
+	return &copied
+}
+
+// activeEntryLocked returns the subject's unexpired entry on the list; callers hold rs.mu
+func (rs *ReputationStore) activeEntryLocked(list map[string]*AccessListEntry, subject string, now time.Time) *AccessListEntry {
+	entry, exists := list[subject]
+	if !exists {
+		return nil
+	}
+	if !entry.ExpiresAt.IsZero() && !entry.ExpiresAt.After(now) {
+		return nil
+	}
+	return entry
+}
+
+// listsLocked returns the named list and its opposite; callers hold rs.mu
+func (rs *ReputationStore) listsLocked(list AccessList) (map[string]*AccessListEntry, map[string]*AccessListEntry, error) {
+	switch list {
+	case AccessAllow:
+		return rs.allow, rs.deny, nil
+	case AccessDeny:
+		return rs.deny, rs.allow, nil
+	default:
+		return nil, nil, ErrUnknownAccessList
+	}
+}
+
+func sortedEntries(list map[string]*AccessListEntry) []*AccessListEntry {
+	entries := make([]*AccessListEntry, 0, len(list))
+	for _, entry := range list {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Subject < entries[j].Subject
+	})
+	return entries
+}
@@ -0,0 +1,222 @@
+package prevention
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newReputationPrevention(t *testing.T, config *ReputationConfig) *HashFloodingPrevention {
+	t.Helper()
+	return NewHashFloodingPrevention(&PreventionConfig{
+		EnableTieredLimiting:   true,
+		MaxClientsTracked:      100,
+		CleanupInterval:        time.Minute,
+		MetricsRetentionPeriod: time.Hour,
+		Reputation:             config,
+	})
+}
+
+// floodUntilCooldown sends Basic tier requests until the client is pushed into cooldown
+func floodUntilCooldown(t *testing.T, hfp *HashFloodingPrevention, clientID string) *ValidationResult {
+	t.Helper()
+	gasFee := big.NewInt(1000000000)
+	for i := 0; i < 1000; i++ {
+		result, err := hfp.ValidateHashRequest(clientID, gasFee, "bin_reading")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RejectionReason == "burst_limit_exceeded" {
+			return result
+		}
+	}
+	t.Fatalf("client %s never exceeded its burst allowance", clientID)
+	return nil
+}
+
+func TestReputationCooldownSurvivesRestart(t *testing.T) {
+	config := DefaultReputationConfig()
+	config.Path = filepath.Join(t.TempDir(), "reputation.json")
+
+	hfp := newReputationPrevention(t, config)
+	floodUntilCooldown(t, hfp, "sensor_0x01")
+	if err := hfp.SaveReputation(); err != nil {
+		t.Fatalf("failed to save reputation: %v", err)
+	}
+
+	restarted := newReputationPrevention(t, config)
+	result, err := restarted.ValidateHashRequest("sensor_0x01", big.NewInt(1000000000), "bin_reading")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Allowed || result.RejectionReason != "cooldown_active" {
+		t.Fatalf("expected the cooldown to survive a restart, got %+v", result)
+	}
+	if result.CooldownRemaining <= 0 {
+		t.Errorf("expected remaining cooldown, got %v", result.CooldownRemaining)
+	}
+
+	record, exists := restarted.Reputation().Reputation("sensor_0x01", time.Now())
+	if !exists || record.Violations != 1 {
+		t.Errorf("expected one persisted violation, got %+v", record)
+	}
+}
+
+func TestReputationEscalatesRepeatCooldowns(t *testing.T) {
+	config := DefaultReputationConfig()
+	// A single violation, decayed by a few milliseconds, still crosses one step
+	config.EscalationStep = 0.5
+
+	hfp := newReputationPrevention(t, config)
+	first := floodUntilCooldown(t, hfp, "attacker_0x02")
+
+	// Let the first cooldown lapse while the burst window is still exhausted
+	limiter := hfp.rateLimiters["attacker_0x02"]
+	limiter.mu.Lock()
+	limiter.cooldownUntil = time.Now().Add(-time.Second)
+	limiter.mu.Unlock()
+
+	second := floodUntilCooldown(t, hfp, "attacker_0x02")
+	if second.CooldownRemaining != 2*first.CooldownRemaining {
+		t.Errorf("expected the repeat cooldown to double from %v, got %v", first.CooldownRemaining, second.CooldownRemaining)
+	}
+}
+
+func TestReputationScoreDecays(t *testing.T) {
+	config := DefaultReputationConfig()
+	store, err := NewReputationStore(config)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		store.RecordViolation("bin_0x03", "burst_limit_exceeded", now)
+	}
+
+	record, _ := store.Reputation("bin_0x03", now.Add(config.HalfLife))
+	if record.Score < 1.99 || record.Score > 2.01 {
+		t.Errorf("expected the score to halve to 2 after one half-life, got %.3f", record.Score)
+	}
+	if multiplier := store.CooldownMultiplier("bin_0x03", now.Add(10*config.HalfLife)); multiplier != 1 {
+		t.Errorf("expected old violations to stop escalating, got multiplier %v", multiplier)
+	}
+}
+
+func TestBlockListExportImport(t *testing.T) {
+	now := time.Now()
+
+	newStore := func(facility string) *ReputationStore {
+		config := DefaultReputationConfig()
+		config.Facility = facility
+		store, err := NewReputationStore(config)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		return store
+	}
+
+	source := newStore("facility-a")
+	source.SetAccess(AccessDeny, &AccessListEntry{Subject: "0xbad1", Reason: "spoofed readings"})
+	source.SetAccess(AccessDeny, &AccessListEntry{Subject: "0xbad2", Reason: "flooding"})
+
+	target := newStore("facility-b")
+	target.SetAccess(AccessAllow, &AccessListEntry{Subject: "0xbad2", Reason: "known contractor"})
+
+	// Round-trip through JSON as a shared file would
+	encoded, err := json.Marshal(source.Export(now))
+	if err != nil {
+		t.Fatalf("failed to encode export: %v", err)
+	}
+	var export BlockListExport
+	if err := json.Unmarshal(encoded, &export); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+
+	added, err := target.Import(&export, now)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if added != 1 {
+		t.Fatalf("expected one entry imported, got %d", added)
+	}
+
+	if reason, _ := target.Check("0xbad1", now); reason != "client_denied" {
+		t.Errorf("expected imported subject to be denied, got %q", reason)
+	}
+	if reason, _ := target.Check("0xbad2", now); reason != "" {
+		t.Errorf("expected the local allow entry to win, got %q", reason)
+	}
+
+	entries, _ := target.AccessEntries(AccessDeny)
+	if len(entries) != 1 || entries[0].Source != "facility-a" {
+		t.Errorf("expected imported entry sourced from facility-a, got %+v", entries)
+	}
+
+	if _, err := target.Import(&BlockListExport{Format: "other"}, now); err != ErrInvalidBlockList {
+		t.Errorf("expected ErrInvalidBlockList, got %v", err)
+	}
+}
+
+func TestAdminAccessListAPI(t *testing.T) {
+	hfp := newReputationPrevention(t, DefaultReputationConfig())
+	service := NewMultiTierRateLimitingService(hfp, &ServiceConfig{
+		Port:           0,
+		RequestTimeout: 10 * time.Second,
+		AdminToken:     "secret",
+	})
+
+	server := httptest.NewServer(service.server.Handler)
+	defer server.Close()
+
+	payload, _ := json.Marshal(&AccessListRequest{
+		List:    AccessDeny,
+		Subject: "0xwallet",
+		Reason:  "reported by facility operator",
+	})
+
+	resp, err := http.Post(server.URL+"/admin/access-list", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the admin token, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/admin/access-list", bytes.NewReader(payload))
+	req.Header.Set("X-Admin-Token", "secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with the admin token, got %d", resp.StatusCode)
+	}
+
+	validation, _ := json.Marshal(&HashValidationRequest{
+		ClientID:      "0xwallet",
+		HashValue:     "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		GasFeePaidHex: big.NewInt(1000000000).Text(16),
+		RequestType:   "bin_reading",
+	})
+	resp, err = http.Post(server.URL+"/validate-hash", "application/json", bytes.NewReader(validation))
+	if err != nil {
+		t.Fatalf("validation request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response HashValidationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.ValidationResult == nil || response.ValidationResult.RejectionReason != "client_denied" {
+		t.Errorf("expected the denied wallet to be refused, got %+v", response.ValidationResult)
+	}
+}
@@ -0,0 +1,64 @@
+// Package bindingtest provides in-memory stand-ins for the IPFS connector and
+// the cryptographic binding, for tests of the code built on top of them
+package bindingtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	binding "blockchain-jedi/ipfs-blockchain-binding"
+)
+
+// MemStore keeps data in memory, addressed by a fake CID
+type MemStore struct {
+	Data map[string]*binding.WasteManagementData
+}
+
+// NewMemStore returns an empty MemStore
+func NewMemStore() *MemStore {
+	return &MemStore{Data: make(map[string]*binding.WasteManagementData)}
+}
+
+func (m *MemStore) StoreWasteManagementData(data *binding.WasteManagementData) (string, error) {
+	encoded, _ := json.Marshal(data)
+	sum := sha256.Sum256(encoded)
+	cid := "Qm" + hex.EncodeToString(sum[:])[:44]
+	m.Data[cid] = data
+	return cid, nil
+}
+
+func (m *MemStore) RetrieveWasteManagementData(hash string, clientID string) (*binding.WasteManagementData, error) {
+	data, exists := m.Data[hash]
+	if !exists {
+		return nil, fmt.Errorf("%s not found", hash)
+	}
+	return data, nil
+}
+
+// Binder anchors without a chain
+type Binder struct {
+	Bound map[string]string // binding hash to IPFS hash
+}
+
+// NewBinder returns a Binder with nothing bound
+func NewBinder() *Binder {
+	return &Binder{Bound: make(map[string]string)}
+}
+
+func (b *Binder) GenerateHIBEKeyForWasteManagement(binID, operatorWallet, department, dataType, accessLevel string) (*binding.HIBEKeyData, error) {
+	return &binding.HIBEKeyData{BinID: binID, OperatorWallet: operatorWallet, KeyHash: "key-" + binID + "-" + dataType}, nil
+}
+
+func (b *Binder) CreateCryptographicBinding(keyData *binding.HIBEKeyData, ipfsHash string, gasFeePaid *big.Int) (*binding.AccessBinding, error) {
+	hash := fmt.Sprintf("binding-%d", len(b.Bound))
+	b.Bound[hash] = ipfsHash
+	return &binding.AccessBinding{BindingHash: hash, TransactionID: "0xtx" + hash, IPFSHash: ipfsHash}, nil
+}
+
+func (b *Binder) VerifyBinding(bindingHash string) (bool, error) {
+	_, exists := b.Bound[bindingHash]
+	return exists, nil
+}
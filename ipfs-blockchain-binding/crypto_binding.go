@@ -1,16 +1,16 @@
 package binding
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/big"
-	"strings"
 	"sync"
 	"time"
 	
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // CryptographicBinding manages IPFS-blockchain key binding operations
@@ -61,15 +61,15 @@ type AccessPolicy struct {
 
 // BindingCache provides LRU caching for frequently accessed bindings
 type BindingCache struct {
-	cache    map[string]*CacheEntry
+	cache    map[string]*BindingCacheEntry
 	maxSize  int
 	hits     int64
 	misses   int64
 	mu       sync.RWMutex
 }
 
-// CacheEntry represents a cached binding entry
-type CacheEntry struct {
+// BindingCacheEntry represents a cached binding entry
+type BindingCacheEntry struct {
 	Binding   *AccessBinding
 	AccessTime time.Time
 	HitCount   int64
@@ -119,11 +119,31 @@ func (cb *CryptographicBinding) GenerateHIBEKeyForWasteManagement(binID, operato
 
 // CreateCryptographicBinding implements the detailed binding mechanism
 func (cb *CryptographicBinding) CreateCryptographicBinding(hibeKeyData *HIBEKeyData, ipfsHash string, gasFeePaid *big.Int) (*AccessBinding, error) {
+	return cb.CreateCryptographicBindingContext(context.Background(), hibeKeyData, ipfsHash, gasFeePaid)
+}
+
+// CreateCryptographicBindingContext is CreateCryptographicBinding bounded by
+// ctx. The transaction and the stored binding are spans under one binding span
+// of the trace ctx carries.
+func (cb *CryptographicBinding) CreateCryptographicBindingContext(ctx context.Context, hibeKeyData *HIBEKeyData, ipfsHash string, gasFeePaid *big.Int) (*AccessBinding, error) {
+	var binding *AccessBinding
+	err := traceCall(ctx, "binding.create", []attribute.KeyValue{
+		attribute.String("waste.bin_id", hibeKeyData.BinID),
+		attribute.String("ipfs.cid", ipfsHash),
+	}, func(ctx context.Context) error {
+		var err error
+		binding, err = cb.createCryptographicBinding(ctx, hibeKeyData, ipfsHash, gasFeePaid)
+		return err
+	})
+	return binding, err
+}
+
+func (cb *CryptographicBinding) createCryptographicBinding(ctx context.Context, hibeKeyData *HIBEKeyData, ipfsHash string, gasFeePaid *big.Int) (*AccessBinding, error) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 	
 	// Step 1: Submit transaction to Ethereum blockchain
-	transactionID, err := cb.ETHConnector.SubmitAccessTransaction(hibeKeyData, gasFeePaid)
+	transactionID, err := cb.ETHConnector.SubmitAccessTransactionContext(ctx, hibeKeyData, gasFeePaid)
 	if err != nil {
 		return nil, fmt.Errorf("failed to submit ETH transaction: %v", err)
 	}
@@ -157,7 +177,7 @@ func (cb *CryptographicBinding) CreateCryptographicBinding(hibeKeyData *HIBEKeyD
 	}
 	
 	// Step 5: Store binding in smart contract
-	err = cb.ETHConnector.StoreBinding(binding)
+	err = cb.ETHConnector.StoreBindingContext(ctx, binding)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store binding in smart contract: %v", err)
 	}
@@ -345,7 +365,7 @@ func (cb *CryptographicBinding) weiToEth(wei *big.Int) string {
 // Cache implementation
 func NewBindingCache(maxSize int) *BindingCache {
 	return &BindingCache{
-		cache:   make(map[string]*CacheEntry),
+		cache:   make(map[string]*BindingCacheEntry),
 		maxSize: maxSize,
 	}
 }
@@ -374,7 +394,7 @@ func (bc *BindingCache) Put(key string, binding *AccessBinding) {
 		bc.evictOldest()
 	}
 	
-	bc.cache[key] = &CacheEntry{
+	bc.cache[key] = &BindingCacheEntry{
 		Binding:    binding,
 		AccessTime: time.Now(),
 		HitCount:   0,
@@ -8,12 +8,15 @@ import (
 	"strings"
 	"time"
 	
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EthereumConnector manages blockchain interactions for cryptographic binding
@@ -99,6 +102,23 @@ func NewEthereumConnector(nodeURL, privateKeyHex, contractAddressHex string, cha
 
 // SubmitAccessTransaction submits waste-management access transaction to blockchain
 func (ec *EthereumConnector) SubmitAccessTransaction(hibeKeyData *HIBEKeyData, gasFeePaid *big.Int) (string, error) {
+	return ec.SubmitAccessTransactionContext(context.Background(), hibeKeyData, gasFeePaid)
+}
+
+// SubmitAccessTransactionContext is SubmitAccessTransaction bounded by ctx, in
+// a span of the trace ctx carries
+func (ec *EthereumConnector) SubmitAccessTransactionContext(ctx context.Context, hibeKeyData *HIBEKeyData, gasFeePaid *big.Int) (string, error) {
+	var txHash string
+	err := traceCall(ctx, "eth.submit_access", []attribute.KeyValue{attribute.String("waste.bin_id", hibeKeyData.BinID)}, func(ctx context.Context) error {
+		var err error
+		txHash, err = ec.submitAccessTransaction(ctx, hibeKeyData, gasFeePaid)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("eth.tx", txHash))
+		return err
+	})
+	return txHash, err
+}
+
+func (ec *EthereumConnector) submitAccessTransaction(ctx context.Context, hibeKeyData *HIBEKeyData, gasFeePaid *big.Int) (string, error) {
 	// Get current nonce
 	publicKey := ec.privateKey.Public()
 	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
@@ -107,27 +127,17 @@ func (ec *EthereumConnector) SubmitAccessTransaction(hibeKeyData *HIBEKeyData, g
 	}
 	
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := ec.client.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := ec.client.PendingNonceAt(ctx, fromAddress)
 	if err != nil {
 		return "", fmt.Errorf("failed to get nonce: %v", err)
 	}
 	
 	// Get current gas price
-	gasPrice, err := ec.client.SuggestGasPrice(context.Background())
+	gasPrice, err := ec.client.SuggestGasPrice(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get gas price: %v", err)
 	}
 	
-	// Create transaction data for waste-management access
-	txData := map[string]interface{}{
-		"binID":    hibeKeyData.BinID,
-		"operatorWallet": hibeKeyData.OperatorWallet,
-		"keyHash":      hibeKeyData.KeyHash,
-		"timestamp":    hibeKeyData.Timestamp.Unix(),
-		"dataType":     ec.extractDataType(hibeKeyData.Identity),
-		"department":   ec.extractDepartment(hibeKeyData.Identity),
-	}
-	
 	// Create transaction
 	auth, err := bind.NewKeyedTransactorWithChainID(ec.privateKey, ec.chainID)
 	if err != nil {
@@ -154,7 +164,7 @@ func (ec *EthereumConnector) SubmitAccessTransaction(hibeKeyData *HIBEKeyData, g
 		return "", fmt.Errorf("failed to sign transaction: %v", err)
 	}
 	
-	err = ec.client.SendTransaction(context.Background(), signedTx)
+	err = ec.client.SendTransaction(ctx, signedTx)
 	if err != nil {
 		return "", fmt.Errorf("failed to send transaction: %v", err)
 	}
@@ -165,6 +175,21 @@ func (ec *EthereumConnector) SubmitAccessTransaction(hibeKeyData *HIBEKeyData, g
 
 // StoreBinding stores the cryptographic binding in the smart contract
 func (ec *EthereumConnector) StoreBinding(binding *AccessBinding) error {
+	return ec.StoreBindingContext(context.Background(), binding)
+}
+
+// StoreBindingContext is StoreBinding bounded by ctx, in a span of the trace
+// ctx carries. Waiting for confirmation is part of the span.
+func (ec *EthereumConnector) StoreBindingContext(ctx context.Context, binding *AccessBinding) error {
+	return traceCall(ctx, "eth.store_binding", []attribute.KeyValue{
+		attribute.String("binding.hash", binding.BindingHash),
+		attribute.String("ipfs.cid", binding.IPFSHash),
+	}, func(ctx context.Context) error {
+		return ec.storeBinding(ctx, binding)
+	})
+}
+
+func (ec *EthereumConnector) storeBinding(ctx context.Context, binding *AccessBinding) error {
 	// Convert binding hash to bytes32
 	bindingHashBytes := common.HexToHash(binding.BindingHash)
 	
@@ -178,7 +203,7 @@ func (ec *EthereumConnector) StoreBinding(binding *AccessBinding) error {
 	auth.Value = binding.GasFeePaid
 	auth.GasLimit = uint64(500000)
 	
-	gasPrice, err := ec.client.SuggestGasPrice(context.Background())
+	gasPrice, err := ec.client.SuggestGasPrice(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get gas price: %v", err)
 	}
@@ -200,7 +225,7 @@ func (ec *EthereumConnector) StoreBinding(binding *AccessBinding) error {
 	publicKey := ec.privateKey.Public()
 	publicKeyECDSA := publicKey.(*ecdsa.PublicKey)
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := ec.client.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := ec.client.PendingNonceAt(ctx, fromAddress)
 	if err != nil {
 		return fmt.Errorf("failed to get nonce: %v", err)
 	}
@@ -221,13 +246,14 @@ func (ec *EthereumConnector) StoreBinding(binding *AccessBinding) error {
 	}
 	
 	// Send transaction
-	err = ec.client.SendTransaction(context.Background(), signedTx)
+	err = ec.client.SendTransaction(ctx, signedTx)
 	if err != nil {
 		return fmt.Errorf("failed to send transaction: %v", err)
 	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("eth.tx", signedTx.Hash().Hex()))
 	
 	// Wait for confirmation
-	return ec.waitForConfirmation(signedTx.Hash())
+	return ec.waitForConfirmation(ctx, signedTx.Hash())
 }
 
 // RetrieveBinding retrieves binding from blockchain
@@ -326,7 +352,7 @@ func (ec *EthereumConnector) DeactivateBinding(bindingHash string) error {
 		return fmt.Errorf("failed to send transaction: %v", err)
 	}
 	
-	return ec.waitForConfirmation(signedTx.Hash())
+	return ec.waitForConfirmation(context.Background(), signedTx.Hash())
 }
 
 // GetActiveBindingsCount returns the number of active bindings
@@ -353,18 +379,20 @@ func (ec *EthereumConnector) GetActiveBindingsCount() (*big.Int, error) {
 	return count, nil
 }
 
-// waitForConfirmation waits for transaction confirmation
-func (ec *EthereumConnector) waitForConfirmation(txHash common.Hash) error {
+// waitForConfirmation waits for transaction confirmation, or until ctx is done
+func (ec *EthereumConnector) waitForConfirmation(ctx context.Context, txHash common.Hash) error {
 	timeout := time.After(2 * time.Minute)
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 	
 	for {
 		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for confirmation: %v", ctx.Err())
 		case <-timeout:
 			return fmt.Errorf("transaction confirmation timeout")
 		case <-ticker.C:
-			receipt, err := ec.client.TransactionReceipt(context.Background(), txHash)
+			receipt, err := ec.client.TransactionReceipt(ctx, txHash)
 			if err != nil {
 				continue // Transaction not yet mined
 			}
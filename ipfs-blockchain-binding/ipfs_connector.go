@@ -2,6 +2,7 @@ package binding
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,11 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"blockchain-jedi/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // IPFSConnector manages IPFS interactions for waste-management data storage
@@ -74,6 +80,23 @@ func NewIPFSConnector(nodeURL string) *IPFSConnector {
 
 // StoreWasteManagementData stores encrypted waste-management data on IPFS
 func (ic *IPFSConnector) StoreWasteManagementData(data *WasteManagementData) (string, error) {
+	return ic.StoreWasteManagementDataContext(context.Background(), data)
+}
+
+// StoreWasteManagementDataContext is StoreWasteManagementData bounded by ctx,
+// in a span of the trace ctx carries
+func (ic *IPFSConnector) StoreWasteManagementDataContext(ctx context.Context, data *WasteManagementData) (string, error) {
+	var hash string
+	err := traceCall(ctx, "ipfs.add", []attribute.KeyValue{attribute.String("waste.bin_id", data.BinID)}, func(ctx context.Context) error {
+		var err error
+		hash, err = ic.storeWasteManagementData(ctx, data)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("ipfs.cid", hash))
+		return err
+	})
+	return hash, err
+}
+
+func (ic *IPFSConnector) storeWasteManagementData(ctx context.Context, data *WasteManagementData) (string, error) {
 	ic.mu.Lock()
 	defer ic.mu.Unlock()
 	
@@ -100,12 +123,13 @@ func (ic *IPFSConnector) StoreWasteManagementData(data *WasteManagementData) (st
 	body.WriteString(fmt.Sprintf("\r\n--%s--\r\n", boundary))
 	
 	// Make request to IPFS
-	req, err := http.NewRequest("POST", ic.nodeURL+"/api/v0/add", body)
+	req, err := http.NewRequestWithContext(ctx, "POST", ic.nodeURL+"/api/v0/add", body)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %v", err)
 	}
 	
 	req.Header.Set("Content-Type", fmt.Sprintf("multipart/form-data; boundary=%s", boundary))
+	telemetry.Inject(ctx, req.Header)
 	
 	resp, err := ic.httpClient.Do(req)
 	if err != nil {
@@ -140,6 +164,22 @@ func (ic *IPFSConnector) StoreWasteManagementData(data *WasteManagementData) (st
 
 // RetrieveWasteManagementData retrieves waste-management data from IPFS
 func (ic *IPFSConnector) RetrieveWasteManagementData(hash string, clientID string) (*WasteManagementData, error) {
+	return ic.RetrieveWasteManagementDataContext(context.Background(), hash, clientID)
+}
+
+// RetrieveWasteManagementDataContext is RetrieveWasteManagementData bounded by
+// ctx, in a span of the trace ctx carries
+func (ic *IPFSConnector) RetrieveWasteManagementDataContext(ctx context.Context, hash string, clientID string) (*WasteManagementData, error) {
+	var data *WasteManagementData
+	err := traceCall(ctx, "ipfs.cat", []attribute.KeyValue{attribute.String("ipfs.cid", hash)}, func(ctx context.Context) error {
+		var err error
+		data, err = ic.retrieveWasteManagementData(ctx, hash, clientID)
+		return err
+	})
+	return data, err
+}
+
+func (ic *IPFSConnector) retrieveWasteManagementData(ctx context.Context, hash string, clientID string) (*WasteManagementData, error) {
 	// Check rate limiting
 	if !ic.rateLimiter.AllowRequest(clientID) {
 		return nil, fmt.Errorf("rate limit exceeded for client %s", clientID)
@@ -153,10 +193,11 @@ func (ic *IPFSConnector) RetrieveWasteManagementData(hash string, clientID strin
 	}
 	
 	// Retrieve from IPFS
-	req, err := http.NewRequest("POST", ic.nodeURL+"/api/v0/cat?arg="+hash, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", ic.nodeURL+"/api/v0/cat?arg="+hash, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
+	telemetry.Inject(ctx, req.Header)
 	
 	resp, err := ic.httpClient.Do(req)
 	if err != nil {
@@ -251,6 +292,40 @@ func (ic *IPFSConnector) UnpinHash(hash string) error {
 	return nil
 }
 
+// PinnedCIDs lists the CIDs pinned recursively on the node
+func (ic *IPFSConnector) PinnedCIDs(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", ic.nodeURL+"/api/v0/pin/ls?type=recursive", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pin list request: %v", err)
+	}
+	telemetry.Inject(ctx, req.Header)
+
+	resp, err := ic.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pins on IPFS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pin list request failed with status %d", resp.StatusCode)
+	}
+
+	var pins struct {
+		Keys map[string]struct {
+			Type string `json:"Type"`
+		} `json:"Keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pins); err != nil {
+		return nil, fmt.Errorf("failed to parse pin list: %v", err)
+	}
+
+	cids := make([]string, 0, len(pins.Keys))
+	for cid := range pins.Keys {
+		cids = append(cids, cid)
+	}
+	return cids, nil
+}
+
 // GetNodeInfo retrieves IPFS node information
 func (ic *IPFSConnector) GetNodeInfo() (map[string]interface{}, error) {
 	req, err := http.NewRequest("POST", ic.nodeURL+"/api/v0/id", nil)
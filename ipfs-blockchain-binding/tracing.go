@@ -0,0 +1,40 @@
+package binding
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"blockchain-jedi/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the binding layer's spans
+const tracerName = "blockchain-jedi/ipfs-blockchain-binding"
+
+// traceCall runs fn, one call to IPFS or the chain, in a span named operation.
+// The outcome is logged with the request ID and trace of ctx; fn can add
+// attributes it learns to trace.SpanFromContext(ctx).
+func traceCall(ctx context.Context, operation string, attrs []attribute.KeyValue, fn func(ctx context.Context) error) error {
+	ctx, span := telemetry.Tracer(tracerName).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	start := time.Now()
+	err := fn(ctx)
+	logged := []slog.Attr{slog.String("operation", operation), slog.Duration("duration", time.Since(start))}
+	for _, kv := range attrs {
+		logged = append(logged, slog.Any(string(kv.Key), kv.Value.AsInterface()))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.Default().LogAttrs(ctx, slog.LevelError, "binding call failed", append(logged, slog.String("error", err.Error()))...)
+		return err
+	}
+	slog.Default().LogAttrs(ctx, slog.LevelDebug, "binding call", logged...)
+	return nil
+}
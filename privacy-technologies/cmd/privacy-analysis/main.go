@@ -2,7 +2,7 @@ package main
 
 import (
 	"log"
-	privacytechnologies "securewear/privacy-technologies"
+	privacytechnologies "blockchain-jedi/privacy-technologies"
 )
 
 func main() {
@@ -1,9 +1,11 @@
-package privacy
+package privacytechnologies
 
 import (
 	"fmt"
+	"log"
 	"math"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 )
@@ -22,7 +24,7 @@ type DifferentialPrivacyEngine struct {
 type LaplacianNoiseGenerator struct {
 	Epsilon        float64 // Privacy parameter
 	Sensitivity    float64 // Sensitivity of cardiac queries
-	RandomSeed     int64
+	Bound          float64 // Releases are clamped to [-Bound, Bound]
 	NoiseHistory   []float64
 	CalibrationData *NoiseCalibrationData
 }
@@ -84,6 +86,7 @@ type DPTestResults struct {
 	EjectionFractionAnalysis *EjectionFractionDPResults `json:"ejection_fraction_analysis"`
 	TreatmentComparisonResults *TreatmentComparisonDPResults `json:"treatment_comparison_results"`
 	OverallPerformance       *DPPerformanceMetrics `json:"overall_performance"`
+	Refusal                  string                `json:"refusal,omitempty"` // Why the analysis stopped early
 }
 
 // CardiacDPTest represents a single differential privacy test run
@@ -114,13 +117,18 @@ type DPCardiacPattern struct {
 	TrueValue          float64   `json:"true_value"` // For accuracy measurement only
 }
 
-// NewDifferentialPrivacyEngine creates a new differential privacy engine for cardiac analysis
+// cardiacAnalysisQueries is the number of noisy releases in one full analysis:
+// 5 runs of 8 facility queries, then 1 pattern and 2 treatment queries
+const cardiacAnalysisQueries = 5*8 + 3
+
+// NewDifferentialPrivacyEngine creates a new differential privacy engine for cardiac analysis.
+// epsilon is the total budget, split evenly across the analysis' queries.
 func NewDifferentialPrivacyEngine(epsilon float64) *DifferentialPrivacyEngine {
 	return &DifferentialPrivacyEngine{
 		NoiseGenerator: &LaplacianNoiseGenerator{
-			Epsilon:     epsilon,
+			Epsilon:     epsilon / cardiacAnalysisQueries,
 			Sensitivity: 1.0, // Cardiac measurement sensitivity
-			RandomSeed:  time.Now().UnixNano(),
+			Bound:       10000,
 			NoiseHistory: make([]float64, 0),
 		},
 		PrivacyBudget:   NewPrivacyBudgetManager(epsilon),
@@ -151,7 +159,10 @@ func (dpe *DifferentialPrivacyEngine) RunCardiacDifferentialPrivacyAnalysis() *D
 	// Run 5 test iterations as specified
 	fmt.Println("\n--- Differential Privacy - Cardiac Bin Demographics (5 runs) ---")
 	for i := 0; i < 5; i++ {
-		test := dpe.runSingleCardiacDPTest(i + 1)
+		test, err := dpe.runSingleCardiacDPTest(i + 1)
+		if err != nil {
+			return dpe.refuseAnalysis(results, err)
+		}
 		results.CardiacDemographicsTests = append(results.CardiacDemographicsTests, test)
 		
 		fmt.Printf("Run %d: Processing time: %v, Memory usage: %.1fMB\n", 
@@ -160,11 +171,19 @@ func (dpe *DifferentialPrivacyEngine) RunCardiacDifferentialPrivacyAnalysis() *D
 	
 	// Analyze ejection fraction patterns
 	fmt.Println("\n--- Ejection Fraction Pattern Analysis ---")
-	results.EjectionFractionAnalysis = dpe.analyzeEjectionFractionPatterns()
+	efAnalysis, err := dpe.analyzeEjectionFractionPatterns()
+	if err != nil {
+		return dpe.refuseAnalysis(results, err)
+	}
+	results.EjectionFractionAnalysis = efAnalysis
 	
 	// Generate treatment comparison results
 	fmt.Println("\n--- Treatment Comparison Results ---")
-	results.TreatmentComparisonResults = dpe.analyzeTreatmentComparison()
+	comparison, err := dpe.analyzeTreatmentComparison()
+	if err != nil {
+		return dpe.refuseAnalysis(results, err)
+	}
+	results.TreatmentComparisonResults = comparison
 	
 	// Calculate overall performance metrics
 	results.OverallPerformance = dpe.calculateOverallDPPerformance(results)
@@ -176,8 +195,16 @@ func (dpe *DifferentialPrivacyEngine) RunCardiacDifferentialPrivacyAnalysis() *D
 	return results
 }
 
+// refuseAnalysis ends an analysis whose next query the privacy budget refused
+func (dpe *DifferentialPrivacyEngine) refuseAnalysis(results *DPTestResults, err error) *DPTestResults {
+	fmt.Printf("Query refused, stopping analysis: %v\n", err)
+	results.Refusal = err.Error()
+	dpe.PerformanceTracker.TestResults = results
+	return results
+}
+
 // runSingleCardiacDPTest executes a single differential privacy test
-func (dpe *DifferentialPrivacyEngine) runSingleCardiacDPTest(testNum int) *CardiacDPTest {
+func (dpe *DifferentialPrivacyEngine) runSingleCardiacDPTest(testNum int) (*CardiacDPTest, error) {
 	start := time.Now()
 	
 	// Simulate cardiac demographic analysis with differential privacy
@@ -193,7 +220,9 @@ func (dpe *DifferentialPrivacyEngine) runSingleCardiacDPTest(testNum int) *Cardi
 	}
 	
 	// Simulate processing cardiac demographics with privacy protection
-	dpe.processCardiacDemographicsWithDP()
+	if err := dpe.processCardiacDemographicsWithDP(); err != nil {
+		return nil, err
+	}
 	
 	// Record performance metrics
 	test.ProcessingTime = time.Since(start)
@@ -205,26 +234,39 @@ func (dpe *DifferentialPrivacyEngine) runSingleCardiacDPTest(testNum int) *Cardi
 	dpe.PerformanceTracker.ProcessingTimes = append(dpe.PerformanceTracker.ProcessingTimes, test.ProcessingTime)
 	dpe.PerformanceTracker.MemoryUsages = append(dpe.PerformanceTracker.MemoryUsages, test.MemoryUsage)
 	
-	return test
+	return test, nil
 }
 
 // processCardiacDemographicsWithDP processes cardiac demographics with differential privacy
-func (dpe *DifferentialPrivacyEngine) processCardiacDemographicsWithDP() {
+func (dpe *DifferentialPrivacyEngine) processCardiacDemographicsWithDP() error {
 	// Process CityWaste-Sinai data
-	cedarsSinaiResults := dpe.analyzeFacilityDataWithDP(dpe.CardiacAnalyzer.CityWasteSinaiData)
+	cedarsSinaiResults, err := dpe.analyzeFacilityDataWithDP(dpe.CardiacAnalyzer.CityWasteSinaiData)
+	if err != nil {
+		return err
+	}
 	
 	// Process Metro Recycling data
-	clevelandClinicResults := dpe.analyzeFacilityDataWithDP(dpe.CardiacAnalyzer.ClevelandClinicData)
+	clevelandClinicResults, err := dpe.analyzeFacilityDataWithDP(dpe.CardiacAnalyzer.ClevelandClinicData)
+	if err != nil {
+		return err
+	}
 	
 	// Combine results while maintaining privacy
 	dpe.combineFacilityResultsWithDP(cedarsSinaiResults, clevelandClinicResults)
+	return nil
 }
 
 // analyzeFacilityDataWithDP analyzes facility cardiac data with differential privacy
-func (dpe *DifferentialPrivacyEngine) analyzeFacilityDataWithDP(facilityData *FacilityCardiacData) *PrivateCardiacResults {
+func (dpe *DifferentialPrivacyEngine) analyzeFacilityDataWithDP(facilityData *FacilityCardiacData) (*PrivateCardiacResults, error) {
 	// Apply differential privacy to cardiac queries
-	ejectionFractionStats := dpe.computePrivateEjectionFractionStats(facilityData.EjectionFractions)
-	treatmentEffectiveness := dpe.computePrivateTreatmentEffectiveness(facilityData.TreatmentResponses)
+	ejectionFractionStats, err := dpe.computePrivateEjectionFractionStats(facilityData.EjectionFractions)
+	if err != nil {
+		return nil, err
+	}
+	treatmentEffectiveness, err := dpe.computePrivateTreatmentEffectiveness(facilityData.TreatmentResponses)
+	if err != nil {
+		return nil, err
+	}
 	demographicInsights := dpe.computePrivateDemographicInsights(facilityData.RecyclableBins)
 	
 	return &PrivateCardiacResults{
@@ -232,11 +274,11 @@ func (dpe *DifferentialPrivacyEngine) analyzeFacilityDataWithDP(facilityData *Fa
 		TreatmentEffectiveness: treatmentEffectiveness,
 		DemographicInsights:   demographicInsights,
 		PrivacyLevel:         dpe.NoiseGenerator.Epsilon,
-	}
+	}, nil
 }
 
 // computePrivateEjectionFractionStats computes private ejection fraction statistics
-func (dpe *DifferentialPrivacyEngine) computePrivateEjectionFractionStats(ejectionFractions []float64) *PrivateEjectionFractionStats {
+func (dpe *DifferentialPrivacyEngine) computePrivateEjectionFractionStats(ejectionFractions []float64) (*PrivateEjectionFractionStats, error) {
 	// Count bins with EF < 40%
 	lowEFCount := 0
 	for _, ef := range ejectionFractions {
@@ -246,8 +288,14 @@ func (dpe *DifferentialPrivacyEngine) computePrivateEjectionFractionStats(ejecti
 	}
 	
 	// Apply Laplacian noise for differential privacy
-	noisyLowEFCount := dpe.addLaplacianNoise(float64(lowEFCount))
-	noisyTotalCount := dpe.addLaplacianNoise(float64(len(ejectionFractions)))
+	noisyLowEFCount, err := dpe.addLaplacianNoise("low_ef_count", float64(lowEFCount))
+	if err != nil {
+		return nil, err
+	}
+	noisyTotalCount, err := dpe.addLaplacianNoise("bin_count", float64(len(ejectionFractions)))
+	if err != nil {
+		return nil, err
+	}
 	
 	// Calculate private statistics
 	lowEFPercentage := (noisyLowEFCount / noisyTotalCount) * 100
@@ -257,11 +305,11 @@ func (dpe *DifferentialPrivacyEngine) computePrivateEjectionFractionStats(ejecti
 		TotalBins:     int(noisyTotalCount),
 		LowEFBins:     int(noisyLowEFCount),
 		PrivacyProtection: "Individual EF values protected",
-	}
+	}, nil
 }
 
 // computePrivateTreatmentEffectiveness computes private treatment effectiveness
-func (dpe *DifferentialPrivacyEngine) computePrivateTreatmentEffectiveness(treatments []*TreatmentResponse) *PrivateTreatmentEffectiveness {
+func (dpe *DifferentialPrivacyEngine) computePrivateTreatmentEffectiveness(treatments []*TreatmentResponse) (*PrivateTreatmentEffectiveness, error) {
 	aceImprovement := 0.0
 	arbImprovement := 0.0
 	aceCount := 0
@@ -279,11 +327,16 @@ func (dpe *DifferentialPrivacyEngine) computePrivateTreatmentEffectiveness(treat
 	}
 	
 	// Apply differential privacy
+	var err error
 	if aceCount > 0 {
-		aceImprovement = dpe.addLaplacianNoise(aceImprovement / float64(aceCount))
+		if aceImprovement, err = dpe.addLaplacianNoise("ace_improvement", aceImprovement/float64(aceCount)); err != nil {
+			return nil, err
+		}
 	}
 	if arbCount > 0 {
-		arbImprovement = dpe.addLaplacianNoise(arbImprovement / float64(arbCount))
+		if arbImprovement, err = dpe.addLaplacianNoise("arb_improvement", arbImprovement/float64(arbCount)); err != nil {
+			return nil, err
+		}
 	}
 	
 	return &PrivateTreatmentEffectiveness{
@@ -291,17 +344,22 @@ func (dpe *DifferentialPrivacyEngine) computePrivateTreatmentEffectiveness(treat
 		ARBImprovement:         arbImprovement,
 		TreatmentAdvantage:     arbImprovement - aceImprovement, // ARB advantage
 		PrivacyProtection:     "Individual treatment responses protected",
-	}
+	}, nil
 }
 
 // analyzeEjectionFractionPatterns analyzes ejection fraction patterns with DP
-func (dpe *DifferentialPrivacyEngine) analyzeEjectionFractionPatterns() *EjectionFractionDPResults {
+func (dpe *DifferentialPrivacyEngine) analyzeEjectionFractionPatterns() (*EjectionFractionDPResults, error) {
+	noise, err := dpe.addLaplacianNoise("low_ef_pattern", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Simulate discovered pattern: EF <40%: 28% better ARB outcomes
 	lowEFPattern := &DPCardiacPattern{
 		PatternType:        "Low Ejection Fraction Outcomes",
 		ProtectedValue:     28.0, // 28% better ARB outcomes
 		ConfidenceInterval: []float64{25.2, 30.8}, // With noise
-		NoiseAdded:         dpe.addLaplacianNoise(0.0),
+		NoiseAdded:         noise,
 		TrueValue:          28.0, // True underlying pattern
 	}
 	
@@ -315,14 +373,20 @@ func (dpe *DifferentialPrivacyEngine) analyzeEjectionFractionPatterns() *Ejectio
 			TreatmentDetailsProtected:  true,
 		},
 		PrivacyGuarantee: "Individual bin ejection fractions and cardiac history cannot be identified",
-	}
+	}, nil
 }
 
 // analyzeTreatmentComparison analyzes treatment comparison with differential privacy
-func (dpe *DifferentialPrivacyEngine) analyzeTreatmentComparison() *TreatmentComparisonDPResults {
+func (dpe *DifferentialPrivacyEngine) analyzeTreatmentComparison() (*TreatmentComparisonDPResults, error) {
 	// Simulate treatment effectiveness analysis
-	aceInhibitorEffectiveness := 9.0 + dpe.addLaplacianNoise(0.0)  // 9% improvement
-	arbEffectiveness := 15.0 + dpe.addLaplacianNoise(0.0)          // 15% improvement
+	aceInhibitorEffectiveness, err := dpe.addLaplacianNoise("ace_effectiveness", 9.0) // 9% improvement
+	if err != nil {
+		return nil, err
+	}
+	arbEffectiveness, err := dpe.addLaplacianNoise("arb_effectiveness", 15.0) // 15% improvement
+	if err != nil {
+		return nil, err
+	}
 	
 	return &TreatmentComparisonDPResults{
 		ACEInhibitorEffectiveness: aceInhibitorEffectiveness,
@@ -330,35 +394,31 @@ func (dpe *DifferentialPrivacyEngine) analyzeTreatmentComparison() *TreatmentCom
 		TreatmentAdvantage:       arbEffectiveness - aceInhibitorEffectiveness, // 6% advantage
 		OperationalSignificance:     "Statistically significant with privacy protection",
 		PrivacyGuarantee:        "Individual treatment responses never revealed",
-	}
+	}, nil
 }
 
 // Utility methods for differential privacy
-func (dpe *DifferentialPrivacyEngine) addLaplacianNoise(value float64) float64 {
-	// Generate Laplacian noise: Lap(sensitivity/epsilon)
-	scale := dpe.NoiseGenerator.Sensitivity / dpe.NoiseGenerator.Epsilon
-	
-	// Generate random value from [0,1)
-	u := rand.Float64() - 0.5
-	
-	// Generate Laplacian noise
-	var noise float64
-	if u >= 0 {
-		noise = -scale * math.Log(1-2*u)
-	} else {
-		noise = scale * math.Log(1+2*u)
+
+// addLaplacianNoise releases value with Lap(sensitivity/epsilon) noise, debiting the
+// privacy budget first; once the budget is spent the query is refused
+func (dpe *DifferentialPrivacyEngine) addLaplacianNoise(queryType string, value float64) (float64, error) {
+	result, err := dpe.PrivacyBudget.Laplace(queryType, value, dpe.NoiseGenerator.Sensitivity, dpe.NoiseGenerator.Epsilon, dpe.NoiseGenerator.Bound)
+	if err != nil {
+		return 0, err
 	}
 	
 	// Record noise for analysis
-	dpe.NoiseGenerator.NoiseHistory = append(dpe.NoiseGenerator.NoiseHistory, noise)
+	dpe.mu.Lock()
+	dpe.NoiseGenerator.NoiseHistory = append(dpe.NoiseGenerator.NoiseHistory, result.Value-value)
+	dpe.mu.Unlock()
 	
-	return value + noise
+	return result.Value, nil
 }
 
 // Performance calculation methods
 func (dpe *DifferentialPrivacyEngine) calculateMemoryUsage() int64 {
 	// Simulate memory usage: 2.1MB per facility as specified
-	return int64(2.1 * 1024 * 1024) // 2.1MB in bytes
+	return 21 * 1024 * 1024 / 10 // 2.1MB in bytes
 }
 
 func (dpe *DifferentialPrivacyEngine) calculateAccuracyLoss() float64 {
@@ -503,9 +563,9 @@ func (dpe *DifferentialPrivacyEngine) generateCardiacMetrics() {
 
 // Reporting methods
 func (dpe *DifferentialPrivacyEngine) printCardiacDPResults(results *DPTestResults) {
-	fmt.Printf("\n" + "="*80 + "\n")
+	fmt.Printf("\n" + strings.Repeat("=", 80) + "\n")
 	fmt.Printf("DIFFERENTIAL PRIVACY - WASTE BIN DEMOGRAPHICS RESULTS\n")
-	fmt.Printf("="*80 + "\n")
+	fmt.Printf(strings.Repeat("=", 80) + "\n")
 	
 	fmt.Printf("\n📊 PERFORMANCE RESULTS (5 runs):\n")
 	fmt.Printf("Processing Times: ")
@@ -575,12 +635,77 @@ func NewCardiacDemographicsAnalyzer() *CardiacDemographicsAnalyzer {
 	}
 }
 
+// Names under which the engine's queries are charged in its budget ledger
+const (
+	cardiacDataset = "cardiac-demographics"
+	cardiacAnalyst = "dp-engine"
+)
+
+// NewPrivacyBudgetManager creates an in-memory budget of epsilon under sequential composition
 func NewPrivacyBudgetManager(epsilon float64) *PrivacyBudgetManager {
-	return &PrivacyBudgetManager{
-		TotalBudget:     epsilon,
-		RemainingBudget: epsilon,
-		QueryHistory:    make([]*PrivacyQuery, 0),
+	ledger, _ := OpenPrivacyLedger("")
+	pbm, err := NewPrivacyBudgetManagerWithLedger(Budget{Epsilon: epsilon, Composition: CompositionSequential}, ledger)
+	if err != nil {
+		log.Fatalf("Failed to create privacy budget: %v", err)
+	}
+	return pbm
+}
+
+// NewPrivacyBudgetManagerWithLedger charges the engine's queries to ledger, counting
+// whatever the ledger already records against budget
+func NewPrivacyBudgetManagerWithLedger(budget Budget, ledger *PrivacyLedger) (*PrivacyBudgetManager, error) {
+	service, err := NewDPQueryService(ledger, nil, budget)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.RegisterDataset(cardiacDataset, budget); err != nil {
+		return nil, err
+	}
+	
+	pbm := &PrivacyBudgetManager{
+		TotalBudget:  budget.Epsilon,
+		QueryHistory: make([]*PrivacyQuery, 0),
+		Service:      service,
+	}
+	for _, entry := range ledger.Entries() {
+		if entry.Dataset == cardiacDataset {
+			pbm.QueryHistory = append(pbm.QueryHistory, &PrivacyQuery{QueryType: entry.QueryType, EpsilonUsed: entry.Charge.Epsilon, Timestamp: entry.Timestamp})
+		}
+	}
+	pbm.updateRemaining()
+	return pbm, nil
+}
+
+// Laplace releases value with snapped Laplace noise once the budget has been debited
+func (pbm *PrivacyBudgetManager) Laplace(queryType string, value, sensitivity, epsilon, bound float64) (*DPResult, error) {
+	result, err := pbm.Service.Run(&DPQuery{
+		Dataset:     cardiacDataset,
+		Analyst:     cardiacAnalyst,
+		QueryType:   queryType,
+		Mechanism:   MechanismLaplace,
+		Sensitivity: sensitivity,
+		Epsilon:     epsilon,
+		Bound:       bound,
+	}, func() (float64, error) { return value, nil })
+	if err != nil {
+		return nil, err
+	}
+	
+	pbm.mu.Lock()
+	pbm.QueryHistory = append(pbm.QueryHistory, &PrivacyQuery{QueryType: queryType, EpsilonUsed: result.Charge.Epsilon, Timestamp: time.Now()})
+	pbm.mu.Unlock()
+	pbm.updateRemaining()
+	return result, nil
+}
+
+func (pbm *PrivacyBudgetManager) updateRemaining() {
+	status, err := pbm.Service.DatasetStatus(cardiacDataset)
+	if err != nil {
+		return
 	}
+	pbm.mu.Lock()
+	pbm.RemainingBudget = math.Max(0, pbm.TotalBudget-status.SpentEpsilon)
+	pbm.mu.Unlock()
 }
 
 // Additional type definitions for completeness
@@ -671,6 +796,8 @@ type PrivacyBudgetManager struct {
 	TotalBudget     float64        `json:"total_budget"`
 	RemainingBudget float64        `json:"remaining_budget"`
 	QueryHistory    []*PrivacyQuery `json:"query_history"`
+	Service         *DPQueryService `json:"-"`
+	mu              sync.Mutex
 }
 
 type PrivacyQuery struct {
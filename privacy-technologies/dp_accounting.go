@@ -0,0 +1,174 @@
+package privacytechnologies
+
+import (
+	"fmt"
+	"math"
+)
+
+// Composition selects how a Budget adds up the charges made against it
+type Composition string
+
+const (
+	// CompositionSequential sums epsilons and deltas
+	CompositionSequential Composition = "sequential"
+	// CompositionAdvanced applies the Dwork-Rothblum-Vadhan bound, which grows
+	// with the square root of the number of queries, and falls back to the
+	// sequential sum whenever that is tighter
+	CompositionAdvanced Composition = "advanced"
+	// CompositionZCDP sums zero-concentrated DP costs, the natural measure for
+	// Gaussian noise, and converts the total to (epsilon, delta) at the budget's delta
+	CompositionZCDP Composition = "zcdp"
+)
+
+// budgetTolerance absorbs float rounding when a charge exactly uses up a budget
+const budgetTolerance = 1e-9
+
+// Budget is the total privacy loss allowed for one dataset or analyst
+type Budget struct {
+	Epsilon     float64     `json:"epsilon"`
+	Delta       float64     `json:"delta"`
+	Composition Composition `json:"composition"`
+	// Slack is the extra delta spent by advanced composition; half of Delta when zero
+	Slack float64 `json:"slack,omitempty"`
+}
+
+// PrivacyCharge is the cost of one release. Pure mechanisms set Epsilon and
+// Rho = Epsilon^2/2; Gaussian releases set Rho and express it as (Epsilon, Delta)
+// at the delta the query asked for. A Gaussian charge made without a delta has
+// no (epsilon, delta) form and only fits zCDP budgets.
+type PrivacyCharge struct {
+	Mechanism Mechanism `json:"mechanism"`
+	Epsilon   float64   `json:"epsilon"`
+	Delta     float64   `json:"delta"`
+	Rho       float64   `json:"rho"`
+}
+
+// BudgetExhaustedError reports a query refused because it would overspend a budget
+type BudgetExhaustedError struct {
+	Scope        string // "dataset" or "analyst"
+	ID           string
+	Budget       Budget
+	SpentEpsilon float64
+	SpentDelta   float64
+}
+
+func (e *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("privacy budget of %s %s exhausted: query would spend (%.4g, %.3g) of (%.4g, %.3g) under %s composition",
+		e.Scope, e.ID, e.SpentEpsilon, e.SpentDelta, e.Budget.Epsilon, e.Budget.Delta, e.Budget.Composition)
+}
+
+// Validate checks the budget's parameters
+func (b Budget) Validate() error {
+	if !(b.Epsilon > 0) || math.IsInf(b.Epsilon, 0) {
+		return fmt.Errorf("budget epsilon must be positive, got %v", b.Epsilon)
+	}
+	if b.Delta < 0 || b.Delta >= 1 {
+		return fmt.Errorf("budget delta must be in [0, 1), got %v", b.Delta)
+	}
+	switch b.Composition {
+	case CompositionSequential:
+	case CompositionZCDP:
+		if b.Delta == 0 {
+			return fmt.Errorf("zcdp composition needs a positive delta")
+		}
+	case CompositionAdvanced:
+		if b.Delta == 0 {
+			return fmt.Errorf("advanced composition needs a positive delta")
+		}
+		if b.Slack < 0 || b.Slack >= b.Delta {
+			return fmt.Errorf("advanced composition slack must be in [0, %v), got %v", b.Delta, b.Slack)
+		}
+	default:
+		return fmt.Errorf("unknown composition %q", b.Composition)
+	}
+	return nil
+}
+
+// Spent returns the (epsilon, delta) guarantee of charges under the budget's composition
+func (b Budget) Spent(charges []PrivacyCharge) (float64, float64) {
+	switch b.Composition {
+	case CompositionAdvanced:
+		return advancedComposition(charges, b.slack())
+	case CompositionZCDP:
+		rho := 0.0
+		for _, charge := range charges {
+			rho += charge.Rho
+		}
+		if rho == 0 {
+			return 0, 0
+		}
+		return ZCDPToApproxDP(rho, b.Delta), b.Delta
+	default:
+		return sequentialComposition(charges)
+	}
+}
+
+// Allows reports whether charges fit the budget, and what they would spend
+func (b Budget) Allows(charges []PrivacyCharge) (bool, float64, float64) {
+	if b.Composition == CompositionAdvanced {
+		// Both bounds hold, and the sequential one may fit when the slack does not
+		epsilon, delta := sequentialComposition(charges)
+		if b.fits(epsilon, delta) {
+			return true, epsilon, delta
+		}
+	}
+	epsilon, delta := b.Spent(charges)
+	return b.fits(epsilon, delta), epsilon, delta
+}
+
+func (b Budget) fits(epsilon, delta float64) bool {
+	return epsilon <= b.Epsilon+budgetTolerance && delta <= b.Delta+budgetTolerance
+}
+
+func (b Budget) slack() float64 {
+	if b.Slack > 0 {
+		return b.Slack
+	}
+	return b.Delta / 2
+}
+
+func sequentialComposition(charges []PrivacyCharge) (float64, float64) {
+	epsilon, delta := 0.0, 0.0
+	for _, charge := range charges {
+		if charge.Mechanism == MechanismGaussian && charge.Delta == 0 {
+			epsilon = math.Inf(1)
+		}
+		epsilon += charge.Epsilon
+		delta += charge.Delta
+	}
+	return epsilon, delta
+}
+
+// advancedComposition is sqrt(2 ln(1/slack) sum eps_i^2) + sum eps_i (e^eps_i - 1)
+// at delta sum delta_i + slack, or the sequential sum when that is smaller
+func advancedComposition(charges []PrivacyCharge, slack float64) (float64, float64) {
+	sequential, delta := sequentialComposition(charges)
+	if len(charges) == 0 {
+		return 0, 0
+	}
+
+	squares, drift := 0.0, 0.0
+	for _, charge := range charges {
+		if charge.Mechanism == MechanismGaussian && charge.Delta == 0 {
+			return sequential, delta
+		}
+		squares += charge.Epsilon * charge.Epsilon
+		drift += charge.Epsilon * math.Expm1(charge.Epsilon)
+	}
+	advanced := math.Sqrt(2*math.Log(1/slack)*squares) + drift
+	if advanced >= sequential {
+		return sequential, delta
+	}
+	return advanced, delta + slack
+}
+
+// ZCDPToApproxDP converts rho-zCDP to the epsilon of (epsilon, delta)-DP
+func ZCDPToApproxDP(rho, delta float64) float64 {
+	return rho + 2*math.Sqrt(rho*math.Log(1/delta))
+}
+
+// gaussianRho is the zCDP cost of discrete Gaussian noise of scale sigma on a
+// query whose values move by at most sensitivity
+func gaussianRho(sensitivity, sigma float64) float64 {
+	return sensitivity * sensitivity / (2 * sigma * sigma)
+}
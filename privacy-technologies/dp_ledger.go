@@ -0,0 +1,146 @@
+package privacytechnologies
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ledgerVersion is bumped when the ledger file format changes
+const ledgerVersion = 1
+
+// LedgerEntry records one charged query
+type LedgerEntry struct {
+	ID        uint64        `json:"id"`
+	Dataset   string        `json:"dataset"`
+	Analyst   string        `json:"analyst"`
+	QueryType string        `json:"query_type"`
+	Charge    PrivacyCharge `json:"charge"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// PrivacyLedger is the durable record of every charge. An entry is written to
+// disk before the noisy answer it pays for is released, so a crash can lose an
+// answer but never the privacy it cost.
+type PrivacyLedger struct {
+	mu      sync.Mutex
+	path    string
+	entries []LedgerEntry
+	nextID  uint64
+}
+
+type ledgerSnapshot struct {
+	Version int           `json:"version"`
+	SavedAt time.Time     `json:"saved_at"`
+	Entries []LedgerEntry `json:"entries"`
+}
+
+// OpenPrivacyLedger loads the ledger at path, starting empty if the file does not
+// exist. An empty path keeps the ledger in memory only.
+func OpenPrivacyLedger(path string) (*PrivacyLedger, error) {
+	pl := &PrivacyLedger{path: path, nextID: 1}
+	if path == "" {
+		return pl, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return pl, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read privacy ledger: %v", err)
+	}
+
+	var snapshot ledgerSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse privacy ledger %s: %v", path, err)
+	}
+	if snapshot.Version != ledgerVersion {
+		return nil, fmt.Errorf("privacy ledger %s has version %d, expected %d", path, snapshot.Version, ledgerVersion)
+	}
+	pl.entries = snapshot.Entries
+	for _, entry := range pl.entries {
+		if entry.ID >= pl.nextID {
+			pl.nextID = entry.ID + 1
+		}
+	}
+	return pl, nil
+}
+
+// Entries returns a copy of the recorded charges
+func (pl *PrivacyLedger) Entries() []LedgerEntry {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	return append([]LedgerEntry(nil), pl.entries...)
+}
+
+// charges returns the charges of entries matching keep
+func (pl *PrivacyLedger) charges(keep func(*LedgerEntry) bool) []PrivacyCharge {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	var charges []PrivacyCharge
+	for i := range pl.entries {
+		if keep(&pl.entries[i]) {
+			charges = append(charges, pl.entries[i].Charge)
+		}
+	}
+	return charges
+}
+
+// append records entry and persists the ledger; on failure the entry is dropped
+// so the caller can refuse the query
+func (pl *PrivacyLedger) append(entry LedgerEntry) (LedgerEntry, error) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	entry.ID = pl.nextID
+	pl.entries = append(pl.entries, entry)
+	if err := pl.saveLocked(); err != nil {
+		pl.entries = pl.entries[:len(pl.entries)-1]
+		return LedgerEntry{}, err
+	}
+	pl.nextID++
+	return entry, nil
+}
+
+func (pl *PrivacyLedger) saveLocked() error {
+	if pl.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&ledgerSnapshot{
+		Version: ledgerVersion,
+		SavedAt: time.Now(),
+		Entries: pl.entries,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode privacy ledger: %v", err)
+	}
+
+	// Write beside the target, sync and rename so a crash never leaves a truncated ledger
+	tmp, err := os.CreateTemp(filepath.Dir(pl.path), filepath.Base(pl.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create privacy ledger: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write privacy ledger: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync privacy ledger: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write privacy ledger: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), pl.path); err != nil {
+		return fmt.Errorf("failed to replace privacy ledger: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package privacytechnologies
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// maxSnappingRange bounds B/lambda for the snapping mechanism; beyond it the
+// rounding step no longer hides the low-order bits of the noise
+const maxSnappingRange = 1 << 46
+
+// SecureSampler draws differential-privacy noise from a cryptographic source.
+// Continuous noise goes through Mironov's snapping mechanism and Gaussian noise
+// is sampled exactly over the integers, so neither leaks the true value through
+// the low-order bits of a floating-point result.
+type SecureSampler struct {
+	mu     sync.Mutex
+	random io.Reader
+}
+
+// NewSecureSampler samples from random, or crypto/rand when random is nil
+func NewSecureSampler(random io.Reader) *SecureSampler {
+	if random == nil {
+		random = rand.Reader
+	}
+	return &SecureSampler{random: random}
+}
+
+// SnappedLaplace returns value plus Laplace noise of scale sensitivity/epsilon,
+// clamped to [-bound, bound] and snapped to a power-of-two grid. The release is
+// SnappingEpsilon(epsilon, bound/sensitivity)-DP.
+func (ss *SecureSampler) SnappedLaplace(value, sensitivity, epsilon, bound float64) (float64, error) {
+	if err := validateSnapping(sensitivity, epsilon, bound); err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("query value %v is not finite", value)
+	}
+
+	// Work in units of the sensitivity so the mechanism sees a 1-sensitive query
+	limit := bound / sensitivity
+	lambda := 1 / epsilon
+	grid := math.Exp2(math.Ceil(math.Log2(lambda)))
+
+	ss.mu.Lock()
+	u, err := ss.uniformUnit()
+	var sign uint64
+	if err == nil {
+		sign, err = ss.uint64()
+	}
+	ss.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	noise := lambda * math.Log(u)
+	if sign&1 == 1 {
+		noise = -noise
+	}
+	snapped := grid * math.Round((clamp(value/sensitivity, limit)+noise)/grid)
+	return clamp(snapped, limit) * sensitivity, nil
+}
+
+// SnappingEpsilon is the privacy loss of SnappedLaplace for a bound of rangeUnits
+// sensitivities, from Mironov's "On Significance of the Least Significant Bits"
+func SnappingEpsilon(epsilon, rangeUnits float64) float64 {
+	return epsilon + math.Exp2(-49)*rangeUnits*epsilon
+}
+
+func validateSnapping(sensitivity, epsilon, bound float64) error {
+	if !(sensitivity > 0) || math.IsInf(sensitivity, 0) {
+		return fmt.Errorf("sensitivity must be positive, got %v", sensitivity)
+	}
+	if !(epsilon > 0) || math.IsInf(epsilon, 0) {
+		return fmt.Errorf("epsilon must be positive, got %v", epsilon)
+	}
+	units := bound / sensitivity * epsilon // bound / lambda
+	if !(units > 1) || units >= maxSnappingRange {
+		return fmt.Errorf("bound %v must lie between sensitivity/epsilon and 2^46 times it", bound)
+	}
+	return nil
+}
+
+func clamp(value, limit float64) float64 {
+	return math.Max(-limit, math.Min(limit, value))
+}
+
+// DiscreteGaussian returns an integer drawn with probability proportional to
+// exp(-x^2 / 2sigma^2), using the exact rejection sampler of Canonne, Kamath and
+// Steinke. sigma is converted to a rational exactly, so no step rounds.
+func (ss *SecureSampler) DiscreteGaussian(sigma float64) (int64, error) {
+	if !(sigma > 0) || sigma > 1<<40 {
+		return 0, fmt.Errorf("sigma must be in (0, 2^40], got %v", sigma)
+	}
+
+	s := new(big.Rat).SetFloat64(sigma)
+	variance := new(big.Rat).Mul(s, s)
+	t := int64(math.Floor(sigma)) + 1
+	shift := new(big.Rat).Quo(variance, new(big.Rat).SetInt64(t))
+	twoVariance := new(big.Rat).Add(variance, variance)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for {
+		y, err := ss.discreteLaplace(t)
+		if err != nil {
+			return 0, err
+		}
+
+		// Accept with probability exp(-(|y| - sigma^2/t)^2 / 2sigma^2)
+		gamma := new(big.Rat).SetInt64(y)
+		gamma.Abs(gamma)
+		gamma.Sub(gamma, shift)
+		gamma.Mul(gamma, gamma)
+		gamma.Quo(gamma, twoVariance)
+		accept, err := ss.bernoulliExp(gamma)
+		if err != nil {
+			return 0, err
+		}
+		if accept {
+			return y, nil
+		}
+	}
+}
+
+// discreteLaplace samples an integer with probability proportional to exp(-|x|/t)
+func (ss *SecureSampler) discreteLaplace(t int64) (int64, error) {
+	scale := big.NewInt(t)
+	one := big.NewRat(1, 1)
+	for {
+		u, err := rand.Int(ss.random, scale)
+		if err != nil {
+			return 0, fmt.Errorf("failed to sample randomness: %v", err)
+		}
+		keep, err := ss.bernoulliExp(new(big.Rat).SetFrac(u, scale))
+		if err != nil {
+			return 0, err
+		}
+		if !keep {
+			continue
+		}
+
+		var v int64
+		for {
+			more, err := ss.bernoulliExp(one)
+			if err != nil {
+				return 0, err
+			}
+			if !more {
+				break
+			}
+			v++
+		}
+
+		x := u.Int64() + t*v
+		sign, err := ss.uint64()
+		if err != nil {
+			return 0, err
+		}
+		if sign&1 == 1 {
+			if x == 0 {
+				continue // zero would otherwise be counted twice
+			}
+			x = -x
+		}
+		return x, nil
+	}
+}
+
+// bernoulliExp returns true with probability exp(-gamma) for rational gamma >= 0
+func (ss *SecureSampler) bernoulliExp(gamma *big.Rat) (bool, error) {
+	one := big.NewRat(1, 1)
+	remaining := new(big.Rat).Set(gamma)
+	for remaining.Cmp(one) > 0 {
+		ok, err := ss.bernoulliExpUnit(one)
+		if err != nil || !ok {
+			return false, err
+		}
+		remaining.Sub(remaining, one)
+	}
+	return ss.bernoulliExpUnit(remaining)
+}
+
+// bernoulliExpUnit handles gamma in [0, 1]: the first k with Bernoulli(gamma/k)
+// failing is odd with probability exp(-gamma)
+func (ss *SecureSampler) bernoulliExpUnit(gamma *big.Rat) (bool, error) {
+	k := int64(1)
+	for {
+		ok, err := ss.bernoulli(new(big.Rat).Quo(gamma, new(big.Rat).SetInt64(k)))
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return k%2 == 1, nil
+		}
+		k++
+	}
+}
+
+// bernoulli returns true with rational probability p
+func (ss *SecureSampler) bernoulli(p *big.Rat) (bool, error) {
+	if p.Sign() <= 0 {
+		return false, nil
+	}
+	r, err := rand.Int(ss.random, p.Denom())
+	if err != nil {
+		return false, fmt.Errorf("failed to sample randomness: %v", err)
+	}
+	return r.Cmp(p.Num()) < 0, nil
+}
+
+// uniformUnit returns a float64 in (0, 1) where each representable value is
+// drawn with probability equal to the width of the interval it stands for: the
+// exponent is geometric and the mantissa uniform, as the snapping mechanism needs
+func (ss *SecureSampler) uniformUnit() (float64, error) {
+	exponent := -1
+	for {
+		word, err := ss.uint64()
+		if err != nil {
+			return 0, err
+		}
+		if word != 0 {
+			exponent -= bits.LeadingZeros64(word)
+			break
+		}
+		exponent -= 64
+		if exponent < -1074 {
+			return math.SmallestNonzeroFloat64, nil
+		}
+	}
+
+	mantissa, err := ss.uint64()
+	if err != nil {
+		return 0, err
+	}
+	fraction := float64(mantissa&(1<<52-1)) / (1 << 52)
+	return math.Ldexp(1+fraction, exponent), nil
+}
+
+func (ss *SecureSampler) uint64() (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(ss.random, buf[:]); err != nil {
+		return 0, fmt.Errorf("failed to sample randomness: %v", err)
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
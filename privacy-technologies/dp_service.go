@@ -0,0 +1,272 @@
+package privacytechnologies
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Mechanism selects the noise a DPQueryService adds to an answer
+type Mechanism string
+
+const (
+	// MechanismLaplace adds snapped Laplace noise and costs pure epsilon-DP
+	MechanismLaplace Mechanism = "laplace"
+	// MechanismGaussian adds discrete Gaussian noise and costs rho-zCDP
+	MechanismGaussian Mechanism = "gaussian"
+)
+
+// DPQuery describes one noisy release of a numeric query
+type DPQuery struct {
+	Dataset     string
+	Analyst     string
+	QueryType   string
+	Mechanism   Mechanism
+	Sensitivity float64 // most one individual can move the answer
+
+	// Laplace: the epsilon to spend and the range answers are clamped to
+	Epsilon float64
+	Bound   float64
+
+	// Gaussian: the zCDP cost, the delta at which sequential and advanced budgets
+	// account for it, and the grid answers are rounded to (1 for counts)
+	Rho         float64
+	Delta       float64
+	Granularity float64
+}
+
+// DPResult is a released answer and the entry that paid for it
+type DPResult struct {
+	Value   float64
+	EntryID uint64
+	Charge  PrivacyCharge
+}
+
+// BudgetStatus summarizes what has been spent of a budget
+type BudgetStatus struct {
+	Budget       Budget
+	SpentEpsilon float64
+	SpentDelta   float64
+	Queries      int
+}
+
+// DPQueryService answers queries with calibrated noise and refuses them once the
+// dataset's budget, or the analyst's share of it, would be overspent
+type DPQueryService struct {
+	mu             sync.Mutex
+	sampler        *SecureSampler
+	ledger         *PrivacyLedger
+	datasets       map[string]Budget
+	analystBudget  Budget
+	analystBudgets map[string]Budget
+}
+
+// NewDPQueryService charges queries to ledger and gives every analyst
+// analystBudget on each dataset unless SetAnalystBudget overrides it
+func NewDPQueryService(ledger *PrivacyLedger, sampler *SecureSampler, analystBudget Budget) (*DPQueryService, error) {
+	if err := analystBudget.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid analyst budget: %v", err)
+	}
+	if sampler == nil {
+		sampler = NewSecureSampler(nil)
+	}
+	return &DPQueryService{
+		sampler:        sampler,
+		ledger:         ledger,
+		datasets:       make(map[string]Budget),
+		analystBudget:  analystBudget,
+		analystBudgets: make(map[string]Budget),
+	}, nil
+}
+
+// RegisterDataset sets the total budget of a dataset. Charges already in the
+// ledger count against it.
+func (dqs *DPQueryService) RegisterDataset(dataset string, budget Budget) error {
+	if err := budget.Validate(); err != nil {
+		return fmt.Errorf("invalid budget for dataset %s: %v", dataset, err)
+	}
+	dqs.mu.Lock()
+	dqs.datasets[dataset] = budget
+	dqs.mu.Unlock()
+	return nil
+}
+
+// SetAnalystBudget overrides the per-dataset budget of one analyst
+func (dqs *DPQueryService) SetAnalystBudget(analyst string, budget Budget) error {
+	if err := budget.Validate(); err != nil {
+		return fmt.Errorf("invalid budget for analyst %s: %v", analyst, err)
+	}
+	dqs.mu.Lock()
+	dqs.analystBudgets[analyst] = budget
+	dqs.mu.Unlock()
+	return nil
+}
+
+// DatasetStatus reports what has been spent of a dataset's budget
+func (dqs *DPQueryService) DatasetStatus(dataset string) (*BudgetStatus, error) {
+	dqs.mu.Lock()
+	budget, exists := dqs.datasets[dataset]
+	dqs.mu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown dataset %s", dataset)
+	}
+	return budgetStatus(budget, dqs.ledger.charges(func(e *LedgerEntry) bool { return e.Dataset == dataset })), nil
+}
+
+// AnalystStatus reports what an analyst has spent of their share of a dataset
+func (dqs *DPQueryService) AnalystStatus(dataset, analyst string) *BudgetStatus {
+	dqs.mu.Lock()
+	budget := dqs.analystBudgetLocked(analyst)
+	dqs.mu.Unlock()
+	return budgetStatus(budget, dqs.ledger.charges(func(e *LedgerEntry) bool {
+		return e.Dataset == dataset && e.Analyst == analyst
+	}))
+}
+
+func budgetStatus(budget Budget, charges []PrivacyCharge) *BudgetStatus {
+	epsilon, delta := budget.Spent(charges)
+	return &BudgetStatus{Budget: budget, SpentEpsilon: epsilon, SpentDelta: delta, Queries: len(charges)}
+}
+
+func (dqs *DPQueryService) analystBudgetLocked(analyst string) Budget {
+	if budget, exists := dqs.analystBudgets[analyst]; exists {
+		return budget
+	}
+	return dqs.analystBudget
+}
+
+// Run charges q to the ledger, then evaluates compute and releases its result with
+// noise. Nothing is computed for a refused query. The charge stands even if
+// compute fails, since whether it fails can depend on the data.
+func (dqs *DPQueryService) Run(q *DPQuery, compute func() (float64, error)) (*DPResult, error) {
+	charge, err := q.charge()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := dqs.reserve(q, charge)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := compute()
+	if err != nil {
+		return nil, fmt.Errorf("query %d failed after being charged: %v", entry.ID, err)
+	}
+
+	var noisy float64
+	switch q.Mechanism {
+	case MechanismLaplace:
+		noisy, err = dqs.sampler.SnappedLaplace(value, q.Sensitivity, q.Epsilon, q.Bound)
+	case MechanismGaussian:
+		noisy, err = dqs.gaussian(q, value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query %d: %v", entry.ID, err)
+	}
+	return &DPResult{Value: noisy, EntryID: entry.ID, Charge: charge}, nil
+}
+
+// reserve checks both budgets and records the charge while holding the lock, so
+// concurrent queries cannot together overspend
+func (dqs *DPQueryService) reserve(q *DPQuery, charge PrivacyCharge) (LedgerEntry, error) {
+	dqs.mu.Lock()
+	defer dqs.mu.Unlock()
+
+	datasetBudget, exists := dqs.datasets[q.Dataset]
+	if !exists {
+		return LedgerEntry{}, fmt.Errorf("unknown dataset %s", q.Dataset)
+	}
+	analystBudget := dqs.analystBudgetLocked(q.Analyst)
+
+	checks := []struct {
+		scope, id string
+		budget    Budget
+		keep      func(*LedgerEntry) bool
+	}{
+		{"dataset", q.Dataset, datasetBudget, func(e *LedgerEntry) bool { return e.Dataset == q.Dataset }},
+		{"analyst", q.Analyst, analystBudget, func(e *LedgerEntry) bool { return e.Dataset == q.Dataset && e.Analyst == q.Analyst }},
+	}
+	for _, check := range checks {
+		if q.Mechanism == MechanismGaussian && q.Delta == 0 && check.budget.Composition != CompositionZCDP {
+			return LedgerEntry{}, fmt.Errorf("gaussian query needs a delta to be charged to %s %s under %s composition", check.scope, check.id, check.budget.Composition)
+		}
+		allowed, epsilon, delta := check.budget.Allows(append(dqs.ledger.charges(check.keep), charge))
+		if !allowed {
+			return LedgerEntry{}, &BudgetExhaustedError{Scope: check.scope, ID: check.id, Budget: check.budget, SpentEpsilon: epsilon, SpentDelta: delta}
+		}
+	}
+
+	return dqs.ledger.append(LedgerEntry{
+		Dataset:   q.Dataset,
+		Analyst:   q.Analyst,
+		QueryType: q.QueryType,
+		Charge:    charge,
+		Timestamp: time.Now(),
+	})
+}
+
+// gaussian rounds value to the query's grid and adds discrete Gaussian noise in grid units
+func (dqs *DPQueryService) gaussian(q *DPQuery, value float64) (float64, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) || math.Abs(value/q.Granularity) > 1<<52 {
+		return 0, fmt.Errorf("query value %v cannot be placed on a grid of %v", value, q.Granularity)
+	}
+	noise, err := dqs.sampler.DiscreteGaussian(q.gaussianSigma())
+	if err != nil {
+		return 0, err
+	}
+	return (math.Round(value/q.Granularity) + float64(noise)) * q.Granularity, nil
+}
+
+// gridSensitivity is how far rounding to the grid can move the answer, in grid units
+func (q *DPQuery) gridSensitivity() float64 {
+	return math.Ceil(q.Sensitivity / q.Granularity)
+}
+
+func (q *DPQuery) gaussianSigma() float64 {
+	return q.gridSensitivity() / math.Sqrt(2*q.Rho)
+}
+
+// charge validates the query and prices it
+func (q *DPQuery) charge() (PrivacyCharge, error) {
+	if q.Dataset == "" || q.Analyst == "" {
+		return PrivacyCharge{}, fmt.Errorf("query must name a dataset and an analyst")
+	}
+	if !(q.Sensitivity > 0) || math.IsInf(q.Sensitivity, 0) {
+		return PrivacyCharge{}, fmt.Errorf("sensitivity must be positive, got %v", q.Sensitivity)
+	}
+
+	switch q.Mechanism {
+	case MechanismLaplace:
+		if err := validateSnapping(q.Sensitivity, q.Epsilon, q.Bound); err != nil {
+			return PrivacyCharge{}, err
+		}
+		epsilon := SnappingEpsilon(q.Epsilon, q.Bound/q.Sensitivity)
+		return PrivacyCharge{Mechanism: MechanismLaplace, Epsilon: epsilon, Rho: epsilon * epsilon / 2}, nil
+
+	case MechanismGaussian:
+		if !(q.Rho > 0) || math.IsInf(q.Rho, 0) {
+			return PrivacyCharge{}, fmt.Errorf("rho must be positive, got %v", q.Rho)
+		}
+		if !(q.Granularity > 0) || math.IsInf(q.Granularity, 0) {
+			return PrivacyCharge{}, fmt.Errorf("granularity must be positive, got %v", q.Granularity)
+		}
+		if q.Delta < 0 || q.Delta >= 1 {
+			return PrivacyCharge{}, fmt.Errorf("delta must be in [0, 1), got %v", q.Delta)
+		}
+		if sigma := q.gaussianSigma(); sigma > 1<<40 {
+			return PrivacyCharge{}, fmt.Errorf("rho %v needs a noise scale of %v grid units, above 2^40", q.Rho, sigma)
+		}
+		// Charge for the sigma actually sampled rather than the rho requested
+		rho := gaussianRho(q.gridSensitivity(), q.gaussianSigma())
+		epsilon := 0.0
+		if q.Delta > 0 {
+			epsilon = ZCDPToApproxDP(rho, q.Delta)
+		}
+		return PrivacyCharge{Mechanism: MechanismGaussian, Epsilon: epsilon, Delta: q.Delta, Rho: rho}, nil
+
+	default:
+		return PrivacyCharge{}, fmt.Errorf("unknown mechanism %q", q.Mechanism)
+	}
+}
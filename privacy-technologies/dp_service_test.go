@@ -0,0 +1,191 @@
+package privacytechnologies
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnappedLaplaceReleasesOnlyGridPoints(t *testing.T) {
+	sampler := NewSecureSampler(nil)
+	// lambda = 1/0.3, so the grid is 4 and outputs are clamped to [-100, 100]
+	total := 0.0
+	const samples = 4000
+	for i := 0; i < samples; i++ {
+		noisy, err := sampler.SnappedLaplace(42.123, 1, 0.3, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Mod(noisy, 4) != 0 && math.Abs(noisy) != 100 {
+			t.Fatalf("release %v is neither on the grid nor at the bound", noisy)
+		}
+		if math.Abs(noisy) > 100 {
+			t.Fatalf("release %v escapes the bound", noisy)
+		}
+		total += noisy
+	}
+	if mean := total / samples; math.Abs(mean-42.123) > 1 {
+		t.Errorf("expected releases centred on 42.123, mean was %v", mean)
+	}
+
+	if _, err := sampler.SnappedLaplace(1, 1, 0.3, 2); err == nil {
+		t.Error("expected a bound below the noise scale to be rejected")
+	}
+	if _, err := NewSecureSampler(strings.NewReader("short")).SnappedLaplace(1, 1, 1, 10); err == nil {
+		t.Error("expected an exhausted random source to fail rather than fall back")
+	}
+}
+
+func TestDiscreteGaussianMoments(t *testing.T) {
+	sampler := NewSecureSampler(nil)
+	const samples = 4000
+	sum, squares := 0.0, 0.0
+	for i := 0; i < samples; i++ {
+		x, err := sampler.DiscreteGaussian(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += float64(x)
+		squares += float64(x * x)
+	}
+	mean := sum / samples
+	variance := squares/samples - mean*mean
+	if math.Abs(mean) > 0.3 || math.Abs(variance-9) > 1.2 {
+		t.Errorf("expected mean 0 and variance 9, got %v and %v", mean, variance)
+	}
+}
+
+func TestCompositionBounds(t *testing.T) {
+	charges := make([]PrivacyCharge, 100)
+	for i := range charges {
+		charges[i] = PrivacyCharge{Mechanism: MechanismLaplace, Epsilon: 0.01, Rho: 0.00005}
+	}
+
+	sequential := Budget{Epsilon: 1, Composition: CompositionSequential}
+	if epsilon, _ := sequential.Spent(charges); math.Abs(epsilon-1) > 1e-9 {
+		t.Errorf("sequential: expected epsilon 1, got %v", epsilon)
+	}
+	advanced := Budget{Epsilon: 1, Delta: 1e-6, Composition: CompositionAdvanced}
+	epsilon, delta := advanced.Spent(charges)
+	if epsilon >= 1 || delta != 5e-7 {
+		t.Errorf("advanced: expected a tighter epsilon at the slack delta, got (%v, %v)", epsilon, delta)
+	}
+	zcdp := Budget{Epsilon: 1, Delta: 1e-6, Composition: CompositionZCDP}
+	if epsilon, _ := zcdp.Spent(charges); math.Abs(epsilon-ZCDPToApproxDP(0.005, 1e-6)) > 1e-12 {
+		t.Errorf("zcdp: unexpected epsilon %v", epsilon)
+	}
+
+	// Two large queries are cheaper to add up sequentially
+	few := []PrivacyCharge{{Epsilon: 0.5}, {Epsilon: 0.5}}
+	if epsilon, delta := advanced.Spent(few); epsilon != 1 || delta != 0 {
+		t.Errorf("advanced: expected the sequential bound, got (%v, %v)", epsilon, delta)
+	}
+
+	for _, budget := range []Budget{
+		{Epsilon: 0, Composition: CompositionSequential},
+		{Epsilon: 1, Composition: CompositionAdvanced},
+		{Epsilon: 1, Composition: CompositionZCDP},
+		{Epsilon: 1, Composition: "renyi"},
+	} {
+		if budget.Validate() == nil {
+			t.Errorf("expected %+v to be rejected", budget)
+		}
+	}
+}
+
+func TestQueryServiceRefusesOnceBudgetIsSpent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	open := func() *DPQueryService {
+		ledger, err := OpenPrivacyLedger(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		service, err := NewDPQueryService(ledger, nil, Budget{Epsilon: 0.5, Composition: CompositionSequential})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := service.RegisterDataset("tonnage", Budget{Epsilon: 0.7, Composition: CompositionSequential}); err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+	count := func(analyst string) *DPQuery {
+		return &DPQuery{Dataset: "tonnage", Analyst: analyst, QueryType: "count", Mechanism: MechanismLaplace, Sensitivity: 1, Epsilon: 0.2, Bound: 1000}
+	}
+	calls := 0
+	compute := func() (float64, error) { calls++; return 120, nil }
+
+	service := open()
+	for i := 0; i < 2; i++ {
+		if _, err := service.Run(count("alice"), compute); err != nil {
+			t.Fatalf("query %d: %v", i, err)
+		}
+	}
+	// Alice has spent 0.4 of her 0.5 share
+	var exhausted *BudgetExhaustedError
+	if _, err := service.Run(count("alice"), compute); !errors.As(err, &exhausted) || exhausted.Scope != "analyst" {
+		t.Fatalf("expected alice's share to be exhausted, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected refused queries not to be computed, compute ran %d times", calls)
+	}
+
+	// The ledger survives a restart, and the dataset has 0.3 left
+	service = open()
+	if _, err := service.Run(count("bob"), compute); err != nil {
+		t.Fatalf("bob's first query: %v", err)
+	}
+	if _, err := service.Run(count("bob"), compute); !errors.As(err, &exhausted) || exhausted.Scope != "dataset" {
+		t.Fatalf("expected the dataset budget to be exhausted, got %v", err)
+	}
+	status, err := service.DatasetStatus("tonnage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Queries != 3 || math.Abs(status.SpentEpsilon-0.6) > 1e-6 {
+		t.Errorf("expected 3 queries spending 0.6, got %+v", status)
+	}
+}
+
+func TestGaussianQueriesUseZCDPAccounting(t *testing.T) {
+	ledger, _ := OpenPrivacyLedger("")
+	zcdp := Budget{Epsilon: 2, Delta: 1e-6, Composition: CompositionZCDP}
+	service, err := NewDPQueryService(ledger, nil, zcdp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RegisterDataset("fill-levels", zcdp); err != nil {
+		t.Fatal(err)
+	}
+
+	query := &DPQuery{Dataset: "fill-levels", Analyst: "ops", Mechanism: MechanismGaussian, Sensitivity: 0.5, Granularity: 0.25, Rho: 0.001}
+	result, err := service.Run(query, func() (float64, error) { return 63.4, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Mod(result.Value, 0.25) != 0 || math.Abs(result.Charge.Rho-0.001) > 1e-12 {
+		t.Errorf("unexpected release %v with charge %+v", result.Value, result.Charge)
+	}
+
+	// Each query alone is (0.24, 1e-6)-DP, yet zCDP admits far more than the 8
+	// sequential composition would
+	refused := false
+	for i := 0; i < 100 && !refused; i++ {
+		_, err := service.Run(query, func() (float64, error) { return 63.4, nil })
+		refused = err != nil
+	}
+	status := service.AnalystStatus("fill-levels", "ops")
+	if !refused || status.SpentEpsilon > 2 || status.Queries < 60 {
+		t.Errorf("expected zCDP to admit 60+ queries before refusing, got %+v", status)
+	}
+
+	if err := service.RegisterDataset("sequential", Budget{Epsilon: 2, Composition: CompositionSequential}); err != nil {
+		t.Fatal(err)
+	}
+	query.Dataset = "sequential"
+	if _, err := service.Run(query, func() (float64, error) { return 1, nil }); err == nil {
+		t.Error("expected a Gaussian query without a delta to be refused by a sequential budget")
+	}
+}
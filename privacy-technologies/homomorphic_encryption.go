@@ -10,13 +10,18 @@ import (
 	"time"
 )
 
+// HomomorphicEncryption aggregates measurements under Paillier encryption.
+// Measurements are fixed-point encoded, so sums of ciphertexts decrypt to the
+// sum of the original values.
 type HomomorphicEncryption struct {
-	PublicKey  *big.Int
-	PrivateKey *big.Int
-	Modulus    *big.Int
-	Generator  *big.Int
+	PublicKey  *PaillierPublicKey
+	PrivateKey *PaillierPrivateKey
+	Encoder    *FixedPointEncoder
 }
 
+// measurementDecimals is the fixed-point precision of encrypted measurements
+const measurementDecimals = 6
+
 type CardiacMeasurement struct {
 	BinID      string
 	PreTreatment   float64
@@ -27,15 +32,6 @@ type CardiacMeasurement struct {
 	EncryptedData  *big.Int
 }
 
-type TreatmentResponse struct {
-	BinID              string
-	BaselineCardiacOutput  float64
-	PostTreatmentOutput    float64
-	ImprovementPercentage  float64
-	TreatmentType          string
-	FacilityID             string
-}
-
 type HomomorphicTestResult struct {
 	TestRun               int
 	ProcessingTime        time.Duration
@@ -64,47 +60,58 @@ type HESummary struct {
 }
 
 func NewHomomorphicEncryption() *HomomorphicEncryption {
-	p, _ := rand.Prime(rand.Reader, 1024)
-	q, _ := rand.Prime(rand.Reader, 1024)
-	n := new(big.Int).Mul(p, q)
-	
-	phi := new(big.Int).Mul(
-		new(big.Int).Sub(p, big.NewInt(1)),
-		new(big.Int).Sub(q, big.NewInt(1)),
-	)
-	
-	e := big.NewInt(65537)
-	d := new(big.Int).ModInverse(e, phi)
+	he, err := NewHomomorphicEncryptionWithBits(DefaultPaillierBits)
+	if err != nil {
+		log.Fatalf("Failed to generate Paillier key: %v", err)
+	}
+	return he
+}
+
+// NewHomomorphicEncryptionWithBits generates a Paillier key with a bits-bit modulus
+func NewHomomorphicEncryptionWithBits(bits int) (*HomomorphicEncryption, error) {
+	sk, err := GeneratePaillierKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
 	
 	return &HomomorphicEncryption{
-		PublicKey:  e,
-		PrivateKey: d,
-		Modulus:    n,
-		Generator:  big.NewInt(2),
+		PublicKey:  sk.Public(),
+		PrivateKey: sk,
+		Encoder:    NewFixedPointEncoder(sk.Public(), measurementDecimals),
+	}, nil
+}
+
+// EncryptCardiacData encrypts a measurement with fresh randomness
+func (he *HomomorphicEncryption) EncryptCardiacData(value float64) (*big.Int, error) {
+	m, err := he.Encoder.Encode(value)
+	if err != nil {
+		return nil, err
 	}
+	return he.PublicKey.Encrypt(rand.Reader, m)
 }
 
-func (he *HomomorphicEncryption) EncryptCardiacData(value float64) *big.Int {
-	valueInt := big.NewInt(int64(value * 1000))
-	r, _ := rand.Int(rand.Reader, he.Modulus)
-	
-	c1 := new(big.Int).Exp(he.Generator, valueInt, he.Modulus)
-	c2 := new(big.Int).Exp(r, he.PublicKey, he.Modulus)
-	
-	return new(big.Int).Mul(c1, c2)
+// EncryptedZero is the identity for HomomorphicAdd
+func (he *HomomorphicEncryption) EncryptedZero() *big.Int {
+	return big.NewInt(1)
 }
 
+// HomomorphicAdd returns an encryption of the sum of a's and b's measurements
 func (he *HomomorphicEncryption) HomomorphicAdd(a, b *big.Int) *big.Int {
-	return new(big.Int).Mul(a, b)
+	return he.PublicKey.Add(a, b)
 }
 
+// HomomorphicMultiply returns an encryption of the measurement times an integer scalar
 func (he *HomomorphicEncryption) HomomorphicMultiply(encrypted *big.Int, scalar *big.Int) *big.Int {
-	return new(big.Int).Exp(encrypted, scalar, he.Modulus)
+	return he.PublicKey.MulScalar(encrypted, scalar)
 }
 
-func (he *HomomorphicEncryption) DecryptResult(encrypted *big.Int) float64 {
-	result := new(big.Int).Exp(encrypted, he.PrivateKey, he.Modulus)
-	return float64(result.Int64()) / 1000.0
+// DecryptResult decrypts a measurement or a sum of measurements
+func (he *HomomorphicEncryption) DecryptResult(encrypted *big.Int) (float64, error) {
+	m, err := he.PrivateKey.Decrypt(encrypted)
+	if err != nil {
+		return 0, err
+	}
+	return he.Encoder.Decode(m), nil
 }
 
 func (he *HomomorphicEncryption) generateCardiacTestData() []CardiacMeasurement {
@@ -199,8 +206,8 @@ func (he *HomomorphicEncryption) generateCardiacTestData() []CardiacMeasurement
 }
 
 func (he *HomomorphicEncryption) processHomomorphicCardiacAnalysis(measurements []CardiacMeasurement) (float64, float64, int) {
-	arbSum := big.NewInt(0)
-	aceSum := big.NewInt(0)
+	arbSum := he.EncryptedZero()
+	aceSum := he.EncryptedZero()
 	arbCount := 0
 	aceCount := 0
 	homomorphicOps := 0
@@ -208,7 +215,11 @@ func (he *HomomorphicEncryption) processHomomorphicCardiacAnalysis(measurements
 	for _, measurement := range measurements {
 		if measurement.TreatmentType == "ARB" || measurement.TreatmentType == "ACE" {
 			improvement := (measurement.PostTreatment - measurement.PreTreatment) / measurement.PreTreatment
-			encryptedImprovement := he.EncryptCardiacData(improvement)
+			encryptedImprovement, err := he.EncryptCardiacData(improvement)
+			if err != nil {
+				log.Printf("Skipping measurement for %s: %v", measurement.BinID, err)
+				continue
+			}
 			homomorphicOps++
 			
 			if measurement.TreatmentType == "ARB" {
@@ -227,12 +238,20 @@ func (he *HomomorphicEncryption) processHomomorphicCardiacAnalysis(measurements
 	aceAverage := 0.0
 	
 	if arbCount > 0 {
-		arbAverage = he.DecryptResult(arbSum) / float64(arbCount)
+		if arbTotal, err := he.DecryptResult(arbSum); err == nil {
+			arbAverage = arbTotal / float64(arbCount)
+		} else {
+			log.Printf("Failed to decrypt ARB total: %v", err)
+		}
 		homomorphicOps++
 	}
 	
 	if aceCount > 0 {
-		aceAverage = he.DecryptResult(aceSum) / float64(aceCount)
+		if aceTotal, err := he.DecryptResult(aceSum); err == nil {
+			aceAverage = aceTotal / float64(aceCount)
+		} else {
+			log.Printf("Failed to decrypt ACE total: %v", err)
+		}
 		homomorphicOps++
 	}
 	
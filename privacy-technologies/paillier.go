@@ -0,0 +1,254 @@
+package privacytechnologies
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+)
+
+// DefaultPaillierBits is the modulus size used by NewHomomorphicEncryption
+const DefaultPaillierBits = 2048
+
+// MinPaillierBits is the smallest modulus GeneratePaillierKey accepts
+const MinPaillierBits = 512
+
+var (
+	ErrMessageOutOfRange = errors.New("plaintext is outside [0, n)")
+	ErrInvalidCiphertext = errors.New("ciphertext is not a unit modulo n^2")
+	ErrEncodingOverflow  = errors.New("value does not fit the fixed-point plaintext range")
+)
+
+var bigOne = big.NewInt(1)
+
+// PaillierPublicKey encrypts values and combines ciphertexts. With g = n+1,
+// Enc(m) = (1 + m*n) * r^n mod n^2, so multiplying ciphertexts adds plaintexts.
+type PaillierPublicKey struct {
+	N        *big.Int
+	NSquared *big.Int
+}
+
+// PaillierPrivateKey decrypts ciphertexts under its public key
+type PaillierPrivateKey struct {
+	PaillierPublicKey
+	Lambda *big.Int // lcm(p-1, q-1)
+	Mu     *big.Int // lambda^-1 mod n
+}
+
+// GeneratePaillierKey creates a key pair whose modulus has exactly bits bits
+func GeneratePaillierKey(random io.Reader, bits int) (*PaillierPrivateKey, error) {
+	if bits < MinPaillierBits || bits%2 != 0 {
+		return nil, fmt.Errorf("paillier modulus must be an even number of bits >= %d, got %d", MinPaillierBits, bits)
+	}
+
+	for {
+		p, err := rand.Prime(random, bits/2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate prime: %v", err)
+		}
+		q, err := rand.Prime(random, bits/2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate prime: %v", err)
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+
+		pMinus := new(big.Int).Sub(p, bigOne)
+		qMinus := new(big.Int).Sub(q, bigOne)
+		phi := new(big.Int).Mul(pMinus, qMinus)
+		// Equal-sized primes give gcd(n, phi) = 1, which makes mu invertible
+		if new(big.Int).GCD(nil, nil, n, phi).Cmp(bigOne) != 0 {
+			continue
+		}
+
+		gcd := new(big.Int).GCD(nil, nil, pMinus, qMinus)
+		lambda := new(big.Int).Div(phi, gcd)
+		mu := new(big.Int).ModInverse(lambda, n)
+		if mu == nil {
+			continue
+		}
+
+		return &PaillierPrivateKey{
+			PaillierPublicKey: PaillierPublicKey{N: n, NSquared: new(big.Int).Mul(n, n)},
+			Lambda:            lambda,
+			Mu:                mu,
+		}, nil
+	}
+}
+
+// Public returns the key's public half
+func (sk *PaillierPrivateKey) Public() *PaillierPublicKey {
+	return &sk.PaillierPublicKey
+}
+
+// Encrypt encrypts m in [0, n) with fresh randomness, so equal plaintexts give
+// unrelated ciphertexts
+func (pk *PaillierPublicKey) Encrypt(random io.Reader, m *big.Int) (*big.Int, error) {
+	if m.Sign() < 0 || m.Cmp(pk.N) >= 0 {
+		return nil, ErrMessageOutOfRange
+	}
+
+	r, err := pk.randomUnit(random)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(big.Int).Exp(r, pk.N, pk.NSquared)
+	c.Mul(c, pk.shift(m))
+	return c.Mod(c, pk.NSquared), nil
+}
+
+// Add returns an encryption of the sum of the plaintexts of a and b
+func (pk *PaillierPublicKey) Add(a, b *big.Int) *big.Int {
+	sum := new(big.Int).Mul(a, b)
+	return sum.Mod(sum, pk.NSquared)
+}
+
+// AddPlaintext returns an encryption of c's plaintext plus m
+func (pk *PaillierPublicKey) AddPlaintext(c, m *big.Int) *big.Int {
+	shifted := new(big.Int).Mod(m, pk.N)
+	sum := new(big.Int).Mul(c, pk.shift(shifted))
+	return sum.Mod(sum, pk.NSquared)
+}
+
+// MulScalar returns an encryption of c's plaintext times k; negative k scales by k mod n
+func (pk *PaillierPublicKey) MulScalar(c, k *big.Int) *big.Int {
+	exponent := new(big.Int).Mod(k, pk.N)
+	return new(big.Int).Exp(c, exponent, pk.NSquared)
+}
+
+// Rerandomize returns a fresh encryption of c's plaintext that cannot be linked to c
+func (pk *PaillierPublicKey) Rerandomize(random io.Reader, c *big.Int) (*big.Int, error) {
+	zero, err := pk.Encrypt(random, new(big.Int))
+	if err != nil {
+		return nil, err
+	}
+	return pk.Add(c, zero), nil
+}
+
+// Decrypt recovers the plaintext in [0, n)
+func (sk *PaillierPrivateKey) Decrypt(c *big.Int) (*big.Int, error) {
+	if c.Sign() <= 0 || c.Cmp(sk.NSquared) >= 0 || new(big.Int).GCD(nil, nil, c, sk.N).Cmp(bigOne) != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	// m = L(c^lambda mod n^2) * mu mod n, where L(x) = (x - 1) / n
+	x := new(big.Int).Exp(c, sk.Lambda, sk.NSquared)
+	x.Sub(x, bigOne)
+	x.Div(x, sk.N)
+	x.Mul(x, sk.Mu)
+	return x.Mod(x, sk.N), nil
+}
+
+// shift computes g^m = 1 + m*n mod n^2 for g = n+1
+func (pk *PaillierPublicKey) shift(m *big.Int) *big.Int {
+	gm := new(big.Int).Mul(m, pk.N)
+	gm.Add(gm, bigOne)
+	return gm.Mod(gm, pk.NSquared)
+}
+
+func (pk *PaillierPublicKey) randomUnit(random io.Reader) (*big.Int, error) {
+	for {
+		r, err := rand.Int(random, pk.N)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample randomness: %v", err)
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, pk.N).Cmp(bigOne) == 0 {
+			return r, nil
+		}
+	}
+}
+
+// FixedPointEncoder maps signed decimals such as fill levels or tonnes onto
+// Paillier plaintexts with a fixed number of fractional digits. Negative values
+// wrap to the top half of [0, n), so sums of encodings decode correctly as long
+// as the true sum stays within the encodable range.
+type FixedPointEncoder struct {
+	Decimals int
+	scale    *big.Float
+	n        *big.Int
+	half     *big.Int
+}
+
+// NewFixedPointEncoder encodes values under pk with the given fractional digits
+func NewFixedPointEncoder(pk *PaillierPublicKey, decimals int) *FixedPointEncoder {
+	return &FixedPointEncoder{
+		Decimals: decimals,
+		scale:    new(big.Float).SetFloat64(math.Pow10(decimals)),
+		n:        pk.N,
+		half:     new(big.Int).Rsh(pk.N, 1),
+	}
+}
+
+// Encode rounds value to the encoder's precision and returns its plaintext
+func (fe *FixedPointEncoder) Encode(value float64) (*big.Int, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, ErrEncodingOverflow
+	}
+
+	scaled := new(big.Float).Mul(new(big.Float).SetFloat64(value), fe.scale)
+	if scaled.Sign() >= 0 {
+		scaled.Add(scaled, big.NewFloat(0.5))
+	} else {
+		scaled.Sub(scaled, big.NewFloat(0.5))
+	}
+	m, _ := scaled.Int(nil)
+	if new(big.Int).Abs(m).Cmp(fe.half) >= 0 {
+		return nil, ErrEncodingOverflow
+	}
+	return m.Mod(m, fe.n), nil
+}
+
+// Decode returns the value of a plaintext produced by Encode or by adding encodings
+func (fe *FixedPointEncoder) Decode(m *big.Int) float64 {
+	signed := new(big.Int).Set(m)
+	if signed.Cmp(fe.half) > 0 {
+		signed.Sub(signed, fe.n)
+	}
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(signed), fe.scale).Float64()
+	return value
+}
+
+// TonnageReport is one facility's encrypted tonnage for a reporting period
+type TonnageReport struct {
+	FacilityID string
+	Period     string
+	Ciphertext *big.Int
+}
+
+// EncryptTonnage produces a facility's report; only the public key is needed
+func EncryptTonnage(random io.Reader, pk *PaillierPublicKey, encoder *FixedPointEncoder, facilityID, period string, tonnes float64) (*TonnageReport, error) {
+	m, err := encoder.Encode(tonnes)
+	if err != nil {
+		return nil, fmt.Errorf("facility %s: %v", facilityID, err)
+	}
+	c, err := pk.Encrypt(random, m)
+	if err != nil {
+		return nil, fmt.Errorf("facility %s: %v", facilityID, err)
+	}
+	return &TonnageReport{FacilityID: facilityID, Period: period, Ciphertext: c}, nil
+}
+
+// AggregateTonnage combines reports for one period into an encryption of their
+// total without decrypting any of them
+func AggregateTonnage(pk *PaillierPublicKey, period string, reports []*TonnageReport) (*big.Int, error) {
+	total := big.NewInt(1) // the trivial encryption of zero
+	for _, report := range reports {
+		if report.Period != period {
+			return nil, fmt.Errorf("report from %s is for period %s, not %s", report.FacilityID, report.Period, period)
+		}
+		if report.Ciphertext.Sign() <= 0 || report.Ciphertext.Cmp(pk.NSquared) >= 0 {
+			return nil, fmt.Errorf("report from %s: %v", report.FacilityID, ErrInvalidCiphertext)
+		}
+		total = pk.Add(total, report.Ciphertext)
+	}
+	return total, nil
+}
@@ -0,0 +1,143 @@
+package privacytechnologies
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func testPaillierKey(t *testing.T) *PaillierPrivateKey {
+	t.Helper()
+	sk, err := GeneratePaillierKey(rand.Reader, MinPaillierBits)
+	if err != nil {
+		t.Fatalf("key generation failed: %v", err)
+	}
+	return sk
+}
+
+func decryptInt(t *testing.T, sk *PaillierPrivateKey, c *big.Int) int64 {
+	t.Helper()
+	m, err := sk.Decrypt(c)
+	if err != nil {
+		t.Fatalf("decryption failed: %v", err)
+	}
+	return m.Int64()
+}
+
+func TestPaillierHomomorphism(t *testing.T) {
+	sk := testPaillierKey(t)
+	pk := sk.Public()
+	if pk.N.BitLen() != MinPaillierBits {
+		t.Errorf("expected a %d-bit modulus, got %d", MinPaillierBits, pk.N.BitLen())
+	}
+
+	a, _ := pk.Encrypt(rand.Reader, big.NewInt(1200))
+	b, _ := pk.Encrypt(rand.Reader, big.NewInt(34))
+	again, _ := pk.Encrypt(rand.Reader, big.NewInt(1200))
+	if a.Cmp(again) == 0 {
+		t.Error("expected encryption to be randomized")
+	}
+
+	if got := decryptInt(t, sk, pk.Add(a, b)); got != 1234 {
+		t.Errorf("Add: got %d, want 1234", got)
+	}
+	if got := decryptInt(t, sk, pk.AddPlaintext(a, big.NewInt(-200))); got != 1000 {
+		t.Errorf("AddPlaintext: got %d, want 1000", got)
+	}
+	if got := decryptInt(t, sk, pk.MulScalar(b, big.NewInt(3))); got != 102 {
+		t.Errorf("MulScalar: got %d, want 102", got)
+	}
+	fresh, _ := pk.Rerandomize(rand.Reader, a)
+	if fresh.Cmp(a) == 0 || decryptInt(t, sk, fresh) != 1200 {
+		t.Error("expected a different ciphertext of the same plaintext")
+	}
+
+	if _, err := pk.Encrypt(rand.Reader, pk.N); !errors.Is(err, ErrMessageOutOfRange) {
+		t.Errorf("expected ErrMessageOutOfRange, got %v", err)
+	}
+	if _, err := sk.Decrypt(pk.N); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("expected ErrInvalidCiphertext, got %v", err)
+	}
+	if _, err := GeneratePaillierKey(rand.Reader, 256); err == nil {
+		t.Error("expected an undersized modulus to be rejected")
+	}
+}
+
+func TestFixedPointSumsOfSignedValues(t *testing.T) {
+	sk := testPaillierKey(t)
+	pk := sk.Public()
+	encoder := NewFixedPointEncoder(pk, 3)
+
+	total := big.NewInt(1)
+	for _, value := range []float64{12.345, -2.5, 0.0004, 7.0} {
+		m, err := encoder.Encode(value)
+		if err != nil {
+			t.Fatalf("encode %v: %v", value, err)
+		}
+		c, _ := pk.Encrypt(rand.Reader, m)
+		total = pk.Add(total, c)
+	}
+	m, _ := sk.Decrypt(total)
+	if got := encoder.Decode(m); math.Abs(got-16.845) > 1e-9 {
+		t.Errorf("expected 16.845, got %v", got)
+	}
+
+	negative := pk.MulScalar(total, big.NewInt(-2))
+	m, _ = sk.Decrypt(negative)
+	if got := encoder.Decode(m); math.Abs(got+33.69) > 1e-9 {
+		t.Errorf("expected -33.69, got %v", got)
+	}
+
+	if _, err := encoder.Encode(math.Inf(1)); !errors.Is(err, ErrEncodingOverflow) {
+		t.Errorf("expected ErrEncodingOverflow, got %v", err)
+	}
+	if _, err := encoder.Encode(1e200); !errors.Is(err, ErrEncodingOverflow) {
+		t.Errorf("expected ErrEncodingOverflow for a huge value, got %v", err)
+	}
+}
+
+func TestAggregateTonnage(t *testing.T) {
+	sk := testPaillierKey(t)
+	pk := sk.Public()
+	encoder := NewFixedPointEncoder(pk, 3)
+
+	tonnes := map[string]float64{"transfer-north": 412.75, "mrf-east": 98.5, "landfill-gate": 1310.125}
+	var reports []*TonnageReport
+	for facility, amount := range tonnes {
+		report, err := EncryptTonnage(rand.Reader, pk, encoder, facility, "2026-10", amount)
+		if err != nil {
+			t.Fatalf("encrypt tonnage: %v", err)
+		}
+		reports = append(reports, report)
+	}
+
+	total, err := AggregateTonnage(pk, "2026-10", reports)
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+	m, _ := sk.Decrypt(total)
+	if got := encoder.Decode(m); math.Abs(got-1821.375) > 1e-9 {
+		t.Errorf("expected 1821.375 tonnes, got %v", got)
+	}
+
+	reports[0].Period = "2026-09"
+	if _, err := AggregateTonnage(pk, "2026-10", reports); err == nil {
+		t.Error("expected a report from another period to be rejected")
+	}
+}
+
+func TestCardiacAnalysisMatchesPlaintext(t *testing.T) {
+	he, err := NewHomomorphicEncryptionWithBits(MinPaillierBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	measurements := he.generateCardiacTestData()[:300]
+
+	arb, _, _ := he.processHomomorphicCardiacAnalysis(measurements)
+	plainARB, _ := he.calculateUnencryptedBaseline(measurements)
+	if math.Abs(arb-plainARB) > 1e-4 {
+		t.Errorf("encrypted ARB average %v differs from plaintext %v", arb, plainARB)
+	}
+}
@@ -48,7 +48,7 @@ type ComparisonSummary struct {
 
 func NewPrivacyUtilityAnalysis() *PrivacyUtilityAnalysis {
 	return &PrivacyUtilityAnalysis{
-		DPEngine:  NewDifferentialPrivacyEngine(),
+		DPEngine:  NewDifferentialPrivacyEngine(1.0),
 		HEEngine:  NewHomomorphicEncryption(),
 		SMCEngine: NewSecureMultipartyComputation(),
 	}
@@ -128,7 +128,7 @@ func (pua *PrivacyUtilityAnalysis) analyzeDifferentialPrivacy() []PrivacyUtility
 	
 	for i, test := range dpResults.CardiacDemographicsTests {
 		privacyLevel := pua.calculatePrivacyLevel("Differential Privacy")
-		accuracyPreservation := test.AccuracyRate * 100.0
+		accuracyPreservation := 100.0 - test.AccuracyLoss
 		utilityScore := pua.calculateUtilityScore("Differential Privacy", accuracyPreservation)
 		performanceOverhead := pua.calculatePerformanceOverhead(baselineTime, test.ProcessingTime)
 		scalabilityScore := pua.calculateScalabilityScore("Differential Privacy", test.ProcessingTime)
@@ -139,7 +139,7 @@ func (pua *PrivacyUtilityAnalysis) analyzeDifferentialPrivacy() []PrivacyUtility
 			PrivacyLevel:         privacyLevel,
 			UtilityScore:         utilityScore,
 			PerformanceOverhead:  performanceOverhead,
-			MemoryUsage:          test.MemoryUsage,
+			MemoryUsage:          uint64(test.MemoryUsage),
 			ProcessingTime:       test.ProcessingTime,
 			AccuracyPreservation: accuracyPreservation,
 			ScalabilityScore:     scalabilityScore,
@@ -2,7 +2,6 @@ package privacytechnologies
 
 import (
 	"crypto/rand"
-	"crypto/sha256"
 	"fmt"
 	"log"
 	"math/big"
@@ -284,7 +283,7 @@ func (smc *SecureMultipartyComputation) createSecretShares(value float64, thresh
 		coefficients[i] = coeff
 	}
 	
-	for i, party := range smc.Parties {
+	for i := range smc.Parties {
 		x := big.NewInt(int64(i + 1))
 		y := big.NewInt(0)
 		
@@ -6,7 +6,7 @@ import (
 	"os"
 	"time"
 	
-	"./comparison"
+	"blockchain-jedi/security-comparison"
 )
 
 func main() {
@@ -339,7 +339,7 @@ func generateComprehensiveSecurityReport(mitmResults *comparison.MITMTestResults
 
 // printUsage displays usage information
 func printUsage() {
-	fmt.Println("Usage: go run main.go [command]")
+	fmt.Println("Usage: go run ./cmd/security-comparison [command]")
 	fmt.Println("")
 	fmt.Println("Commands:")
 	fmt.Println("  mitm              - Run MITM attack resistance analysis")
@@ -348,11 +348,11 @@ func printUsage() {
 	fmt.Println("  comprehensive     - Run all security comparison tests (default)")
 	fmt.Println("")
 	fmt.Println("Examples:")
-	fmt.Println("  go run main.go")
-	fmt.Println("  go run main.go comprehensive")
-	fmt.Println("  go run main.go mitm")
-	fmt.Println("  go run main.go sidechannel")
-	fmt.Println("  go run main.go ddos")
+	fmt.Println("  go run ./cmd/security-comparison")
+	fmt.Println("  go run ./cmd/security-comparison comprehensive")
+	fmt.Println("  go run ./cmd/security-comparison mitm")
+	fmt.Println("  go run ./cmd/security-comparison sidechannel")
+	fmt.Println("  go run ./cmd/security-comparison ddos")
 	fmt.Println("")
 	fmt.Println("Expected Results:")
 	fmt.Println("  • MITM Attack Resistance: 0% success rate (complete resistance)")
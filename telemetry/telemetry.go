@@ -0,0 +1,82 @@
+// Package telemetry carries request IDs and trace context through swt and the
+// binding layer, and on to the HIBE API server. It is the HIBE API server's own
+// telemetry package, hibe-api/telemetry, under the name the rest of this module
+// imports, so both sides of a request agree on the request ID header, the tags
+// logs carry and how spans are exported.
+package telemetry
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+	hibetelemetry "hibe-api/telemetry"
+)
+
+// RequestIDHeader carries a request's ID between services
+const RequestIDHeader = hibetelemetry.RequestIDHeader
+
+// Exporters spans can be sent to
+const (
+	ExporterNone = hibetelemetry.ExporterNone
+	ExporterFile = hibetelemetry.ExporterFile
+	ExporterOTLP = hibetelemetry.ExporterOTLP
+)
+
+// Handler tags every record with the request ID, trace ID and span ID of the
+// context it is logged with
+type Handler = hibetelemetry.Handler
+
+// TracingConfig selects where spans go
+type TracingConfig = hibetelemetry.TracingConfig
+
+// WithRequestID returns ctx carrying id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return hibetelemetry.WithRequestID(ctx, id)
+}
+
+// RequestID returns the request ID ctx carries, or ""
+func RequestID(ctx context.Context) string {
+	return hibetelemetry.RequestID(ctx)
+}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	return hibetelemetry.NewRequestID()
+}
+
+// Extract returns ctx carrying the request ID and remote trace context of an
+// incoming request's headers. A request without a usable ID is given one.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return hibetelemetry.Extract(ctx, header)
+}
+
+// Inject adds the request ID and trace context of ctx to outgoing headers
+func Inject(ctx context.Context, header http.Header) {
+	hibetelemetry.Inject(ctx, header)
+}
+
+// NewHandler wraps inner
+func NewHandler(inner slog.Handler) *Handler {
+	return hibetelemetry.NewHandler(inner)
+}
+
+// NewLogger returns a logger writing level and above to w as text or JSON,
+// tagged by Handler
+func NewLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	return hibetelemetry.NewLogger(w, level, format)
+}
+
+// Setup installs a tracer provider exporting as cfg says, and the W3C trace
+// context propagator, as the global OpenTelemetry defaults. The returned
+// function flushes buffered spans and stops the exporter.
+func Setup(ctx context.Context, cfg TracingConfig) (func(context.Context) error, error) {
+	return hibetelemetry.Setup(ctx, cfg)
+}
+
+// Tracer returns the named tracer of the global provider
+func Tracer(name string) trace.Tracer {
+	return hibetelemetry.Tracer(name)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"blockchain-jedi/waste-management-access-control/keytool"
+)
+
+func main() {
+	if err := keytool.New("hibe-keytool").Run(os.Args[1:]); err != nil {
+		if errors.Is(err, keytool.ErrUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "hibe-keytool %v\n", err)
+		os.Exit(1)
+	}
+}
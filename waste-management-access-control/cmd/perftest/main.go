@@ -6,12 +6,12 @@ import (
 	"os"
 	"time"
 	
-	"./testing"
-	"./hibe"
-	"./pattern"
-	"./wildcard"
-	"./waste-management"
-	"./memory"
+	"blockchain-jedi/waste-management-access-control/hibe"
+	"blockchain-jedi/waste-management-access-control/memory"
+	"blockchain-jedi/waste-management-access-control/pattern"
+	"blockchain-jedi/waste-management-access-control/testing"
+	wastedata "blockchain-jedi/waste-management-access-control/waste-data"
+	"blockchain-jedi/waste-management-access-control/wildcard"
 )
 
 func main() {
@@ -57,10 +57,10 @@ func runBenchmarkTests() {
 	
 	// Note: In a real implementation, these would use go test -bench
 	fmt.Println("To run benchmark tests, execute:")
-	fmt.Println("  go test -bench=. ./testing/")
-	fmt.Println("  go test -bench=BenchmarkHIBEKeyGeneration ./testing/")
-	fmt.Println("  go test -bench=BenchmarkPatternMatching ./testing/")
-	fmt.Println("  go test -bench=BenchmarkConcurrentLoad ./testing/")
+	fmt.Println("  go test -bench=. ./testing")
+	fmt.Println("  go test -bench=BenchmarkHIBEKeyGeneration ./testing")
+	fmt.Println("  go test -bench=BenchmarkPatternMatching ./testing")
+	fmt.Println("  go test -bench=BenchmarkConcurrentLoad ./testing")
 }
 
 // runLoadTests executes load testing scenarios
@@ -143,7 +143,7 @@ func testComponentInitialization() {
 	fmt.Println("  ✓ Wildcard Processor initialized")
 	
 	// Initialize WasteManagement Parser
-	parser := waste-management.NewWasteManagementParser()
+	parser := wastedata.NewWasteManagementParser()
 	if parser == nil {
 		log.Fatal("Failed to initialize WasteManagement Parser")
 	}
@@ -166,7 +166,7 @@ func testDataFlowIntegration() {
 	hibeGen, _ := hibe.NewHIBEKeyGenerator(params)
 	matcher := pattern.NewPatternMatcher(1000)
 	processor := wildcard.NewWildcardProcessor(500)
-	parser := waste-management.NewWasteManagementParser()
+	parser := wastedata.NewWasteManagementParser()
 	
 	// Test waste-management URI
 	testURI := "/facility/cardiology/bin/12345/vitals/realtime"
@@ -196,13 +196,13 @@ func testDataFlowIntegration() {
 		len(compiledPattern.OptimizedComponents), compiledPattern.CompareCount)
 	
 	// Step 4: Generate HIBE key
-	waste-managementPattern := &hibe.WasteManagementPattern{
+	wastePattern := &hibe.WasteManagementPattern{
 		Components:   parsedData.Components,
 		WildcardMask: []bool{false, true, false, true, false, true},
 		PatternType:  parsedData.DepartmentType,
 	}
 	
-	privateKey, duration, err := hibeGen.GenerateWasteManagementKey(waste-managementPattern)
+	privateKey, duration, err := hibeGen.GenerateWasteManagementKey(wastePattern)
 	if err != nil {
 		log.Fatalf("Failed to generate HIBE key: %v", err)
 	}
@@ -221,7 +221,7 @@ func testDataFlowIntegration() {
 func testErrorHandling() {
 	fmt.Println("\nTesting error handling...")
 	
-	parser := waste-management.NewWasteManagementParser()
+	parser := wastedata.NewWasteManagementParser()
 	
 	// Test invalid URI
 	invalidURIs := []string{
@@ -306,7 +306,7 @@ func generateFinalSummary() {
 
 // printUsage prints command usage information
 func printUsage() {
-	fmt.Println("Usage: go run main_test.go [command]")
+	fmt.Println("Usage: go run ./cmd/perftest [command]")
 	fmt.Println("")
 	fmt.Println("Commands:")
 	fmt.Println("  comprehensive  - Run complete performance test suite (default)")
@@ -315,8 +315,8 @@ func printUsage() {
 	fmt.Println("  comparison     - Run performance comparison tests")
 	fmt.Println("")
 	fmt.Println("Examples:")
-	fmt.Println("  go run main_test.go")
-	fmt.Println("  go run main_test.go comprehensive")
-	fmt.Println("  go run main_test.go load")
-	fmt.Println("  go run main_test.go benchmark")
+	fmt.Println("  go run ./cmd/perftest")
+	fmt.Println("  go run ./cmd/perftest comprehensive")
+	fmt.Println("  go run ./cmd/perftest load")
+	fmt.Println("  go run ./cmd/perftest benchmark")
 }
\ No newline at end of file
@@ -0,0 +1,125 @@
+package hibe
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrKeyExpired      = errors.New("key is outside its validity window")
+	ErrInvalidValidity = errors.New("validity window ends before it starts")
+)
+
+// ValidityWindow bounds when a key may be used. A zero time leaves that side open.
+type ValidityWindow struct {
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// Contains reports whether t falls inside the window
+func (vw ValidityWindow) Contains(t time.Time) bool {
+	if !vw.NotBefore.IsZero() && t.Before(vw.NotBefore) {
+		return false
+	}
+	if !vw.NotAfter.IsZero() && t.After(vw.NotAfter) {
+		return false
+	}
+	return true
+}
+
+// narrow fits the window inside a parent's window. Open sides inherit the parent's
+// bound; a side reaching beyond the parent's bound is refused.
+func (vw ValidityWindow) narrow(parent ValidityWindow) (ValidityWindow, error) {
+	if vw.NotBefore.IsZero() {
+		vw.NotBefore = parent.NotBefore
+	} else if !parent.NotBefore.IsZero() && vw.NotBefore.Before(parent.NotBefore) {
+		return ValidityWindow{}, ErrNotNarrowing
+	}
+
+	if vw.NotAfter.IsZero() {
+		vw.NotAfter = parent.NotAfter
+	} else if !parent.NotAfter.IsZero() && vw.NotAfter.After(parent.NotAfter) {
+		return ValidityWindow{}, ErrNotNarrowing
+	}
+
+	if err := vw.validate(); err != nil {
+		return ValidityWindow{}, err
+	}
+	return vw, nil
+}
+
+// validate refuses a window that ends before it starts
+func (vw ValidityWindow) validate() error {
+	if vw.bounded() && vw.NotAfter.Before(vw.NotBefore) {
+		return ErrInvalidValidity
+	}
+	return nil
+}
+
+// cacheSuffix distinguishes bounded keys from unbounded keys for the same pattern
+func (vw ValidityWindow) cacheSuffix() string {
+	if vw.NotBefore.IsZero() && vw.NotAfter.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("@%d-%d", unixOrZero(vw.NotBefore), unixOrZero(vw.NotAfter))
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Delegate derives a key for a narrower pattern from a parent key, for example
+// pinning the department and bin of a facility-wide key before handing it to a
+// contractor. The child must keep every component the parent pins and its Validity
+// must lie inside the parent's; open sides inherit the parent's bounds. Derived keys
+// are cached under the child pattern and window.
+func (kg *HIBEKeyGenerator) Delegate(parent *PrivateKey, child *WasteManagementPattern) (*PrivateKey, error) {
+	start := time.Now()
+
+	if parent == nil {
+		return nil, ErrMissingPairingKey
+	}
+
+	attrs, err := kg.PublicKey.attributes(child)
+	if err != nil {
+		return nil, err
+	}
+
+	parent.mu.RLock()
+	parentWindow := parent.Validity
+	narrowing := covers(parent.Components, attrs)
+	parent.mu.RUnlock()
+
+	if !parentWindow.Contains(start) {
+		return nil, ErrKeyExpired
+	}
+	if !narrowing {
+		return nil, ErrNotNarrowing
+	}
+	window, err := child.Validity.narrow(parentWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refusals above run before the cache so it never hands out a key the parent could not derive
+	cacheKey := kg.buildCacheKey(child) + window.cacheSuffix()
+	if cachedKey, found := kg.Cache.Acquire(cacheKey); found {
+		kg.updateMetrics(time.Since(start), 0, true)
+		return cachedKey, nil
+	}
+
+	key, err := Delegate(kg.PublicKey, parent, child)
+	if err != nil {
+		return nil, err
+	}
+
+	key.refs = 1
+	kg.Cache.Put(cacheKey, key)
+	kg.updateMetrics(time.Since(start), kg.calculateMemoryUsage(key), false)
+
+	return key, nil
+}
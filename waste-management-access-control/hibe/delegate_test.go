@@ -0,0 +1,113 @@
+package hibe
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// during sets the pattern's validity window and returns it
+func during(pattern *WasteManagementPattern, window ValidityWindow) *WasteManagementPattern {
+	pattern.Validity = window
+	return pattern
+}
+
+func TestGeneratorDelegateCachesChildKeys(t *testing.T) {
+	generator, err := NewHIBEKeyGenerator(NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	parent, _, err := generator.GenerateWasteManagementKey(parsePattern("facility", "*", "bin", "*", "fill-level", "*"))
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+
+	shift := ValidityWindow{NotBefore: time.Now().Add(-time.Minute), NotAfter: time.Now().Add(8 * time.Hour)}
+	child := func(window ValidityWindow) *WasteManagementPattern {
+		return during(parsePattern("facility", "general", "bin", "12345", "fill-level", "*"), window)
+	}
+
+	first, err := generator.Delegate(parent, child(shift))
+	if err != nil {
+		t.Fatalf("delegate failed: %v", err)
+	}
+	if first.Validity != shift {
+		t.Errorf("expected the shift window on the child key, got %+v", first.Validity)
+	}
+
+	second, err := generator.Delegate(parent, child(shift))
+	if err != nil {
+		t.Fatalf("second delegate failed: %v", err)
+	}
+	if second != first {
+		t.Error("expected the second delegation to be served from the key cache")
+	}
+
+	// The same pattern with a different window is a different key
+	nextShift := ValidityWindow{NotBefore: shift.NotAfter, NotAfter: shift.NotAfter.Add(8 * time.Hour)}
+	other, err := generator.Delegate(parent, child(nextShift))
+	if err != nil {
+		t.Fatalf("delegate for the next shift failed: %v", err)
+	}
+	if other == first {
+		t.Error("expected a separate cache entry for a different window")
+	}
+
+	ciphertext, _ := Encrypt(generator.PublicKey, parsePattern("facility", "general", "bin", "12345", "fill-level", "realtime"), []byte("reading"))
+	if _, err := Decrypt(generator.PublicKey, first, ciphertext); err != nil {
+		t.Errorf("delegated key should decrypt within its window: %v", err)
+	}
+	if _, err := Decrypt(generator.PublicKey, other, ciphertext); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("expected ErrKeyExpired before the window opens, got %v", err)
+	}
+}
+
+func TestGeneratorDelegateRefusesWidening(t *testing.T) {
+	generator, err := NewHIBEKeyGenerator(NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	parent, _, err := generator.GenerateWasteManagementKey(parsePattern("facility", "general", "bin", "*", "*", "*"))
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+	day := ValidityWindow{NotAfter: time.Now().Add(24 * time.Hour)}
+	crew, err := generator.Delegate(parent, during(parsePattern("facility", "general", "bin", "12345", "*", "*"), day))
+	if err != nil {
+		t.Fatalf("delegate failed: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		parent  *PrivateKey
+		child   *WasteManagementPattern
+		window  ValidityWindow
+		wantErr error
+	}{
+		{"unpins a component", parent, parsePattern("facility", "*", "bin", "12345", "*", "*"), ValidityWindow{}, ErrNotNarrowing},
+		{"changes a pinned component", parent, parsePattern("facility", "oncology", "bin", "12345", "*", "*"), ValidityWindow{}, ErrNotNarrowing},
+		{"outlives the parent", crew, parsePattern("facility", "general", "bin", "12345", "fill-level", "*"), ValidityWindow{NotAfter: day.NotAfter.Add(time.Hour)}, ErrNotNarrowing},
+		{"ends before it starts", parent, parsePattern("facility", "general", "bin", "12345", "*", "*"), ValidityWindow{NotBefore: time.Now(), NotAfter: time.Now().Add(-time.Hour)}, ErrInvalidValidity},
+	}
+	for _, tc := range cases {
+		if _, err := generator.Delegate(tc.parent, during(tc.child, tc.window)); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+
+	// An open window inherits the parent's bound
+	inherited, err := generator.Delegate(crew, parsePattern("facility", "general", "bin", "12345", "fill-level", "*"))
+	if err != nil {
+		t.Fatalf("delegate failed: %v", err)
+	}
+	if !inherited.Validity.NotAfter.Equal(day.NotAfter) {
+		t.Errorf("expected the child to inherit the parent's expiry, got %+v", inherited.Validity)
+	}
+
+	expired := &PrivateKey{Components: crew.Components, Validity: ValidityWindow{NotAfter: time.Now().Add(-time.Second)}, secret: crew.secret}
+	if _, err := generator.Delegate(expired, parsePattern("facility", "general", "bin", "12345", "fill-level", "*")); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("expected ErrKeyExpired for an expired parent, got %v", err)
+	}
+}
@@ -0,0 +1,618 @@
+package hibe
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockchain-jedi/waste-management-access-control/memory"
+	"github.com/ucbrise/jedi-pairing/lang/go/cryptutils"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Binary encoding: a 4-byte magic, a format version, a kind byte, then the kind's
+// fields in order. Every field is a uvarint length followed by that many bytes, so
+// readers can reject truncated or padded input before touching pairing code.
+const (
+	encodingMagic   = "WHBE"
+	encodingVersion = 1
+)
+
+// Kinds of encoded objects
+const (
+	kindPublicKey       byte = 1
+	kindMasterKey       byte = 2
+	kindPrivateKey      byte = 3
+	kindSealedMasterKey byte = 4
+	kindCiphertext      byte = 5
+)
+
+// Armored block types
+const (
+	BlockPublicKey       = "HIBE PUBLIC KEY"
+	BlockMasterKey       = "HIBE MASTER KEY"
+	BlockSealedMasterKey = "HIBE ENCRYPTED MASTER KEY"
+	BlockPrivateKey      = "HIBE PRIVATE KEY"
+	BlockCiphertext      = "HIBE CIPHERTEXT"
+)
+
+// Default scrypt cost for sealing master keys
+const (
+	DefaultScryptLogN = 15
+	scryptR           = 8
+	scryptP           = 1
+	scryptSaltSize    = 16
+)
+
+var (
+	ErrMalformedEncoding   = errors.New("malformed hibe encoding")
+	ErrUnsupportedEncoding = errors.New("unsupported hibe encoding version")
+	ErrWrongKind           = errors.New("encoded object is of a different kind")
+	ErrPassphraseRequired  = errors.New("master key is encrypted; a passphrase is required")
+	ErrWrongPassphrase     = errors.New("wrong passphrase or corrupted master key")
+)
+
+// MarshalBinary encodes the public parameters
+func (pk *PublicKey) MarshalBinary() ([]byte, error) {
+	w := newFieldWriter(kindPublicKey)
+	w.uint(uint64(pk.Params.MaxDepth))
+	w.uint(uint64(pk.Params.SecurityLevel))
+	w.bytes(pk.pairing.Marshal(true))
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes public parameters written by MarshalBinary
+func (pk *PublicKey) UnmarshalBinary(data []byte) error {
+	r, err := newFieldReader(data, kindPublicKey)
+	if err != nil {
+		return err
+	}
+	maxDepth, securityLevel := r.int(), r.int()
+	marshalled := r.bytes()
+	if err := r.finish(); err != nil {
+		return err
+	}
+
+	pairing := new(wkdibe.Params)
+	if !pairing.Unmarshal(marshalled, true, true) || pairing.NumAttributes() != maxDepth+TimeDepth {
+		return ErrMalformedEncoding
+	}
+
+	pk.Params = NewSystemParams(maxDepth, securityLevel)
+	pk.pairing = pairing
+	return nil
+}
+
+// MarshalBinary encodes the master key in the clear; use SealMasterKey to protect it at rest
+func (mk *MasterKey) MarshalBinary() ([]byte, error) {
+	mk.mu.RLock()
+	defer mk.mu.RUnlock()
+
+	w := newFieldWriter(kindMasterKey)
+	w.uint(uint64(mk.params.MaxDepth))
+	w.uint(uint64(mk.params.SecurityLevel))
+	w.bytes(mk.secret.Marshal(true))
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a master key written by MarshalBinary
+func (mk *MasterKey) UnmarshalBinary(data []byte) error {
+	r, err := newFieldReader(data, kindMasterKey)
+	if err != nil {
+		return err
+	}
+	maxDepth, securityLevel := r.int(), r.int()
+	marshalled := r.bytes()
+	if err := r.finish(); err != nil {
+		return err
+	}
+
+	secret := new(wkdibe.MasterKey)
+	if !secret.Unmarshal(marshalled, true, true) {
+		return ErrMalformedEncoding
+	}
+
+	mk.mu.Lock()
+	defer mk.mu.Unlock()
+	mk.params = NewSystemParams(maxDepth, securityLevel)
+	mk.secret = secret
+	return nil
+}
+
+// MarshalBinary encodes the key's pattern, timestamps and pairing key
+func (key *PrivateKey) MarshalBinary() ([]byte, error) {
+	key.mu.RLock()
+	defer key.mu.RUnlock()
+
+	if key.secret == nil && key.epochs == nil {
+		return nil, ErrMissingPairingKey
+	}
+
+	w := newFieldWriter(kindPrivateKey)
+	w.uint(uint64(len(key.Identity)))
+	for i, component := range key.Identity {
+		if key.IsWildcard[i] {
+			w.bytes(nil)
+		} else {
+			w.bytes(append([]byte{1}, component...))
+		}
+	}
+	w.time(key.Timestamp)
+	w.time(key.Validity.NotBefore)
+	w.time(key.Validity.NotAfter)
+	if key.secret != nil {
+		w.bytes(key.secret.Marshal(true))
+	} else {
+		w.bytes(nil)
+	}
+	w.uint(uint64(len(key.epochs)))
+	for _, epoch := range key.epochs {
+		w.uint(uint64(len(epoch.prefix)))
+		for _, value := range epoch.prefix {
+			w.uint(uint64(value))
+		}
+		w.bytes(epoch.secret.Marshal(true))
+	}
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a private key written by MarshalBinary. Component
+// attribute values are recomputed from the pattern rather than trusted.
+func (key *PrivateKey) UnmarshalBinary(data []byte) error {
+	r, err := newFieldReader(data, kindPrivateKey)
+	if err != nil {
+		return err
+	}
+
+	count := r.int()
+	if count > len(data) {
+		return ErrMalformedEncoding
+	}
+	pattern := &WasteManagementPattern{
+		Components:   make([]string, count),
+		WildcardMask: make([]bool, count),
+	}
+	for i := 0; i < count; i++ {
+		field := r.bytes()
+		if len(field) == 0 {
+			pattern.WildcardMask[i] = true
+		} else if field[0] == 1 && len(field) > 1 {
+			pattern.Components[i] = string(field[1:])
+		} else {
+			return ErrMalformedEncoding
+		}
+	}
+	timestamp, notBefore, notAfter := r.time(), r.time(), r.time()
+	marshalled := r.bytes()
+	epochCount := r.int()
+	if epochCount > len(data) {
+		return ErrMalformedEncoding
+	}
+	epochs := make([]epochKey, epochCount)
+	for i := range epochs {
+		prefix := make(timePrefix, r.int())
+		if len(prefix) == 0 || len(prefix) > TimeDepth {
+			return ErrMalformedEncoding
+		}
+		for j := range prefix {
+			prefix[j] = r.int()
+		}
+		secret := new(wkdibe.SecretKey)
+		if !secret.Unmarshal(r.bytes(), true, true) {
+			return ErrMalformedEncoding
+		}
+		epochs[i] = epochKey{prefix: prefix, secret: secret}
+	}
+	if err := r.finish(); err != nil {
+		return err
+	}
+
+	// Untimed keys carry one pairing key, time-bounded keys one per epoch
+	var secret *wkdibe.SecretKey
+	if len(marshalled) != 0 {
+		secret = new(wkdibe.SecretKey)
+		if !secret.Unmarshal(marshalled, true, true) {
+			return ErrMalformedEncoding
+		}
+	}
+	if (secret == nil) == (epochCount == 0) {
+		return ErrMalformedEncoding
+	}
+
+	attrs := make(wkdibe.AttributeList, count)
+	for i, component := range pattern.Components {
+		if !pattern.WildcardMask[i] {
+			attrs[wkdibe.AttributeIndex(i)] = cryptutils.HashToZp(new(big.Int), fastWasteManagementHash(component, i))
+		}
+	}
+	decoded := newPrivateKey(pattern, attrs, secret)
+
+	key.mu.Lock()
+	defer key.mu.Unlock()
+	key.Components = decoded.Components
+	key.Depth = decoded.Depth
+	key.Identity = decoded.Identity
+	key.IsWildcard = decoded.IsWildcard
+	key.Timestamp = timestamp
+	key.Validity = ValidityWindow{NotBefore: notBefore, NotAfter: notAfter}
+	key.secret = secret
+	key.epochs = nil
+	if epochCount > 0 {
+		key.epochs = epochs
+	}
+	return nil
+}
+
+// MarshalBinary encodes the ciphertext for storage or transport
+func (c *Ciphertext) MarshalBinary() ([]byte, error) {
+	if c.encapsulated == nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	w := newFieldWriter(kindCiphertext)
+	w.uint(uint64(len(c.Identity)))
+	for i, component := range c.Identity {
+		if c.IsWildcard[i] {
+			w.bytes(nil)
+		} else {
+			w.bytes(append([]byte{1}, component...))
+		}
+	}
+	w.time(c.Epoch)
+	w.bytes(c.Nonce)
+	w.bytes(c.Payload)
+	w.bytes(c.encapsulated.Marshal(true))
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a ciphertext written by MarshalBinary
+func (c *Ciphertext) UnmarshalBinary(data []byte) error {
+	r, err := newFieldReader(data, kindCiphertext)
+	if err != nil {
+		return err
+	}
+
+	count := r.int()
+	if count > len(data) {
+		return ErrMalformedEncoding
+	}
+	identity := make([]string, count)
+	wildcards := make([]bool, count)
+	for i := 0; i < count; i++ {
+		field := r.bytes()
+		if len(field) == 0 {
+			wildcards[i] = true
+		} else if field[0] == 1 && len(field) > 1 {
+			identity[i] = string(field[1:])
+		} else {
+			return ErrMalformedEncoding
+		}
+	}
+	epoch := r.time()
+	nonce, payload, marshalled := r.bytes(), r.bytes(), r.bytes()
+	if err := r.finish(); err != nil {
+		return err
+	}
+	if len(nonce) != nonceSize {
+		return ErrMalformedEncoding
+	}
+
+	encapsulated := new(wkdibe.Ciphertext)
+	if !encapsulated.Unmarshal(marshalled, true, true) {
+		return ErrMalformedEncoding
+	}
+
+	c.Identity = identity
+	c.IsWildcard = wildcards
+	c.Epoch = epoch.UTC()
+	c.Nonce = nonce
+	c.Payload = payload
+	c.encapsulated = encapsulated
+	return nil
+}
+
+// SealMasterKey encrypts an encoded master key under a passphrase with scrypt and
+// AES-256-GCM. logN sets the scrypt cost; zero uses DefaultScryptLogN.
+func SealMasterKey(mk *MasterKey, passphrase []byte, logN int) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrPassphraseRequired
+	}
+	if logN == 0 {
+		logN = DefaultScryptLogN
+	}
+
+	plaintext, err := mk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	defer memory.WipeBytes(plaintext)
+
+	salt := make([]byte, scryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := passphraseAEAD(passphrase, salt, logN)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	w := newFieldWriter(kindSealedMasterKey)
+	w.uint(uint64(logN))
+	w.bytes(salt)
+	w.bytes(nonce)
+	header := append([]byte(nil), w.buf...)
+	w.bytes(aead.Seal(nil, nonce, plaintext, header))
+	return w.buf, nil
+}
+
+// OpenMasterKey decodes a master key, decrypting it first when it was sealed
+func OpenMasterKey(data, passphrase []byte) (*MasterKey, error) {
+	mk := &MasterKey{}
+	if len(data) > len(encodingMagic)+1 && data[len(encodingMagic)+1] == kindMasterKey {
+		return mk, mk.UnmarshalBinary(data)
+	}
+
+	r, err := newFieldReader(data, kindSealedMasterKey)
+	if err != nil {
+		return nil, err
+	}
+	logN := r.int()
+	salt, nonce := r.bytes(), r.bytes()
+	headerLength := len(data) - r.remaining()
+	sealed := r.bytes()
+	if err := r.finish(); err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, ErrPassphraseRequired
+	}
+	if logN < 10 || logN > 22 {
+		return nil, ErrMalformedEncoding
+	}
+
+	aead, err := passphraseAEAD(passphrase, salt, logN)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrMalformedEncoding
+	}
+	plaintext, err := aead.Open(nil, nonce, sealed, data[:headerLength])
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	defer memory.WipeBytes(plaintext)
+
+	if err := mk.UnmarshalBinary(plaintext); err != nil {
+		return nil, err
+	}
+	return mk, nil
+}
+
+// Armor wraps an encoded object in a PEM block; headers are informational only
+func Armor(blockType string, data []byte, headers map[string]string) []byte {
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	headers["Version"] = strconv.Itoa(encodingVersion)
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Headers: headers, Bytes: data})
+}
+
+// Dearmor extracts the first PEM block of one of the accepted types
+func Dearmor(armored []byte, accepted ...string) (*pem.Block, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(armored))
+	if block == nil {
+		return nil, ErrMalformedEncoding
+	}
+	for _, blockType := range accepted {
+		if block.Type == blockType {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("%v: found %q", ErrWrongKind, block.Type)
+}
+
+// BlockType names the armored block type for a binary encoding
+func BlockType(data []byte) (string, error) {
+	if len(data) < len(encodingMagic)+2 || string(data[:len(encodingMagic)]) != encodingMagic {
+		return "", ErrMalformedEncoding
+	}
+	switch data[len(encodingMagic)+1] {
+	case kindPublicKey:
+		return BlockPublicKey, nil
+	case kindMasterKey:
+		return BlockMasterKey, nil
+	case kindSealedMasterKey:
+		return BlockSealedMasterKey, nil
+	case kindPrivateKey:
+		return BlockPrivateKey, nil
+	case kindCiphertext:
+		return BlockCiphertext, nil
+	}
+	return "", ErrMalformedEncoding
+}
+
+// ArmorPrivateKey encodes a private key as armored text, noting its pattern and window
+func ArmorPrivateKey(key *PrivateKey) ([]byte, error) {
+	data, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	key.mu.RLock()
+	headers := map[string]string{"Pattern": FormatPattern(key.Identity, key.IsWildcard)}
+	if !key.Validity.NotBefore.IsZero() {
+		headers["Not-Before"] = key.Validity.NotBefore.UTC().Format(time.RFC3339)
+	}
+	if !key.Validity.NotAfter.IsZero() {
+		headers["Not-After"] = key.Validity.NotAfter.UTC().Format(time.RFC3339)
+	}
+	key.mu.RUnlock()
+
+	return Armor(BlockPrivateKey, data, headers), nil
+}
+
+// ParsePattern reads a slash-separated pattern such as facility/*/bin/*/fill-level/*,
+// where "*" marks a wildcard position
+func ParsePattern(s string) (*WasteManagementPattern, error) {
+	s = strings.Trim(strings.TrimSpace(s), "/")
+	if s == "" {
+		return nil, ErrInvalidPattern
+	}
+
+	parts := strings.Split(s, "/")
+	pattern := &WasteManagementPattern{
+		Components:   make([]string, len(parts)),
+		WildcardMask: make([]bool, len(parts)),
+	}
+	for i, part := range parts {
+		switch part {
+		case "*":
+			pattern.WildcardMask[i] = true
+		case "":
+			return nil, ErrInvalidPattern
+		default:
+			pattern.Components[i] = part
+			pattern.Depth++
+		}
+	}
+	return pattern, nil
+}
+
+// FormatPattern renders a key or ciphertext identity in ParsePattern's syntax
+func FormatPattern(identity []string, wildcards []bool) string {
+	parts := make([]string, len(identity))
+	for i, component := range identity {
+		if wildcards[i] {
+			parts[i] = "*"
+		} else {
+			parts[i] = component
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func passphraseAEAD(passphrase, salt []byte, logN int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<logN, scryptR, scryptP, symmetricKeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer memory.WipeBytes(key)
+	return newAEAD(key)
+}
+
+// fieldWriter builds the binary encoding
+type fieldWriter struct {
+	buf []byte
+}
+
+func newFieldWriter(kind byte) *fieldWriter {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, encodingMagic...)
+	buf = append(buf, encodingVersion, kind)
+	return &fieldWriter{buf: buf}
+}
+
+func (w *fieldWriter) bytes(field []byte) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(field)))
+	w.buf = append(w.buf, field...)
+}
+
+func (w *fieldWriter) uint(v uint64) {
+	w.bytes(binary.AppendUvarint(nil, v))
+}
+
+func (w *fieldWriter) time(t time.Time) {
+	if t.IsZero() {
+		w.bytes(nil)
+		return
+	}
+	w.bytes(binary.AppendVarint(nil, t.UnixNano()))
+}
+
+// fieldReader walks the binary encoding, remembering the first error
+type fieldReader struct {
+	data []byte
+	err  error
+}
+
+func newFieldReader(data []byte, kind byte) (*fieldReader, error) {
+	if len(data) < len(encodingMagic)+2 || string(data[:len(encodingMagic)]) != encodingMagic {
+		return nil, ErrMalformedEncoding
+	}
+	if data[len(encodingMagic)] != encodingVersion {
+		return nil, fmt.Errorf("%v: %d", ErrUnsupportedEncoding, data[len(encodingMagic)])
+	}
+	if data[len(encodingMagic)+1] != kind {
+		return nil, ErrWrongKind
+	}
+	return &fieldReader{data: data[len(encodingMagic)+2:]}, nil
+}
+
+func (r *fieldReader) bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	length, n := binary.Uvarint(r.data)
+	if n <= 0 || length > uint64(len(r.data)-n) {
+		r.err = ErrMalformedEncoding
+		return nil
+	}
+	field := r.data[n : n+int(length)]
+	r.data = r.data[n+int(length):]
+	return field
+}
+
+func (r *fieldReader) int() int {
+	field := r.bytes()
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(field)
+	if n != len(field) || v > 1<<20 {
+		r.err = ErrMalformedEncoding
+		return 0
+	}
+	return int(v)
+}
+
+func (r *fieldReader) time() time.Time {
+	field := r.bytes()
+	if r.err != nil || len(field) == 0 {
+		return time.Time{}
+	}
+	v, n := binary.Varint(field)
+	if n != len(field) {
+		r.err = ErrMalformedEncoding
+		return time.Time{}
+	}
+	return time.Unix(0, v)
+}
+
+func (r *fieldReader) remaining() int {
+	return len(r.data)
+}
+
+// finish reports the first decoding error, or trailing bytes after the last field
+func (r *fieldReader) finish() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return ErrMalformedEncoding
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package hibe
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeysSurviveEncoding(t *testing.T) {
+	publicKey, masterKey := newTestScheme(t)
+
+	key, err := KeyGen(publicKey, masterKey, parsePattern("facility", "general", "bin", "*", "fill-level", "*"))
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+	key.Validity = ValidityWindow{NotAfter: time.Now().Add(time.Hour).Truncate(time.Second)}
+
+	armored, err := ArmorPrivateKey(key)
+	if err != nil {
+		t.Fatalf("armor failed: %v", err)
+	}
+	block, err := Dearmor(armored, BlockPrivateKey)
+	if err != nil {
+		t.Fatalf("dearmor failed: %v", err)
+	}
+	if block.Headers["Pattern"] != "facility/general/bin/*/fill-level/*" {
+		t.Errorf("unexpected pattern header %q", block.Headers["Pattern"])
+	}
+	decodedKey := new(PrivateKey)
+	if err := decodedKey.UnmarshalBinary(block.Bytes); err != nil {
+		t.Fatalf("private key decode failed: %v", err)
+	}
+	if !decodedKey.Validity.NotAfter.Equal(key.Validity.NotAfter) || decodedKey.Depth != key.Depth {
+		t.Errorf("decoded key lost its window or depth: %+v", decodedKey.Validity)
+	}
+
+	encodedPublic, _ := publicKey.MarshalBinary()
+	decodedPublic := new(PublicKey)
+	if err := decodedPublic.UnmarshalBinary(encodedPublic); err != nil {
+		t.Fatalf("public key decode failed: %v", err)
+	}
+
+	// A reading encrypted under the decoded parameters opens with the decoded key
+	ciphertext, err := Encrypt(decodedPublic, parsePattern("facility", "general", "bin", "12345", "fill-level", "realtime"), []byte("reading"))
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	encodedCiphertext, _ := ciphertext.MarshalBinary()
+	decodedCiphertext := new(Ciphertext)
+	if err := decodedCiphertext.UnmarshalBinary(encodedCiphertext); err != nil {
+		t.Fatalf("ciphertext decode failed: %v", err)
+	}
+	plaintext, err := Decrypt(publicKey, decodedKey, decodedCiphertext)
+	if err != nil || !bytes.Equal(plaintext, []byte("reading")) {
+		t.Errorf("decoded key failed to decrypt: %q, %v", plaintext, err)
+	}
+
+	// A nonce GCM cannot use is refused, both when decoding and when decrypting
+	truncated := *ciphertext
+	truncated.Nonce = truncated.Nonce[:4]
+	encodedTruncated, _ := truncated.MarshalBinary()
+	if err := new(Ciphertext).UnmarshalBinary(encodedTruncated); !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("expected ErrMalformedEncoding for a short nonce, got %v", err)
+	}
+	if _, err := Decrypt(publicKey, decodedKey, &truncated); !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("expected Decrypt to refuse a short nonce, got %v", err)
+	}
+
+	if err := decodedKey.UnmarshalBinary(encodedPublic); !errors.Is(err, ErrWrongKind) {
+		t.Errorf("expected ErrWrongKind for a public key, got %v", err)
+	}
+	if err := decodedKey.UnmarshalBinary(block.Bytes[:len(block.Bytes)-1]); !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("expected ErrMalformedEncoding for a truncated key, got %v", err)
+	}
+}
+
+func TestSealedMasterKey(t *testing.T) {
+	publicKey, masterKey := newTestScheme(t)
+
+	sealed, err := SealMasterKey(masterKey, []byte("correct horse"), 10)
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+
+	if _, err := OpenMasterKey(sealed, nil); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := OpenMasterKey(sealed, []byte("battery staple")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+
+	opened, err := OpenMasterKey(sealed, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	pattern := parsePattern("facility", "general", "bin", "12345", "fill-level", "realtime")
+	ciphertext, _ := Encrypt(publicKey, pattern, []byte("reading"))
+	key, err := KeyGen(publicKey, opened, pattern)
+	if err != nil {
+		t.Fatalf("keygen with the opened master key failed: %v", err)
+	}
+	if _, err := Decrypt(publicKey, key, ciphertext); err != nil {
+		t.Errorf("key from the opened master key should decrypt: %v", err)
+	}
+}
@@ -1,12 +1,16 @@
 package hibe
 
 import (
-	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 	"time"
+	"unsafe"
+	
+	"blockchain-jedi/waste-management-access-control/memory"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
 )
 
 // HIBEKeyGenerator manages hierarchical key generation
@@ -19,14 +23,8 @@ type HIBEKeyGenerator struct {
 
 // NewHIBEKeyGenerator creates a new key generator
 func NewHIBEKeyGenerator(params *SystemParams) (*HIBEKeyGenerator, error) {
-	// Generate master key
-	masterKey, err := generateMasterKey(params)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Generate public key
-	publicKey, err := generatePublicKey(masterKey, params)
+	// Generate pairing parameters and master key
+	publicKey, masterKey, err := Setup(params)
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +32,7 @@ func NewHIBEKeyGenerator(params *SystemParams) (*HIBEKeyGenerator, error) {
 	return &HIBEKeyGenerator{
 		MasterKey: masterKey,
 		PublicKey: publicKey,
-		Cache:     NewKeyCache(1000), // Cache up to 1000 keys
+		Cache:     NewKeyCacheWithTTL(1000, DefaultKeyCacheTTL), // Cache up to 1000 keys
 		Metrics:   &KeyGenMetrics{},
 	}, nil
 }
@@ -43,9 +41,9 @@ func NewHIBEKeyGenerator(params *SystemParams) (*HIBEKeyGenerator, error) {
 func (kg *HIBEKeyGenerator) GenerateWasteManagementKey(pattern *WasteManagementPattern) (*PrivateKey, time.Duration, error) {
 	start := time.Now()
 	
-	// Check cache first
-	cacheKey := kg.buildCacheKey(pattern)
-	if cachedKey, found := kg.Cache.Get(cacheKey); found {
+	// Check cache first; bounded keys are cached per window
+	cacheKey := kg.buildCacheKey(pattern) + pattern.Validity.cacheSuffix()
+	if cachedKey, found := kg.Cache.Acquire(cacheKey); found {
 		duration := time.Since(start)
 		kg.updateMetrics(duration, 0, true)
 		return cachedKey, duration, nil
@@ -57,7 +55,8 @@ func (kg *HIBEKeyGenerator) GenerateWasteManagementKey(pattern *WasteManagementP
 		return nil, 0, err
 	}
 	
-	// Cache the result
+	// Cache the result, held by this caller
+	key.refs = 1
 	kg.Cache.Put(cacheKey, key)
 	
 	duration := time.Since(start)
@@ -69,91 +68,113 @@ func (kg *HIBEKeyGenerator) GenerateWasteManagementKey(pattern *WasteManagementP
 
 // generateOptimizedKey creates an optimized key based on wildcard patterns
 func (kg *HIBEKeyGenerator) generateOptimizedKey(pattern *WasteManagementPattern) (*PrivateKey, error) {
-	// Get key from pool
-	key := kg.MasterKey.params.KeyPool.Get().(*PrivateKey)
-	
-	// Reset key properties
-	key.Components = key.Components[:0]
-	key.Identity = make([]string, 0, len(pattern.Components))
-	key.IsWildcard = make([]bool, 0, len(pattern.WildcardMask))
-	key.Timestamp = time.Now()
-	
 	// Algorithm 3: Optimized Key Generation for WasteManagement Patterns
-	// Only process non-wildcard components
-	activeComponents := 0
-	for i, component := range pattern.Components {
-		key.Identity = append(key.Identity, component)
-		key.IsWildcard = append(key.IsWildcard, pattern.WildcardMask[i])
-		
-		if !pattern.WildcardMask[i] && component != "" {
-			// Generate component key only for non-wildcard elements
-			componentKey, err := kg.generateComponentKey(component, activeComponents)
-			if err != nil {
-				return nil, err
-			}
-			key.Components = append(key.Components, componentKey)
-			activeComponents++
-		} else {
-			// For wildcards, use nil pointer (memory optimization)
-			key.Components = append(key.Components, nil)
-		}
+	// Only non-wildcard components become attributes; wildcard slots stay free
+	return KeyGen(kg.PublicKey, kg.MasterKey, pattern)
+}
+
+// ReleaseKey gives up the caller's hold on a key from GenerateWasteManagementKey
+// or Delegate. Each caller the generator or its cache returned the key to holds
+// it once; the last to release it drops it from the cache and wipes it. The
+// caller must not use the key afterwards.
+func (kg *HIBEKeyGenerator) ReleaseKey(key *PrivateKey) {
+	if key == nil {
+		return
 	}
 	
-	key.Depth = activeComponents // Only count non-wildcard components
+	key.mu.RLock()
+	pattern := &WasteManagementPattern{Components: key.Identity, WildcardMask: key.IsWildcard}
+	cacheKey := kg.buildCacheKey(pattern) + key.Validity.cacheSuffix()
+	key.mu.RUnlock()
+	if !kg.Cache.Release(cacheKey, key) {
+		return
+	}
 	
-	return key, nil
+	key.mu.Lock()
+	defer key.mu.Unlock()
+	for i, component := range key.Components {
+		memory.WipeBigInt(component)
+		key.Components[i] = nil
+	}
+	if key.secret != nil {
+		wipeSecretKey(key.secret)
+	}
+	for _, epoch := range key.epochs {
+		wipeSecretKey(epoch.secret)
+	}
+	key.secret = nil
+	key.epochs = nil
+	key.Identity = nil
+	key.IsWildcard = nil
+	key.Depth = 0
+	key.Validity = ValidityWindow{}
 }
 
-// generateComponentKey generates a key for a single component
-func (kg *HIBEKeyGenerator) generateComponentKey(component string, depth int) (*big.Int, error) {
-	// Get big.Int from pool
-	result := kg.MasterKey.params.BigIntPool.Get().(*big.Int)
-	
-	// Fast hash-based key generation optimized for waste-management components
-	hash := kg.fastWasteManagementHash(component, depth)
-	result.SetBytes(hash)
-	
-	// Apply HIBE key generation formula
-	// K_component = g^(alpha + H(component) * beta) * u_depth^r
-	kg.MasterKey.mu.RLock()
-	alpha := kg.MasterKey.Alpha
-	beta := kg.MasterKey.Beta
-	kg.MasterKey.mu.RUnlock()
-	
-	// r is a random value
-	r, err := rand.Int(rand.Reader, kg.MasterKey.params.Q)
-	if err != nil {
-		return nil, err
+// secretKeyLayout locates the free-slot table wkdibe keeps in unexported C
+// fields of SecretKey.Data: b points at the table and l counts its entries
+var secretKeyLayout = findSecretKeyLayout()
+
+type secretKeyFields struct {
+	data, count, table []int // field indexes for reflect
+	found              bool  // false if wkdibe no longer lays the fields out so
+}
+
+func findSecretKeyLayout() secretKeyFields {
+	data, found := reflect.TypeOf(wkdibe.SecretKey{}).FieldByName("Data")
+	if !found || data.Type.Kind() != reflect.Struct {
+		return secretKeyFields{}
 	}
-	
-	// Compute: alpha + H(component) * beta
-	temp := kg.MasterKey.params.BigIntPool.Get().(*big.Int)
-	temp.Mul(result, beta)
-	temp.Add(alpha, temp)
-	temp.Mod(temp, kg.MasterKey.params.Q)
-	
-	// Apply randomization with u_depth^r
-	if depth < len(kg.PublicKey.U) {
-		uDepth := kg.PublicKey.U[depth]
-		rPow := kg.MasterKey.params.BigIntPool.Get().(*big.Int)
-		rPow.Exp(uDepth, r, kg.MasterKey.params.P)
-		result.Mul(temp, rPow)
-		result.Mod(result, kg.MasterKey.params.P)
-		
-		// Return rPow to pool
-		kg.MasterKey.params.BigIntPool.Put(rPow)
-	} else {
-		result.Set(temp)
+	count, countFound := data.Type.FieldByName("l")
+	table, tableFound := data.Type.FieldByName("b")
+	if !countFound || !tableFound || table.Type.Kind() != reflect.Pointer {
+		return secretKeyFields{}
 	}
-	
-	// Return temp to pool
-	kg.MasterKey.params.BigIntPool.Put(temp)
-	
-	return result, nil
+	switch count.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	default:
+		return secretKeyFields{}
+	}
+	return secretKeyFields{data: data.Index, count: count.Index, table: table.Index, found: true}
+}
+
+// freeSlotTable returns where sk keeps its free-slot table's pointer and the
+// table's size in bytes, or false if secretKeyLayout did not find the fields
+func freeSlotTable(sk *wkdibe.SecretKey) (*unsafe.Pointer, uintptr, bool) {
+	if !secretKeyLayout.found {
+		return nil, 0, false
+	}
+	data := reflect.ValueOf(sk).Elem().FieldByIndex(secretKeyLayout.data)
+	slots := data.FieldByIndex(secretKeyLayout.count).Int()
+	table := data.FieldByIndex(secretKeyLayout.table)
+	return (*unsafe.Pointer)(unsafe.Pointer(table.UnsafeAddr())), uintptr(slots) * table.Type().Elem().Size(), true
+}
+
+// wipeSecretKey zeroes a pairing key in place, including the C-allocated
+// free-slot table that lets it be delegated. The table's pointer is kept, so
+// wkdibe's finalizer still frees it. If wkdibe's fields have changed the key
+// is left as it is rather than losing the table; TestSecretKeyLayout fails then.
+func wipeSecretKey(sk *wkdibe.SecretKey) {
+	tablePtr, size, found := freeSlotTable(sk)
+	if !found {
+		return
+	}
+	freeSlots := *tablePtr
+	if freeSlots != nil && size > 0 {
+		memory.WipeBytes(unsafe.Slice((*byte)(freeSlots), size))
+	}
+	*sk = wkdibe.SecretKey{}
+	*tablePtr = freeSlots
+}
+
+// ReleaseBigInt wipes n before returning it to the BigIntPool
+func (params *SystemParams) ReleaseBigInt(n *big.Int) {
+	memory.WipeBigInt(n)
+	params.BigIntPool.Put(n)
 }
 
-// fastWasteManagementHash provides optimized hashing for waste-management components
-func (kg *HIBEKeyGenerator) fastWasteManagementHash(component string, depth int) []byte {
+// fastWasteManagementHash provides optimized hashing for waste-management components;
+// the digest is mapped onto the pairing group as the component's attribute value
+func fastWasteManagementHash(component string, depth int) []byte {
 	hasher := sha256.New()
 	
 	// Add depth for unique hashing per level
@@ -294,61 +315,6 @@ func (kg *HIBEKeyGenerator) GetMetrics() KeyGenMetrics {
 	return *kg.Metrics
 }
 
-// generateMasterKey generates the master secret key
-func generateMasterKey(params *SystemParams) (*MasterKey, error) {
-	alpha, err := rand.Int(rand.Reader, params.Q)
-	if err != nil {
-		return nil, err
-	}
-	
-	beta, err := rand.Int(rand.Reader, params.Q)
-	if err != nil {
-		return nil, err
-	}
-	
-	gamma, err := rand.Int(rand.Reader, params.Q)
-	if err != nil {
-		return nil, err
-	}
-	
-	return &MasterKey{
-		Alpha:  alpha,
-		Beta:   beta,
-		Gamma:  gamma,
-		params: params,
-	}, nil
-}
-
-// generatePublicKey generates the public parameters
-func generatePublicKey(masterKey *MasterKey, params *SystemParams) (*PublicKey, error) {
-	// Generate U and H arrays for waste-management hierarchy
-	U := make([]*big.Int, params.MaxDepth)
-	H := make([]*big.Int, params.MaxDepth)
-	
-	for i := 0; i < params.MaxDepth; i++ {
-		u, err := rand.Int(rand.Reader, params.P)
-		if err != nil {
-			return nil, err
-		}
-		U[i] = u
-		
-		h, err := rand.Int(rand.Reader, params.P)
-		if err != nil {
-			return nil, err
-		}
-		H[i] = h
-	}
-	
-	return &PublicKey{
-		G:      params.G1,
-		G1:     params.G1,
-		G2:     params.G2,
-		U:      U,
-		H:      H,
-		Params: params,
-	}, nil
-}
-
 // PrintMetrics displays performance metrics
 func (kg *HIBEKeyGenerator) PrintMetrics() {
 	metrics := kg.GetMetrics()
@@ -0,0 +1,111 @@
+package hibe
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestReleaseKeyWipesAndUncaches(t *testing.T) {
+	generator, err := NewHIBEKeyGenerator(NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	pattern := parsePattern("facility", "general", "bin", "*", "*", "*")
+	key, _, err := generator.GenerateWasteManagementKey(pattern)
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+	component := key.Components[0]
+
+	generator.ReleaseKey(key)
+
+	if component.Sign() != 0 {
+		t.Error("expected the key's components to be wiped")
+	}
+	if key.secret != nil || key.Identity != nil {
+		t.Error("expected the key to drop its pairing key and pattern")
+	}
+	if generator.Cache.Len() != 0 {
+		t.Error("expected the released key to leave the cache")
+	}
+
+	fresh, _, err := generator.GenerateWasteManagementKey(pattern)
+	if err != nil {
+		t.Fatalf("keygen after release failed: %v", err)
+	}
+	if fresh == key {
+		t.Error("expected a new key rather than the released one")
+	}
+}
+
+func TestReleaseKeyWaitsForEveryHolder(t *testing.T) {
+	generator, err := NewHIBEKeyGenerator(NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	pattern := parsePattern("facility", "general", "bin", "*", "*", "*")
+	first, _, err := generator.GenerateWasteManagementKey(pattern)
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+	second, _, err := generator.GenerateWasteManagementKey(pattern)
+	if err != nil {
+		t.Fatalf("cached keygen failed: %v", err)
+	}
+	if second != first {
+		t.Fatal("expected the second caller to be served the cached key")
+	}
+
+	generator.ReleaseKey(first)
+	if second.secret == nil || second.Components[0].Sign() == 0 {
+		t.Fatal("expected the key to stay intact while another caller holds it")
+	}
+	if generator.Cache.Len() != 1 {
+		t.Error("expected the key to stay cached while another caller holds it")
+	}
+
+	generator.ReleaseKey(second)
+	if second.secret != nil || generator.Cache.Len() != 0 {
+		t.Error("expected the last release to wipe and uncache the key")
+	}
+	generator.ReleaseKey(second) // a release too many is ignored
+}
+
+func TestSecretKeyLayout(t *testing.T) {
+	if !secretKeyLayout.found {
+		t.Fatal("wkdibe.SecretKey no longer keeps its free-slot table in Data.b and its length in Data.l; update findSecretKeyLayout, or released keys are not wiped")
+	}
+}
+
+func TestWipeSecretKeyKeepsFreeSlotTable(t *testing.T) {
+	generator, err := NewHIBEKeyGenerator(NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	key, _, err := generator.GenerateWasteManagementKey(parsePattern("facility", "general", "bin", "*", "*", "*"))
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+
+	tablePtr, size, found := freeSlotTable(key.secret)
+	if !found {
+		t.Fatal("expected the free-slot table to be found")
+	}
+	freeSlots := *tablePtr
+	if freeSlots == nil || size == 0 {
+		t.Fatal("expected a wildcard key to have free slots")
+	}
+
+	wipeSecretKey(key.secret)
+
+	if *tablePtr != freeSlots {
+		t.Error("expected the table to stay allocated for wkdibe to free")
+	}
+	for i, b := range unsafe.Slice((*byte)(freeSlots), size) {
+		if b != 0 {
+			t.Fatalf("expected the free-slot table to be zeroed, byte %d is %#x", i, b)
+		}
+	}
+}
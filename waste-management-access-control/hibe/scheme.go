@@ -0,0 +1,338 @@
+package hibe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ucbrise/jedi-pairing/lang/go/cryptutils"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
+)
+
+// symmetricKeySize is the AES-256 key derived from each encapsulated group element
+const symmetricKeySize = 32
+
+// nonceSize is the length of the standard GCM nonce newAEAD expects
+const nonceSize = 12
+
+var (
+	ErrInvalidPattern    = errors.New("invalid waste-management pattern")
+	ErrPatternTooDeep    = errors.New("pattern is deeper than the system maximum")
+	ErrNotNarrowing      = errors.New("child pattern does not narrow the parent key")
+	ErrPatternMismatch   = errors.New("key pattern does not match ciphertext pattern")
+	ErrDecryptionFailed  = errors.New("ciphertext could not be decrypted")
+	ErrMissingPairingKey = errors.New("key has no pairing material")
+)
+
+// Ciphertext is a hybrid HIBE ciphertext: a WKD-IBE encapsulated key bound to the
+// pattern and hour it was encrypted to, and the payload sealed with AES-GCM under that key
+type Ciphertext struct {
+	Identity     []string
+	IsWildcard   []bool
+	Epoch        time.Time // hour the payload was sealed in, pinned in the time slots
+	Nonce        []byte
+	Payload      []byte
+	encapsulated *wkdibe.Ciphertext
+}
+
+// Setup generates public parameters and a master key supporting patterns of up to
+// params.MaxDepth components. Components are WKD-IBE attribute slots, so wildcard
+// positions in a key can later be pinned by delegation; TimeDepth further slots
+// follow them for the time hierarchy.
+func Setup(params *SystemParams) (*PublicKey, *MasterKey, error) {
+	if params == nil || params.MaxDepth <= 0 {
+		return nil, nil, fmt.Errorf("hibe setup needs a positive maximum depth")
+	}
+
+	pairing, secret := wkdibe.Setup(params.MaxDepth+TimeDepth, false)
+
+	publicKey := &PublicKey{
+		Params:  params,
+		pairing: pairing,
+	}
+	masterKey := &MasterKey{
+		params: params,
+		secret: secret,
+	}
+	return publicKey, masterKey, nil
+}
+
+// KeyGen derives the private key for a pattern from the master key. A pattern with a
+// bounded validity window yields a key that only opens data sealed inside the window.
+func KeyGen(publicKey *PublicKey, masterKey *MasterKey, pattern *WasteManagementPattern) (*PrivateKey, error) {
+	attrs, err := publicKey.attributes(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := pattern.Validity.validate(); err != nil {
+		return nil, err
+	}
+
+	masterKey.mu.RLock()
+	defer masterKey.mu.RUnlock()
+
+	key := newPrivateKey(pattern, attrs, nil)
+	key.Validity = pattern.Validity
+	if !pattern.Validity.bounded() {
+		key.secret = wkdibe.KeyGen(publicKey.pairing, masterKey.secret, attrs)
+		return key, nil
+	}
+
+	for _, prefix := range pattern.Validity.timePrefixes() {
+		key.epochs = append(key.epochs, epochKey{
+			prefix: prefix,
+			secret: wkdibe.KeyGen(publicKey.pairing, masterKey.secret, publicKey.withTime(attrs, prefix)),
+		})
+	}
+	return key, nil
+}
+
+// Delegate derives a key for a child pattern from a parent key. The child must keep
+// every component the parent pins and may only pin further wildcard positions. Its
+// validity window must lie inside the parent's; open sides inherit the parent's bounds.
+func Delegate(publicKey *PublicKey, parent *PrivateKey, child *WasteManagementPattern) (*PrivateKey, error) {
+	attrs, err := publicKey.attributes(child)
+	if err != nil {
+		return nil, err
+	}
+
+	parent.mu.RLock()
+	defer parent.mu.RUnlock()
+
+	if parent.secret == nil && parent.epochs == nil {
+		return nil, ErrMissingPairingKey
+	}
+	if !covers(parent.Components, attrs) {
+		return nil, ErrNotNarrowing
+	}
+	window, err := child.Validity.narrow(parent.Validity)
+	if err != nil {
+		return nil, err
+	}
+
+	key := newPrivateKey(child, attrs, nil)
+	key.Validity = window
+	if !window.bounded() {
+		key.secret = wkdibe.QualifyKey(publicKey.pairing, parent.secret, attrs)
+		return key, nil
+	}
+
+	for _, prefix := range window.timePrefixes() {
+		secret, _ := parent.secretFor(prefix)
+		if secret == nil {
+			return nil, ErrNotNarrowing
+		}
+		key.epochs = append(key.epochs, epochKey{
+			prefix: prefix,
+			secret: wkdibe.QualifyKey(publicKey.pairing, secret, publicKey.withTime(attrs, prefix)),
+		})
+	}
+	return key, nil
+}
+
+// Encrypt seals plaintext so that only keys whose pattern covers the target pattern
+// can open it. A wildcard position in the target is left unset, so only keys that
+// also leave it a wildcard can open the ciphertext; a key pinning it is refused.
+func Encrypt(publicKey *PublicKey, pattern *WasteManagementPattern, plaintext []byte) (*Ciphertext, error) {
+	return encryptAt(publicKey, pattern, time.Now(), plaintext)
+}
+
+func encryptAt(publicKey *PublicKey, pattern *WasteManagementPattern, sealedAt time.Time, plaintext []byte) (*Ciphertext, error) {
+	attrs, err := publicKey.attributes(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := publicKey.Params.secretBuffer()
+	defer buffer.Release()
+
+	symmetricKey, message := cryptutils.GenerateKey(buffer.Bytes())
+	aead, err := newAEAD(symmetricKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	epoch := sealedAt.UTC().Truncate(time.Hour)
+	identity, wildcards := patternIdentity(pattern)
+	return &Ciphertext{
+		Identity:     identity,
+		IsWildcard:   wildcards,
+		Epoch:        epoch,
+		Nonce:        nonce,
+		Payload:      aead.Seal(nil, nonce, plaintext, additionalData(identity, wildcards)),
+		encapsulated: wkdibe.Encrypt(message, publicKey.pairing, publicKey.withTime(attrs, timePath(epoch))),
+	}, nil
+}
+
+// Decrypt opens a ciphertext with a key whose pattern covers the ciphertext's pattern
+func Decrypt(publicKey *PublicKey, key *PrivateKey, ciphertext *Ciphertext) ([]byte, error) {
+	if ciphertext == nil || ciphertext.encapsulated == nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	attrs, err := publicKey.attributes(&WasteManagementPattern{
+		Components:   ciphertext.Identity,
+		WildcardMask: ciphertext.IsWildcard,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	key.mu.RLock()
+	if key.secret == nil && key.epochs == nil {
+		key.mu.RUnlock()
+		return nil, ErrMissingPairingKey
+	}
+	if !key.Validity.Contains(time.Now()) {
+		key.mu.RUnlock()
+		return nil, ErrKeyExpired
+	}
+	if !covers(key.Components, attrs) {
+		key.mu.RUnlock()
+		return nil, ErrPatternMismatch
+	}
+
+	// A time-bounded key only holds pairing keys for the hours inside its window
+	epoch := timePath(ciphertext.Epoch)
+	secret, pinnedTime := key.secretFor(epoch)
+	if secret == nil {
+		key.mu.RUnlock()
+		return nil, ErrKeyExpired
+	}
+
+	// Pin the key's remaining wildcards to the ciphertext's components; the result
+	// is used once and discarded, so the cheaper non-delegable qualification is safe
+	attrs = publicKey.withTime(attrs, epoch)
+	if len(attrs) != pinnedCount(key.Components)+pinnedTime {
+		secret = wkdibe.NonDelegableQualifyKey(publicKey.pairing, secret, attrs)
+	}
+	key.mu.RUnlock()
+
+	buffer := publicKey.Params.secretBuffer()
+	defer buffer.Release()
+
+	message := wkdibe.Decrypt(ciphertext.encapsulated, secret)
+	aead, err := newAEAD(message.HashToSymmetricKey(buffer.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext.Nonce) != aead.NonceSize() {
+		return nil, ErrMalformedEncoding
+	}
+	plaintext, err := aead.Open(nil, ciphertext.Nonce, ciphertext.Payload, additionalData(ciphertext.Identity, ciphertext.IsWildcard))
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// attributes maps a pattern onto WKD-IBE attribute slots, one per component.
+// Wildcards and empty components leave their slot unset.
+func (pk *PublicKey) attributes(pattern *WasteManagementPattern) (wkdibe.AttributeList, error) {
+	if pattern == nil || len(pattern.Components) != len(pattern.WildcardMask) {
+		return nil, ErrInvalidPattern
+	}
+	if len(pattern.Components) > pk.Params.MaxDepth {
+		return nil, ErrPatternTooDeep
+	}
+
+	attrs := make(wkdibe.AttributeList, len(pattern.Components))
+	for i, component := range pattern.Components {
+		if pattern.WildcardMask[i] || component == "" {
+			continue
+		}
+		attrs[wkdibe.AttributeIndex(i)] = cryptutils.HashToZp(new(big.Int), fastWasteManagementHash(component, i))
+	}
+	return attrs, nil
+}
+
+// covers reports whether every component pinned in a key is pinned to the same
+// value in attrs
+func covers(components []*big.Int, attrs wkdibe.AttributeList) bool {
+	for i, component := range components {
+		if component == nil {
+			continue
+		}
+		value, pinned := attrs[wkdibe.AttributeIndex(i)]
+		if !pinned || value.Cmp(component) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func pinnedCount(components []*big.Int) int {
+	count := 0
+	for _, component := range components {
+		if component != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// newPrivateKey records the pattern alongside the pairing key; Components hold the
+// attribute value of each pinned position and nil for wildcards
+func newPrivateKey(pattern *WasteManagementPattern, attrs wkdibe.AttributeList, secret *wkdibe.SecretKey) *PrivateKey {
+	identity, wildcards := patternIdentity(pattern)
+	components := make([]*big.Int, len(identity))
+	for index, value := range attrs {
+		components[index] = value
+	}
+
+	return &PrivateKey{
+		Components: components,
+		Depth:      len(attrs),
+		Identity:   identity,
+		IsWildcard: wildcards,
+		Timestamp:  time.Now(),
+		secret:     secret,
+	}
+}
+
+func patternIdentity(pattern *WasteManagementPattern) ([]string, []bool) {
+	identity := make([]string, len(pattern.Components))
+	wildcards := make([]bool, len(pattern.Components))
+	for i, component := range pattern.Components {
+		wildcards[i] = pattern.WildcardMask[i] || component == ""
+		if !wildcards[i] {
+			identity[i] = component
+		}
+	}
+	return identity, wildcards
+}
+
+// additionalData binds the payload to the pattern it was encrypted for; components
+// are length-prefixed so no choice of component strings can alias another pattern
+func additionalData(identity []string, wildcards []bool) []byte {
+	data := make([]byte, 0, 64)
+	for i, component := range identity {
+		if wildcards[i] {
+			data = append(data, 0)
+			continue
+		}
+		data = append(data, 1)
+		data = binary.AppendUvarint(data, uint64(len(component)))
+		data = append(data, component...)
+	}
+	return data
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
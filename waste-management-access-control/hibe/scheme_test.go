@@ -0,0 +1,115 @@
+package hibe
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestScheme(t *testing.T) (*PublicKey, *MasterKey) {
+	t.Helper()
+	publicKey, masterKey, err := Setup(NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	return publicKey, masterKey
+}
+
+// parsePattern builds a pattern from components, treating "*" as a wildcard
+func parsePattern(components ...string) *WasteManagementPattern {
+	pattern := &WasteManagementPattern{
+		Components:   make([]string, len(components)),
+		WildcardMask: make([]bool, len(components)),
+	}
+	for i, component := range components {
+		if component == "*" {
+			pattern.WildcardMask[i] = true
+		} else {
+			pattern.Components[i] = component
+		}
+	}
+	return pattern
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	publicKey, masterKey := newTestScheme(t)
+	target := parsePattern("facility", "general", "bin", "12345", "fill-level", "realtime")
+	reading := []byte(`{"fill_level": 0.82}`)
+
+	ciphertext, err := Encrypt(publicKey, target, reading)
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+
+	// An exact key and a wildcard key covering the bin both open the reading
+	for _, pattern := range []*WasteManagementPattern{
+		target,
+		parsePattern("facility", "*", "bin", "*", "fill-level", "*"),
+	} {
+		key, err := KeyGen(publicKey, masterKey, pattern)
+		if err != nil {
+			t.Fatalf("keygen failed: %v", err)
+		}
+		plaintext, err := Decrypt(publicKey, key, ciphertext)
+		if err != nil {
+			t.Fatalf("decrypt with %v failed: %v", pattern.Components, err)
+		}
+		if !bytes.Equal(plaintext, reading) {
+			t.Errorf("decrypted %q, expected %q", plaintext, reading)
+		}
+	}
+}
+
+func TestDecryptRejectsOtherPatterns(t *testing.T) {
+	publicKey, masterKey := newTestScheme(t)
+
+	ciphertext, err := Encrypt(publicKey, parsePattern("facility", "general", "bin", "12345", "fill-level", "realtime"), []byte("reading"))
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+
+	key, err := KeyGen(publicKey, masterKey, parsePattern("facility", "*", "bin", "67890", "*", "*"))
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+	if _, err := Decrypt(publicKey, key, ciphertext); !errors.Is(err, ErrPatternMismatch) {
+		t.Errorf("expected ErrPatternMismatch, got %v", err)
+	}
+
+	// Relabelling the ciphertext cannot trick a key into opening it
+	ciphertext.Identity[3] = "67890"
+	if _, err := Decrypt(publicKey, key, ciphertext); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("expected ErrDecryptionFailed for a relabelled ciphertext, got %v", err)
+	}
+}
+
+func TestDelegateNarrowsWildcards(t *testing.T) {
+	publicKey, masterKey := newTestScheme(t)
+
+	parent, err := KeyGen(publicKey, masterKey, parsePattern("facility", "*", "bin", "*", "fill-level", "*"))
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+
+	contractor, err := Delegate(publicKey, parent, parsePattern("facility", "general", "bin", "12345", "fill-level", "*"))
+	if err != nil {
+		t.Fatalf("delegate failed: %v", err)
+	}
+	if contractor.Depth != 5 {
+		t.Errorf("expected 5 pinned components, got %d", contractor.Depth)
+	}
+
+	ciphertext, _ := Encrypt(publicKey, parsePattern("facility", "general", "bin", "12345", "fill-level", "historical"), []byte("reading"))
+	if _, err := Decrypt(publicKey, contractor, ciphertext); err != nil {
+		t.Errorf("delegated key should open its bin: %v", err)
+	}
+
+	other, _ := Encrypt(publicKey, parsePattern("facility", "general", "bin", "67890", "fill-level", "historical"), []byte("reading"))
+	if _, err := Decrypt(publicKey, contractor, other); !errors.Is(err, ErrPatternMismatch) {
+		t.Errorf("delegated key should not open another bin, got %v", err)
+	}
+
+	if _, err := Delegate(publicKey, contractor, parsePattern("facility", "*", "bin", "12345", "fill-level", "*")); !errors.Is(err, ErrNotNarrowing) {
+		t.Errorf("expected ErrNotNarrowing when widening, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package hibe
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ucbrise/jedi-pairing/lang/go/cryptutils"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
+)
+
+// TimeDepth is the number of attribute slots after the pattern that encode time:
+// year, month, day and hour in UTC. As in JEDI's time hierarchy, a ciphertext pins
+// all four to the hour it was sealed in, and a time-bounded key holds one pairing
+// key per aligned year, month, day or hour block covering its window.
+const TimeDepth = 4
+
+// timePrefix is a path into the time hierarchy, e.g. {2026, 10, 18} for one day
+type timePrefix []int
+
+// epochKey is the pairing key for one block of a time-bounded key's window
+type epochKey struct {
+	prefix timePrefix
+	secret *wkdibe.SecretKey
+}
+
+// timePath pins every level of the time hierarchy to the hour containing t
+func timePath(t time.Time) timePrefix {
+	t = t.UTC()
+	year, month, day := t.Date()
+	return timePrefix{year, int(month), day, t.Hour()}
+}
+
+// contains reports whether every hour under other also falls under p
+func (p timePrefix) contains(other timePrefix) bool {
+	if len(p) > len(other) {
+		return false
+	}
+	for i, value := range p {
+		if other[i] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// bounded reports whether the window can be encoded in the time hierarchy. Windows
+// open on either side are enforced only by the Validity check.
+func (vw ValidityWindow) bounded() bool {
+	return !vw.NotBefore.IsZero() && !vw.NotAfter.IsZero()
+}
+
+// timePrefixes covers the window, widened outward to whole hours, with the fewest
+// aligned blocks. Blocks nest, so every block of a narrower window lies inside
+// exactly one block of the window it was narrowed from.
+func (vw ValidityWindow) timePrefixes() []timePrefix {
+	start := vw.NotBefore.UTC().Truncate(time.Hour)
+	end := vw.NotAfter.UTC()
+	if truncated := end.Truncate(time.Hour); truncated.Before(end) {
+		end = truncated.Add(time.Hour)
+	}
+
+	var prefixes []timePrefix
+	for cursor := start; cursor.Before(end); {
+		year, month, day := cursor.Date()
+		hour := cursor.Hour()
+
+		switch {
+		case month == time.January && day == 1 && hour == 0 && !cursor.AddDate(1, 0, 0).After(end):
+			prefixes = append(prefixes, timePrefix{year})
+			cursor = cursor.AddDate(1, 0, 0)
+		case day == 1 && hour == 0 && !cursor.AddDate(0, 1, 0).After(end):
+			prefixes = append(prefixes, timePrefix{year, int(month)})
+			cursor = cursor.AddDate(0, 1, 0)
+		case hour == 0 && !cursor.AddDate(0, 0, 1).After(end):
+			prefixes = append(prefixes, timePrefix{year, int(month), day})
+			cursor = cursor.AddDate(0, 0, 1)
+		default:
+			prefixes = append(prefixes, timePrefix{year, int(month), day, hour})
+			cursor = cursor.Add(time.Hour)
+		}
+	}
+	return prefixes
+}
+
+// withTime copies pattern attributes and pins the time slots named by prefix
+func (pk *PublicKey) withTime(attrs wkdibe.AttributeList, prefix timePrefix) wkdibe.AttributeList {
+	timed := make(wkdibe.AttributeList, len(attrs)+len(prefix))
+	for index, value := range attrs {
+		timed[index] = value
+	}
+	for i, value := range prefix {
+		position := pk.Params.MaxDepth + i
+		timed[wkdibe.AttributeIndex(position)] = cryptutils.HashToZp(new(big.Int), fastWasteManagementHash(strconv.Itoa(value), position))
+	}
+	return timed
+}
+
+// secretFor picks the pairing key able to open data sealed under prefix, along with
+// the number of time slots it already pins. Untimed keys leave all time slots free.
+func (key *PrivateKey) secretFor(prefix timePrefix) (*wkdibe.SecretKey, int) {
+	if key.secret != nil {
+		return key.secret, 0
+	}
+	for _, epoch := range key.epochs {
+		if epoch.prefix.contains(prefix) {
+			return epoch.secret, len(epoch.prefix)
+		}
+	}
+	return nil, 0
+}
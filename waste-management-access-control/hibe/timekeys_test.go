@@ -0,0 +1,98 @@
+package hibe
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimePrefixesCoverWindow(t *testing.T) {
+	cases := []struct {
+		name   string
+		window ValidityWindow
+		want   []timePrefix
+	}{
+		{
+			"partial days around whole days",
+			ValidityWindow{
+				NotBefore: time.Date(2026, 10, 18, 22, 30, 0, 0, time.UTC),
+				NotAfter:  time.Date(2026, 10, 21, 1, 15, 0, 0, time.UTC),
+			},
+			[]timePrefix{{2026, 10, 18, 22}, {2026, 10, 18, 23}, {2026, 10, 19}, {2026, 10, 20}, {2026, 10, 21, 0}, {2026, 10, 21, 1}},
+		},
+		{
+			"a year and a month",
+			ValidityWindow{
+				NotBefore: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				NotAfter:  time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+			[]timePrefix{{2026}, {2027, 1}},
+		},
+	}
+
+	for _, tc := range cases {
+		if got := tc.window.timePrefixes(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTimeBoundedKeyOpensOnlyItsWindow(t *testing.T) {
+	publicKey, masterKey := newTestScheme(t)
+	now := time.Now()
+	target := parsePattern("facility", "general", "bin", "12345", "fill-level", "realtime")
+
+	shift := parsePattern("facility", "general", "bin", "*", "fill-level", "*")
+	shift.Validity = ValidityWindow{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+	key, err := KeyGen(publicKey, masterKey, shift)
+	if err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+
+	current, _ := encryptAt(publicKey, target, now, []byte("reading"))
+	earlier, _ := encryptAt(publicKey, target, now.Add(-3*time.Hour), []byte("reading"))
+
+	if _, err := Decrypt(publicKey, key, current); err != nil {
+		t.Errorf("bounded key should open data sealed during its window: %v", err)
+	}
+	if _, err := Decrypt(publicKey, key, earlier); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("expected ErrKeyExpired for data sealed before the window, got %v", err)
+	}
+
+	// Relabelling the epoch cannot stretch the key over other hours
+	forged := *earlier
+	forged.Epoch = current.Epoch
+	if _, err := Decrypt(publicKey, key, &forged); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("expected ErrDecryptionFailed for a relabelled epoch, got %v", err)
+	}
+
+	untimed, _ := KeyGen(publicKey, masterKey, parsePattern("facility", "*", "bin", "*", "*", "*"))
+	if _, err := Decrypt(publicKey, untimed, earlier); err != nil {
+		t.Errorf("unbounded key should open data from any hour: %v", err)
+	}
+
+	// Narrowing keeps working from the bounded key, and survives encoding
+	crew := parsePattern("facility", "general", "bin", "12345", "fill-level", "*")
+	crew.Validity = ValidityWindow{NotBefore: now.Add(-30 * time.Minute), NotAfter: now.Add(30 * time.Minute)}
+	crewKey, err := Delegate(publicKey, key, crew)
+	if err != nil {
+		t.Fatalf("delegate failed: %v", err)
+	}
+	encoded, err := crewKey.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	decoded := new(PrivateKey)
+	if err := decoded.UnmarshalBinary(encoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if _, err := Decrypt(publicKey, decoded, current); err != nil {
+		t.Errorf("decoded crew key should open the current reading: %v", err)
+	}
+
+	crew.Validity.NotAfter = now.Add(2 * time.Hour)
+	if _, err := Delegate(publicKey, key, crew); !errors.Is(err, ErrNotNarrowing) {
+		t.Errorf("expected ErrNotNarrowing when outliving the parent, got %v", err)
+	}
+}
@@ -1,55 +1,54 @@
 package hibe
 
 import (
+	"container/list"
 	"math/big"
 	"sync"
 	"time"
+	
+	"blockchain-jedi/waste-management-access-control/memory"
+	"github.com/ucbrise/jedi-pairing/lang/go/wkdibe"
 )
 
-// SystemParams contains the system-wide parameters for HIBE
+// SystemParams contains the system-wide parameters for HIBE.
+// Pairing groups are BLS12-381, fixed by the WKD-IBE implementation.
 type SystemParams struct {
-	// Pairing parameters
-	P    *big.Int // Large prime
-	Q    *big.Int // Order of subgroup  
-	G1   *big.Int // Generator of G1
-	G2   *big.Int // Generator of G2
-	GT   *big.Int // Target group
-	
 	// System parameters
 	MaxDepth    int
 	SecurityLevel int
 	
-	// Memory pools for optimization
-	KeyPool     *sync.Pool
+	// Memory pool for optimization; return values with ReleaseBigInt, which
+	// wipes them first
 	BigIntPool  *sync.Pool
+	
+	// Symmetric keys, zeroized on release and kept apart from the pools above
+	Secrets     *memory.SecretPool
 }
 
 // MasterKey represents the master secret key
 type MasterKey struct {
-	Alpha *big.Int
-	Beta  *big.Int
-	Gamma *big.Int
+	secret *wkdibe.MasterKey
 	params *SystemParams
 	mu    sync.RWMutex
 }
 
 // PublicKey represents the public parameters
 type PublicKey struct {
-	G       *big.Int
-	G1      *big.Int  
-	G2      *big.Int
-	U       []*big.Int // U_1, U_2, ..., U_maxdepth
-	H       []*big.Int // H_1, H_2, ..., H_maxdepth
 	Params  *SystemParams
+	pairing *wkdibe.Params // one attribute slot per hierarchy level
 }
 
 // PrivateKey represents a hierarchical private key
 type PrivateKey struct {
-	Components []*big.Int
-	Depth      int
+	Components []*big.Int // attribute value per pinned component, nil for wildcards
+	Depth      int        // number of pinned components
 	Identity   []string
 	IsWildcard []bool
 	Timestamp  time.Time
+	Validity   ValidityWindow
+	secret     *wkdibe.SecretKey // nil for time-bounded keys
+	epochs     []epochKey        // one pairing key per time block of a bounded window
+	refs       int               // callers holding the key, changed under the KeyCache lock
 	mu         sync.RWMutex
 }
 
@@ -64,21 +63,35 @@ type WasteManagementPattern struct {
 	BinID     string
 	DataType      string
 	AccessLevel   string
+	Validity      ValidityWindow // e.g. a collection crew's shift; zero for unbounded keys
 }
 
-// KeyCache for caching frequently used keys
+// DefaultKeyCacheTTL bounds how long the key generator serves a cached key
+const DefaultKeyCacheTTL = time.Hour
+
+// KeyCache for caching frequently used keys, least recently used first out.
+// Entries expire after the TTL or at the key's NotAfter, whichever is sooner.
 type KeyCache struct {
-	cache    map[string]*PrivateKey
+	entries  map[string]*list.Element
+	order    *list.List // most recently used at the front
 	maxSize  int
-	mu       sync.RWMutex
+	ttl      time.Duration
+	mu       sync.Mutex
 	stats    *CacheStats
 }
 
+type cacheEntry struct {
+	key     string
+	privKey *PrivateKey
+	expires time.Time // zero if the entry never expires
+}
+
 type CacheStats struct {
-	Hits      int64
-	Misses    int64
-	Evictions int64
-	mu        sync.RWMutex
+	Hits        int64
+	Misses      int64
+	Evictions   int64
+	Expirations int64
+	mu          sync.RWMutex
 }
 
 // Performance metrics
@@ -98,46 +111,105 @@ func NewSystemParams(maxDepth, securityLevel int) *SystemParams {
 	return &SystemParams{
 		MaxDepth:      maxDepth,
 		SecurityLevel: securityLevel,
-		KeyPool: &sync.Pool{
-			New: func() interface{} {
-				return &PrivateKey{
-					Components: make([]*big.Int, maxDepth),
-				}
-			},
-		},
 		BigIntPool: &sync.Pool{
 			New: func() interface{} {
 				return new(big.Int)
 			},
 		},
+		Secrets: memory.SharedSecretPool(symmetricKeySize),
 	}
 }
 
-// NewKeyCache creates a new key cache
+// secretBuffer draws a symmetric key buffer, falling back to the heap for params
+// built without a secret pool
+func (params *SystemParams) secretBuffer() *memory.SecretBuffer {
+	if params == nil || params.Secrets == nil {
+		return memory.NewSecretBuffer(symmetricKeySize)
+	}
+	return params.Secrets.Get()
+}
+
+// NewKeyCache creates a new key cache whose entries only expire with their keys
 func NewKeyCache(maxSize int) *KeyCache {
+	return NewKeyCacheWithTTL(maxSize, 0)
+}
+
+// NewKeyCacheWithTTL creates a key cache whose entries also expire ttl after insertion
+func NewKeyCacheWithTTL(maxSize int, ttl time.Duration) *KeyCache {
 	return &KeyCache{
-		cache:   make(map[string]*PrivateKey),
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
 		maxSize: maxSize,
+		ttl:     ttl,
 		stats:   &CacheStats{},
 	}
 }
 
-// Get retrieves a key from cache
+// Get retrieves a key from cache, evicting it instead if it has expired
 func (kc *KeyCache) Get(key string) (*PrivateKey, bool) {
-	kc.mu.RLock()
-	defer kc.mu.RUnlock()
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	return kc.getLocked(key)
+}
+
+// Acquire is Get for a caller that will release the key: the caller is counted
+// among the key's holders, so the key is not wiped while it still uses it
+func (kc *KeyCache) Acquire(key string) (*PrivateKey, bool) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	
+	privKey, found := kc.getLocked(key)
+	if found {
+		privKey.mu.Lock()
+		privKey.refs++
+		privKey.mu.Unlock()
+	}
+	return privKey, found
+}
+
+// Release drops one holder of privKey, cached under key, and reports whether it
+// was the last. The last holder's release also drops privKey from the cache, so
+// nobody can acquire it once it is to be wiped.
+func (kc *KeyCache) Release(key string, privKey *PrivateKey) bool {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	
+	privKey.mu.Lock()
+	if privKey.refs == 0 {
+		privKey.mu.Unlock()
+		return false
+	}
+	privKey.refs--
+	last := privKey.refs == 0
+	privKey.mu.Unlock()
 	
-	if privKey, exists := kc.cache[key]; exists {
-		kc.stats.mu.Lock()
-		kc.stats.Hits++
-		kc.stats.mu.Unlock()
-		return privKey, true
+	if element, exists := kc.entries[key]; last && exists && element.Value.(*cacheEntry).privKey == privKey {
+		kc.order.Remove(element)
+		delete(kc.entries, key)
+	}
+	return last
+}
+
+func (kc *KeyCache) getLocked(key string) (*PrivateKey, bool) {
+	element, exists := kc.entries[key]
+	if !exists {
+		kc.recordMiss(false)
+		return nil, false
 	}
 	
+	entry := element.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		kc.order.Remove(element)
+		delete(kc.entries, key)
+		kc.recordMiss(true)
+		return nil, false
+	}
+	
+	kc.order.MoveToFront(element)
 	kc.stats.mu.Lock()
-	kc.stats.Misses++
+	kc.stats.Hits++
 	kc.stats.mu.Unlock()
-	return nil, false
+	return entry.privKey, true
 }
 
 // Put stores a key in cache
@@ -145,18 +217,62 @@ func (kc *KeyCache) Put(key string, privKey *PrivateKey) {
 	kc.mu.Lock()
 	defer kc.mu.Unlock()
 	
-	if len(kc.cache) >= kc.maxSize {
-		// Simple LRU eviction - remove oldest
-		for k := range kc.cache {
-			delete(kc.cache, k)
+	expires := time.Time{}
+	if kc.ttl > 0 {
+		expires = time.Now().Add(kc.ttl)
+	}
+	privKey.mu.RLock()
+	notAfter := privKey.Validity.NotAfter
+	privKey.mu.RUnlock()
+	if !notAfter.IsZero() && (expires.IsZero() || notAfter.Before(expires)) {
+		expires = notAfter
+	}
+	
+	if element, exists := kc.entries[key]; exists {
+		element.Value = &cacheEntry{key: key, privKey: privKey, expires: expires}
+		kc.order.MoveToFront(element)
+		return
+	}
+	
+	if kc.order.Len() >= kc.maxSize {
+		// Evict the least recently used entry
+		if oldest := kc.order.Back(); oldest != nil {
+			kc.order.Remove(oldest)
+			delete(kc.entries, oldest.Value.(*cacheEntry).key)
 			kc.stats.mu.Lock()
 			kc.stats.Evictions++
 			kc.stats.mu.Unlock()
-			break
 		}
 	}
 	
-	kc.cache[key] = privKey
+	kc.entries[key] = kc.order.PushFront(&cacheEntry{key: key, privKey: privKey, expires: expires})
+}
+
+// Remove drops a key from the cache
+func (kc *KeyCache) Remove(key string) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	
+	if element, exists := kc.entries[key]; exists {
+		kc.order.Remove(element)
+		delete(kc.entries, key)
+	}
+}
+
+// Len returns the number of cached keys, including expired ones not yet evicted
+func (kc *KeyCache) Len() int {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	return kc.order.Len()
+}
+
+func (kc *KeyCache) recordMiss(expired bool) {
+	kc.stats.mu.Lock()
+	defer kc.stats.mu.Unlock()
+	kc.stats.Misses++
+	if expired {
+		kc.stats.Expirations++
+	}
 }
 
 // GetStats returns cache statistics
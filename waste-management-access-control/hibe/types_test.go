@@ -0,0 +1,54 @@
+package hibe
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewKeyCache(2)
+	first, second, third := &PrivateKey{}, &PrivateKey{}, &PrivateKey{}
+
+	cache.Put("first", first)
+	cache.Put("second", second)
+	if _, found := cache.Get("first"); !found {
+		t.Fatal("expected the first key to be cached")
+	}
+	cache.Put("third", third)
+
+	if _, found := cache.Get("second"); found {
+		t.Error("expected the least recently used key to be evicted")
+	}
+	if key, found := cache.Get("first"); !found || key != first {
+		t.Error("expected the recently used key to survive")
+	}
+	if stats := cache.GetStats(); stats.Evictions != 1 {
+		t.Errorf("expected 1 eviction, got %d", stats.Evictions)
+	}
+}
+
+func TestKeyCacheExpiresEntries(t *testing.T) {
+	cache := NewKeyCacheWithTTL(10, 20*time.Millisecond)
+
+	cache.Put("shift", &PrivateKey{Validity: ValidityWindow{NotAfter: time.Now().Add(-time.Second)}})
+	cache.Put("facility", &PrivateKey{})
+
+	if _, found := cache.Get("shift"); found {
+		t.Error("expected a key past its NotAfter to be rejected")
+	}
+	if _, found := cache.Get("facility"); !found {
+		t.Error("expected a fresh key to be served")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, found := cache.Get("facility"); found {
+		t.Error("expected the key to expire after the TTL")
+	}
+
+	if cache.Len() != 0 {
+		t.Errorf("expected expired entries to be evicted, %d remain", cache.Len())
+	}
+	if stats := cache.GetStats(); stats.Expirations != 2 {
+		t.Errorf("expected 2 expirations, got %d", stats.Expirations)
+	}
+}
@@ -0,0 +1,410 @@
+// Package keytool implements the commands that generate, derive, inspect and
+// convert HIBE key files
+package keytool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"blockchain-jedi/waste-management-access-control/hibe"
+)
+
+// passphraseEnv holds the master key passphrase; it is never taken as a flag so it
+// stays out of shell history and process listings
+const passphraseEnv = "HIBE_PASSPHRASE"
+
+// ErrUsage is returned when no known command is given; the usage has been printed
+var ErrUsage = errors.New("usage")
+
+// Tool runs the key management commands. The same commands back hibe-keytool
+// and swt keys.
+type Tool struct {
+	Name      string // shown in usage, e.g. hibe-keytool
+	PublicKey string // default -public file
+	MasterKey string // default -master file
+}
+
+// New returns a tool reading public.key and master.key by default
+func New(name string) *Tool {
+	return &Tool{Name: name, PublicKey: "public.key", MasterKey: "master.key"}
+}
+
+// Run runs the command named by args[0] with the remaining flags
+func (t *Tool) Run(args []string) error {
+	if len(args) < 1 {
+		t.PrintUsage()
+		return ErrUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "generate":
+		err = t.runGenerate(args[1:])
+	case "inspect":
+		err = t.runInspect(args[1:])
+	case "keygen":
+		err = t.runKeyGen(args[1:])
+	case "delegate":
+		err = t.runDelegate(args[1:])
+	case "export":
+		err = t.runExport(args[1:])
+	default:
+		t.PrintUsage()
+		return ErrUsage
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %v", args[0], err)
+	}
+	return nil
+}
+
+// runGenerate creates public parameters and a master key
+func (t *Tool) runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	dir := fs.String("out", ".", "directory for public.key and master.key")
+	depth := fs.Int("depth", 6, "maximum pattern depth")
+	security := fs.Int("security", 128, "security level in bits")
+	insecure := fs.Bool("insecure", false, "write the master key without a passphrase")
+	fs.Parse(args)
+
+	passphrase := []byte(os.Getenv(passphraseEnv))
+	if len(passphrase) == 0 && !*insecure {
+		return fmt.Errorf("set %s to seal the master key, or pass -insecure", passphraseEnv)
+	}
+
+	publicKey, masterKey, err := hibe.Setup(hibe.NewSystemParams(*depth, *security))
+	if err != nil {
+		return err
+	}
+
+	encodedPublic, err := publicKey.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(*dir, "public.key"), hibe.Armor(hibe.BlockPublicKey, encodedPublic, nil), 0644); err != nil {
+		return err
+	}
+
+	var armoredMaster []byte
+	if len(passphrase) > 0 {
+		sealed, err := hibe.SealMasterKey(masterKey, passphrase, 0)
+		if err != nil {
+			return err
+		}
+		armoredMaster = hibe.Armor(hibe.BlockSealedMasterKey, sealed, nil)
+	} else {
+		encoded, err := masterKey.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		armoredMaster = hibe.Armor(hibe.BlockMasterKey, encoded, nil)
+	}
+	if err := writeFile(filepath.Join(*dir, "master.key"), armoredMaster, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s and %s (max depth %d)\n", filepath.Join(*dir, "public.key"), filepath.Join(*dir, "master.key"), *depth)
+	return nil
+}
+
+// runInspect prints what a key or ciphertext file contains without exposing secrets
+func (t *Tool) runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s inspect <file>", t.Name)
+	}
+
+	// Armor headers are informational, so only the decoded contents are shown
+	blockType, data, _, err := readEncoded(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Type:     %s\n", blockType)
+	fmt.Printf("Size:     %d bytes\n", len(data))
+
+	switch blockType {
+	case hibe.BlockPublicKey:
+		publicKey := new(hibe.PublicKey)
+		if err := publicKey.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		fmt.Printf("Depth:    %d\n", publicKey.Params.MaxDepth)
+		fmt.Printf("Security: %d bits\n", publicKey.Params.SecurityLevel)
+	case hibe.BlockPrivateKey:
+		key := new(hibe.PrivateKey)
+		if err := key.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		fmt.Printf("Pattern:  %s\n", hibe.FormatPattern(key.Identity, key.IsWildcard))
+		fmt.Printf("Pinned:   %d of %d\n", key.Depth, len(key.Identity))
+		fmt.Printf("Issued:   %s\n", formatTime(key.Timestamp))
+		fmt.Printf("Valid:    %s to %s\n", formatTime(key.Validity.NotBefore), formatTime(key.Validity.NotAfter))
+		if !key.Validity.Contains(time.Now()) {
+			fmt.Println("Status:   outside its validity window")
+		}
+	case hibe.BlockMasterKey:
+		fmt.Println("Sealed:   no")
+	case hibe.BlockSealedMasterKey:
+		fmt.Println("Sealed:   yes (scrypt, AES-256-GCM)")
+	case hibe.BlockCiphertext:
+		ciphertext := new(hibe.Ciphertext)
+		if err := ciphertext.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		fmt.Printf("Pattern:  %s\n", hibe.FormatPattern(ciphertext.Identity, ciphertext.IsWildcard))
+		fmt.Printf("Payload:  %d bytes\n", len(ciphertext.Payload))
+	}
+	return nil
+}
+
+// runKeyGen derives a private key for a pattern from the master key
+func (t *Tool) runKeyGen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	publicPath := fs.String("public", t.PublicKey, "public parameters file")
+	masterPath := fs.String("master", t.MasterKey, "master key file")
+	patternFlag := fs.String("pattern", "", "pattern such as facility/*/bin/*/fill-level/*")
+	out := fs.String("out", "", "output file (default stdout)")
+	window := windowFlags(fs)
+	fs.Parse(args)
+
+	publicKey, err := LoadPublicKey(*publicPath)
+	if err != nil {
+		return err
+	}
+	masterKey, err := LoadMasterKey(*masterPath)
+	if err != nil {
+		return err
+	}
+	pattern, err := hibe.ParsePattern(*patternFlag)
+	if err != nil {
+		return fmt.Errorf("pattern %q: %v", *patternFlag, err)
+	}
+	pattern.Validity = window()
+
+	key, err := hibe.KeyGen(publicKey, masterKey, pattern)
+	if err != nil {
+		return err
+	}
+	return writePrivateKey(*out, key)
+}
+
+// runDelegate derives a narrower key from an existing private key
+func (t *Tool) runDelegate(args []string) error {
+	fs := flag.NewFlagSet("delegate", flag.ExitOnError)
+	publicPath := fs.String("public", t.PublicKey, "public parameters file")
+	parentPath := fs.String("key", "", "parent private key file")
+	patternFlag := fs.String("pattern", "", "narrower pattern such as facility/general/bin/12345/fill-level/*")
+	out := fs.String("out", "", "output file (default stdout)")
+	window := windowFlags(fs)
+	fs.Parse(args)
+
+	publicKey, err := LoadPublicKey(*publicPath)
+	if err != nil {
+		return err
+	}
+	parent, err := LoadPrivateKey(*parentPath)
+	if err != nil {
+		return err
+	}
+	pattern, err := hibe.ParsePattern(*patternFlag)
+	if err != nil {
+		return fmt.Errorf("pattern %q: %v", *patternFlag, err)
+	}
+	pattern.Validity = window()
+
+	generator := &hibe.HIBEKeyGenerator{
+		PublicKey: publicKey,
+		Cache:     hibe.NewKeyCache(1),
+		Metrics:   &hibe.KeyGenMetrics{},
+	}
+	key, err := generator.Delegate(parent, pattern)
+	if err != nil {
+		return err
+	}
+	return writePrivateKey(*out, key)
+}
+
+// runExport converts between armored text and raw binary, optionally unsealing a master key
+func (t *Tool) runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	in := fs.String("in", "", "input file")
+	out := fs.String("out", "", "output file (default stdout)")
+	format := fs.String("format", "armor", "output format: armor, binary or hex")
+	unseal := fs.Bool("unseal", false, "decrypt a sealed master key using "+passphraseEnv)
+	fs.Parse(args)
+
+	blockType, data, headers, err := readEncoded(*in)
+	if err != nil {
+		return err
+	}
+
+	if *unseal {
+		if blockType != hibe.BlockSealedMasterKey {
+			return fmt.Errorf("%s is not a sealed master key", *in)
+		}
+		masterKey, err := hibe.OpenMasterKey(data, []byte(os.Getenv(passphraseEnv)))
+		if err != nil {
+			return err
+		}
+		if data, err = masterKey.MarshalBinary(); err != nil {
+			return err
+		}
+		blockType, headers = hibe.BlockMasterKey, nil
+	}
+
+	var output []byte
+	switch *format {
+	case "armor":
+		output = hibe.Armor(blockType, data, headers)
+	case "binary":
+		output = data
+	case "hex":
+		output = []byte(hex.EncodeToString(data) + "\n")
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	mode := os.FileMode(0644)
+	if blockType != hibe.BlockPublicKey && blockType != hibe.BlockCiphertext {
+		mode = 0600
+	}
+	return writeFile(*out, output, mode)
+}
+
+// windowFlags registers -not-before and -valid-for and returns the window they describe
+func windowFlags(fs *flag.FlagSet) func() hibe.ValidityWindow {
+	notBefore := fs.String("not-before", "", "start of validity (RFC 3339, default now with -valid-for, else open)")
+	validFor := fs.Duration("valid-for", 0, "validity length, e.g. 8h (default open)")
+
+	return func() hibe.ValidityWindow {
+		var window hibe.ValidityWindow
+		if *notBefore != "" {
+			start, err := time.Parse(time.RFC3339, *notBefore)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid -not-before: %v\n", err)
+				os.Exit(2)
+			}
+			window.NotBefore = start
+		}
+		// Both bounds are set so the window is encoded in the key's time hierarchy
+		if *validFor > 0 {
+			if window.NotBefore.IsZero() {
+				window.NotBefore = time.Now()
+			}
+			window.NotAfter = window.NotBefore.Add(*validFor)
+		}
+		return window
+	}
+}
+
+// readEncoded loads a file in either armored or raw binary form
+func readEncoded(path string) (string, []byte, map[string]string, error) {
+	if path == "" {
+		return "", nil, nil, errors.New("no input file given")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if blockType, err := hibe.BlockType(raw); err == nil {
+		return blockType, raw, nil, nil
+	}
+	block, err := hibe.Dearmor(raw, hibe.BlockPublicKey, hibe.BlockMasterKey, hibe.BlockSealedMasterKey, hibe.BlockPrivateKey, hibe.BlockCiphertext)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	delete(block.Headers, "Version")
+	return block.Type, block.Bytes, block.Headers, nil
+}
+
+// LoadPublicKey reads public parameters in armored or binary form
+func LoadPublicKey(path string) (*hibe.PublicKey, error) {
+	blockType, data, _, err := readEncoded(path)
+	if err != nil {
+		return nil, err
+	}
+	if blockType != hibe.BlockPublicKey {
+		return nil, fmt.Errorf("%s holds a %s, not public parameters", path, blockType)
+	}
+	publicKey := new(hibe.PublicKey)
+	return publicKey, publicKey.UnmarshalBinary(data)
+}
+
+// LoadPrivateKey reads a private key in armored or binary form
+func LoadPrivateKey(path string) (*hibe.PrivateKey, error) {
+	blockType, data, _, err := readEncoded(path)
+	if err != nil {
+		return nil, err
+	}
+	if blockType != hibe.BlockPrivateKey {
+		return nil, fmt.Errorf("%s holds a %s, not a private key", path, blockType)
+	}
+	key := new(hibe.PrivateKey)
+	return key, key.UnmarshalBinary(data)
+}
+
+// LoadMasterKey reads a master key, unsealing it with $HIBE_PASSPHRASE if needed
+func LoadMasterKey(path string) (*hibe.MasterKey, error) {
+	blockType, data, _, err := readEncoded(path)
+	if err != nil {
+		return nil, err
+	}
+	if blockType != hibe.BlockMasterKey && blockType != hibe.BlockSealedMasterKey {
+		return nil, fmt.Errorf("%s holds a %s, not a master key", path, blockType)
+	}
+	return hibe.OpenMasterKey(data, []byte(os.Getenv(passphraseEnv)))
+}
+
+func writePrivateKey(path string, key *hibe.PrivateKey) error {
+	armored, err := hibe.ArmorPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, armored, 0600)
+}
+
+// writeFile writes to path, or to stdout when path is empty; it refuses to overwrite
+func writeFile(path string, data []byte, mode os.FileMode) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := bytes.NewReader(data).WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "open"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// PrintUsage lists the commands
+func (t *Tool) PrintUsage() {
+	fmt.Printf("Usage: %s <command> [flags]\n", t.Name)
+	fmt.Println("Commands:")
+	fmt.Println("  generate  - Create public.key and master.key (sealed with $" + passphraseEnv + ")")
+	fmt.Println("  inspect   - Describe a key or ciphertext file")
+	fmt.Println("  keygen    - Derive a private key for a pattern from the master key")
+	fmt.Println("  delegate  - Derive a narrower, optionally time-bounded key from a private key")
+	fmt.Println("  export    - Convert a file to armor, binary or hex; -unseal decrypts a master key")
+	fmt.Println("Patterns are slash-separated with * for wildcards, e.g. facility/*/bin/*/fill-level/*")
+}
@@ -44,12 +44,13 @@ type WasteManagementComponentPool struct {
 
 // CryptoKeyPool manages cryptographic key component allocations
 type CryptoKeyPool struct {
-	bigIntPool     *sync.Pool
 	componentPool  *sync.Pool
 	privateKeyPool *sync.Pool
 	
-	// Reusable buffers for cryptographic operations
-	hashBuffers   *sync.Pool
+	// Buffers that hold key material are zeroized on release and kept apart
+	// from the pools for ordinary buffers
+	bigIntBuffers *SecretPool
+	hashBuffers   *SecretPool
 	tempBuffers   *sync.Pool
 }
 
@@ -547,14 +548,19 @@ func (hcp *WasteManagementComponentPool) cleanup() {
 	// Cleanup would involve removing least recently used entries
 }
 
-// NewCryptoKeyPool creates a new crypto key pool
+// Secret buffer sizes and how many of each stay pooled
+const (
+	bigIntBufferSize   = 256 // Typical crypto key size
+	hashBufferSize     = 64  // SHA256 size, with room for SHA512
+	secretPoolCapacity = 64
+)
+
+// NewCryptoKeyPool creates a new crypto key pool; secret buffers come from the
+// process's shared pools, which are mlock'd where possible
 func NewCryptoKeyPool() *CryptoKeyPool {
 	return &CryptoKeyPool{
-		bigIntPool: &sync.Pool{
-			New: func() interface{} {
-				return make([]byte, 0, 256) // Typical crypto key size
-			},
-		},
+		bigIntBuffers: SharedSecretPool(bigIntBufferSize),
+		hashBuffers:   SharedSecretPool(hashBufferSize),
 		componentPool: &sync.Pool{
 			New: func() interface{} {
 				return make([][]byte, 0, 8)
@@ -565,11 +571,6 @@ func NewCryptoKeyPool() *CryptoKeyPool {
 				return make(map[string]interface{})
 			},
 		},
-		hashBuffers: &sync.Pool{
-			New: func() interface{} {
-				return make([]byte, 0, 64) // SHA256 size
-			},
-		},
 		tempBuffers: &sync.Pool{
 			New: func() interface{} {
 				return make([]byte, 0, 512)
@@ -579,24 +580,39 @@ func NewCryptoKeyPool() *CryptoKeyPool {
 }
 
 // Key pool methods for cryptographic operations
-func (ckp *CryptoKeyPool) GetBigIntBuffer() []byte {
-	return ckp.bigIntPool.Get().([]byte)[:0]
+func (ckp *CryptoKeyPool) GetBigIntBuffer() *SecretBuffer {
+	return ckp.bigIntBuffers.Get()
+}
+
+// ReleaseBigIntBuffer zeroes the buffer before it can be handed out again
+func (ckp *CryptoKeyPool) ReleaseBigIntBuffer(buf *SecretBuffer) {
+	buf.Release()
+}
+
+func (ckp *CryptoKeyPool) GetHashBuffer() *SecretBuffer {
+	return ckp.hashBuffers.Get()
 }
 
-func (ckp *CryptoKeyPool) ReleaseBigIntBuffer(buf []byte) {
-	ckp.bigIntPool.Put(buf[:0])
+// ReleaseHashBuffer zeroes the buffer before it can be handed out again
+func (ckp *CryptoKeyPool) ReleaseHashBuffer(buf *SecretBuffer) {
+	buf.Release()
 }
 
-func (ckp *CryptoKeyPool) GetHashBuffer() []byte {
-	return ckp.hashBuffers.Get().([]byte)[:0]
+// SecretMemoryLocked reports whether key buffers are pinned in RAM
+func (ckp *CryptoKeyPool) SecretMemoryLocked() bool {
+	return ckp.bigIntBuffers.Locked() && ckp.hashBuffers.Locked()
 }
 
-func (ckp *CryptoKeyPool) ReleaseHashBuffer(buf []byte) {
-	ckp.hashBuffers.Put(buf[:0])
+// Close leaves the secret buffer slabs open: they are the process's shared
+// pools, which outlive any one CryptoKeyPool
+func (ckp *CryptoKeyPool) Close() {
+	ckp.bigIntBuffers.Close()
+	ckp.hashBuffers.Close()
 }
 
 func (ckp *CryptoKeyPool) cleanup() {
 	// Crypto key cleanup - remove expired keys and buffers
+	// Released secret buffers are already zeroed, so nothing lingers in the free lists
 }
 
 // NewAllocationStats creates a new allocation statistics tracker
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// SecretBuffer holds key material. Its bytes are zeroed on Release and it is only
+// ever recycled through a SecretPool, never through the pools for ordinary buffers.
+type SecretBuffer struct {
+	data     []byte
+	pool     *SecretPool // nil for buffers allocated outside a pool's slab
+	released bool
+	mu       sync.Mutex
+}
+
+// SecretPool recycles fixed-size secret buffers carved from one slab. On Linux the
+// slab can be mlock'd so key material is never written to swap. When every pooled
+// buffer is in use, Get falls back to heap buffers that are wiped and dropped on release.
+type SecretPool struct {
+	size   int
+	slab   []byte
+	locked bool
+	shared bool // owned by the process; see SharedSecretPool
+	free   []*SecretBuffer
+	closed bool
+	mu     sync.Mutex
+}
+
+var (
+	sharedPools   = make(map[int]*SecretPool)
+	sharedPoolsMu sync.Mutex
+)
+
+// NewSecretBuffer allocates an unpooled secret buffer on the heap
+func NewSecretBuffer(size int) *SecretBuffer {
+	return &SecretBuffer{data: make([]byte, size)}
+}
+
+// Bytes returns the buffer's contents; the slice must not be kept after Release
+func (sb *SecretBuffer) Bytes() []byte {
+	return sb.data
+}
+
+// Release zeroes the buffer and hands it back to its pool. Releasing twice is a no-op.
+func (sb *SecretBuffer) Release() {
+	sb.mu.Lock()
+	if sb.released {
+		sb.mu.Unlock()
+		return
+	}
+	sb.released = true
+	WipeBytes(sb.data)
+	sb.mu.Unlock()
+
+	if sb.pool != nil {
+		sb.pool.put(sb)
+	}
+}
+
+// NewSecretPool creates a pool of capacity buffers of size bytes each. With lock
+// set the slab is mlock'd where the platform allows it; see Locked. The slab is
+// only released by Close, so a pool that is not closed lives as long as the
+// process; code that builds pools repeatedly should use SharedSecretPool.
+func NewSecretPool(size, capacity int, lock bool) *SecretPool {
+	pool := &SecretPool{size: size}
+
+	if lock {
+		pool.slab, pool.locked = allocLocked(size * capacity)
+	}
+	if pool.slab == nil {
+		pool.slab = make([]byte, size*capacity)
+	}
+
+	pool.free = make([]*SecretBuffer, 0, capacity)
+	for i := 0; i < capacity; i++ {
+		pool.free = append(pool.free, &SecretBuffer{
+			data: pool.slab[i*size : (i+1)*size : (i+1)*size],
+			pool: pool,
+		})
+	}
+	return pool
+}
+
+// SharedSecretPool returns the process's locked pool of size-byte buffers,
+// creating it on first use, so every caller draws from one mlock'd slab per size
+// instead of locking a new one each. Closing it is a no-op.
+func SharedSecretPool(size int) *SecretPool {
+	sharedPoolsMu.Lock()
+	defer sharedPoolsMu.Unlock()
+
+	pool, ok := sharedPools[size]
+	if !ok {
+		pool = NewSecretPool(size, secretPoolCapacity, true)
+		pool.shared = true
+		sharedPools[size] = pool
+	}
+	return pool
+}
+
+// Get returns a zeroed buffer of the pool's size
+func (sp *SecretPool) Get() *SecretBuffer {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.closed || len(sp.free) == 0 {
+		return NewSecretBuffer(sp.size)
+	}
+	buffer := sp.free[len(sp.free)-1]
+	sp.free = sp.free[:len(sp.free)-1]
+	buffer.released = false
+	return buffer
+}
+
+// Size returns the length of the pool's buffers
+func (sp *SecretPool) Size() int {
+	return sp.size
+}
+
+// Locked reports whether the pool's slab is mlock'd
+func (sp *SecretPool) Locked() bool {
+	return sp.locked
+}
+
+// Available returns the number of pooled buffers not currently in use
+func (sp *SecretPool) Available() int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return len(sp.free)
+}
+
+// Close wipes the slab and releases its locked memory. Pooled buffers must be
+// released before closing; their bytes are invalid afterwards. Shared pools stay
+// open.
+func (sp *SecretPool) Close() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.closed || sp.shared {
+		return
+	}
+	sp.closed = true
+	sp.free = nil
+
+	WipeBytes(sp.slab)
+	if sp.locked {
+		freeLocked(sp.slab)
+	}
+	sp.slab = nil
+}
+
+func (sp *SecretPool) put(buffer *SecretBuffer) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if !sp.closed {
+		sp.free = append(sp.free, buffer)
+	}
+}
+
+// WipeBytes overwrites b with zeros
+func WipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
+
+// WipeBigInt zeroes the words backing n and sets it to zero
+func WipeBigInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	runtime.KeepAlive(words)
+	n.SetInt64(0)
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package memory
+
+import "syscall"
+
+// allocLocked maps anonymous memory and pins it in RAM. It reports false when the
+// mapping or the lock fails, typically because RLIMIT_MEMLOCK is exhausted.
+func allocLocked(size int) ([]byte, bool) {
+	if size <= 0 {
+		return nil, false
+	}
+
+	pageSize := syscall.Getpagesize()
+	length := (size + pageSize - 1) / pageSize * pageSize
+	region, err := syscall.Mmap(-1, 0, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, false
+	}
+	if err := syscall.Mlock(region); err != nil {
+		syscall.Munmap(region)
+		return nil, false
+	}
+	return region[:size], true
+}
+
+// freeLocked unpins and unmaps a region returned by allocLocked
+func freeLocked(region []byte) {
+	region = region[:cap(region)]
+	syscall.Munlock(region)
+	syscall.Munmap(region)
+}
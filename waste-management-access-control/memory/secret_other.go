@@ -0,0 +1,10 @@
+//go:build !linux
+
+package memory
+
+// allocLocked is unsupported on this platform; secret pools fall back to heap slabs
+func allocLocked(size int) ([]byte, bool) {
+	return nil, false
+}
+
+func freeLocked(region []byte) {}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestSecretPoolZeroesOnRelease(t *testing.T) {
+	pool := NewSecretPool(32, 1, true)
+	defer pool.Close()
+
+	buffer := pool.Get()
+	copy(buffer.Bytes(), bytes.Repeat([]byte{0xAA}, 32))
+	backing := buffer.Bytes()
+	buffer.Release()
+	buffer.Release() // a second release must not pool the buffer twice
+
+	if !bytes.Equal(backing, make([]byte, 32)) {
+		t.Error("expected the released buffer to be zeroed")
+	}
+	if pool.Available() != 1 {
+		t.Errorf("expected 1 pooled buffer, got %d", pool.Available())
+	}
+
+	// The slab is exhausted while one buffer is out, so the next comes from the heap
+	reused := pool.Get()
+	overflow := pool.Get()
+	if &reused.Bytes()[0] != &backing[0] {
+		t.Error("expected the pooled buffer to be reused")
+	}
+	copy(overflow.Bytes(), []byte("secret"))
+	overflow.Release()
+	reused.Release()
+	if pool.Available() != 1 {
+		t.Errorf("expected heap buffers to be dropped, %d pooled", pool.Available())
+	}
+	if !bytes.Equal(overflow.Bytes(), make([]byte, 32)) {
+		t.Error("expected the heap buffer to be zeroed")
+	}
+}
+
+func TestWipeBigInt(t *testing.T) {
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	words := n.Bits()
+	WipeBigInt(n)
+
+	if n.Sign() != 0 {
+		t.Errorf("expected zero, got %v", n)
+	}
+	for _, word := range words[:cap(words)] {
+		if word != 0 {
+			t.Fatal("expected the backing words to be zeroed")
+		}
+	}
+}
+
+func TestSharedSecretPool(t *testing.T) {
+	pool := SharedSecretPool(48)
+	if SharedSecretPool(48) != pool {
+		t.Fatal("expected one shared pool per buffer size")
+	}
+
+	pool.Close()
+	buffer := pool.Get()
+	defer buffer.Release()
+	if available := pool.Available(); available != secretPoolCapacity-1 {
+		t.Errorf("expected the shared pool to stay open after Close, %d pooled", available)
+	}
+}
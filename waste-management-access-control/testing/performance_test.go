@@ -104,7 +104,6 @@ func NewPerformanceTestSuite() *PerformanceTestSuite {
 	// Initialize system parameters for HIBE
 	params := &hibe.SystemParams{
 		MaxDepth: 6, // facility/dept/bin/id/data/access
-		KeyPool:  &sync.Pool{New: func() interface{} { return &hibe.PrivateKey{} }},
 		BigIntPool: &sync.Pool{New: func() interface{} { return big.NewInt(0) }},
 	}
 	
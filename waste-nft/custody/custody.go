@@ -0,0 +1,413 @@
+// Package custody records the chain of custody of a waste batch from bin pickup
+// to the processing facility. Each hand-off is a step in a hash chain. Its signed
+// sensor evidence is encrypted under the batch's HIBE URI and stored on IPFS, and
+// the step is anchored on chain as a cryptographic binding.
+package custody
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	binding "blockchain-jedi/ipfs-blockchain-binding"
+	"blockchain-jedi/waste-management-access-control/hibe"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// logVersion is bumped when the custody log file format changes
+const logVersion = 1
+
+// DefaultWeightTolerance is how far, as a fraction, weights measured for the same
+// batch may disagree before a hand-off is refused
+const DefaultWeightTolerance = 0.02
+
+// Stage is a point in the custody chain
+type Stage string
+
+const (
+	StageBinPickup       Stage = "bin-pickup"       // a collection vehicle empties a bin into the batch
+	StageTransferStation Stage = "transfer-station" // the batch is consolidated for onward transport
+	StageMRF             Stage = "mrf"              // a materials recovery facility sorts the batch
+	StageProcessor       Stage = "processor"        // the final processor takes the batch; nothing follows
+)
+
+// stageOrder ranks the stages; custody never moves to a lower rank
+var stageOrder = map[Stage]int{
+	StageBinPickup:       0,
+	StageTransferStation: 1,
+	StageMRF:             2,
+	StageProcessor:       3,
+}
+
+// Party is a custodian, or the bin a batch is first collected from
+type Party struct {
+	ID     string         `json:"id"`
+	Wallet common.Address `json:"wallet"`
+}
+
+// Handoff is one transfer of a batch, reported by the receiving party
+type Handoff struct {
+	BatchID  string
+	Stage    Stage
+	From     Party // the current holder; the bin for the first pickup
+	To       Party
+	Location string
+	Readings []SensorReading // signed readings, including at least one weight
+	Notes    string          // kept with the encrypted evidence
+}
+
+// Anchor locates a step's cryptographic binding
+type Anchor struct {
+	BindingHash   string `json:"binding_hash"`
+	TransactionID string `json:"transaction_id"`
+	KeyHash       string `json:"key_hash"`
+}
+
+// Step is a recorded hand-off. Hash covers every field except itself and the
+// Anchor, and PrevHash chains it to the step before.
+type Step struct {
+	BatchID        string    `json:"batch_id"`
+	Index          int       `json:"index"`
+	Stage          Stage     `json:"stage"`
+	From           Party     `json:"from"`
+	To             Party     `json:"to"`
+	Location       string    `json:"location,omitempty"`
+	WeightGrams    uint64    `json:"weight_grams"`
+	RecordedAt     time.Time `json:"recorded_at"`
+	EvidenceURI    string    `json:"evidence_uri"`    // HIBE pattern the evidence is encrypted under
+	EvidenceDigest string    `json:"evidence_digest"` // SHA-256 of the evidence before encryption
+	EvidenceCID    string    `json:"evidence_cid"`
+	PrevHash       string    `json:"prev_hash"`
+	Hash           string    `json:"hash"`
+	Anchor         Anchor    `json:"anchor"`
+}
+
+// computeHash hashes the step's fields other than Hash and Anchor
+func (s *Step) computeHash() string {
+	unhashed := *s
+	unhashed.Hash = ""
+	unhashed.Anchor = Anchor{}
+	encoded, _ := json.Marshal(&unhashed)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// EvidenceStore keeps encrypted evidence; *binding.IPFSConnector is one
+type EvidenceStore interface {
+	StoreWasteManagementData(data *binding.WasteManagementData) (string, error)
+	RetrieveWasteManagementData(hash string, clientID string) (*binding.WasteManagementData, error)
+}
+
+// Binder anchors steps on chain; *binding.CryptographicBinding is one
+type Binder interface {
+	GenerateHIBEKeyForWasteManagement(binID, operatorWallet, department, dataType, accessLevel string) (*binding.HIBEKeyData, error)
+	CreateCryptographicBinding(hibeKeyData *binding.HIBEKeyData, ipfsHash string, gasFeePaid *big.Int) (*binding.AccessBinding, error)
+	VerifyBinding(bindingHash string) (bool, error)
+}
+
+// Config configures a Service
+type Config struct {
+	Facility        string          // facility component of batch URIs
+	PublicKey       *hibe.PublicKey // evidence is encrypted under it
+	Store           EvidenceStore
+	Binder          Binder
+	Sensors         *SensorRegistry
+	GasFee          *big.Int         // paid to anchor each step
+	WeightTolerance float64          // DefaultWeightTolerance when zero
+	Path            string           // custody log file; empty keeps the log in memory
+	Now             func() time.Time // time.Now when nil
+}
+
+// HandoffError reports a hand-off that breaks the custody rules
+type HandoffError struct {
+	BatchID string
+	Index   int
+	Reason  string
+}
+
+func (e *HandoffError) Error() string {
+	return fmt.Sprintf("custody of batch %s, step %d: %s", e.BatchID, e.Index, e.Reason)
+}
+
+// ErrUnknownBatch is returned for a batch with no recorded steps
+var ErrUnknownBatch = errors.New("no custody recorded for batch")
+
+// Service records hand-offs and reports on them. It is safe for concurrent use;
+// hand-offs are recorded one at a time.
+type Service struct {
+	mu      sync.Mutex
+	cfg     Config
+	batches map[string][]Step
+}
+
+type logSnapshot struct {
+	Version int               `json:"version"`
+	SavedAt time.Time         `json:"saved_at"`
+	Batches map[string][]Step `json:"batches"`
+}
+
+// NewService creates a custody service, loading the log at cfg.Path if it exists
+func NewService(cfg Config) (*Service, error) {
+	if err := validComponent("facility", cfg.Facility); err != nil {
+		return nil, err
+	}
+	if cfg.PublicKey == nil || cfg.Store == nil || cfg.Binder == nil || cfg.Sensors == nil {
+		return nil, errors.New("custody service needs a public key, evidence store, binder and sensor registry")
+	}
+	if cfg.WeightTolerance == 0 {
+		cfg.WeightTolerance = DefaultWeightTolerance
+	}
+	if cfg.WeightTolerance < 0 || cfg.WeightTolerance >= 1 {
+		return nil, fmt.Errorf("weight tolerance must be in [0, 1), got %g", cfg.WeightTolerance)
+	}
+	if cfg.GasFee == nil {
+		cfg.GasFee = new(big.Int)
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	s := &Service{cfg: cfg, batches: make(map[string][]Step)}
+	if cfg.Path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(cfg.Path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custody log: %v", err)
+	}
+	var snapshot logSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse custody log %s: %v", cfg.Path, err)
+	}
+	if snapshot.Version != logVersion {
+		return nil, fmt.Errorf("custody log %s has version %d, expected %d", cfg.Path, snapshot.Version, logVersion)
+	}
+	for batchID, steps := range snapshot.Batches {
+		if err := verifyChain(batchID, steps, cfg.WeightTolerance); err != nil {
+			return nil, fmt.Errorf("custody log %s is corrupt: %v", cfg.Path, err)
+		}
+		s.batches[batchID] = steps
+	}
+	return s, nil
+}
+
+// BatchURI is the HIBE URI covering all custody evidence of a batch; a key
+// delegated for it opens every step's evidence
+func BatchURI(facility, batchID string) string {
+	return fmt.Sprintf("facility/%s/batch/%s/custody/*", facility, batchID)
+}
+
+// stageURI is the HIBE URI one step's evidence is encrypted under
+func stageURI(facility, batchID string, stage Stage) string {
+	return fmt.Sprintf("facility/%s/batch/%s/custody/%s", facility, batchID, stage)
+}
+
+// validComponent checks a value can be one component of a HIBE URI
+func validComponent(name, value string) error {
+	if value == "" || value == "*" || strings.Contains(value, "/") {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+	return nil
+}
+
+// Record validates a hand-off against the batch's custody so far, encrypts and
+// stores its evidence, anchors it and appends it to the log. The evidence and
+// anchor are published before the log is saved, so if saving fails the step is
+// not recorded and its anchor is orphaned.
+func (s *Service) Record(h *Handoff) (*Step, error) {
+	if err := validComponent("batch ID", h.BatchID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	steps := s.batches[h.BatchID]
+	step := &Step{
+		BatchID:     h.BatchID,
+		Index:       len(steps),
+		Stage:       h.Stage,
+		From:        h.From,
+		To:          h.To,
+		Location:    h.Location,
+		RecordedAt:  s.cfg.Now().UTC(),
+		EvidenceURI: stageURI(s.cfg.Facility, h.BatchID, h.Stage),
+	}
+	if len(steps) > 0 {
+		step.PrevHash = steps[len(steps)-1].Hash
+	}
+	rejected := func(reason string, args ...interface{}) error {
+		return &HandoffError{BatchID: h.BatchID, Index: step.Index, Reason: fmt.Sprintf(reason, args...)}
+	}
+
+	if err := validComponent("custodian ID", h.To.ID); err != nil {
+		return nil, rejected("%v", err)
+	}
+	weight, err := s.cfg.Sensors.verifyReadings(h.BatchID, h.Readings, s.cfg.WeightTolerance)
+	if err != nil {
+		return nil, rejected("%v", err)
+	}
+	step.WeightGrams = weight
+	var prev *Step
+	if len(steps) > 0 {
+		prev = &steps[len(steps)-1]
+	}
+	if reason := nextStepViolation(prev, step, s.cfg.WeightTolerance); reason != "" {
+		return nil, rejected("%s", reason)
+	}
+
+	plaintext, err := json.Marshal(&Evidence{
+		BatchID:  h.BatchID,
+		Index:    step.Index,
+		Stage:    h.Stage,
+		Readings: h.Readings,
+		Notes:    h.Notes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode evidence: %v", err)
+	}
+	digest := sha256.Sum256(plaintext)
+	step.EvidenceDigest = hex.EncodeToString(digest[:])
+
+	sealed, err := sealEvidence(s.cfg.PublicKey, step.EvidenceURI, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	cid, err := s.cfg.Store.StoreWasteManagementData(&binding.WasteManagementData{
+		BinID:          h.BatchID,
+		OperatorWallet: h.To.Wallet.Hex(),
+		Department:     s.cfg.Facility,
+		DataType:       "custody-" + string(h.Stage),
+		AccessLevel:    "evidence",
+		Timestamp:      step.RecordedAt,
+		EncryptedData:  sealed,
+		Metadata: map[string]interface{}{
+			"index":           step.Index,
+			"prev_hash":       step.PrevHash,
+			"evidence_uri":    step.EvidenceURI,
+			"evidence_digest": step.EvidenceDigest,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store evidence: %v", err)
+	}
+	step.EvidenceCID = cid
+	step.Hash = step.computeHash()
+
+	keyData, err := s.cfg.Binder.GenerateHIBEKeyForWasteManagement(h.BatchID, h.To.Wallet.Hex(), s.cfg.Facility, "custody-"+string(h.Stage), "evidence")
+	if err != nil {
+		return nil, fmt.Errorf("failed to anchor step: %v", err)
+	}
+	bound, err := s.cfg.Binder.CreateCryptographicBinding(keyData, cid, s.cfg.GasFee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to anchor step: %v", err)
+	}
+	step.Anchor = Anchor{BindingHash: bound.BindingHash, TransactionID: bound.TransactionID, KeyHash: keyData.KeyHash}
+
+	s.batches[h.BatchID] = append(steps, *step)
+	if err := s.saveLocked(); err != nil {
+		s.batches[h.BatchID] = steps
+		if len(steps) == 0 {
+			delete(s.batches, h.BatchID)
+		}
+		return nil, err
+	}
+	recorded := *step
+	return &recorded, nil
+}
+
+// Steps returns a copy of a batch's recorded steps
+func (s *Service) Steps(batchID string) ([]Step, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	steps, exists := s.batches[batchID]
+	if !exists {
+		return nil, ErrUnknownBatch
+	}
+	return append([]Step(nil), steps...), nil
+}
+
+// nextStepViolation describes how next breaks the custody rules after prev, the
+// batch's last step or nil, or returns ""
+func nextStepViolation(prev, next *Step, tolerance float64) string {
+	rank, known := stageOrder[next.Stage]
+	if !known {
+		return fmt.Sprintf("unknown stage %q", next.Stage)
+	}
+	if next.To.ID == "" {
+		return "the receiving custodian is missing"
+	}
+	if next.WeightGrams == 0 {
+		return "the batch weighs nothing"
+	}
+	if prev == nil {
+		if next.Stage != StageBinPickup {
+			return fmt.Sprintf("custody must start with %s, not %s", StageBinPickup, next.Stage)
+		}
+		return ""
+	}
+
+	if prev.Stage == StageProcessor {
+		return "the batch has already reached its final processor"
+	}
+	if rank < stageOrder[prev.Stage] {
+		return fmt.Sprintf("custody cannot move back from %s to %s", prev.Stage, next.Stage)
+	}
+	if next.From.ID != prev.To.ID {
+		return fmt.Sprintf("%s does not hold the batch; %s does", next.From.ID, prev.To.ID)
+	}
+	// Only collecting more bins adds weight; every later stage can only lose it
+	if next.Stage != StageBinPickup && float64(next.WeightGrams) > float64(prev.WeightGrams)*(1+tolerance) {
+		return fmt.Sprintf("weight rose from %d g to %d g", prev.WeightGrams, next.WeightGrams)
+	}
+	return ""
+}
+
+func (s *Service) saveLocked() error {
+	if s.cfg.Path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&logSnapshot{
+		Version: logVersion,
+		SavedAt: s.cfg.Now(),
+		Batches: s.batches,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode custody log: %v", err)
+	}
+
+	// Write beside the target, sync and rename so a crash never leaves a truncated log
+	tmp, err := os.CreateTemp(filepath.Dir(s.cfg.Path), filepath.Base(s.cfg.Path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create custody log: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write custody log: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync custody log: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write custody log: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.cfg.Path); err != nil {
+		return fmt.Errorf("failed to replace custody log: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package custody
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"blockchain-jedi/ipfs-blockchain-binding/bindingtest"
+	"blockchain-jedi/waste-management-access-control/hibe"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type sensor struct {
+	id  string
+	key *ecdsa.PrivateKey
+}
+
+type custodyFixture struct {
+	service   *Service
+	cfg       Config
+	store     *bindingtest.MemStore
+	binder    *bindingtest.Binder
+	masterKey *hibe.MasterKey
+	scales    map[string]*sensor
+	now       time.Time
+}
+
+func newCustodyFixture(t *testing.T, path string) *custodyFixture {
+	t.Helper()
+	publicKey, masterKey, err := hibe.Setup(hibe.NewSystemParams(6, 128))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &custodyFixture{
+		store:     bindingtest.NewMemStore(),
+		binder:    bindingtest.NewBinder(),
+		masterKey: masterKey,
+		scales:    make(map[string]*sensor),
+		now:       time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+	}
+	registry := NewSensorRegistry()
+	for _, id := range []string{"truck-7-scale", "station-scale", "mrf-scale", "processor-scale"} {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		registry.Register(id, crypto.PubkeyToAddress(key.PublicKey))
+		f.scales[id] = &sensor{id: id, key: key}
+	}
+	f.cfg = Config{
+		Facility:  "north",
+		PublicKey: publicKey,
+		Store:     f.store,
+		Binder:    f.binder,
+		Sensors:   registry,
+		GasFee:    big.NewInt(1000),
+		Path:      path,
+		Now:       func() time.Time { return f.now },
+	}
+	f.service, err = NewService(f.cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// weigh returns a weight reading signed by the named scale
+func (f *custodyFixture) weigh(t *testing.T, scale, batchID string, kg float64) SensorReading {
+	t.Helper()
+	f.now = f.now.Add(time.Minute)
+	reading := SensorReading{SensorID: scale, BatchID: batchID, Kind: ReadingWeight, Value: kg, Unit: "kg", TakenAt: f.now}
+	if err := SignReading(f.scales[scale].key, &reading); err != nil {
+		t.Fatal(err)
+	}
+	return reading
+}
+
+var (
+	bin7     = Party{ID: "bin-7"}
+	bin8     = Party{ID: "bin-8"}
+	truck7   = Party{ID: "truck-7", Wallet: common.HexToAddress("0x1000000000000000000000000000000000000007")}
+	station  = Party{ID: "station-a", Wallet: common.HexToAddress("0x2000000000000000000000000000000000000002")}
+	mrf      = Party{ID: "mrf-east", Wallet: common.HexToAddress("0x3000000000000000000000000000000000000003")}
+	finalFab = Party{ID: "recycler-a", Wallet: common.HexToAddress("0x4000000000000000000000000000000000000004")}
+)
+
+// recordFullChain takes batch B-1 from two bins through to the final processor
+func (f *custodyFixture) recordFullChain(t *testing.T) {
+	t.Helper()
+	handoffs := []*Handoff{
+		{Stage: StageBinPickup, From: bin7, To: truck7, Readings: []SensorReading{f.weigh(t, "truck-7-scale", "B-1", 40)}},
+		// Emptying a second bin into the truck adds to the batch
+		{Stage: StageBinPickup, From: truck7, To: truck7, Readings: []SensorReading{f.weigh(t, "truck-7-scale", "B-1", 95)}},
+		{Stage: StageTransferStation, From: truck7, To: station, Readings: []SensorReading{f.weigh(t, "station-scale", "B-1", 95.5)}},
+		{Stage: StageMRF, From: station, To: mrf, Readings: []SensorReading{f.weigh(t, "mrf-scale", "B-1", 93), f.weigh(t, "mrf-scale", "B-1", 93.2)}},
+		{Stage: StageProcessor, From: mrf, To: finalFab, Readings: []SensorReading{f.weigh(t, "processor-scale", "B-1", 71)}, Notes: "sorted residue removed"},
+	}
+	for _, h := range handoffs {
+		h.BatchID = "B-1"
+		if _, err := f.service.Record(h); err != nil {
+			t.Fatalf("recording %s: %v", h.Stage, err)
+		}
+	}
+}
+
+func TestCustodyChainAndReport(t *testing.T) {
+	f := newCustodyFixture(t, "")
+	f.recordFullChain(t)
+
+	report, err := f.service.Report("B-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Verify(f.binder); err != nil {
+		t.Fatalf("expected the report to verify, got %v", err)
+	}
+	if !report.Complete || report.Holder != finalFab || report.Collected != 95000 || report.Delivered != 71000 || len(report.Unanchored) != 0 {
+		t.Errorf("unexpected report summary %+v", report)
+	}
+	if mrfStep := report.Steps[3]; mrfStep.WeightGrams != 93100 || mrfStep.EvidenceURI != "facility/north/batch/B-1/custody/mrf" {
+		t.Errorf("expected the mean MRF weight under the stage URI, got %d at %s", mrfStep.WeightGrams, mrfStep.EvidenceURI)
+	}
+	for _, step := range report.Steps {
+		if f.binder.Bound[step.Anchor.BindingHash] != step.EvidenceCID {
+			t.Errorf("step %d is not anchored to its evidence", step.Index)
+		}
+	}
+
+	// A key for the batch URI opens every step's evidence
+	pattern, err := hibe.ParsePattern(report.URI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := hibe.KeyGen(f.cfg.PublicKey, f.masterKey, pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evidence, err := VerifyEvidence(report, f.cfg.PublicKey, key, f.store, f.cfg.Sensors)
+	if err != nil {
+		t.Fatalf("expected the evidence to verify, got %v", err)
+	}
+	if len(evidence) != 5 || evidence[4].Notes != "sorted residue removed" {
+		t.Errorf("unexpected evidence %+v", evidence)
+	}
+
+	// Nothing follows the final processor
+	_, err = f.service.Record(&Handoff{BatchID: "B-1", Stage: StageProcessor, From: finalFab, To: mrf, Readings: []SensorReading{f.weigh(t, "processor-scale", "B-1", 71)}})
+	var handoffErr *HandoffError
+	if !errors.As(err, &handoffErr) || handoffErr.Index != 5 {
+		t.Errorf("expected a hand-off after the final processor to be refused, got %v", err)
+	}
+}
+
+func TestRejectsBrokenHandoffs(t *testing.T) {
+	f := newCustodyFixture(t, "")
+	if _, err := f.service.Record(&Handoff{BatchID: "B-2", Stage: StageBinPickup, From: bin8, To: truck7, Readings: []SensorReading{f.weigh(t, "truck-7-scale", "B-2", 50)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	forged := f.weigh(t, "station-scale", "B-2", 50)
+	forged.Value = 49 // altered after signing
+	otherBatch := f.weigh(t, "station-scale", "B-9", 50)
+	unregistered := f.weigh(t, "station-scale", "B-2", 50)
+	unregistered.SensorID = "rogue-scale"
+	fill := f.weigh(t, "station-scale", "B-2", 50)
+	fill.Kind = "fill-level"
+	if err := SignReading(f.scales["station-scale"].key, &fill); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]*Handoff{
+		"wrong holder":      {Stage: StageTransferStation, From: mrf, To: station, Readings: []SensorReading{f.weigh(t, "station-scale", "B-2", 50)}},
+		"weight rises":      {Stage: StageTransferStation, From: truck7, To: station, Readings: []SensorReading{f.weigh(t, "station-scale", "B-2", 60)}},
+		"forged reading":    {Stage: StageTransferStation, From: truck7, To: station, Readings: []SensorReading{forged}},
+		"other batch":       {Stage: StageTransferStation, From: truck7, To: station, Readings: []SensorReading{otherBatch}},
+		"unknown sensor":    {Stage: StageTransferStation, From: truck7, To: station, Readings: []SensorReading{unregistered}},
+		"no weight":         {Stage: StageTransferStation, From: truck7, To: station, Readings: []SensorReading{fill}},
+		"scales disagree":   {Stage: StageTransferStation, From: truck7, To: station, Readings: []SensorReading{f.weigh(t, "station-scale", "B-2", 50), f.weigh(t, "station-scale", "B-2", 40)}},
+		"unknown stage":     {Stage: "landfill", From: truck7, To: station, Readings: []SensorReading{f.weigh(t, "station-scale", "B-2", 50)}},
+		"missing custodian": {Stage: StageTransferStation, From: truck7, Readings: []SensorReading{f.weigh(t, "station-scale", "B-2", 50)}},
+	}
+	for name, h := range cases {
+		h.BatchID = "B-2"
+		var handoffErr *HandoffError
+		if _, err := f.service.Record(h); !errors.As(err, &handoffErr) || handoffErr.Index != 1 {
+			t.Errorf("%s: expected the hand-off to be refused, got %v", name, err)
+		}
+	}
+
+	if _, err := f.service.Record(&Handoff{BatchID: "B-2", Stage: StageMRF, From: truck7, To: mrf, Readings: []SensorReading{f.weigh(t, "mrf-scale", "B-2", 49.5)}}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.service.Record(&Handoff{BatchID: "B-2", Stage: StageTransferStation, From: mrf, To: station, Readings: []SensorReading{f.weigh(t, "station-scale", "B-2", 49)}})
+	if err == nil {
+		t.Error("expected custody not to move back from the MRF to a transfer station")
+	}
+	if _, err := f.service.Record(&Handoff{BatchID: "B-3", Stage: StageMRF, From: bin7, To: mrf, Readings: []SensorReading{f.weigh(t, "mrf-scale", "B-3", 10)}}); err == nil {
+		t.Error("expected custody to start with a bin pickup")
+	}
+	if steps, _ := f.service.Steps("B-2"); len(steps) != 2 {
+		t.Errorf("expected only the valid hand-offs to be recorded, got %d", len(steps))
+	}
+	if _, err := f.service.Steps("B-3"); err != ErrUnknownBatch {
+		t.Errorf("expected a refused first hand-off to leave no batch, got %v", err)
+	}
+}
+
+func TestReportDetectsTampering(t *testing.T) {
+	f := newCustodyFixture(t, "")
+	f.recordFullChain(t)
+	report, err := f.service.Report("B-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := *report
+	tampered.Steps = append([]Step(nil), report.Steps...)
+	tampered.Steps[2].WeightGrams = 90000
+	if err := tampered.Verify(f.binder); err == nil {
+		t.Error("expected an altered weight to break the chain")
+	}
+
+	dropped := *report
+	dropped.Steps = append(append([]Step(nil), report.Steps[:2]...), report.Steps[3:]...)
+	if err := dropped.Verify(f.binder); err == nil {
+		t.Error("expected a removed step to break the chain")
+	}
+
+	// Anchors must verify with the binder, and a report may not list unanchored steps
+	unbound := *report
+	unbound.Steps = append([]Step(nil), report.Steps...)
+	unbound.Steps[1].Anchor.BindingHash = "0xunbound"
+	if err := unbound.Verify(f.binder); err == nil {
+		t.Error("expected an anchor the binder does not know to be refused")
+	}
+	unanchored := *report
+	unanchored.Unanchored = []int{2}
+	if err := unanchored.Verify(f.binder); err == nil {
+		t.Error("expected a report with unanchored steps to be refused")
+	}
+
+	// Swapping in another step's evidence fails its digest
+	swapped := *report
+	swapped.Steps = append([]Step(nil), report.Steps...)
+	stored := f.store.Data[swapped.Steps[1].EvidenceCID]
+	f.store.Data[swapped.Steps[1].EvidenceCID] = f.store.Data[swapped.Steps[0].EvidenceCID]
+	pattern, _ := hibe.ParsePattern(report.URI)
+	key, err := hibe.KeyGen(f.cfg.PublicKey, f.masterKey, pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyEvidence(&swapped, f.cfg.PublicKey, key, f.store, f.cfg.Sensors); err == nil {
+		t.Error("expected swapped evidence to be detected")
+	}
+	f.store.Data[swapped.Steps[1].EvidenceCID] = stored
+
+	// A key for another batch cannot open the evidence
+	otherPattern, _ := hibe.ParsePattern(BatchURI("north", "B-2"))
+	otherKey, err := hibe.KeyGen(f.cfg.PublicKey, f.masterKey, otherPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyEvidence(report, f.cfg.PublicKey, otherKey, f.store, f.cfg.Sensors); err == nil {
+		t.Error("expected another batch's key to be refused")
+	}
+}
+
+func TestCustodyLogPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custody.json")
+	f := newCustodyFixture(t, path)
+	f.recordFullChain(t)
+
+	reopened, err := NewService(f.cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := reopened.Steps("B-1")
+	if err != nil || len(steps) != 5 {
+		t.Fatalf("expected the five steps back, got %d, %v", len(steps), err)
+	}
+	report, err := reopened.Report("B-1")
+	if err != nil || report.Verify(f.binder) != nil {
+		t.Errorf("expected the reloaded chain to verify, got %v", err)
+	}
+
+	// An edited log is refused rather than trusted
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot logSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	snapshot.Batches["B-1"][4].To = mrf
+	edited, _ := json.Marshal(&snapshot)
+	if err := os.WriteFile(path, edited, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewService(f.cfg); err == nil {
+		t.Error("expected an edited custody log to be refused")
+	}
+}
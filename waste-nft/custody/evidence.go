@@ -0,0 +1,169 @@
+package custody
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"blockchain-jedi/waste-management-access-control/hibe"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ReadingWeight is the kind of reading that weighs a batch, in "kg" or "g"
+const ReadingWeight = "weight"
+
+// SensorReading is a measurement signed by the sensor that took it. The batch
+// ID is signed too, so a reading cannot be replayed as evidence for another batch.
+type SensorReading struct {
+	SensorID  string    `json:"sensor_id"`
+	BatchID   string    `json:"batch_id"`
+	Kind      string    `json:"kind"` // e.g. weight, fill-level, temperature
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit"`
+	TakenAt   time.Time `json:"taken_at"`
+	Signature []byte    `json:"signature"` // secp256k1 over Digest, recoverable
+}
+
+// Digest is the Keccak-256 hash a sensor signs
+func (r *SensorReading) Digest() []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("waste-custody-reading|%s|%s|%s|%s|%s|%d",
+		r.SensorID, r.BatchID, r.Kind, strconv.FormatFloat(r.Value, 'g', -1, 64), r.Unit, r.TakenAt.UnixNano())))
+}
+
+// SignReading signs a reading with the sensor's key
+func SignReading(key *ecdsa.PrivateKey, r *SensorReading) error {
+	signature, err := crypto.Sign(r.Digest(), key)
+	if err != nil {
+		return err
+	}
+	r.Signature = signature
+	return nil
+}
+
+// grams converts a weight reading to grams
+func (r *SensorReading) grams() (float64, error) {
+	if r.Value <= 0 || math.IsInf(r.Value, 0) || math.IsNaN(r.Value) {
+		return 0, fmt.Errorf("sensor %s reported an invalid weight %g", r.SensorID, r.Value)
+	}
+	switch r.Unit {
+	case "kg":
+		return r.Value * 1000, nil
+	case "g":
+		return r.Value, nil
+	default:
+		return 0, fmt.Errorf("sensor %s reported weight in unknown unit %q", r.SensorID, r.Unit)
+	}
+}
+
+// SensorRegistry maps sensor IDs to the address of their signing key
+type SensorRegistry struct {
+	mu      sync.RWMutex
+	sensors map[string]common.Address
+}
+
+// NewSensorRegistry creates an empty registry
+func NewSensorRegistry() *SensorRegistry {
+	return &SensorRegistry{sensors: make(map[string]common.Address)}
+}
+
+// Register records the signing address of a sensor, replacing any earlier one
+func (sr *SensorRegistry) Register(sensorID string, signer common.Address) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.sensors[sensorID] = signer
+}
+
+// Verify checks a reading was signed by its registered sensor
+func (sr *SensorRegistry) Verify(r *SensorReading) error {
+	sr.mu.RLock()
+	signer, known := sr.sensors[r.SensorID]
+	sr.mu.RUnlock()
+	if !known {
+		return fmt.Errorf("sensor %s is not registered", r.SensorID)
+	}
+	publicKey, err := crypto.SigToPub(r.Digest(), r.Signature)
+	if err != nil || crypto.PubkeyToAddress(*publicKey) != signer {
+		return fmt.Errorf("reading from sensor %s has an invalid signature", r.SensorID)
+	}
+	return nil
+}
+
+// verifyReadings checks every reading is signed and belongs to the batch, and
+// returns the batch weight: the mean of the weight readings, which must agree
+// within tolerance
+func (sr *SensorRegistry) verifyReadings(batchID string, readings []SensorReading, tolerance float64) (uint64, error) {
+	var total, lightest, heaviest float64
+	weighed := 0
+	for i := range readings {
+		r := &readings[i]
+		if r.BatchID != batchID {
+			return 0, fmt.Errorf("reading from sensor %s is for batch %s", r.SensorID, r.BatchID)
+		}
+		if err := sr.Verify(r); err != nil {
+			return 0, err
+		}
+		if r.Kind != ReadingWeight {
+			continue
+		}
+		grams, err := r.grams()
+		if err != nil {
+			return 0, err
+		}
+		if weighed == 0 || grams < lightest {
+			lightest = grams
+		}
+		if grams > heaviest {
+			heaviest = grams
+		}
+		total += grams
+		weighed++
+	}
+	if weighed == 0 {
+		return 0, errors.New("no signed weight reading")
+	}
+	if heaviest-lightest > heaviest*tolerance {
+		return 0, fmt.Errorf("weight readings disagree: %.0f g to %.0f g", lightest, heaviest)
+	}
+	return uint64(math.Round(total / float64(weighed))), nil
+}
+
+// Evidence is what a step's EvidenceCID holds once decrypted
+type Evidence struct {
+	BatchID  string          `json:"batch_id"`
+	Index    int             `json:"index"`
+	Stage    Stage           `json:"stage"`
+	Readings []SensorReading `json:"readings"`
+	Notes    string          `json:"notes,omitempty"`
+}
+
+// sealEvidence encrypts evidence under a HIBE URI and encodes the ciphertext
+func sealEvidence(publicKey *hibe.PublicKey, uri string, plaintext []byte) ([]byte, error) {
+	pattern, err := hibe.ParsePattern(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid evidence URI %s: %v", uri, err)
+	}
+	ciphertext, err := hibe.Encrypt(publicKey, pattern, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt evidence: %v", err)
+	}
+	return ciphertext.MarshalBinary()
+}
+
+// openEvidence decrypts evidence sealed by sealEvidence
+func openEvidence(publicKey *hibe.PublicKey, key *hibe.PrivateKey, sealed []byte) ([]byte, error) {
+	ciphertext := new(hibe.Ciphertext)
+	if err := ciphertext.UnmarshalBinary(sealed); err != nil {
+		return nil, fmt.Errorf("invalid evidence ciphertext: %v", err)
+	}
+	plaintext, err := hibe.Decrypt(publicKey, key, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt evidence: %v", err)
+	}
+	return plaintext, nil
+}
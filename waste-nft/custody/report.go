@@ -0,0 +1,176 @@
+package custody
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"blockchain-jedi/waste-management-access-control/hibe"
+)
+
+// Report is the custody of one batch. Anyone holding it can check the hash
+// chain, custody rules and on-chain anchors with Verify; a holder of a key for
+// URI can also check the encrypted evidence with VerifyEvidence.
+type Report struct {
+	BatchID     string    `json:"batch_id"`
+	URI         string    `json:"uri"`  // HIBE URI covering the evidence of every step
+	Head        string    `json:"head"` // hash of the last step
+	Holder      Party     `json:"holder"`
+	Complete    bool      `json:"complete"` // the batch reached its final processor
+	Collected   uint64    `json:"collected_grams"`
+	Delivered   uint64    `json:"delivered_grams"`
+	Tolerance   float64   `json:"weight_tolerance"`
+	Steps       []Step    `json:"steps"`
+	Unanchored  []int     `json:"unanchored,omitempty"` // steps whose binding did not verify
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Report builds the custody report for a batch, verifying the chain and asking
+// the binder to verify each step's anchor. CryptographicBinding only verifies
+// bindings whose access policy has not expired, so an old step can be listed as
+// unanchored without having been tampered with.
+func (s *Service) Report(batchID string) (*Report, error) {
+	steps, err := s.Steps(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChain(batchID, steps, s.cfg.WeightTolerance); err != nil {
+		return nil, err
+	}
+
+	last := steps[len(steps)-1]
+	report := &Report{
+		BatchID:     batchID,
+		URI:         BatchURI(s.cfg.Facility, batchID),
+		Head:        last.Hash,
+		Holder:      last.To,
+		Complete:    last.Stage == StageProcessor,
+		Delivered:   last.WeightGrams,
+		Tolerance:   s.cfg.WeightTolerance,
+		Steps:       steps,
+		GeneratedAt: s.cfg.Now().UTC(),
+	}
+	for _, step := range steps {
+		if step.Stage == StageBinPickup {
+			report.Collected = step.WeightGrams
+		}
+		verified, err := s.cfg.Binder.VerifyBinding(step.Anchor.BindingHash)
+		if err != nil || !verified {
+			report.Unanchored = append(report.Unanchored, step.Index)
+		}
+	}
+	return report, nil
+}
+
+// Verify checks the report's hash chain, custody rules and summary, and asks the
+// binder to verify every step's anchor. A report listing unanchored steps fails.
+func (r *Report) Verify(binder Binder) error {
+	if err := r.verifyRecords(); err != nil {
+		return err
+	}
+	if len(r.Unanchored) > 0 {
+		return &HandoffError{BatchID: r.BatchID, Index: r.Unanchored[0], Reason: "report lists the step as unanchored"}
+	}
+	for _, step := range r.Steps {
+		verified, err := binder.VerifyBinding(step.Anchor.BindingHash)
+		if err != nil {
+			return &HandoffError{BatchID: r.BatchID, Index: step.Index, Reason: fmt.Sprintf("anchor cannot be verified: %v", err)}
+		}
+		if !verified {
+			return &HandoffError{BatchID: r.BatchID, Index: step.Index, Reason: "anchor does not verify"}
+		}
+	}
+	return nil
+}
+
+// verifyRecords checks the report's hash chain, custody rules and summary
+func (r *Report) verifyRecords() error {
+	if len(r.Steps) == 0 {
+		return fmt.Errorf("custody report for batch %s has no steps", r.BatchID)
+	}
+	if err := verifyChain(r.BatchID, r.Steps, r.Tolerance); err != nil {
+		return err
+	}
+	last := r.Steps[len(r.Steps)-1]
+	if r.Head != last.Hash || r.Holder != last.To || r.Delivered != last.WeightGrams || r.Complete != (last.Stage == StageProcessor) {
+		return &HandoffError{BatchID: r.BatchID, Index: last.Index, Reason: "report summary does not match its last step"}
+	}
+	return nil
+}
+
+// verifyChain checks every step is linked, hashed and allowed after the one before
+func verifyChain(batchID string, steps []Step, tolerance float64) error {
+	var prev *Step
+	for i := range steps {
+		step := &steps[i]
+		broken := func(reason string, args ...interface{}) error {
+			return &HandoffError{BatchID: batchID, Index: i, Reason: fmt.Sprintf(reason, args...)}
+		}
+		if step.BatchID != batchID || step.Index != i {
+			return broken("step is out of place")
+		}
+		expectedPrev := ""
+		if prev != nil {
+			expectedPrev = prev.Hash
+		}
+		if step.PrevHash != expectedPrev {
+			return broken("step does not follow the one before")
+		}
+		if step.Hash != step.computeHash() {
+			return broken("step hash does not match its contents")
+		}
+		if reason := nextStepViolation(prev, step, tolerance); reason != "" {
+			return broken("%s", reason)
+		}
+		prev = step
+	}
+	return nil
+}
+
+// VerifyEvidence decrypts every step's evidence from store with a key covering
+// the report's URI, and checks it matches the step's digest, is signed by the
+// registered sensors and weighs what the step records. It checks the hash chain
+// but not the anchors; call Verify for those.
+func VerifyEvidence(r *Report, publicKey *hibe.PublicKey, key *hibe.PrivateKey, store EvidenceStore, sensors *SensorRegistry) ([]Evidence, error) {
+	if err := r.verifyRecords(); err != nil {
+		return nil, err
+	}
+	evidence := make([]Evidence, 0, len(r.Steps))
+	for _, step := range r.Steps {
+		invalid := func(reason string, args ...interface{}) error {
+			return &HandoffError{BatchID: r.BatchID, Index: step.Index, Reason: "evidence " + fmt.Sprintf(reason, args...)}
+		}
+
+		stored, err := store.RetrieveWasteManagementData(step.EvidenceCID, "custody-verifier")
+		if err != nil {
+			return nil, invalid("cannot be retrieved: %v", err)
+		}
+		plaintext, err := openEvidence(publicKey, key, stored.EncryptedData)
+		if err != nil {
+			return nil, invalid("cannot be opened: %v", err)
+		}
+		digest := sha256.Sum256(plaintext)
+		if hex.EncodeToString(digest[:]) != step.EvidenceDigest {
+			return nil, invalid("does not match the recorded digest")
+		}
+
+		var decoded Evidence
+		if err := json.Unmarshal(plaintext, &decoded); err != nil {
+			return nil, invalid("is malformed: %v", err)
+		}
+		if decoded.BatchID != r.BatchID || decoded.Index != step.Index || decoded.Stage != step.Stage {
+			return nil, invalid("belongs to another step")
+		}
+		weight, err := sensors.verifyReadings(r.BatchID, decoded.Readings, r.Tolerance)
+		if err != nil {
+			return nil, invalid("%v", err)
+		}
+		if weight != step.WeightGrams {
+			return nil, invalid("weighs %d g, but the step records %d g", weight, step.WeightGrams)
+		}
+		evidence = append(evidence, decoded)
+	}
+	return evidence, nil
+}
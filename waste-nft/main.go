@@ -0,0 +1,325 @@
+// Command waste-nft deploys the WasteManagement batch NFT contract and mints and
+// transfers waste batch tokens whose metadata lives on IPFS.
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	binding "blockchain-jedi/ipfs-blockchain-binding"
+	"blockchain-jedi/waste-nft/wastemanagement"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// keyEnv holds a hex private key when no keystore file is given
+	keyEnv = "WASTE_NFT_PRIVATE_KEY"
+	// passphraseEnv unlocks the keystore file; like the key, it is never taken as a flag
+	passphraseEnv = "WASTE_NFT_PASSPHRASE"
+	// rpcEnv overrides the default RPC endpoint
+	rpcEnv = "WASTE_NFT_RPC"
+	// contractEnv holds the deployed contract address
+	contractEnv = "WASTE_NFT_CONTRACT"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "deploy":
+		err = runDeploy(os.Args[2:])
+	case "mint":
+		err = runMint(os.Args[2:])
+	case "transfer":
+		err = runTransfer(os.Args[2:])
+	case "show":
+		err = runShow(os.Args[2:])
+	default:
+		printUsage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "waste-nft %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// chainFlags are the connection and signing flags shared by every subcommand
+type chainFlags struct {
+	rpc      *string
+	keystore *string
+	timeout  *time.Duration
+}
+
+func addChainFlags(fs *flag.FlagSet) *chainFlags {
+	rpc := os.Getenv(rpcEnv)
+	if rpc == "" {
+		rpc = "http://localhost:8545"
+	}
+	return &chainFlags{
+		rpc:      fs.String("rpc", rpc, "JSON-RPC endpoint (or set "+rpcEnv+")"),
+		keystore: fs.String("keystore", "", "keystore file to sign with, unlocked by "+passphraseEnv+"; "+keyEnv+" is used when empty"),
+		timeout:  fs.Duration("timeout", 2*time.Minute, "how long to wait for the transaction to be mined"),
+	}
+}
+
+// connect dials the node and builds a transactor for its chain
+func (f *chainFlags) connect(ctx context.Context) (*ethclient.Client, *bind.TransactOpts, error) {
+	key, err := f.key()
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := ethclient.DialContext(ctx, *f.rpc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %v", *f.rpc, err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("failed to read chain ID from %s: %v", *f.rpc, err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return client, auth, nil
+}
+
+func (f *chainFlags) key() (*ecdsa.PrivateKey, error) {
+	if *f.keystore == "" {
+		return wastemanagement.KeyFromEnv(keyEnv)
+	}
+	return wastemanagement.KeyFromKeystore(*f.keystore, os.Getenv(passphraseEnv))
+}
+
+// contractAddress parses the -contract flag, falling back to contractEnv
+func contractAddress(value string) (common.Address, error) {
+	if value == "" {
+		value = os.Getenv(contractEnv)
+	}
+	if !common.IsHexAddress(value) {
+		return common.Address{}, fmt.Errorf("pass the contract address with -contract or %s", contractEnv)
+	}
+	return common.HexToAddress(value), nil
+}
+
+// openBatches connects and binds the deployed contract
+func openBatches(ctx context.Context, f *chainFlags, contract string) (*wastemanagement.Batches, func(), error) {
+	address, err := contractAddress(contract)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, auth, err := f.connect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	instance, err := wastemanagement.NewWasteManagement(address, client)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return wastemanagement.NewBatches(instance, client, auth), client.Close, nil
+}
+
+// runDeploy deploys the compiled contract with the signer as owner
+func runDeploy(args []string) error {
+	fs := flag.NewFlagSet("deploy", flag.ExitOnError)
+	chain := addChainFlags(fs)
+	artifactPath := fs.String("artifact", wastemanagement.DefaultArtifactPath, "hardhat artifact of WasteManagement")
+	fs.Parse(args)
+
+	artifact, err := wastemanagement.LoadArtifact(*artifactPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *chain.timeout)
+	defer cancel()
+	client, auth, err := chain.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	auth.Context = ctx
+
+	address, tx, _, err := wastemanagement.DeployWasteManagement(auth, client, artifact)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Deploying from %s in %s\n", auth.From.Hex(), tx.Hash().Hex())
+	if _, err := bind.WaitDeployed(ctx, client, tx); err != nil {
+		return err
+	}
+	fmt.Printf("Contract: %s\n", address.Hex())
+	return nil
+}
+
+// runMint mints a batch token, storing its metadata on IPFS first when given a data file
+func runMint(args []string) error {
+	fs := flag.NewFlagSet("mint", flag.ExitOnError)
+	chain := addChainFlags(fs)
+	contract := fs.String("contract", "", "contract address (or set "+contractEnv+")")
+	token := fs.String("token", "", "token ID of the batch")
+	cid := fs.String("cid", "", "IPFS CID of metadata already stored")
+	dataFile := fs.String("data", "", "waste-management data JSON to store on IPFS instead of -cid")
+	ipfsURL := fs.String("ipfs", "http://localhost:5001", "IPFS API used with -data")
+	fs.Parse(args)
+
+	tokenID, err := parseBigInt("token", *token)
+	if err != nil {
+		return err
+	}
+	if (*cid == "") == (*dataFile == "") {
+		return errors.New("pass exactly one of -cid and -data")
+	}
+	if *dataFile != "" {
+		stored, err := storeBatchData(*ipfsURL, *dataFile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Stored %s on IPFS as %s\n", *dataFile, stored)
+		*cid = stored
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *chain.timeout)
+	defer cancel()
+	batches, closeClient, err := openBatches(ctx, chain, *contract)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	receipt, err := batches.Mint(ctx, tokenID, *cid)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Minted batch %s with metadata ipfs://%s in %s\n", tokenID, *cid, receipt.TxHash.Hex())
+	return nil
+}
+
+// storeBatchData uploads a data file through the IPFS connector and returns its CID
+func storeBatchData(ipfsURL, path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var data binding.WasteManagementData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", fmt.Errorf("invalid data file %s: %v", path, err)
+	}
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+	return binding.NewIPFSConnector(strings.TrimRight(ipfsURL, "/")).StoreWasteManagementData(&data)
+}
+
+// runTransfer hands a batch to a processor, paying them the processing fee
+func runTransfer(args []string) error {
+	fs := flag.NewFlagSet("transfer", flag.ExitOnError)
+	chain := addChainFlags(fs)
+	contract := fs.String("contract", "", "contract address (or set "+contractEnv+")")
+	token := fs.String("token", "", "token ID of the batch")
+	to := fs.String("to", "", "address of the processor receiving the batch")
+	fee := fs.String("fee", "0", "processing fee in wei, paid to the processor")
+	fs.Parse(args)
+
+	tokenID, err := parseBigInt("token", *token)
+	if err != nil {
+		return err
+	}
+	value, err := parseBigInt("fee", *fee)
+	if err != nil {
+		return err
+	}
+	if !common.IsHexAddress(*to) {
+		return fmt.Errorf("invalid -to address %q", *to)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *chain.timeout)
+	defer cancel()
+	batches, closeClient, err := openBatches(ctx, chain, *contract)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	event, err := batches.Transfer(ctx, common.HexToAddress(*to), tokenID, value)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Batch %s (weight %s) transferred to %s in %s\n", event.Id, event.Weight, *to, event.Raw.TxHash.Hex())
+	return nil
+}
+
+// runShow prints a batch's owner and metadata location
+func runShow(args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	chain := addChainFlags(fs)
+	contract := fs.String("contract", "", "contract address (or set "+contractEnv+")")
+	token := fs.String("token", "", "token ID of the batch")
+	fs.Parse(args)
+
+	tokenID, err := parseBigInt("token", *token)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *chain.timeout)
+	defer cancel()
+	batches, closeClient, err := openBatches(ctx, chain, *contract)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	batch, err := batches.Get(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Batch:    %s\n", batch.TokenID)
+	fmt.Printf("Owner:    %s\n", batch.Owner.Hex())
+	fmt.Printf("Metadata: %s\n", batch.MetadataURI)
+	if batch.CID == "" {
+		fmt.Println("CID:      none (metadata is not on IPFS)")
+	} else {
+		fmt.Printf("CID:      %s\n", batch.CID)
+	}
+	return nil
+}
+
+func parseBigInt(name, value string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("-%s must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, `usage: waste-nft <command> [flags]
+
+Commands:
+  deploy    deploy the WasteManagement contract from its hardhat artifact
+  mint      mint a batch token whose metadataURI is ipfs://<cid>
+  transfer  transfer a batch to a processor, paying the processing fee
+  show      print a batch's owner and metadata
+
+Transactions are signed with the keystore given by -keystore, unlocked with
+`+passphraseEnv+`, or with the hex key in `+keyEnv+`.`)
+}
@@ -0,0 +1,99 @@
+package wastemanagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultArtifactPath is where `npx hardhat compile` writes the contract, relative to the repository root
+const DefaultArtifactPath = "kyc-contract/artifacts/contracts/KYC.sol/WasteManagement.json"
+
+// Artifact is the part of a hardhat artifact needed to deploy the contract
+type Artifact struct {
+	ContractName string          `json:"contractName"`
+	ABI          json.RawMessage `json:"abi"`
+	Bytecode     string          `json:"bytecode"`
+}
+
+// ArtifactMismatchError reports an artifact whose ABI no longer matches the bindings
+type ArtifactMismatchError struct {
+	Path    string
+	Missing []string // methods and events the bindings use but the artifact lacks
+}
+
+func (e *ArtifactMismatchError) Error() string {
+	return fmt.Sprintf("%s does not match the generated bindings (missing %s); regenerate them with go generate",
+		e.Path, strings.Join(e.Missing, ", "))
+}
+
+// LoadArtifact reads a hardhat artifact and checks it is the contract the bindings were generated from
+func LoadArtifact(path string) (*Artifact, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var artifact Artifact
+	if err := json.Unmarshal(raw, &artifact); err != nil {
+		return nil, fmt.Errorf("invalid artifact %s: %v", path, err)
+	}
+	if artifact.ContractName != "WasteManagement" {
+		return nil, fmt.Errorf("%s holds %q, not WasteManagement", path, artifact.ContractName)
+	}
+	if len(common.FromHex(artifact.Bytecode)) == 0 {
+		return nil, fmt.Errorf("%s has no bytecode; is the contract abstract?", path)
+	}
+
+	compiled, err := abi.JSON(strings.NewReader(string(artifact.ABI)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABI in %s: %v", path, err)
+	}
+	bound, err := WasteManagementMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingFrom(bound, &compiled); len(missing) > 0 {
+		return nil, &ArtifactMismatchError{Path: path, Missing: missing}
+	}
+	return &artifact, nil
+}
+
+// missingFrom lists the methods and events of bound whose signature compiled lacks
+func missingFrom(bound, compiled *abi.ABI) []string {
+	var missing []string
+	for _, method := range bound.Methods {
+		if _, err := compiled.MethodById(method.ID); err != nil {
+			missing = append(missing, method.Sig)
+		}
+	}
+	for _, event := range bound.Events {
+		if _, err := compiled.EventByID(event.ID); err != nil {
+			missing = append(missing, event.Sig)
+		}
+	}
+	return missing
+}
+
+// DeployWasteManagement deploys the artifact's bytecode and binds the new contract.
+// The deployer becomes the contract owner, the only account allowed to mint.
+func DeployWasteManagement(auth *bind.TransactOpts, backend bind.ContractBackend, artifact *Artifact) (common.Address, *types.Transaction, *WasteManagement, error) {
+	parsed, err := WasteManagementMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(artifact.Bytecode), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &WasteManagement{
+		WasteManagementCaller:     WasteManagementCaller{contract: contract},
+		WasteManagementTransactor: WasteManagementTransactor{contract: contract},
+		WasteManagementFilterer:   WasteManagementFilterer{contract: contract},
+	}, nil
+}
@@ -0,0 +1,168 @@
+package wastemanagement
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ipfsScheme prefixes the token metadataURI of every batch
+const ipfsScheme = "ipfs://"
+
+const (
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base32Alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+// ValidateCID checks that cid looks like an IPFS content identifier: a base58
+// CIDv0 ("Qm...", 46 characters) or a base32 CIDv1 ("b...")
+func ValidateCID(cid string) error {
+	switch {
+	case strings.HasPrefix(cid, "Qm"):
+		if len(cid) != 46 || !onlyFrom(cid, base58Alphabet) {
+			return fmt.Errorf("invalid CIDv0 %q", cid)
+		}
+	case strings.HasPrefix(cid, "b"):
+		if len(cid) < 50 || !onlyFrom(cid[1:], base32Alphabet) {
+			return fmt.Errorf("invalid CIDv1 %q", cid)
+		}
+	default:
+		return fmt.Errorf("%q is not an IPFS CID", cid)
+	}
+	return nil
+}
+
+func onlyFrom(s, alphabet string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(alphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// MetadataURI is the token metadataURI pointing at a batch's metadata on IPFS
+func MetadataURI(cid string) (string, error) {
+	if err := ValidateCID(cid); err != nil {
+		return "", err
+	}
+	return ipfsScheme + cid, nil
+}
+
+// CIDFromTokenURI extracts the CID from a token URI. Bare CIDs, as minted by the
+// hardhat tests, are accepted too.
+func CIDFromTokenURI(uri string) (string, error) {
+	cid := strings.TrimPrefix(uri, ipfsScheme)
+	if err := ValidateCID(cid); err != nil {
+		return "", fmt.Errorf("token URI %q does not point at IPFS: %v", uri, err)
+	}
+	return cid, nil
+}
+
+// TransactionFailedError reports a transaction that was mined but reverted
+type TransactionFailedError struct {
+	Hash   common.Hash
+	Action string
+}
+
+func (e *TransactionFailedError) Error() string {
+	return fmt.Sprintf("%s transaction %s reverted", e.Action, e.Hash.Hex())
+}
+
+// Batch is a waste batch token as recorded on chain
+type Batch struct {
+	TokenID     *big.Int
+	Owner       common.Address
+	MetadataURI string
+	CID         string // empty when MetadataURI does not point at IPFS
+}
+
+// Batches mints and transfers waste batch tokens, waiting for each transaction to be mined
+type Batches struct {
+	contract *WasteManagement
+	backend  bind.DeployBackend
+	auth     *bind.TransactOpts
+}
+
+// NewBatches creates Batches sending transactions signed by auth. Minting needs
+// auth to be the contract owner; transferring does not.
+func NewBatches(contract *WasteManagement, backend bind.DeployBackend, auth *bind.TransactOpts) *Batches {
+	return &Batches{contract: contract, backend: backend, auth: auth}
+}
+
+// Mint creates the token for a batch, owned by the deployer, with its metadataURI set to ipfs://cid
+func (b *Batches) Mint(ctx context.Context, tokenID *big.Int, cid string) (*types.Receipt, error) {
+	uri, err := MetadataURI(cid)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := b.contract.Mint(b.opts(ctx, nil), tokenID, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint batch %s: %v", tokenID, err)
+	}
+	return b.wait(ctx, tx, "mint")
+}
+
+// Transfer hands a batch held by the deployer to a processor, paying fee wei to
+// the new owner, and returns the WasteBatchProcessed event it emitted
+func (b *Batches) Transfer(ctx context.Context, to common.Address, tokenID, fee *big.Int) (*WasteManagementWasteBatchProcessed, error) {
+	tx, err := b.contract.Transfer(b.opts(ctx, fee), to, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer batch %s: %v", tokenID, err)
+	}
+	receipt, err := b.wait(ctx, tx, "transfer")
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range receipt.Logs {
+		if log.Address != *tx.To() {
+			continue
+		}
+		if event, err := b.contract.ParseWasteBatchProcessed(*log); err == nil {
+			return event, nil
+		}
+	}
+	return nil, fmt.Errorf("transfer transaction %s emitted no WasteBatchProcessed event", tx.Hash().Hex())
+}
+
+// Get reads a batch's owner and metadata
+func (b *Batches) Get(ctx context.Context, tokenID *big.Int) (*Batch, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	owner, err := b.contract.OwnerOf(opts, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read owner of batch %s: %v", tokenID, err)
+	}
+	uri, err := b.contract.TokenURI(opts, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata of batch %s: %v", tokenID, err)
+	}
+	batch := &Batch{TokenID: tokenID, Owner: owner, MetadataURI: uri}
+	if cid, err := CIDFromTokenURI(uri); err == nil {
+		batch.CID = cid
+	}
+	return batch, nil
+}
+
+// opts copies the shared transact options so concurrent calls do not race on them
+func (b *Batches) opts(ctx context.Context, value *big.Int) *bind.TransactOpts {
+	opts := *b.auth
+	opts.Context = ctx
+	opts.Value = value
+	return &opts
+}
+
+func (b *Batches) wait(ctx context.Context, tx *types.Transaction, action string) (*types.Receipt, error) {
+	receipt, err := bind.WaitMined(ctx, b.backend, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed waiting for %s transaction %s: %v", action, tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, &TransactionFailedError{Hash: tx.Hash(), Action: action}
+	}
+	return receipt, nil
+}
@@ -0,0 +1,98 @@
+package wastemanagement
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testCIDv0 = "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"
+	testCIDv1 = "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"
+)
+
+func TestValidateCID(t *testing.T) {
+	for _, cid := range []string{testCIDv0, testCIDv1} {
+		if err := ValidateCID(cid); err != nil {
+			t.Errorf("expected %s to be valid, got %v", cid, err)
+		}
+	}
+	for _, cid := range []string{
+		"",
+		"QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbd",  // too short
+		"QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbd0", // 0 is not base58
+		"bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzd1",
+		"https://example.org/batch.json",
+	} {
+		if err := ValidateCID(cid); err == nil {
+			t.Errorf("expected %q to be rejected", cid)
+		}
+	}
+}
+
+func TestTokenURIRoundTrip(t *testing.T) {
+	uri, err := MetadataURI(testCIDv0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri != "ipfs://"+testCIDv0 {
+		t.Errorf("unexpected metadata URI %s", uri)
+	}
+	for _, tokenURI := range []string{uri, testCIDv0} {
+		cid, err := CIDFromTokenURI(tokenURI)
+		if err != nil || cid != testCIDv0 {
+			t.Errorf("CIDFromTokenURI(%q) = %q, %v", tokenURI, cid, err)
+		}
+	}
+	if _, err := CIDFromTokenURI("https://example.org/batch.json"); err == nil {
+		t.Error("expected a non-IPFS token URI to be rejected")
+	}
+}
+
+// writeArtifact writes a hardhat-style artifact using the ABI the bindings were generated from
+func writeArtifact(t *testing.T, name, bytecode string, dropMethod string) string {
+	t.Helper()
+	raw, err := os.ReadFile("WasteManagement.abi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		t.Fatal(err)
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry["name"] != dropMethod {
+			kept = append(kept, entry)
+		}
+	}
+	abiJSON, _ := json.Marshal(kept)
+	artifact, _ := json.Marshal(Artifact{ContractName: name, ABI: abiJSON, Bytecode: bytecode})
+
+	path := filepath.Join(t.TempDir(), "WasteManagement.json")
+	if err := os.WriteFile(path, artifact, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadArtifact(t *testing.T) {
+	if _, err := LoadArtifact(writeArtifact(t, "WasteManagement", "0x6080", "")); err != nil {
+		t.Fatalf("expected a matching artifact to load, got %v", err)
+	}
+	if _, err := LoadArtifact(writeArtifact(t, "KYC", "0x6080", "")); err == nil {
+		t.Error("expected another contract's artifact to be rejected")
+	}
+	if _, err := LoadArtifact(writeArtifact(t, "WasteManagement", "0x", "")); err == nil {
+		t.Error("expected an artifact without bytecode to be rejected")
+	}
+
+	_, err := LoadArtifact(writeArtifact(t, "WasteManagement", "0x6080", "mint"))
+	var mismatch *ArtifactMismatchError
+	if !errors.As(err, &mismatch) || len(mismatch.Missing) != 1 || !strings.HasPrefix(mismatch.Missing[0], "mint(") {
+		t.Errorf("expected the missing mint method to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Package wastemanagement holds Go bindings for the WasteManagement batch NFT
+// contract in kyc-contract/contracts/KYC.sol, and helpers to deploy it, mint a
+// token per waste batch with its metadata on IPFS, and hand batches over to a
+// processor.
+//
+// The bindings are generated from WasteManagement.abi.json. The contract's public
+// _owner variable clashes with Ownable's owner() in Go, so it is bound as Deployer.
+// The bytecode is not embedded: DeployWasteManagement takes it from the hardhat
+// artifact, so the deployed code is always what `npx hardhat compile` produced.
+package wastemanagement
+
+//go:generate abigen --abi WasteManagement.abi.json --pkg wastemanagement --type WasteManagement --alias _owner=deployer --out waste_management.go
@@ -0,0 +1,38 @@
+package wastemanagement
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KeyFromKeystore decrypts a geth or clef keystore file
+func KeyFromKeystore(path, passphrase string) (*ecdsa.PrivateKey, error) {
+	encrypted, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(encrypted, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %v", path, err)
+	}
+	return key.PrivateKey, nil
+}
+
+// KeyFromEnv reads a hex private key, with or without 0x, from the named environment variable
+func KeyFromEnv(name string) (*ecdsa.PrivateKey, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return nil, fmt.Errorf("%s is not set", name)
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		// The error never includes the key itself
+		return nil, fmt.Errorf("%s does not hold a valid private key", name)
+	}
+	return key, nil
+}
@@ -0,0 +1,164 @@
+package wastemanagement
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+// simulatedChainID is the chain ID the simulated backend always uses
+var simulatedChainID = big.NewInt(1337)
+
+// miningBackend mines pending transactions whenever a receipt is asked for, so
+// bind.WaitMined returns without a separate goroutine committing blocks
+type miningBackend struct {
+	simulated.Client
+	sim *simulated.Backend
+}
+
+func (b miningBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	b.sim.Commit()
+	return b.Client.TransactionReceipt(ctx, hash)
+}
+
+type chainFixture struct {
+	sim       *simulated.Backend
+	backend   miningBackend
+	contract  *WasteManagement
+	owner     *bind.TransactOpts
+	processor *bind.TransactOpts
+}
+
+func newAccount(t *testing.T) (*ecdsa.PrivateKey, *bind.TransactOpts) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, simulatedChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, auth
+}
+
+// newChainFixture deploys the compiled contract to a fresh simulated chain. The
+// artifact comes from `npx hardhat compile` in kyc-contract, so the test is
+// skipped until it has been built.
+func newChainFixture(t *testing.T) *chainFixture {
+	t.Helper()
+	path := filepath.Join("..", "..", DefaultArtifactPath)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		t.Skipf("%s not found; run npx hardhat compile in kyc-contract", path)
+	}
+	artifact, err := LoadArtifact(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, owner := newAccount(t)
+	_, processor := newAccount(t)
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
+	sim := simulated.NewBackend(types.GenesisAlloc{
+		owner.From:     {Balance: funds},
+		processor.From: {Balance: funds},
+	})
+	t.Cleanup(func() { sim.Close() })
+
+	f := &chainFixture{sim: sim, backend: miningBackend{Client: sim.Client(), sim: sim}, owner: owner, processor: processor}
+	_, tx, contract, err := DeployWasteManagement(owner, f.backend, artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bind.WaitDeployed(context.Background(), f.backend, tx); err != nil {
+		t.Fatal(err)
+	}
+	f.contract = contract
+	return f
+}
+
+func TestMintAndTransferBatch(t *testing.T) {
+	f := newChainFixture(t)
+	ctx := context.Background()
+	batches := NewBatches(f.contract, f.backend, f.owner)
+	tokenID := big.NewInt(7)
+
+	if deployer, err := f.contract.Deployer(nil); err != nil || deployer != f.owner.From {
+		t.Fatalf("expected the deployer to be recorded, got %s, %v", deployer.Hex(), err)
+	}
+	if _, err := batches.Mint(ctx, tokenID, testCIDv0); err != nil {
+		t.Fatal(err)
+	}
+	batch, err := batches.Get(ctx, tokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Owner != f.owner.From || batch.MetadataURI != "ipfs://"+testCIDv0 || batch.CID != testCIDv0 {
+		t.Errorf("unexpected minted batch %+v", batch)
+	}
+
+	before, err := f.backend.BalanceAt(ctx, f.processor.From, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := big.NewInt(1e9)
+	event, err := batches.Transfer(ctx, f.processor.From, tokenID, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Id.Cmp(tokenID) != 0 || event.Weight.Cmp(big.NewInt(1)) != 0 || event.Processor != f.owner.From {
+		t.Errorf("unexpected WasteBatchProcessed event %+v", event)
+	}
+	after, err := f.backend.BalanceAt(ctx, f.processor.From, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid := new(big.Int).Sub(after, before); paid.Cmp(fee) != 0 {
+		t.Errorf("expected the processor to receive the %s wei fee, got %s", fee, paid)
+	}
+	if batch, err := batches.Get(ctx, tokenID); err != nil || batch.Owner != f.processor.From {
+		t.Errorf("expected the processor to own the batch, got %+v, %v", batch, err)
+	}
+
+	// The contract only hands over batches the deployer still holds
+	if _, err := batches.Transfer(ctx, f.owner.From, tokenID, nil); err == nil {
+		t.Error("expected transferring a batch the deployer no longer holds to fail")
+	}
+}
+
+func TestMintIsOwnerOnly(t *testing.T) {
+	f := newChainFixture(t)
+	ctx := context.Background()
+
+	if _, err := NewBatches(f.contract, f.backend, f.processor).Mint(ctx, big.NewInt(1), testCIDv1); err == nil {
+		t.Error("expected a mint by an account other than the owner to fail")
+	}
+	if _, err := NewBatches(f.contract, f.backend, f.owner).Mint(ctx, big.NewInt(1), "not-a-cid"); err == nil {
+		t.Error("expected a metadata URI without a CID to be refused before sending")
+	}
+	if _, err := f.contract.OwnerOf(nil, big.NewInt(1)); err == nil {
+		t.Error("expected no token to have been minted")
+	}
+
+	// A transaction that is mined but reverts is reported as such
+	opts := *f.owner
+	opts.GasLimit = 500000 // skip estimation, which would catch the revert
+	tx, err := f.contract.Transfer(&opts, f.processor.From, big.NewInt(99))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed *TransactionFailedError
+	if _, err := NewBatches(f.contract, f.backend, f.owner).wait(ctx, tx, "transfer"); !errors.As(err, &failed) || failed.Hash != tx.Hash() {
+		t.Errorf("expected a reverted transaction error, got %v", err)
+	}
+}